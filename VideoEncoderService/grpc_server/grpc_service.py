@@ -1,21 +1,44 @@
 import transcoding_pb2
 import transcoding_pb2_grpc
 from concurrent import futures
-from services.transcode import encoder, status
+from services.transcode import encoder, status, TranscodeFailed
 
 
 class TranscoderServicer(transcoding_pb2_grpc.TranscoderServicer):
     def NotifyUploadComplete(self, request, context):
         vid_uuid = request.uuid
-        encoder(vid_uuid)
+        encryption = request.profile.encryption if request.profile.HasField("encryption") else None
+        result = encoder(vid_uuid, encryption, request.job_id, request.lease_id)
+        if result != "OK":
+            # encoder reports errors as {"error": ...}, optionally with a status.
+            error = result[0] if isinstance(result, tuple) else result
+            return transcoding_pb2.TranscodeResponse(
+                status_code=500,
+                stage=transcoding_pb2.TRANSCODE_STAGE_FAILED,
+                error_code="encoder_failed",
+                error_message=error.get("error", "") if isinstance(error, dict) else str(error),
+            )
         return transcoding_pb2.TranscodeResponse(status_code=200)
 
 # Define a class to implement the VideoStatusService
 class VideoStatusServicer(transcoding_pb2_grpc.VideoStatusServiceServicer):
     def StatusVideo(self, request, context):
         vid_uuid = request.uuid
+        progress = 0
         for i in status(vid_uuid):
-            yield transcoding_pb2.VideoStatusResponse(status=i)
-
-
-
+            if isinstance(i, TranscodeFailed):
+                yield transcoding_pb2.VideoStatusResponse(
+                    status=progress,
+                    stage=transcoding_pb2.TRANSCODE_STAGE_FAILED,
+                    error_code="encoder_failed",
+                    error_message=i.message,
+                )
+                return
+            progress = i
+            yield transcoding_pb2.VideoStatusResponse(status=i, stage=transcoding_pb2.TRANSCODE_STAGE_ENCODING)
+        # The upload service treats a stream without a final stage as failed.
+        yield transcoding_pb2.VideoStatusResponse(
+            status=100,
+            stage=transcoding_pb2.TRANSCODE_STAGE_COMPLETE,
+            manifest_location=f"encoded/{vid_uuid}/master.m3u8",
+        )
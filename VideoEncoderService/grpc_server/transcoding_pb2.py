@@ -14,7 +14,7 @@ _sym_db = _symbol_database.Default()
 
 
 
-DESCRIPTOR = _descriptor_pool.Default().AddSerializedFile(b'\n\x11transcoding.proto\x12\x0btranscoding\"(\n\x11TranscodeResponse\x12\x13\n\x0bstatus_code\x18\x01 \x01(\r\" \n\x10VideoUuidRequest\x12\x0c\n\x04uuid\x18\x01 \x01(\t\"%\n\x13VideoStatusResponse\x12\x0e\n\x06status\x18\x01 \x01(\r2f\n\x12VideoStatusService\x12P\n\x0bStatusVideo\x12\x1d.transcoding.VideoUuidRequest\x1a .transcoding.VideoStatusResponse0\x01\x32\x63\n\nTranscoder\x12U\n\x14NotifyUploadComplete\x12\x1d.transcoding.VideoUuidRequest\x1a\x1e.transcoding.TranscodeResponseB\"Z ./videoUploadService/transcodingb\x06proto3')
+DESCRIPTOR = _descriptor_pool.Default().AddSerializedFile(b'\n\x11transcoding.proto\x12\x0btranscoding\"\x7f\n\x11TranscodeResponse\x12\x13\n\x0bstatus_code\x18\x01 \x01(\r\x12*\n\x05stage\x18\x02 \x01(\x0e\x32\x1b.transcoding.TranscodeStage\x12\x12\n\nerror_code\x18\x03 \x01(\t\x12\x15\n\rerror_message\x18\x04 \x01(\t\" \n\x10VideoUuidRequest\x12\x0c\n\x04uuid\x18\x01 \x01(\t\"v\n\x15UploadCompleteRequest\x12\x0c\n\x04uuid\x18\x01 \x01(\t\x12-\n\x07profile\x18\x02 \x01(\x0b\x32\x1c.transcoding.EncodingProfile\x12\x0e\n\x06job_id\x18\x03 \x01(\t\x12\x10\n\x08lease_id\x18\x04 \x01(\t\"\xe1\x01\n\x0f\x45ncodingProfile\x12\x0c\n\x04name\x18\x01 \x01(\t\x12.\n\nrenditions\x18\x02 \x03(\x0b\x32\x1a.transcoding.RenditionSpec\x12\x13\n\x0bvideo_codec\x18\x03 \x01(\t\x12 \n\x18segment_duration_seconds\x18\x04 \x01(\r\x12)\n\x05\x61udio\x18\x05 \x01(\x0b\x32\x1a.transcoding.AudioSettings\x12.\n\nencryption\x18\x06 \x01(\x0b\x32\x1a.transcoding.HlsEncryption\"a\n\rHlsEncryption\x12\x0e\n\x06method\x18\x01 \x01(\t\x12\x19\n\x11rotation_segments\x18\x02 \x01(\r\x12%\n\x04keys\x18\x03 \x03(\x0b\x32\x17.transcoding.ContentKey\"J\n\nContentKey\x12\r\n\x05index\x18\x01 \x01(\r\x12\x0b\n\x03uri\x18\x04 \x01(\t\x12\x0b\n\x03kid\x18\x05 \x01(\x0cJ\x04\x08\x02\x10\x03J\x04\x08\x03\x10\x04R\x03keyR\x02iv\"<\n\x12\x43ontentKeyMaterial\x12\r\n\x05index\x18\x01 \x01(\r\x12\x0b\n\x03key\x18\x02 \x01(\x0c\x12\n\n\x02iv\x18\x03 \x01(\x0c\"\x86\x01\n\rRenditionSpec\x12\x0c\n\x04name\x18\x01 \x01(\t\x12\r\n\x05width\x18\x02 \x01(\r\x12\x0e\n\x06height\x18\x03 \x01(\r\x12\x1a\n\x12video_bitrate_kbps\x18\x04 \x01(\r\x12\x18\n\x10max_bitrate_kbps\x18\x05 \x01(\r\x12\x12\n\nframe_rate\x18\x06 \x01(\r\"[\n\rAudioSettings\x12\r\n\x05\x63odec\x18\x01 \x01(\t\x12\x14\n\x0c\x62itrate_kbps\x18\x02 \x01(\r\x12\x13\n\x0bsample_rate\x18\x03 \x01(\r\x12\x10\n\x08\x63hannels\x18\x04 \x01(\r\"3\n\x0fReencodeRequest\x12\x0c\n\x04uuid\x18\x01 \x01(\t\x12\x12\n\nrenditions\x18\x02 \x03(\t\"\x99\x01\n\x11RenditionProgress\x12\x0c\n\x04name\x18\x01 \x01(\t\x12\r\n\x05width\x18\x02 \x01(\r\x12\x0e\n\x06height\x18\x03 \x01(\r\x12*\n\x05stage\x18\x04 \x01(\x0e\x32\x1b.transcoding.TranscodeStage\x12\x10\n\x08progress\x18\x05 \x01(\r\x12\x19\n\x11playlist_location\x18\x06 \x01(\t\"\xe0\x01\n\x13VideoStatusResponse\x12\x0e\n\x06status\x18\x01 \x01(\r\x12*\n\x05stage\x18\x02 \x01(\x0e\x32\x1b.transcoding.TranscodeStage\x12\x32\n\nrenditions\x18\x03 \x03(\x0b\x32\x1e.transcoding.RenditionProgress\x12\x13\n\x0b\x65ta_seconds\x18\x04 \x01(\x03\x12\x12\n\nerror_code\x18\x05 \x01(\t\x12\x15\n\rerror_message\x18\x06 \x01(\t\x12\x19\n\x11manifest_location\x18\x07 \x01(\t\"~\n\x0cTranscodeJob\x12\x0e\n\x06job_id\x18\x01 \x01(\t\x12\x0c\n\x04uuid\x18\x02 \x01(\t\x12-\n\x07profile\x18\x03 \x01(\x0b\x32\x1c.transcoding.EncodingProfile\x12\x0f\n\x07\x61ttempt\x18\x04 \x01(\r\x12\x10\n\x08priority\x18\x05 \x01(\x05\"H\n\x0fLeaseJobRequest\x12\x11\n\tworker_id\x18\x01 \x01(\t\x12\"\n\x1avisibility_timeout_seconds\x18\x02 \x01(\r\"f\n\x10LeaseJobResponse\x12&\n\x03job\x18\x01 \x01(\x0b\x32\x19.transcoding.TranscodeJob\x12\x10\n\x08lease_id\x18\x02 \x01(\t\x12\x18\n\x10lease_expires_at\x18\x03 \x01(\x03\"\x81\x01\n\x13JobHeartbeatRequest\x12\x0e\n\x06job_id\x18\x01 \x01(\t\x12\x10\n\x08lease_id\x18\x02 \x01(\t\x12\x16\n\x0e\x65xtend_seconds\x18\x03 \x01(\r\x12\x30\n\x06status\x18\x04 \x01(\x0b\x32 .transcoding.VideoStatusResponse\"C\n\x14JobHeartbeatResponse\x12\x18\n\x10lease_expires_at\x18\x01 \x01(\x03\x12\x11\n\tcancelled\x18\x02 \x01(\x08\"Q\n\x12\x43ompleteJobRequest\x12\x0e\n\x06job_id\x18\x01 \x01(\t\x12\x10\n\x08lease_id\x18\x02 \x01(\t\x12\x19\n\x11manifest_location\x18\x03 \x01(\t\"p\n\x0e\x46\x61ilJobRequest\x12\x0e\n\x06job_id\x18\x01 \x01(\t\x12\x10\n\x08lease_id\x18\x02 \x01(\t\x12\x12\n\nerror_code\x18\x03 \x01(\t\x12\x15\n\rerror_message\x18\x04 \x01(\t\x12\x11\n\tretryable\x18\x05 \x01(\x08\"\x08\n\x06JobAck\";\n\x17\x46\x65tchContentKeysRequest\x12\x0e\n\x06job_id\x18\x01 \x01(\t\x12\x10\n\x08lease_id\x18\x02 \x01(\t\"I\n\x18\x46\x65tchContentKeysResponse\x12-\n\x04keys\x18\x01 \x03(\x0b\x32\x1f.transcoding.ContentKeyMaterial*\xc5\x01\n\x0eTranscodeStage\x12\x1f\n\x1bTRANSCODE_STAGE_UNSPECIFIED\x10\x00\x12\x1b\n\x17TRANSCODE_STAGE_PROBING\x10\x01\x12\x1c\n\x18TRANSCODE_STAGE_ENCODING\x10\x02\x12\x1d\n\x19TRANSCODE_STAGE_PACKAGING\x10\x03\x12\x1c\n\x18TRANSCODE_STAGE_COMPLETE\x10\x04\x12\x1a\n\x16TRANSCODE_STAGE_FAILED\x10\x05\x32\x66\n\x12VideoStatusService\x12P\n\x0bStatusVideo\x12\x1d.transcoding.VideoUuidRequest\x1a .transcoding.VideoStatusResponse0\x01\x32\xda\x02\n\nTranscoder\x12Z\n\x14NotifyUploadComplete\x12\".transcoding.UploadCompleteRequest\x1a\x1e.transcoding.TranscodeResponse\x12P\n\x0f\x43\x61ncelTranscode\x12\x1d.transcoding.VideoUuidRequest\x1a\x1e.transcoding.TranscodeResponse\x12O\n\x0eRetryTranscode\x12\x1d.transcoding.VideoUuidRequest\x1a\x1e.transcoding.TranscodeResponse\x12M\n\rReencodeVideo\x12\x1c.transcoding.ReencodeRequest\x1a\x1e.transcoding.TranscodeResponse2\x91\x03\n\x11TranscodeJobQueue\x12G\n\x08LeaseJob\x12\x1c.transcoding.LeaseJobRequest\x1a\x1d.transcoding.LeaseJobResponse\x12P\n\tHeartbeat\x12 .transcoding.JobHeartbeatRequest\x1a!.transcoding.JobHeartbeatResponse\x12\x43\n\x0b\x43ompleteJob\x12\x1f.transcoding.CompleteJobRequest\x1a\x13.transcoding.JobAck\x12;\n\x07\x46\x61ilJob\x12\x1b.transcoding.FailJobRequest\x1a\x13.transcoding.JobAck\x12_\n\x10\x46\x65tchContentKeys\x12$.transcoding.FetchContentKeysRequest\x1a%.transcoding.FetchContentKeysResponseB\"Z ./videoUploadService/transcodingb\x06proto3')
 
 _globals = globals()
 _builder.BuildMessageAndEnumDescriptors(DESCRIPTOR, _globals)
@@ -22,14 +22,56 @@ _builder.BuildTopDescriptorsAndMessages(DESCRIPTOR, 'transcoding_pb2', _globals)
 if not _descriptor._USE_C_DESCRIPTORS:
   _globals['DESCRIPTOR']._loaded_options = None
   _globals['DESCRIPTOR']._serialized_options = b'Z ./videoUploadService/transcoding'
+  _globals['_TRANSCODESTAGE']._serialized_start=2299
+  _globals['_TRANSCODESTAGE']._serialized_end=2496
   _globals['_TRANSCODERESPONSE']._serialized_start=34
-  _globals['_TRANSCODERESPONSE']._serialized_end=74
-  _globals['_VIDEOUUIDREQUEST']._serialized_start=76
-  _globals['_VIDEOUUIDREQUEST']._serialized_end=108
-  _globals['_VIDEOSTATUSRESPONSE']._serialized_start=110
-  _globals['_VIDEOSTATUSRESPONSE']._serialized_end=147
-  _globals['_VIDEOSTATUSSERVICE']._serialized_start=149
-  _globals['_VIDEOSTATUSSERVICE']._serialized_end=251
-  _globals['_TRANSCODER']._serialized_start=253
-  _globals['_TRANSCODER']._serialized_end=352
+  _globals['_TRANSCODERESPONSE']._serialized_end=161
+  _globals['_VIDEOUUIDREQUEST']._serialized_start=163
+  _globals['_VIDEOUUIDREQUEST']._serialized_end=195
+  _globals['_UPLOADCOMPLETEREQUEST']._serialized_start=197
+  _globals['_UPLOADCOMPLETEREQUEST']._serialized_end=315
+  _globals['_ENCODINGPROFILE']._serialized_start=318
+  _globals['_ENCODINGPROFILE']._serialized_end=543
+  _globals['_HLSENCRYPTION']._serialized_start=545
+  _globals['_HLSENCRYPTION']._serialized_end=642
+  _globals['_CONTENTKEY']._serialized_start=644
+  _globals['_CONTENTKEY']._serialized_end=718
+  _globals['_CONTENTKEYMATERIAL']._serialized_start=720
+  _globals['_CONTENTKEYMATERIAL']._serialized_end=780
+  _globals['_RENDITIONSPEC']._serialized_start=783
+  _globals['_RENDITIONSPEC']._serialized_end=917
+  _globals['_AUDIOSETTINGS']._serialized_start=919
+  _globals['_AUDIOSETTINGS']._serialized_end=1010
+  _globals['_REENCODEREQUEST']._serialized_start=1012
+  _globals['_REENCODEREQUEST']._serialized_end=1063
+  _globals['_RENDITIONPROGRESS']._serialized_start=1066
+  _globals['_RENDITIONPROGRESS']._serialized_end=1219
+  _globals['_VIDEOSTATUSRESPONSE']._serialized_start=1222
+  _globals['_VIDEOSTATUSRESPONSE']._serialized_end=1446
+  _globals['_TRANSCODEJOB']._serialized_start=1448
+  _globals['_TRANSCODEJOB']._serialized_end=1574
+  _globals['_LEASEJOBREQUEST']._serialized_start=1576
+  _globals['_LEASEJOBREQUEST']._serialized_end=1648
+  _globals['_LEASEJOBRESPONSE']._serialized_start=1650
+  _globals['_LEASEJOBRESPONSE']._serialized_end=1752
+  _globals['_JOBHEARTBEATREQUEST']._serialized_start=1755
+  _globals['_JOBHEARTBEATREQUEST']._serialized_end=1884
+  _globals['_JOBHEARTBEATRESPONSE']._serialized_start=1886
+  _globals['_JOBHEARTBEATRESPONSE']._serialized_end=1953
+  _globals['_COMPLETEJOBREQUEST']._serialized_start=1955
+  _globals['_COMPLETEJOBREQUEST']._serialized_end=2036
+  _globals['_FAILJOBREQUEST']._serialized_start=2038
+  _globals['_FAILJOBREQUEST']._serialized_end=2150
+  _globals['_JOBACK']._serialized_start=2152
+  _globals['_JOBACK']._serialized_end=2160
+  _globals['_FETCHCONTENTKEYSREQUEST']._serialized_start=2162
+  _globals['_FETCHCONTENTKEYSREQUEST']._serialized_end=2221
+  _globals['_FETCHCONTENTKEYSRESPONSE']._serialized_start=2223
+  _globals['_FETCHCONTENTKEYSRESPONSE']._serialized_end=2296
+  _globals['_VIDEOSTATUSSERVICE']._serialized_start=2498
+  _globals['_VIDEOSTATUSSERVICE']._serialized_end=2600
+  _globals['_TRANSCODER']._serialized_start=2603
+  _globals['_TRANSCODER']._serialized_end=2949
+  _globals['_TRANSCODEJOBQUEUE']._serialized_start=2952
+  _globals['_TRANSCODEJOBQUEUE']._serialized_end=3353
 # @@protoc_insertion_point(module_scope)
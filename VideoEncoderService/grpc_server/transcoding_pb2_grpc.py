@@ -113,9 +113,24 @@ class TranscoderStub(object):
         """
         self.NotifyUploadComplete = channel.unary_unary(
                 '/transcoding.Transcoder/NotifyUploadComplete',
+                request_serializer=transcoding__pb2.UploadCompleteRequest.SerializeToString,
+                response_deserializer=transcoding__pb2.TranscodeResponse.FromString,
+                _registered_method=True)
+        self.CancelTranscode = channel.unary_unary(
+                '/transcoding.Transcoder/CancelTranscode',
+                request_serializer=transcoding__pb2.VideoUuidRequest.SerializeToString,
+                response_deserializer=transcoding__pb2.TranscodeResponse.FromString,
+                _registered_method=True)
+        self.RetryTranscode = channel.unary_unary(
+                '/transcoding.Transcoder/RetryTranscode',
                 request_serializer=transcoding__pb2.VideoUuidRequest.SerializeToString,
                 response_deserializer=transcoding__pb2.TranscodeResponse.FromString,
                 _registered_method=True)
+        self.ReencodeVideo = channel.unary_unary(
+                '/transcoding.Transcoder/ReencodeVideo',
+                request_serializer=transcoding__pb2.ReencodeRequest.SerializeToString,
+                response_deserializer=transcoding__pb2.TranscodeResponse.FromString,
+                _registered_method=True)
 
 
 class TranscoderServicer(object):
@@ -127,14 +142,47 @@ class TranscoderServicer(object):
         context.set_details('Method not implemented!')
         raise NotImplementedError('Method not implemented!')
 
+    def CancelTranscode(self, request, context):
+        """Missing associated documentation comment in .proto file."""
+        context.set_code(grpc.StatusCode.UNIMPLEMENTED)
+        context.set_details('Method not implemented!')
+        raise NotImplementedError('Method not implemented!')
+
+    def RetryTranscode(self, request, context):
+        """Missing associated documentation comment in .proto file."""
+        context.set_code(grpc.StatusCode.UNIMPLEMENTED)
+        context.set_details('Method not implemented!')
+        raise NotImplementedError('Method not implemented!')
+
+    def ReencodeVideo(self, request, context):
+        """Missing associated documentation comment in .proto file."""
+        context.set_code(grpc.StatusCode.UNIMPLEMENTED)
+        context.set_details('Method not implemented!')
+        raise NotImplementedError('Method not implemented!')
+
 
 def add_TranscoderServicer_to_server(servicer, server):
     rpc_method_handlers = {
             'NotifyUploadComplete': grpc.unary_unary_rpc_method_handler(
                     servicer.NotifyUploadComplete,
+                    request_deserializer=transcoding__pb2.UploadCompleteRequest.FromString,
+                    response_serializer=transcoding__pb2.TranscodeResponse.SerializeToString,
+            ),
+            'CancelTranscode': grpc.unary_unary_rpc_method_handler(
+                    servicer.CancelTranscode,
                     request_deserializer=transcoding__pb2.VideoUuidRequest.FromString,
                     response_serializer=transcoding__pb2.TranscodeResponse.SerializeToString,
             ),
+            'RetryTranscode': grpc.unary_unary_rpc_method_handler(
+                    servicer.RetryTranscode,
+                    request_deserializer=transcoding__pb2.VideoUuidRequest.FromString,
+                    response_serializer=transcoding__pb2.TranscodeResponse.SerializeToString,
+            ),
+            'ReencodeVideo': grpc.unary_unary_rpc_method_handler(
+                    servicer.ReencodeVideo,
+                    request_deserializer=transcoding__pb2.ReencodeRequest.FromString,
+                    response_serializer=transcoding__pb2.TranscodeResponse.SerializeToString,
+            ),
     }
     generic_handler = grpc.method_handlers_generic_handler(
             'transcoding.Transcoder', rpc_method_handlers)
@@ -161,6 +209,60 @@ class Transcoder(object):
             request,
             target,
             '/transcoding.Transcoder/NotifyUploadComplete',
+            transcoding__pb2.UploadCompleteRequest.SerializeToString,
+            transcoding__pb2.TranscodeResponse.FromString,
+            options,
+            channel_credentials,
+            insecure,
+            call_credentials,
+            compression,
+            wait_for_ready,
+            timeout,
+            metadata,
+            _registered_method=True)
+
+    @staticmethod
+    def CancelTranscode(request,
+            target,
+            options=(),
+            channel_credentials=None,
+            call_credentials=None,
+            insecure=False,
+            compression=None,
+            wait_for_ready=None,
+            timeout=None,
+            metadata=None):
+        return grpc.experimental.unary_unary(
+            request,
+            target,
+            '/transcoding.Transcoder/CancelTranscode',
+            transcoding__pb2.VideoUuidRequest.SerializeToString,
+            transcoding__pb2.TranscodeResponse.FromString,
+            options,
+            channel_credentials,
+            insecure,
+            call_credentials,
+            compression,
+            wait_for_ready,
+            timeout,
+            metadata,
+            _registered_method=True)
+
+    @staticmethod
+    def RetryTranscode(request,
+            target,
+            options=(),
+            channel_credentials=None,
+            call_credentials=None,
+            insecure=False,
+            compression=None,
+            wait_for_ready=None,
+            timeout=None,
+            metadata=None):
+        return grpc.experimental.unary_unary(
+            request,
+            target,
+            '/transcoding.Transcoder/RetryTranscode',
             transcoding__pb2.VideoUuidRequest.SerializeToString,
             transcoding__pb2.TranscodeResponse.FromString,
             options,
@@ -172,3 +274,282 @@ class Transcoder(object):
             timeout,
             metadata,
             _registered_method=True)
+
+    @staticmethod
+    def ReencodeVideo(request,
+            target,
+            options=(),
+            channel_credentials=None,
+            call_credentials=None,
+            insecure=False,
+            compression=None,
+            wait_for_ready=None,
+            timeout=None,
+            metadata=None):
+        return grpc.experimental.unary_unary(
+            request,
+            target,
+            '/transcoding.Transcoder/ReencodeVideo',
+            transcoding__pb2.ReencodeRequest.SerializeToString,
+            transcoding__pb2.TranscodeResponse.FromString,
+            options,
+            channel_credentials,
+            insecure,
+            call_credentials,
+            compression,
+            wait_for_ready,
+            timeout,
+            metadata,
+            _registered_method=True)
+
+
+class TranscodeJobQueueStub(object):
+    """TranscodeJobQueue is served by the upload service. Encoder workers pull
+    jobs from it instead of being pushed one request per upload.
+    """
+
+    def __init__(self, channel):
+        """Constructor.
+
+        Args:
+            channel: A grpc.Channel.
+        """
+        self.LeaseJob = channel.unary_unary(
+                '/transcoding.TranscodeJobQueue/LeaseJob',
+                request_serializer=transcoding__pb2.LeaseJobRequest.SerializeToString,
+                response_deserializer=transcoding__pb2.LeaseJobResponse.FromString,
+                _registered_method=True)
+        self.Heartbeat = channel.unary_unary(
+                '/transcoding.TranscodeJobQueue/Heartbeat',
+                request_serializer=transcoding__pb2.JobHeartbeatRequest.SerializeToString,
+                response_deserializer=transcoding__pb2.JobHeartbeatResponse.FromString,
+                _registered_method=True)
+        self.CompleteJob = channel.unary_unary(
+                '/transcoding.TranscodeJobQueue/CompleteJob',
+                request_serializer=transcoding__pb2.CompleteJobRequest.SerializeToString,
+                response_deserializer=transcoding__pb2.JobAck.FromString,
+                _registered_method=True)
+        self.FailJob = channel.unary_unary(
+                '/transcoding.TranscodeJobQueue/FailJob',
+                request_serializer=transcoding__pb2.FailJobRequest.SerializeToString,
+                response_deserializer=transcoding__pb2.JobAck.FromString,
+                _registered_method=True)
+        self.FetchContentKeys = channel.unary_unary(
+                '/transcoding.TranscodeJobQueue/FetchContentKeys',
+                request_serializer=transcoding__pb2.FetchContentKeysRequest.SerializeToString,
+                response_deserializer=transcoding__pb2.FetchContentKeysResponse.FromString,
+                _registered_method=True)
+
+
+class TranscodeJobQueueServicer(object):
+    """TranscodeJobQueue is served by the upload service. Encoder workers pull
+    jobs from it instead of being pushed one request per upload.
+    """
+
+    def LeaseJob(self, request, context):
+        """Missing associated documentation comment in .proto file."""
+        context.set_code(grpc.StatusCode.UNIMPLEMENTED)
+        context.set_details('Method not implemented!')
+        raise NotImplementedError('Method not implemented!')
+
+    def Heartbeat(self, request, context):
+        """Missing associated documentation comment in .proto file."""
+        context.set_code(grpc.StatusCode.UNIMPLEMENTED)
+        context.set_details('Method not implemented!')
+        raise NotImplementedError('Method not implemented!')
+
+    def CompleteJob(self, request, context):
+        """Missing associated documentation comment in .proto file."""
+        context.set_code(grpc.StatusCode.UNIMPLEMENTED)
+        context.set_details('Method not implemented!')
+        raise NotImplementedError('Method not implemented!')
+
+    def FailJob(self, request, context):
+        """Missing associated documentation comment in .proto file."""
+        context.set_code(grpc.StatusCode.UNIMPLEMENTED)
+        context.set_details('Method not implemented!')
+        raise NotImplementedError('Method not implemented!')
+
+    def FetchContentKeys(self, request, context):
+        """FetchContentKeys returns the key material referenced by the encryption
+        settings of a leased job.
+        """
+        context.set_code(grpc.StatusCode.UNIMPLEMENTED)
+        context.set_details('Method not implemented!')
+        raise NotImplementedError('Method not implemented!')
+
+
+def add_TranscodeJobQueueServicer_to_server(servicer, server):
+    rpc_method_handlers = {
+            'LeaseJob': grpc.unary_unary_rpc_method_handler(
+                    servicer.LeaseJob,
+                    request_deserializer=transcoding__pb2.LeaseJobRequest.FromString,
+                    response_serializer=transcoding__pb2.LeaseJobResponse.SerializeToString,
+            ),
+            'Heartbeat': grpc.unary_unary_rpc_method_handler(
+                    servicer.Heartbeat,
+                    request_deserializer=transcoding__pb2.JobHeartbeatRequest.FromString,
+                    response_serializer=transcoding__pb2.JobHeartbeatResponse.SerializeToString,
+            ),
+            'CompleteJob': grpc.unary_unary_rpc_method_handler(
+                    servicer.CompleteJob,
+                    request_deserializer=transcoding__pb2.CompleteJobRequest.FromString,
+                    response_serializer=transcoding__pb2.JobAck.SerializeToString,
+            ),
+            'FailJob': grpc.unary_unary_rpc_method_handler(
+                    servicer.FailJob,
+                    request_deserializer=transcoding__pb2.FailJobRequest.FromString,
+                    response_serializer=transcoding__pb2.JobAck.SerializeToString,
+            ),
+            'FetchContentKeys': grpc.unary_unary_rpc_method_handler(
+                    servicer.FetchContentKeys,
+                    request_deserializer=transcoding__pb2.FetchContentKeysRequest.FromString,
+                    response_serializer=transcoding__pb2.FetchContentKeysResponse.SerializeToString,
+            ),
+    }
+    generic_handler = grpc.method_handlers_generic_handler(
+            'transcoding.TranscodeJobQueue', rpc_method_handlers)
+    server.add_generic_rpc_handlers((generic_handler,))
+    server.add_registered_method_handlers('transcoding.TranscodeJobQueue', rpc_method_handlers)
+
+
+ # This class is part of an EXPERIMENTAL API.
+class TranscodeJobQueue(object):
+    """TranscodeJobQueue is served by the upload service. Encoder workers pull
+    jobs from it instead of being pushed one request per upload.
+    """
+
+    @staticmethod
+    def LeaseJob(request,
+            target,
+            options=(),
+            channel_credentials=None,
+            call_credentials=None,
+            insecure=False,
+            compression=None,
+            wait_for_ready=None,
+            timeout=None,
+            metadata=None):
+        return grpc.experimental.unary_unary(
+            request,
+            target,
+            '/transcoding.TranscodeJobQueue/LeaseJob',
+            transcoding__pb2.LeaseJobRequest.SerializeToString,
+            transcoding__pb2.LeaseJobResponse.FromString,
+            options,
+            channel_credentials,
+            insecure,
+            call_credentials,
+            compression,
+            wait_for_ready,
+            timeout,
+            metadata,
+            _registered_method=True)
+
+    @staticmethod
+    def Heartbeat(request,
+            target,
+            options=(),
+            channel_credentials=None,
+            call_credentials=None,
+            insecure=False,
+            compression=None,
+            wait_for_ready=None,
+            timeout=None,
+            metadata=None):
+        return grpc.experimental.unary_unary(
+            request,
+            target,
+            '/transcoding.TranscodeJobQueue/Heartbeat',
+            transcoding__pb2.JobHeartbeatRequest.SerializeToString,
+            transcoding__pb2.JobHeartbeatResponse.FromString,
+            options,
+            channel_credentials,
+            insecure,
+            call_credentials,
+            compression,
+            wait_for_ready,
+            timeout,
+            metadata,
+            _registered_method=True)
+
+    @staticmethod
+    def CompleteJob(request,
+            target,
+            options=(),
+            channel_credentials=None,
+            call_credentials=None,
+            insecure=False,
+            compression=None,
+            wait_for_ready=None,
+            timeout=None,
+            metadata=None):
+        return grpc.experimental.unary_unary(
+            request,
+            target,
+            '/transcoding.TranscodeJobQueue/CompleteJob',
+            transcoding__pb2.CompleteJobRequest.SerializeToString,
+            transcoding__pb2.JobAck.FromString,
+            options,
+            channel_credentials,
+            insecure,
+            call_credentials,
+            compression,
+            wait_for_ready,
+            timeout,
+            metadata,
+            _registered_method=True)
+
+    @staticmethod
+    def FailJob(request,
+            target,
+            options=(),
+            channel_credentials=None,
+            call_credentials=None,
+            insecure=False,
+            compression=None,
+            wait_for_ready=None,
+            timeout=None,
+            metadata=None):
+        return grpc.experimental.unary_unary(
+            request,
+            target,
+            '/transcoding.TranscodeJobQueue/FailJob',
+            transcoding__pb2.FailJobRequest.SerializeToString,
+            transcoding__pb2.JobAck.FromString,
+            options,
+            channel_credentials,
+            insecure,
+            call_credentials,
+            compression,
+            wait_for_ready,
+            timeout,
+            metadata,
+            _registered_method=True)
+
+    @staticmethod
+    def FetchContentKeys(request,
+            target,
+            options=(),
+            channel_credentials=None,
+            call_credentials=None,
+            insecure=False,
+            compression=None,
+            wait_for_ready=None,
+            timeout=None,
+            metadata=None):
+        return grpc.experimental.unary_unary(
+            request,
+            target,
+            '/transcoding.TranscodeJobQueue/FetchContentKeys',
+            transcoding__pb2.FetchContentKeysRequest.SerializeToString,
+            transcoding__pb2.FetchContentKeysResponse.FromString,
+            options,
+            channel_credentials,
+            insecure,
+            call_credentials,
+            compression,
+            wait_for_ready,
+            timeout,
+            metadata,
+            _registered_method=True)
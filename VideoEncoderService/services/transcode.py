@@ -1,9 +1,14 @@
 import subprocess
 import os 
 import json
+import shutil
+import tempfile
 from queue import Queue
 from threading import Thread
 from dotenv import load_dotenv
+import grpc
+import transcoding_pb2
+import transcoding_pb2_grpc
 
 
 load_dotenv()
@@ -11,9 +16,17 @@ load_dotenv()
 
 dev_path: str | None = os.getenv('DEV_PATH')
 transcode: str | None = os.getenv('TRANSCODE')
+# The upload service's job queue, which hands out the keys of encrypted jobs.
+upload_service: str = os.getenv('UPLOAD_SERVICE_ADDR', 'localhost:50052')
+worker_token: str | None = os.getenv('TRANSCODE_WORKER_TOKEN')
 
 progress_queues = {}
 
+class TranscodeFailed:
+    """Put on a progress queue before the final None when a transcode fails."""
+    def __init__(self, message) -> None:
+        self.message = message
+
 class VideoInfo:
     def __init__(self, bitrate_1080, bitrate_720, bitrate_480, bitrate_360) -> None:
         self.bitrate_1080 = bitrate_1080
@@ -54,7 +67,38 @@ def get_video_info(file_path) -> VideoInfo:
         bitrate_360=int(bitrate * 0.08)
     )
 
-def transcode_video(upload_path, output_dir, video_info, progress_queue, uuid):
+def fetch_keys(job_id, lease_id, encryption):
+    """Fetches the key material of an encrypted job and writes it to a
+    private directory, one key per line as its URI, key and IV in hex. The
+    caller removes the directory."""
+    if not job_id or not lease_id:
+        raise ValueError("encrypted jobs need a job and lease to fetch keys with")
+    metadata = [('x-worker-token', worker_token)] if worker_token else None
+    with grpc.insecure_channel(upload_service) as channel:
+        stub = transcoding_pb2_grpc.TranscodeJobQueueStub(channel)
+        res = stub.FetchContentKeys(
+            transcoding_pb2.FetchContentKeysRequest(job_id=job_id, lease_id=lease_id),
+            metadata=metadata,
+            timeout=30,
+        )
+    material = {k.index: k for k in res.keys}
+    key_dir = tempfile.mkdtemp(prefix="keys-")
+    lines = []
+    for key in sorted(encryption.keys, key=lambda k: k.index):
+        m = material.get(key.index)
+        if m is None:
+            shutil.rmtree(key_dir)
+            raise ValueError(f"no material for key {key.index}")
+        lines.append(f"{key.uri}\t{m.key.hex()}\t{m.iv.hex()}\n")
+    if not lines:
+        shutil.rmtree(key_dir)
+        raise ValueError("encryption lists no keys")
+    keys_file = os.path.join(key_dir, "keys")
+    with open(os.open(keys_file, os.O_WRONLY | os.O_CREAT, 0o600), "w") as f:
+        f.writelines(lines)
+    return key_dir, keys_file
+
+def transcode_video(upload_path, output_dir, video_info, progress_queue, uuid, keys=None):
     cmd = [
         transcode,
         upload_path,
@@ -64,8 +108,19 @@ def transcode_video(upload_path, output_dir, video_info, progress_queue, uuid):
         str(video_info.bitrate_720),
         str(video_info.bitrate_1080)
     ]
+    key_dir = None
+    if keys is not None:
+        key_dir, keys_file, rotation = keys
+        cmd += [keys_file, str(rotation)]
     
-    process = subprocess.Popen(cmd, stdout=subprocess.PIPE, stderr=subprocess.PIPE, universal_newlines=True)
+    try:
+        process = subprocess.Popen(cmd, stdout=subprocess.PIPE, stderr=subprocess.PIPE, universal_newlines=True)
+    except OSError as e:
+        if key_dir:
+            shutil.rmtree(key_dir, ignore_errors=True)
+        progress_queue.put(TranscodeFailed(str(e)))
+        progress_queue.put(None)
+        return
     seen_progress = set()
     for line in process.stdout:
         if line.startswith("Overall Progress:"):
@@ -78,15 +133,19 @@ def transcode_video(upload_path, output_dir, video_info, progress_queue, uuid):
                 print(f"Failed to parse progress line: {line}")
     
     process.wait()
+    if key_dir:
+        shutil.rmtree(key_dir, ignore_errors=True)
     
     if process.returncode != 0:
-        print(f"Transcoding failed for UUID {uuid}. Error: {process.stderr.read()}")
-        progress_queue.put(None)  
-    else:
-        progress_queue.put(None) 
+        error = process.stderr.read()
+        print(f"Transcoding failed for UUID {uuid}. Error: {error}")
+        progress_queue.put(TranscodeFailed(error.strip() or f"transcode exited with status {process.returncode}"))
+    progress_queue.put(None)
     return
 
-def encoder(uuid):
+def encoder(uuid, encryption=None, job_id="", lease_id=""):
+    """Starts transcoding an upload. With encryption set, the segments are
+    encrypted with the keys of the job's lease."""
     upload_path = f"{dev_path}{uuid}"
     if not os.path.exists(upload_path):
         print(f"File not found: {upload_path}")
@@ -98,13 +157,21 @@ def encoder(uuid):
     except Exception as e:
         return {"error": f"Failed to get video info: {str(e)}"}
     
+    keys = None
+    if encryption is not None:
+        try:
+            key_dir, keys_file = fetch_keys(job_id, lease_id, encryption)
+        except (grpc.RpcError, ValueError) as e:
+            return {"error": f"Failed to fetch content keys: {e}"}
+        keys = (key_dir, keys_file, encryption.rotation_segments)
+
     output_dir = f"{dev_path}encoded/{uuid}"
     os.makedirs(output_dir, exist_ok=True)
     
     progress_queue = Queue()
     progress_queues[uuid] = progress_queue
 
-    thread = Thread(target=transcode_video, args=(upload_path, output_dir, vid_info, progress_queue, uuid))
+    thread = Thread(target=transcode_video, args=(upload_path, output_dir, vid_info, progress_queue, uuid, keys))
     thread.start()
     progress_queue = progress_queues.get(uuid)
 
@@ -112,7 +179,12 @@ def encoder(uuid):
 
 
 def status(uuid):
+    """Yields the progress of a transcode in percent, followed by a
+    TranscodeFailed if it did not succeed."""
     progress_queue = progress_queues.get(uuid)
+    if progress_queue is None:
+        yield TranscodeFailed("No transcoding job found for this UUID")
+        return
     while True:
         progress = progress_queue.get()
         if progress is None:
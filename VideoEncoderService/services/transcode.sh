@@ -8,6 +8,11 @@ bitrate_360=$3
 bitrate_480=$4
 bitrate_720=$5
 bitrate_1080=$6
+# Optional file of content keys, one per line as its URI, key and IV in hex
+# separated by tabs. Segments are then AES-128 encrypted, starting a new key
+# every rotation_segments segments and keeping the last key for the rest.
+keys_file=${7:-}
+rotation_segments=${8:-0}
 
 # Check if input file exists
 if [[ ! -f "$input_file" ]]; then
@@ -39,6 +44,51 @@ fmt="yuv420p"
 total_duration=$(ffprobe -v error -show_entries format=duration -of default=noprint_wrappers=1:nokey=1 "$input_file")
 total_duration=${total_duration%.*}  # Remove decimal part
 
+key_uris=()
+key_hex=()
+key_ivs=()
+if [[ -n "$keys_file" ]]; then
+  while IFS=$'\t' read -r uri key iv; do
+    key_uris+=("$uri")
+    key_hex+=("$key")
+    key_ivs+=("$iv")
+  done < "$keys_file"
+  if [[ ${#key_hex[@]} -eq 0 ]]; then
+    echo "Error: no keys in '$keys_file'." >&2
+    exit 1
+  fi
+fi
+
+# Encrypt the segments of a media playlist in place and add the EXT-X-KEY
+# tags of their keys.
+encrypt_playlist() {
+    local playlist="$1"
+    local dir segment=0 current=-1 k
+    dir=$(dirname "$playlist")
+    while IFS= read -r line; do
+        if [[ $line == "#EXTINF"* ]]; then
+            k=0
+            if (( rotation_segments > 0 )); then
+                k=$(( segment / rotation_segments ))
+            fi
+            if (( k >= ${#key_hex[@]} )); then
+                k=$(( ${#key_hex[@]} - 1 ))
+            fi
+            if (( k != current )); then
+                current=$k
+                echo "#EXT-X-KEY:METHOD=AES-128,URI=\"${key_uris[k]}\",IV=0x${key_ivs[k]}"
+            fi
+        elif [[ -n $line && $line != "#"* ]]; then
+            openssl enc -aes-128-cbc -K "${key_hex[current]}" -iv "${key_ivs[current]}" \
+                -in "$dir/$line" -out "$dir/$line.enc"
+            mv "$dir/$line.enc" "$dir/$line"
+            segment=$((segment + 1))
+        fi
+        echo "$line"
+    done < "$playlist" > "$playlist.tmp"
+    mv "$playlist.tmp" "$playlist"
+}
+
 # Initialize variables for progress tracking
 current_progress=0
 total_tasks=4  
@@ -72,6 +122,16 @@ encode_video() {
         fi
     done
 
+    # Progressive MP4 for players without HLS support and for downloads. A
+    # clear copy of encrypted output would bypass its encryption.
+    if [[ -z "$keys_file" ]]; then
+        ffmpeg -v error -y -i "$output_dir/${height}p/${height}p.m3u8" \
+            -c copy -bsf:a aac_adtstoasc -movflags +faststart \
+            "$output_dir/${height}p/${height}p.mp4"
+    else
+        encrypt_playlist "$output_dir/${height}p/${height}p.m3u8"
+    fi
+
     current_progress=$((current_progress + 100 / total_tasks))
     echo -ne "Overall Progress: $current_progress%\r"
 }
@@ -82,16 +142,24 @@ encode_video 480 "$bitrate_480"
 encode_video 720 "$bitrate_720"
 encode_video 1080 "$bitrate_1080"
 
-# Create master playlist
+# Create master playlist. BANDWIDTH is the peak and AVERAGE-BANDWIDTH the
+# target bitrate of video plus audio, in bits per second.
+audio_bps=$(( ${audio_bitrate%k} * 1000 ))
+stream_inf() {
+    local bitrate="$1" resolution="$2" codecs="$3"
+    echo "#EXT-X-STREAM-INF:BANDWIDTH=$(( (bitrate + 500) * 1000 + audio_bps )),AVERAGE-BANDWIDTH=$(( bitrate * 1000 + audio_bps )),CODECS=\"$codecs,mp4a.40.2\",RESOLUTION=$resolution,FRAME-RATE=$fps.000"
+}
+
 cat > "$output_dir/master.m3u8" << EOF
 #EXTM3U
-#EXT-X-STREAM-INF:BANDWIDTH=$((bitrate_360 * 1000))K,RESOLUTION=640x360
+#EXT-X-VERSION:3
+$(stream_inf "$bitrate_360" 640x360 avc1.4d401e)
 360p/360p.m3u8
-#EXT-X-STREAM-INF:BANDWIDTH=$((bitrate_480 * 1000))K,RESOLUTION=854x480
+$(stream_inf "$bitrate_480" 854x480 avc1.4d401e)
 480p/480p.m3u8
-#EXT-X-STREAM-INF:BANDWIDTH=$((bitrate_720 * 1000))K,RESOLUTION=1280x720
+$(stream_inf "$bitrate_720" 1280x720 avc1.4d401f)
 720p/720p.m3u8
-#EXT-X-STREAM-INF:BANDWIDTH=$((bitrate_1080 * 1000))K,RESOLUTION=1920x1080
+$(stream_inf "$bitrate_1080" 1920x1080 avc1.4d4028)
 1080p/1080p.m3u8
 EOF
 
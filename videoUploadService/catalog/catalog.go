@@ -0,0 +1,217 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+)
+
+// Catalog drives videos through their lifecycle, rejecting transitions the
+// state machine does not allow.
+type Catalog struct {
+	store Store
+}
+
+// Default is the catalog used by the upload paths. It is set up in main.
+var Default *Catalog
+
+func New(store Store) *Catalog {
+	return &Catalog{store: store}
+}
+
+// Store returns the underlying store.
+func (c *Catalog) Store() Store {
+	return c.store
+}
+
+// Create records a new video in the uploading state.
+func (c *Catalog) Create(ctx context.Context, id, owner string) (Video, error) {
+	now := time.Now().UTC()
+	v := Video{
+		ID:        id,
+		Owner:     owner,
+		State:     StateUploading,
+		Metadata:  Metadata{Visibility: VisibilityPrivate},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := c.store.Create(ctx, v); err != nil {
+		return Video{}, err
+	}
+	return v, nil
+}
+
+func (c *Catalog) Get(ctx context.Context, id string) (Video, error) {
+	return c.store.Get(ctx, id)
+}
+
+// TrickPlay lists the scrubbing aids of a ready video: a WebVTT thumbnail
+// track and I-frame playlists by rendition name.
+type TrickPlay struct {
+	Thumbnails      string            `json:"thumbnails,omitempty"`
+	IFramePlaylists map[string]string `json:"iframe_playlists,omitempty"`
+}
+
+// TrickPlayFor looks up the trick play assets of a video. It is set in main;
+// nil or a nil result means there are none.
+var TrickPlayFor func(ctx context.Context, videoID string) *TrickPlay
+
+// trickPlay returns the trick play assets of a ready video.
+func trickPlay(ctx context.Context, v Video) *TrickPlay {
+	if TrickPlayFor == nil || v.State != StateReady {
+		return nil
+	}
+	return TrickPlayFor(ctx, v.ID)
+}
+
+// Viewer is the caller of a metadata request.
+type Viewer struct {
+	ID    string
+	Admin bool
+}
+
+func (v Viewer) owns(video Video) bool {
+	return v.Admin || (v.ID != "" && v.ID == video.Owner)
+}
+
+// DefaultPageSize and MaxPageSize bound List pages.
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+var ErrForbidden = errors.New("caller may not modify this video")
+
+// View returns a video the viewer may see. Other people's private videos and
+// deleted videos are reported as not found.
+func (c *Catalog) View(ctx context.Context, viewer Viewer, id string) (Video, error) {
+	v, err := c.store.Get(ctx, id)
+	if err != nil {
+		return Video{}, err
+	}
+	if v.State == StateDeleted && !viewer.Admin {
+		return Video{}, ErrNotFound
+	}
+	if !viewer.owns(v) && (v.Visibility == VisibilityPrivate || v.State != StateReady) {
+		return Video{}, ErrNotFound
+	}
+	return v, nil
+}
+
+// List returns a page of videos matching the filter and the offset of the
+// next page, or zero on the last page. Viewers only see other people's
+// videos once they are public and ready.
+func (c *Catalog) List(ctx context.Context, viewer Viewer, f ListFilter) ([]Video, int, error) {
+	if f.Limit <= 0 {
+		f.Limit = DefaultPageSize
+	}
+	if f.Limit > MaxPageSize {
+		f.Limit = MaxPageSize
+	}
+	switch {
+	case viewer.Admin, f.Owner != "" && f.Owner == viewer.ID:
+		if len(f.States) == 0 {
+			f.States = []State{StateUploading, StateUploaded, StateQueued, StateTranscoding, StateReady, StateFailed}
+		}
+	default:
+		f.States = []State{StateReady}
+		f.Visibility = VisibilityPublic
+	}
+
+	limit := f.Limit
+	f.Limit++
+	videos, err := c.store.List(ctx, f)
+	if err != nil {
+		return nil, 0, err
+	}
+	next := 0
+	if len(videos) > limit {
+		videos = videos[:limit]
+		next = f.Offset + limit
+	}
+	return videos, next, nil
+}
+
+// Update applies a metadata patch on behalf of the video owner.
+func (c *Catalog) Update(ctx context.Context, viewer Viewer, id string, p Patch) (Video, error) {
+	v, err := c.store.Get(ctx, id)
+	if err != nil {
+		return Video{}, err
+	}
+	if v.State == StateDeleted {
+		return Video{}, ErrNotFound
+	}
+	if !viewer.owns(v) {
+		return Video{}, ErrForbidden
+	}
+	m := p.Apply(v.Metadata)
+	if err := m.Validate(); err != nil {
+		return Video{}, err
+	}
+	now := time.Now().UTC()
+	if err := c.store.SetMetadata(ctx, id, m, now); err != nil {
+		return Video{}, err
+	}
+	v.Metadata, v.UpdatedAt = m, now
+	return v, nil
+}
+
+// Delete marks a video as deleted on behalf of its owner.
+func (c *Catalog) Delete(ctx context.Context, viewer Viewer, id string) error {
+	v, err := c.store.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if v.State == StateDeleted {
+		return ErrNotFound
+	}
+	if !viewer.owns(v) {
+		return ErrForbidden
+	}
+	_, err = c.Transition(ctx, id, StateDeleted, "")
+	return err
+}
+
+// Transition moves a video to a new state. Moving a video to the state it is
+// already in is a no-op, so repeated status reports are harmless. reason is
+// kept for failed videos and cleared otherwise.
+func (c *Catalog) Transition(ctx context.Context, id string, to State, reason string) (Video, error) {
+	if to != StateFailed {
+		reason = ""
+	}
+	for attempt := 0; attempt < 3; attempt++ {
+		v, err := c.store.Get(ctx, id)
+		if err != nil {
+			return Video{}, err
+		}
+		if v.State == to {
+			return v, nil
+		}
+		if err := checkTransition(v.State, to); err != nil {
+			return v, err
+		}
+		now := time.Now().UTC()
+		err = c.store.SetState(ctx, id, v.State, to, reason, now)
+		if errors.Is(err, ErrConflict) {
+			continue
+		}
+		if err != nil {
+			return Video{}, err
+		}
+		v.State, v.FailureReason, v.UpdatedAt = to, reason, now
+		return v, nil
+	}
+	return Video{}, ErrConflict
+}
+
+// Advance is Transition for callers that only log failures, such as status
+// hooks.
+func (c *Catalog) Advance(id string, to State, reason string) {
+	if c == nil {
+		return
+	}
+	if _, err := c.Transition(context.Background(), id, to, reason); err != nil {
+		log.Printf("Catalog %s -> %s: %v", id, to, err)
+	}
+}
@@ -0,0 +1,102 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCheckTransition(t *testing.T) {
+	tests := []struct {
+		from, to State
+		ok       bool
+	}{
+		{StateUploading, StateUploaded, true},
+		{StateUploading, StateQueued, false},
+		{StateUploaded, StateQueued, true},
+		{StateUploaded, StateReady, false},
+		{StateQueued, StateTranscoding, true},
+		{StateQueued, StateReady, false},
+		{StateTranscoding, StateReady, true},
+		{StateTranscoding, StateQueued, true},
+		{StateTranscoding, StateUploaded, false},
+		{StateReady, StateQueued, true},
+		{StateReady, StateTranscoding, true},
+		{StateReady, StateFailed, false},
+		{StateFailed, StateQueued, true},
+		{StateFailed, StateReady, false},
+		{StateDeleted, StateQueued, false},
+		{StateDeleted, StateUploading, false},
+		{StateReady, StateDeleted, true},
+		{State("unknown"), StateReady, false},
+	}
+	for _, tt := range tests {
+		err := checkTransition(tt.from, tt.to)
+		if tt.ok && err != nil {
+			t.Errorf("%s -> %s: %v", tt.from, tt.to, err)
+		}
+		if !tt.ok && !errors.Is(err, ErrInvalidTransition) {
+			t.Errorf("%s -> %s: err = %v, want ErrInvalidTransition", tt.from, tt.to, err)
+		}
+	}
+}
+
+// racingStore lets another writer move a video to each of the states in
+// races just before the catalog writes it.
+type racingStore struct {
+	Store
+	races []State
+	sets  int
+}
+
+func (s *racingStore) SetState(ctx context.Context, id string, from, to State, reason string, at time.Time) error {
+	s.sets++
+	if len(s.races) > 0 {
+		v, err := s.Store.Get(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := s.Store.SetState(ctx, id, v.State, s.races[0], "", at); err != nil {
+			return err
+		}
+		s.races = s.races[1:]
+	}
+	return s.Store.SetState(ctx, id, from, to, reason, at)
+}
+
+func TestTransitionRetriesConflicts(t *testing.T) {
+	tests := []struct {
+		name  string
+		races []State
+		want  error
+		state State
+		sets  int
+	}{
+		{"no race", nil, nil, StateTranscoding, 1},
+		{"still allowed after the race", []State{StateFailed}, nil, StateTranscoding, 2},
+		{"already there after the race", []State{StateTranscoding}, nil, StateTranscoding, 1},
+		{"no longer allowed", []State{StateDeleted}, ErrInvalidTransition, StateDeleted, 1},
+		{"gives up", []State{StateFailed, StateQueued, StateFailed}, ErrConflict, StateFailed, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &racingStore{Store: openTestStore(t), races: tt.races}
+			c := New(store)
+			ctx := context.Background()
+			now := time.Now()
+			if err := store.Create(ctx, Video{ID: "v1", Owner: "alice", State: StateQueued, CreatedAt: now, UpdatedAt: now}); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := c.Transition(ctx, "v1", StateTranscoding, ""); !errors.Is(err, tt.want) {
+				t.Fatalf("Transition = %v, want %v", err, tt.want)
+			}
+			if v, _ := store.Get(ctx, "v1"); v.State != tt.state {
+				t.Errorf("video is %s, want %s", v.State, tt.state)
+			}
+			if store.sets != tt.sets {
+				t.Errorf("%d writes, want %d", store.sets, tt.sets)
+			}
+		})
+	}
+}
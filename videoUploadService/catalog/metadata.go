@@ -0,0 +1,147 @@
+package catalog
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+type Visibility string
+
+const (
+	VisibilityPublic   Visibility = "public"
+	VisibilityUnlisted Visibility = "unlisted"
+	VisibilityPrivate  Visibility = "private"
+)
+
+// Limits on creator supplied metadata.
+const (
+	MaxTitleLength       = 100
+	MaxDescriptionLength = 5000
+	MaxTags              = 30
+	MaxTagLength         = 30
+	// ThumbnailCandidates is the number of thumbnails generated per video
+	// that a creator can choose from.
+	ThumbnailCandidates = 3
+)
+
+// Categories are the categories a video can be filed under.
+var Categories = map[string]bool{
+	"autos":         true,
+	"comedy":        true,
+	"education":     true,
+	"entertainment": true,
+	"film":          true,
+	"gaming":        true,
+	"howto":         true,
+	"music":         true,
+	"news":          true,
+	"people":        true,
+	"pets":          true,
+	"science":       true,
+	"sports":        true,
+	"travel":        true,
+}
+
+var languagePattern = regexp.MustCompile(`^[a-z]{2,3}(-[A-Za-z0-9]{2,8})*$`)
+
+var ErrInvalidMetadata = errors.New("invalid metadata")
+
+// Metadata is the creator editable part of a video.
+type Metadata struct {
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Tags        []string   `json:"tags"`
+	Category    string     `json:"category"`
+	Language    string     `json:"language"`
+	Thumbnail   uint32     `json:"thumbnail"`
+	Visibility  Visibility `json:"visibility"`
+	// AllowDownload lets viewers save the video as an MP4 file. Creators can
+	// always download their own videos.
+	AllowDownload bool `json:"allow_download"`
+}
+
+// Patch holds metadata changes; nil fields are left untouched.
+type Patch struct {
+	Title         *string     `json:"title"`
+	Description   *string     `json:"description"`
+	Tags          *[]string   `json:"tags"`
+	Category      *string     `json:"category"`
+	Language      *string     `json:"language"`
+	Thumbnail     *uint32     `json:"thumbnail"`
+	Visibility    *Visibility `json:"visibility"`
+	AllowDownload *bool       `json:"allow_download"`
+}
+
+// Apply returns m with the patch applied. Tags are trimmed, lower-cased and
+// de-duplicated.
+func (p Patch) Apply(m Metadata) Metadata {
+	if p.Title != nil {
+		m.Title = strings.TrimSpace(*p.Title)
+	}
+	if p.Description != nil {
+		m.Description = strings.TrimSpace(*p.Description)
+	}
+	if p.Tags != nil {
+		seen := make(map[string]bool)
+		m.Tags = nil
+		for _, t := range *p.Tags {
+			t = strings.ToLower(strings.TrimSpace(t))
+			if t != "" && !seen[t] {
+				seen[t] = true
+				m.Tags = append(m.Tags, t)
+			}
+		}
+	}
+	if p.Category != nil {
+		m.Category = *p.Category
+	}
+	if p.Language != nil {
+		m.Language = *p.Language
+	}
+	if p.Thumbnail != nil {
+		m.Thumbnail = *p.Thumbnail
+	}
+	if p.Visibility != nil {
+		m.Visibility = *p.Visibility
+	}
+	if p.AllowDownload != nil {
+		m.AllowDownload = *p.AllowDownload
+	}
+	return m
+}
+
+// Validate checks the metadata against the limits above.
+func (m Metadata) Validate() error {
+	if n := utf8.RuneCountInString(m.Title); n > MaxTitleLength {
+		return fmt.Errorf("%w: title is %d characters, at most %d allowed", ErrInvalidMetadata, n, MaxTitleLength)
+	}
+	if n := utf8.RuneCountInString(m.Description); n > MaxDescriptionLength {
+		return fmt.Errorf("%w: description is %d characters, at most %d allowed", ErrInvalidMetadata, n, MaxDescriptionLength)
+	}
+	if len(m.Tags) > MaxTags {
+		return fmt.Errorf("%w: %d tags, at most %d allowed", ErrInvalidMetadata, len(m.Tags), MaxTags)
+	}
+	for _, t := range m.Tags {
+		if utf8.RuneCountInString(t) > MaxTagLength {
+			return fmt.Errorf("%w: tag %q is longer than %d characters", ErrInvalidMetadata, t, MaxTagLength)
+		}
+	}
+	if m.Category != "" && !Categories[m.Category] {
+		return fmt.Errorf("%w: unknown category %q", ErrInvalidMetadata, m.Category)
+	}
+	if m.Language != "" && !languagePattern.MatchString(m.Language) {
+		return fmt.Errorf("%w: language %q is not a BCP 47 tag", ErrInvalidMetadata, m.Language)
+	}
+	if m.Thumbnail >= ThumbnailCandidates {
+		return fmt.Errorf("%w: thumbnail must be below %d", ErrInvalidMetadata, ThumbnailCandidates)
+	}
+	switch m.Visibility {
+	case VisibilityPublic, VisibilityUnlisted, VisibilityPrivate:
+	default:
+		return fmt.Errorf("%w: unknown visibility %q", ErrInvalidMetadata, m.Visibility)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package catalog
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	valid := Metadata{Title: "Trip", Tags: []string{"travel"}, Category: "travel", Language: "en-US", Visibility: VisibilityPublic}
+	tests := []struct {
+		name   string
+		modify func(m *Metadata)
+		ok     bool
+	}{
+		{"valid", func(m *Metadata) {}, true},
+		{"empty but visible", func(m *Metadata) { *m = Metadata{Visibility: VisibilityPrivate} }, true},
+		{"longest title", func(m *Metadata) { m.Title = strings.Repeat("é", MaxTitleLength) }, true},
+		{"title too long", func(m *Metadata) { m.Title = strings.Repeat("a", MaxTitleLength+1) }, false},
+		{"longest description", func(m *Metadata) { m.Description = strings.Repeat("ü", MaxDescriptionLength) }, true},
+		{"description too long", func(m *Metadata) { m.Description = strings.Repeat("a", MaxDescriptionLength+1) }, false},
+		{"most tags", func(m *Metadata) { m.Tags = make([]string, MaxTags) }, true},
+		{"too many tags", func(m *Metadata) { m.Tags = make([]string, MaxTags+1) }, false},
+		{"longest tag", func(m *Metadata) { m.Tags = []string{strings.Repeat("ß", MaxTagLength)} }, true},
+		{"tag too long", func(m *Metadata) { m.Tags = []string{strings.Repeat("a", MaxTagLength+1)} }, false},
+		{"unknown category", func(m *Metadata) { m.Category = "cooking" }, false},
+		{"language without region", func(m *Metadata) { m.Language = "de" }, true},
+		{"language in upper case", func(m *Metadata) { m.Language = "EN" }, false},
+		{"language name", func(m *Metadata) { m.Language = "english" }, false},
+		{"last thumbnail", func(m *Metadata) { m.Thumbnail = ThumbnailCandidates - 1 }, true},
+		{"thumbnail out of range", func(m *Metadata) { m.Thumbnail = ThumbnailCandidates }, false},
+		{"unlisted", func(m *Metadata) { m.Visibility = VisibilityUnlisted }, true},
+		{"no visibility", func(m *Metadata) { m.Visibility = "" }, false},
+		{"unknown visibility", func(m *Metadata) { m.Visibility = "friends" }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := valid
+			tt.modify(&m)
+			err := m.Validate()
+			if tt.ok && err != nil {
+				t.Errorf("Validate = %v", err)
+			}
+			if !tt.ok && !errors.Is(err, ErrInvalidMetadata) {
+				t.Errorf("Validate = %v, want ErrInvalidMetadata", err)
+			}
+		})
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestPatchApply(t *testing.T) {
+	base := Metadata{Title: "Trip", Description: "Day one", Tags: []string{"travel"}, Category: "travel",
+		Language: "en", Thumbnail: 1, Visibility: VisibilityPrivate, AllowDownload: true}
+	tests := []struct {
+		name  string
+		patch Patch
+		want  Metadata
+	}{
+		{"empty", Patch{}, base},
+		{
+			"title only",
+			Patch{Title: ptr("  Road trip ")},
+			Metadata{Title: "Road trip", Description: "Day one", Tags: []string{"travel"}, Category: "travel",
+				Language: "en", Thumbnail: 1, Visibility: VisibilityPrivate, AllowDownload: true},
+		},
+		{
+			"tags are normalised",
+			Patch{Tags: ptr([]string{" Travel", "ROAD", "", "road", "  "})},
+			Metadata{Title: "Trip", Description: "Day one", Tags: []string{"travel", "road"}, Category: "travel",
+				Language: "en", Thumbnail: 1, Visibility: VisibilityPrivate, AllowDownload: true},
+		},
+		{
+			"tags cleared",
+			Patch{Tags: ptr([]string{})},
+			Metadata{Title: "Trip", Description: "Day one", Category: "travel",
+				Language: "en", Thumbnail: 1, Visibility: VisibilityPrivate, AllowDownload: true},
+		},
+		{
+			"zero values are applied",
+			Patch{Description: ptr(""), Category: ptr(""), Thumbnail: ptr(uint32(0)), AllowDownload: ptr(false)},
+			Metadata{Title: "Trip", Tags: []string{"travel"}, Language: "en", Visibility: VisibilityPrivate},
+		},
+		{
+			"visibility and language",
+			Patch{Visibility: ptr(VisibilityPublic), Language: ptr("de")},
+			Metadata{Title: "Trip", Description: "Day one", Tags: []string{"travel"}, Category: "travel",
+				Language: "de", Thumbnail: 1, Visibility: VisibilityPublic, AllowDownload: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := base
+			m.Tags = append([]string(nil), base.Tags...)
+			if got := tt.patch.Apply(m); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Apply\n got %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package catalog
+
+import (
+	"VideoUploadService/identity"
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func SetupRoutes(app *fiber.App) {
+	app.Get("/videos", listVideosHandler)
+	app.Get("/videos/:id", getVideoHandler)
+	app.Patch("/videos/:id", updateVideoHandler)
+	app.Delete("/videos/:id", deleteVideoHandler)
+}
+
+func viewerFromFiber(c *fiber.Ctx) Viewer {
+	return Viewer{ID: identity.FromFiber(c), Admin: identity.IsAdminFiber(c)}
+}
+
+func listVideosHandler(c *fiber.Ctx) error {
+	f, err := parseFilter(c.Query("owner"), c.Query("state"), c.Query("visibility"), c.Query("category"),
+		c.Query("language"), c.Query("tag"), c.QueryInt("page_size"), c.Query("page_token"))
+	if err != nil {
+		return c.Status(400).SendString(err.Error())
+	}
+	videos, next, err := Default.List(c.Context(), viewerFromFiber(c), f)
+	if err != nil {
+		return respondErr(c, err)
+	}
+	res := fiber.Map{"videos": videos}
+	if next > 0 {
+		res["next_page_token"] = strconv.Itoa(next)
+	}
+	return c.JSON(res)
+}
+
+func getVideoHandler(c *fiber.Ctx) error {
+	v, err := Default.View(c.Context(), viewerFromFiber(c), c.Params("id"))
+	if err != nil {
+		return respondErr(c, err)
+	}
+	return c.JSON(struct {
+		Video
+		TrickPlay *TrickPlay `json:"trick_play,omitempty"`
+	}{v, trickPlay(c.Context(), v)})
+}
+
+func updateVideoHandler(c *fiber.Ctx) error {
+	var p Patch
+	if err := c.BodyParser(&p); err != nil {
+		return c.Status(400).SendString("Invalid request body")
+	}
+	v, err := Default.Update(c.Context(), viewerFromFiber(c), c.Params("id"), p)
+	if err != nil {
+		return respondErr(c, err)
+	}
+	return c.JSON(v)
+}
+
+func deleteVideoHandler(c *fiber.Ctx) error {
+	if err := Default.Delete(c.Context(), viewerFromFiber(c), c.Params("id")); err != nil {
+		return respondErr(c, err)
+	}
+	return c.SendStatus(204)
+}
+
+func respondErr(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return c.Status(404).SendString(err.Error())
+	case errors.Is(err, ErrForbidden):
+		return c.Status(403).SendString(err.Error())
+	case errors.Is(err, ErrInvalidMetadata):
+		return c.Status(400).SendString(err.Error())
+	case errors.Is(err, ErrInvalidTransition), errors.Is(err, ErrConflict):
+		return c.Status(409).SendString(err.Error())
+	default:
+		return c.Status(500).SendString(err.Error())
+	}
+}
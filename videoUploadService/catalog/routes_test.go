@@ -0,0 +1,82 @@
+package catalog
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestHandlersCheckOwner(t *testing.T) {
+	saved := Default
+	defer func() { Default = saved }()
+	store := openTestStore(t)
+	Default = New(store)
+	now := time.Now()
+	for _, v := range []Video{
+		{ID: "private", Owner: "alice", State: StateReady, Metadata: Metadata{Title: "Mine", Visibility: VisibilityPrivate}},
+		{ID: "public", Owner: "alice", State: StateReady, Metadata: Metadata{Title: "Ours", Visibility: VisibilityPublic}},
+	} {
+		v.CreatedAt, v.UpdatedAt = now, now
+		if err := store.Create(context.Background(), v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	app := fiber.New()
+	SetupRoutes(app)
+
+	tests := []struct {
+		name, method, path, body, user, role string
+		want                                 int
+	}{
+		{"anonymous views private", "GET", "/videos/private", "", "", "", 404},
+		{"other user views private", "GET", "/videos/private", "", "bob", "", 404},
+		{"owner views private", "GET", "/videos/private", "", "alice", "", 200},
+		{"admin views private", "GET", "/videos/private", "", "ops", "admin", 200},
+		{"other user views public", "GET", "/videos/public", "", "bob", "", 200},
+
+		{"anonymous updates", "PATCH", "/videos/public", `{"title": "Theirs"}`, "", "", 403},
+		{"other user updates", "PATCH", "/videos/public", `{"title": "Theirs"}`, "bob", "", 403},
+		{"other user updates private", "PATCH", "/videos/private", `{"title": "Theirs"}`, "bob", "", 403},
+		{"owner updates invalid", "PATCH", "/videos/public", `{"visibility": "friends"}`, "alice", "", 400},
+		{"owner updates", "PATCH", "/videos/public", `{"title": "Still ours"}`, "alice", "", 200},
+		{"admin updates", "PATCH", "/videos/private", `{"description": "Moderated"}`, "ops", "admin", 200},
+
+		{"anonymous deletes", "DELETE", "/videos/public", "", "", "", 403},
+		{"other user deletes", "DELETE", "/videos/public", "", "bob", "", 403},
+		{"owner deletes", "DELETE", "/videos/public", "", "alice", "", 204},
+		{"owner deletes again", "DELETE", "/videos/public", "", "alice", "", 404},
+		{"other user views deleted", "GET", "/videos/public", "", "bob", "", 404},
+		{"owner updates deleted", "PATCH", "/videos/public", `{"title": "Back"}`, "alice", "", 404},
+		{"missing video", "GET", "/videos/missing", "", "alice", "", 404},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		if tt.user != "" {
+			req.Header.Set("x-user-id", tt.user)
+		}
+		if tt.role != "" {
+			req.Header.Set("x-user-role", tt.role)
+		}
+		res, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.StatusCode != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, res.StatusCode, tt.want)
+		}
+	}
+
+	// Refused updates left the metadata alone.
+	v, _ := store.Get(context.Background(), "private")
+	if v.Title != "Mine" || v.Description != "Moderated" {
+		t.Errorf("private video metadata %+v", v.Metadata)
+	}
+	if v, _ := store.Get(context.Background(), "public"); v.Title != "Still ours" || v.State != StateDeleted {
+		t.Errorf("public video is %s titled %q", v.State, v.Title)
+	}
+}
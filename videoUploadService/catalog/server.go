@@ -0,0 +1,143 @@
+package catalog
+
+import (
+	"VideoUploadService/identity"
+	pbc "VideoUploadService/videocatalog"
+	"context"
+	"errors"
+	"strconv"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Server exposes the Default catalog's metadata API over gRPC.
+type Server struct {
+	pbc.UnimplementedVideoCatalogServer
+}
+
+func (s *Server) ListVideos(ctx context.Context, req *pbc.ListVideosRequest) (*pbc.ListVideosResponse, error) {
+	f, err := parseFilter(req.Owner, req.State, req.Visibility, req.Category, req.Language, req.Tag, int(req.PageSize), req.PageToken)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	videos, next, err := Default.List(ctx, viewerFromContext(ctx), f)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	res := &pbc.ListVideosResponse{}
+	for _, v := range videos {
+		res.Videos = append(res.Videos, toProto(v))
+	}
+	if next > 0 {
+		res.NextPageToken = strconv.Itoa(next)
+	}
+	return res, nil
+}
+
+func (s *Server) GetVideo(ctx context.Context, req *pbc.GetVideoRequest) (*pbc.Video, error) {
+	v, err := Default.View(ctx, viewerFromContext(ctx), req.Id)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	res := toProto(v)
+	if tp := trickPlay(ctx, v); tp != nil {
+		res.TrickPlay = &pbc.TrickPlay{Thumbnails: tp.Thumbnails, IframePlaylists: tp.IFramePlaylists}
+	}
+	return res, nil
+}
+
+func (s *Server) UpdateVideo(ctx context.Context, req *pbc.UpdateVideoRequest) (*pbc.Video, error) {
+	p := Patch{
+		Title:         req.Title,
+		Description:   req.Description,
+		Category:      req.Category,
+		Language:      req.Language,
+		Thumbnail:     req.Thumbnail,
+		AllowDownload: req.AllowDownload,
+	}
+	if req.Tags != nil {
+		p.Tags = &req.Tags.Tags
+	}
+	if req.Visibility != nil {
+		visibility := Visibility(*req.Visibility)
+		p.Visibility = &visibility
+	}
+	v, err := Default.Update(ctx, viewerFromContext(ctx), req.Id, p)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toProto(v), nil
+}
+
+func (s *Server) DeleteVideo(ctx context.Context, req *pbc.DeleteVideoRequest) (*pbc.DeleteVideoResponse, error) {
+	if err := Default.Delete(ctx, viewerFromContext(ctx), req.Id); err != nil {
+		return nil, toStatus(err)
+	}
+	return &pbc.DeleteVideoResponse{}, nil
+}
+
+func viewerFromContext(ctx context.Context) Viewer {
+	return Viewer{ID: identity.FromContext(ctx), Admin: identity.IsAdmin(ctx)}
+}
+
+// parseFilter builds a ListFilter from request parameters shared by the gRPC
+// and REST APIs.
+func parseFilter(owner, state, visibility, category, language, tag string, pageSize int, pageToken string) (ListFilter, error) {
+	f := ListFilter{
+		Owner:      owner,
+		Visibility: Visibility(visibility),
+		Category:   category,
+		Language:   language,
+		Tag:        tag,
+		Limit:      pageSize,
+	}
+	if state != "" {
+		if _, ok := transitions[State(state)]; !ok {
+			return ListFilter{}, errors.New("unknown state " + state)
+		}
+		f.States = []State{State(state)}
+	}
+	if pageToken != "" {
+		offset, err := strconv.Atoi(pageToken)
+		if err != nil || offset < 0 {
+			return ListFilter{}, errors.New("invalid page token")
+		}
+		f.Offset = offset
+	}
+	return f, nil
+}
+
+func toProto(v Video) *pbc.Video {
+	return &pbc.Video{
+		Id:            v.ID,
+		Owner:         v.Owner,
+		State:         string(v.State),
+		FailureReason: v.FailureReason,
+		Title:         v.Title,
+		Description:   v.Description,
+		Tags:          v.Tags,
+		Category:      v.Category,
+		Language:      v.Language,
+		Thumbnail:     v.Thumbnail,
+		Visibility:    string(v.Visibility),
+		CreatedAt:     v.CreatedAt.Unix(),
+		UpdatedAt:     v.UpdatedAt.Unix(),
+		AllowDownload: v.AllowDownload,
+	}
+}
+
+func toStatus(err error) error {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, ErrForbidden):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, ErrInvalidMetadata):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, ErrInvalidTransition), errors.Is(err, ErrConflict):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
@@ -0,0 +1,50 @@
+package catalog
+
+import (
+	"errors"
+	"fmt"
+)
+
+type State string
+
+const (
+	StateUploading   State = "uploading"
+	StateUploaded    State = "uploaded"
+	StateQueued      State = "queued"
+	StateTranscoding State = "transcoding"
+	StateReady       State = "ready"
+	StateFailed      State = "failed"
+	StateDeleted     State = "deleted"
+)
+
+var ErrInvalidTransition = errors.New("invalid state transition")
+
+// transitions lists the states a video may move to from each state. Ready
+// and failed videos can be queued again for a retry or re-encode; deleted is
+// final.
+var transitions = map[State][]State{
+	StateUploading:   {StateUploaded, StateFailed, StateDeleted},
+	StateUploaded:    {StateQueued, StateFailed, StateDeleted},
+	StateQueued:      {StateTranscoding, StateFailed, StateDeleted},
+	StateTranscoding: {StateReady, StateFailed, StateQueued, StateDeleted},
+	StateReady:       {StateQueued, StateTranscoding, StateDeleted},
+	StateFailed:      {StateQueued, StateTranscoding, StateDeleted},
+	StateDeleted:     {},
+}
+
+// CanTransition reports whether a video may move from one state to another.
+func CanTransition(from, to State) bool {
+	for _, s := range transitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+func checkTransition(from, to State) error {
+	if !CanTransition(from, to) {
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, from, to)
+	}
+	return nil
+}
@@ -0,0 +1,302 @@
+package catalog
+
+import (
+	"VideoUploadService/schema"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	_ "github.com/lib/pq"
+	_ "modernc.org/sqlite"
+)
+
+var (
+	ErrNotFound = errors.New("video not found")
+	ErrExists   = errors.New("video already exists")
+	ErrConflict = errors.New("video was modified concurrently")
+)
+
+// Video is a catalog entry.
+type Video struct {
+	ID            string `json:"id"`
+	Owner         string `json:"owner"`
+	State         State  `json:"state"`
+	FailureReason string `json:"failure_reason,omitempty"`
+	Metadata
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ListFilter selects videos for List. Empty fields match everything.
+type ListFilter struct {
+	Owner      string
+	States     []State
+	Visibility Visibility
+	Category   string
+	Language   string
+	Tag        string
+	Limit      int
+	Offset     int
+}
+
+// Store persists videos.
+type Store interface {
+	Create(ctx context.Context, v Video) error
+	Get(ctx context.Context, id string) (Video, error)
+	List(ctx context.Context, f ListFilter) ([]Video, error)
+	// SetMetadata replaces the metadata of a video.
+	SetMetadata(ctx context.Context, id string, m Metadata, at time.Time) error
+	// SetState moves a video to a new state only if it is still in the
+	// expected state, returning ErrConflict otherwise.
+	SetState(ctx context.Context, id string, from, to State, reason string, at time.Time) error
+}
+
+// migrations create and change the catalog's tables; see schema.Migrate.
+var migrations = []string{
+	`CREATE TABLE videos (
+		id TEXT PRIMARY KEY,
+		owner TEXT NOT NULL,
+		state TEXT NOT NULL,
+		failure_reason TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMP NOT NULL,
+		updated_at TIMESTAMP NOT NULL
+	)`,
+	`CREATE INDEX videos_owner_idx ON videos (owner)`,
+	`ALTER TABLE videos ADD COLUMN title TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE videos ADD COLUMN description TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE videos ADD COLUMN tags TEXT NOT NULL DEFAULT '[]'`,
+	`ALTER TABLE videos ADD COLUMN category TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE videos ADD COLUMN language TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE videos ADD COLUMN thumbnail INTEGER NOT NULL DEFAULT 0`,
+	`ALTER TABLE videos ADD COLUMN visibility TEXT NOT NULL DEFAULT 'private'`,
+	`CREATE INDEX videos_visibility_idx ON videos (visibility, state)`,
+	`ALTER TABLE videos ADD COLUMN allow_download BOOLEAN NOT NULL DEFAULT FALSE`,
+}
+
+const videoColumns = `id, owner, state, failure_reason, title, description, tags, category, language, thumbnail, visibility, allow_download, created_at, updated_at`
+
+// SQLStore stores videos in PostgreSQL or SQLite.
+type SQLStore struct {
+	db *sql.DB
+}
+
+// Open connects to the database and applies pending migrations. driver is
+// "postgres" or "sqlite"; an in-memory SQLite database ("file::memory:")
+// is enough for tests.
+func Open(driver, dsn string) (*SQLStore, error) {
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	if driver == "sqlite" {
+		// SQLite allows a single writer; serialise through one connection so
+		// that in-memory databases are shared as well.
+		db.SetMaxOpenConns(1)
+	}
+	s := &SQLStore{db: db}
+	if err := s.migrate(context.Background()); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// DB returns the underlying database handle.
+func (s *SQLStore) DB() *sql.DB {
+	return s.db
+}
+
+func (s *SQLStore) Close() error {
+	return s.db.Close()
+}
+
+func (s *SQLStore) migrate(ctx context.Context) error {
+	if err := s.adoptLegacyVersion(ctx); err != nil {
+		return err
+	}
+	return schema.Migrate(ctx, s.db, "catalog", migrations)
+}
+
+// legacyCatalogSteps is how many of the steps counted in schema_migrations,
+// which databases kept before each package recorded its own, were the
+// catalog's.
+const legacyCatalogSteps = 11
+
+// adoptLegacyVersion carries the catalog's version over from
+// schema_migrations, if the database still has one.
+func (s *SQLStore) adoptLegacyVersion(ctx context.Context) error {
+	var legacy int
+	if err := s.db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&legacy); err != nil {
+		// No legacy table.
+		return nil
+	}
+	if err := schema.Baseline(ctx, s.db, "catalog", min(legacy, legacyCatalogSteps)); err != nil {
+		return err
+	}
+	_, err := s.db.ExecContext(ctx, `DROP TABLE schema_migrations`)
+	return err
+}
+
+func (s *SQLStore) Create(ctx context.Context, v Video) error {
+	if v.Visibility == "" {
+		v.Visibility = VisibilityPrivate
+	}
+	tags, err := json.Marshal(nonNil(v.Tags))
+	if err != nil {
+		return err
+	}
+	// The primary key decides between concurrent creates of the same video.
+	res, err := s.db.ExecContext(ctx,
+		`INSERT INTO videos (`+videoColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		ON CONFLICT (id) DO NOTHING`,
+		v.ID, v.Owner, string(v.State), v.FailureReason, v.Title, v.Description, string(tags),
+		v.Category, v.Language, v.Thumbnail, string(v.Visibility), v.AllowDownload, v.CreatedAt.UTC(), v.UpdatedAt.UTC())
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrExists
+	}
+	return nil
+}
+
+func (s *SQLStore) Get(ctx context.Context, id string) (Video, error) {
+	v, err := scanVideo(s.db.QueryRowContext(ctx, `SELECT `+videoColumns+` FROM videos WHERE id = $1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return Video{}, ErrNotFound
+	}
+	return v, err
+}
+
+func (s *SQLStore) List(ctx context.Context, f ListFilter) ([]Video, error) {
+	var where []string
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	if f.Owner != "" {
+		where = append(where, "owner = "+arg(f.Owner))
+	}
+	if len(f.States) > 0 {
+		var in []string
+		for _, st := range f.States {
+			in = append(in, arg(string(st)))
+		}
+		where = append(where, "state IN ("+strings.Join(in, ", ")+")")
+	}
+	if f.Visibility != "" {
+		where = append(where, "visibility = "+arg(string(f.Visibility)))
+	}
+	if f.Category != "" {
+		where = append(where, "category = "+arg(f.Category))
+	}
+	if f.Language != "" {
+		where = append(where, "language = "+arg(f.Language))
+	}
+	if f.Tag != "" {
+		// tags holds a compact JSON array. Wrapped in commas, every element
+		// appears as ,"tag", so the pattern only matches whole elements.
+		tag, _ := json.Marshal(f.Tag)
+		where = append(where, `(',' || SUBSTR(tags, 2, LENGTH(tags) - 2) || ',') LIKE `+arg("%,"+likeEscaper.Replace(string(tag))+",%")+` ESCAPE '!'`)
+	}
+
+	query := `SELECT ` + videoColumns + ` FROM videos`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY created_at DESC, id"
+	if f.Limit > 0 {
+		query += " LIMIT " + arg(f.Limit)
+	}
+	if f.Offset > 0 {
+		query += " OFFSET " + arg(f.Offset)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var videos []Video
+	for rows.Next() {
+		v, err := scanVideo(rows)
+		if err != nil {
+			return nil, err
+		}
+		videos = append(videos, v)
+	}
+	return videos, rows.Err()
+}
+
+func (s *SQLStore) SetMetadata(ctx context.Context, id string, m Metadata, at time.Time) error {
+	tags, err := json.Marshal(nonNil(m.Tags))
+	if err != nil {
+		return err
+	}
+	res, err := s.db.ExecContext(ctx,
+		`UPDATE videos SET title = $1, description = $2, tags = $3, category = $4, language = $5, thumbnail = $6, visibility = $7, allow_download = $8, updated_at = $9 WHERE id = $10`,
+		m.Title, m.Description, string(tags), m.Category, m.Language, m.Thumbnail, string(m.Visibility), m.AllowDownload, at.UTC(), id)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrNotFound
+	}
+	return err
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanVideo(row scanner) (Video, error) {
+	var v Video
+	var state, tags, visibility string
+	err := row.Scan(&v.ID, &v.Owner, &state, &v.FailureReason, &v.Title, &v.Description, &tags,
+		&v.Category, &v.Language, &v.Thumbnail, &visibility, &v.AllowDownload, &v.CreatedAt, &v.UpdatedAt)
+	if err != nil {
+		return Video{}, err
+	}
+	v.State = State(state)
+	v.Visibility = Visibility(visibility)
+	if err := json.Unmarshal([]byte(tags), &v.Tags); err != nil {
+		return Video{}, fmt.Errorf("video %s tags: %w", v.ID, err)
+	}
+	return v, nil
+}
+
+// likeEscaper escapes the wildcards of a LIKE pattern using ESCAPE '!'.
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+func nonNil(tags []string) []string {
+	if tags == nil {
+		return []string{}
+	}
+	return tags
+}
+
+func (s *SQLStore) SetState(ctx context.Context, id string, from, to State, reason string, at time.Time) error {
+	res, err := s.db.ExecContext(ctx,
+		`UPDATE videos SET state = $1, failure_reason = $2, updated_at = $3 WHERE id = $4 AND state = $5`,
+		string(to), reason, at.UTC(), id, string(from))
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrConflict
+	}
+	return nil
+}
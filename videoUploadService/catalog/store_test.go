@@ -0,0 +1,229 @@
+package catalog
+
+import (
+	"VideoUploadService/schema"
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func openTestStore(t *testing.T) *SQLStore {
+	t.Helper()
+	s, err := Open("sqlite", "file::memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestStoreCreate(t *testing.T) {
+	s := openTestStore(t)
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+	v := Video{ID: "v1", Owner: "alice", State: StateUploading, CreatedAt: now, UpdatedAt: now,
+		Metadata: Metadata{Title: "Trip", Tags: []string{"travel"}}}
+	if err := s.Create(ctx, v); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Create(ctx, v); !errors.Is(err, ErrExists) {
+		t.Errorf("second Create = %v, want ErrExists", err)
+	}
+
+	got, err := s.Get(ctx, "v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Owner != "alice" || got.Title != "Trip" || got.Visibility != VisibilityPrivate || len(got.Tags) != 1 {
+		t.Errorf("Get = %+v", got)
+	}
+	if !got.CreatedAt.Equal(now) {
+		t.Errorf("CreatedAt = %v, want %v", got.CreatedAt, now)
+	}
+	if _, err := s.Get(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get(missing) = %v, want ErrNotFound", err)
+	}
+}
+
+func TestStoreCreateConcurrent(t *testing.T) {
+	s := openTestStore(t)
+	now := time.Now()
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- s.Create(context.Background(), Video{ID: "v1", Owner: "alice", State: StateUploading, CreatedAt: now, UpdatedAt: now})
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	created := 0
+	for err := range errs {
+		switch {
+		case err == nil:
+			created++
+		case !errors.Is(err, ErrExists):
+			t.Errorf("Create = %v, want nil or ErrExists", err)
+		}
+	}
+	if created != 1 {
+		t.Errorf("%d creates succeeded, want 1", created)
+	}
+}
+
+func TestStoreSetState(t *testing.T) {
+	s := openTestStore(t)
+	ctx := context.Background()
+	now := time.Now()
+	if err := s.Create(ctx, Video{ID: "v1", Owner: "alice", State: StateQueued, CreatedAt: now, UpdatedAt: now}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetState(ctx, "v1", StateQueued, StateTranscoding, "", now); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetState(ctx, "v1", StateQueued, StateFailed, "late", now); !errors.Is(err, ErrConflict) {
+		t.Errorf("SetState from a stale state = %v, want ErrConflict", err)
+	}
+	if err := s.SetState(ctx, "v1", StateTranscoding, StateFailed, "encoder crashed", now); err != nil {
+		t.Fatal(err)
+	}
+	v, _ := s.Get(ctx, "v1")
+	if v.State != StateFailed || v.FailureReason != "encoder crashed" {
+		t.Errorf("state = %s (%q), want failed (encoder crashed)", v.State, v.FailureReason)
+	}
+}
+
+func TestStoreSetMetadata(t *testing.T) {
+	s := openTestStore(t)
+	ctx := context.Background()
+	now := time.Now()
+	if err := s.SetMetadata(ctx, "missing", Metadata{}, now); !errors.Is(err, ErrNotFound) {
+		t.Errorf("SetMetadata(missing) = %v, want ErrNotFound", err)
+	}
+	if err := s.Create(ctx, Video{ID: "v1", Owner: "alice", State: StateReady, CreatedAt: now, UpdatedAt: now}); err != nil {
+		t.Fatal(err)
+	}
+	m := Metadata{Title: "New", Tags: []string{"a", "b"}, Visibility: VisibilityPublic, AllowDownload: true}
+	if err := s.SetMetadata(ctx, "v1", m, now); err != nil {
+		t.Fatal(err)
+	}
+	v, _ := s.Get(ctx, "v1")
+	if v.Title != "New" || len(v.Tags) != 2 || v.Visibility != VisibilityPublic || !v.AllowDownload {
+		t.Errorf("metadata = %+v", v.Metadata)
+	}
+}
+
+func TestStoreList(t *testing.T) {
+	s := openTestStore(t)
+	ctx := context.Background()
+	base := time.Now().UTC()
+	videos := []Video{
+		{ID: "a", Owner: "alice", State: StateReady, Metadata: Metadata{Visibility: VisibilityPublic, Category: "music", Language: "en", Tags: []string{"live"}}},
+		{ID: "b", Owner: "alice", State: StateFailed, Metadata: Metadata{Visibility: VisibilityPrivate, Category: "music"}},
+		{ID: "c", Owner: "bob", State: StateReady, Metadata: Metadata{Visibility: VisibilityPublic, Language: "de", Tags: []string{"live", "concert"}}},
+		{ID: "d", Owner: "carol", State: StateUploading, Metadata: Metadata{Tags: []string{"100%", "a_b", "x\"y", "con"}}},
+	}
+	for i, v := range videos {
+		v.CreatedAt = base.Add(time.Duration(i) * time.Minute)
+		v.UpdatedAt = v.CreatedAt
+		if err := s.Create(ctx, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		f    ListFilter
+		want []string
+	}{
+		{"all, newest first", ListFilter{}, []string{"d", "c", "b", "a"}},
+		{"owner", ListFilter{Owner: "alice"}, []string{"b", "a"}},
+		{"states", ListFilter{States: []State{StateFailed, StateDeleted}}, []string{"b"}},
+		{"visibility", ListFilter{Visibility: VisibilityPublic}, []string{"c", "a"}},
+		{"category", ListFilter{Category: "music"}, []string{"b", "a"}},
+		{"language", ListFilter{Language: "de"}, []string{"c"}},
+		{"tag", ListFilter{Tag: "concert"}, []string{"c"}},
+		{"tag in several videos", ListFilter{Tag: "live"}, []string{"c", "a"}},
+		{"tag prefix", ListFilter{Tag: "conc"}, nil},
+		{"percent is literal", ListFilter{Tag: "%"}, nil},
+		{"tag with percent", ListFilter{Tag: "100%"}, []string{"d"}},
+		{"underscore is literal", ListFilter{Tag: "a-b"}, nil},
+		{"tag with underscore", ListFilter{Tag: "a_b"}, []string{"d"}},
+		{"tag with quote", ListFilter{Tag: `x"y`}, []string{"d"}},
+		{"separator", ListFilter{Tag: `","`}, nil},
+		{"limit and offset", ListFilter{Limit: 1, Offset: 1}, []string{"c"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.List(ctx, tt.f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var ids []string
+			for _, v := range got {
+				ids = append(ids, v.ID)
+			}
+			if len(ids) != len(tt.want) {
+				t.Fatalf("List = %v, want %v", ids, tt.want)
+			}
+			for i := range ids {
+				if ids[i] != tt.want[i] {
+					t.Fatalf("List = %v, want %v", ids, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestStoreMigrateIsIdempotent(t *testing.T) {
+	s := openTestStore(t)
+	if err := s.migrate(context.Background()); err != nil {
+		t.Fatalf("second migrate: %v", err)
+	}
+	version, err := schema.Version(context.Background(), s.db, "catalog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != len(migrations) {
+		t.Errorf("schema version = %d, want %d", version, len(migrations))
+	}
+}
+
+func TestStoreAdoptsLegacyVersion(t *testing.T) {
+	db, err := sql.Open("sqlite", "file::memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	defer db.Close()
+	// A database migrated when schema_migrations also counted the tables of
+	// other packages.
+	legacy := append(migrations[:legacyCatalogSteps:legacyCatalogSteps], `CREATE TABLE IF NOT EXISTS content_keys (video_id TEXT)`)
+	if _, err := db.Exec(`CREATE TABLE schema_migrations (version INTEGER PRIMARY KEY)`); err != nil {
+		t.Fatal(err)
+	}
+	for i, step := range legacy {
+		if _, err := db.Exec(step); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.Exec(`INSERT INTO schema_migrations (version) VALUES ($1)`, i+1); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s := &SQLStore{db: db}
+	if err := s.migrate(context.Background()); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if version, _ := schema.Version(context.Background(), db, "catalog"); version != len(migrations) {
+		t.Errorf("schema version = %d, want %d", version, len(migrations))
+	}
+	if _, err := db.Exec(`SELECT 1 FROM schema_migrations`); err == nil {
+		t.Error("schema_migrations was kept")
+	}
+}
@@ -0,0 +1,106 @@
+package clearkey
+
+import (
+	"VideoUploadService/schema"
+	"context"
+	"database/sql"
+	"log"
+	"strings"
+	"time"
+)
+
+// Decision is the outcome of a license request.
+type Decision string
+
+const (
+	Granted Decision = "granted"
+	Denied  Decision = "denied"
+)
+
+// Entry is one audited license request.
+type Entry struct {
+	VideoID  string    `json:"video_id"`
+	Viewer   string    `json:"viewer,omitempty"`
+	IP       string    `json:"ip"`
+	KeyIDs   []string  `json:"kids,omitempty"`
+	Granted  int       `json:"granted"`
+	Decision Decision  `json:"decision"`
+	Reason   string    `json:"reason,omitempty"`
+	At       time.Time `json:"at"`
+}
+
+// AuditLog records every license request.
+type AuditLog struct {
+	db *sql.DB
+}
+
+// Default is set up in main. A nil log only writes to the process log.
+var Default *AuditLog
+
+// migrations create the license_audit table. Earlier versions created it
+// in the catalog migrations, hence IF NOT EXISTS.
+var migrations = []string{
+	`CREATE TABLE IF NOT EXISTS license_audit (
+		video_id TEXT NOT NULL,
+		viewer TEXT NOT NULL,
+		ip TEXT NOT NULL,
+		kids TEXT NOT NULL,
+		granted INTEGER NOT NULL,
+		decision TEXT NOT NULL,
+		reason TEXT NOT NULL,
+		at TIMESTAMP NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS license_audit_video_idx ON license_audit (video_id, at)`,
+}
+
+// OpenAudit records into the license_audit table of db, migrating it first.
+func OpenAudit(db *sql.DB) (*AuditLog, error) {
+	if err := schema.Migrate(context.Background(), db, "clearkey", migrations); err != nil {
+		return nil, err
+	}
+	return &AuditLog{db: db}, nil
+}
+
+// Record logs the entry and stores it. Failing to store an entry is logged
+// but does not fail the request.
+func (a *AuditLog) Record(ctx context.Context, e Entry) {
+	log.Printf("License %s for video %s: viewer=%q ip=%s kids=%d granted=%d %s",
+		e.Decision, e.VideoID, e.Viewer, e.IP, len(e.KeyIDs), e.Granted, e.Reason)
+	if a == nil {
+		return
+	}
+	_, err := a.db.ExecContext(ctx,
+		`INSERT INTO license_audit (video_id, viewer, ip, kids, granted, decision, reason, at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		e.VideoID, e.Viewer, e.IP, strings.Join(e.KeyIDs, ","), e.Granted, string(e.Decision), e.Reason, e.At.UTC())
+	if err != nil {
+		log.Printf("Storing license audit for %s: %v", e.VideoID, err)
+	}
+}
+
+// List returns the most recent entries for a video, newest first.
+func (a *AuditLog) List(ctx context.Context, videoID string, limit int) ([]Entry, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	rows, err := a.db.QueryContext(ctx,
+		`SELECT video_id, viewer, ip, kids, granted, decision, reason, at FROM license_audit WHERE video_id = $1 ORDER BY at DESC LIMIT $2`,
+		videoID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var entries []Entry
+	for rows.Next() {
+		var e Entry
+		var kids, decision string
+		if err := rows.Scan(&e.VideoID, &e.Viewer, &e.IP, &kids, &e.Granted, &decision, &e.Reason, &e.At); err != nil {
+			return nil, err
+		}
+		if kids != "" {
+			e.KeyIDs = strings.Split(kids, ",")
+		}
+		e.Decision = Decision(decision)
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
@@ -0,0 +1,43 @@
+package clearkey
+
+import (
+	"VideoUploadService/catalog"
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestAuditLog(t *testing.T) {
+	store, err := catalog.Open("sqlite", "file::memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	a, err := OpenAudit(store.DB())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	at := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	a.Record(ctx, Entry{VideoID: "v1", Viewer: "bob", IP: "10.0.0.1", KeyIDs: []string{"a", "b"}, Granted: 2, Decision: Granted, At: at})
+	a.Record(ctx, Entry{VideoID: "v1", IP: "10.0.0.2", Decision: Denied, Reason: "invalid playback token", At: at.Add(time.Minute)})
+	a.Record(ctx, Entry{VideoID: "v2", IP: "10.0.0.3", Decision: Denied, At: at})
+
+	entries, err := a.List(ctx, "v1", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+	if e := entries[0]; e.Decision != Denied || e.Reason != "invalid playback token" || e.KeyIDs != nil {
+		t.Errorf("newest entry = %+v", e)
+	}
+	if e := entries[1]; e.Decision != Granted || e.Viewer != "bob" || !slices.Equal(e.KeyIDs, []string{"a", "b"}) || !e.At.Equal(at) {
+		t.Errorf("oldest entry = %+v", e)
+	}
+	if entries, _ := a.List(ctx, "v1", 1); len(entries) != 1 {
+		t.Errorf("limit 1 returned %d entries", len(entries))
+	}
+}
@@ -0,0 +1,95 @@
+package clearkey
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// KeyIDSize is the size of CENC key IDs.
+const KeyIDSize = 16
+
+// maxKeyIDs bounds how many keys one license request may ask for.
+const maxKeyIDs = 64
+
+// Session types of the W3C ClearKey license format.
+const (
+	SessionTemporary         = "temporary"
+	SessionPersistentLicense = "persistent-license"
+)
+
+var ErrBadRequest = errors.New("malformed license request")
+
+// Request is an EME ClearKey license request as produced by the browser's
+// "license-request" message.
+type Request struct {
+	KeyIDs []string `json:"kids"`
+	Type   string   `json:"type,omitempty"`
+}
+
+// JWK is a symmetric JSON Web Key carrying one content key.
+type JWK struct {
+	Kty string `json:"kty"`
+	K   string `json:"k"`
+	Kid string `json:"kid"`
+}
+
+// Response is the license handed back to the browser's CDM.
+type Response struct {
+	Keys []JWK  `json:"keys"`
+	Type string `json:"type"`
+}
+
+// ParseRequest decodes a license request and its key IDs.
+func ParseRequest(body []byte) (Request, [][]byte, error) {
+	var req Request
+	if err := json.Unmarshal(body, &req); err != nil {
+		return Request{}, nil, fmt.Errorf("%w: %v", ErrBadRequest, err)
+	}
+	if req.Type == "" {
+		req.Type = SessionTemporary
+	}
+	if req.Type != SessionTemporary && req.Type != SessionPersistentLicense {
+		return Request{}, nil, fmt.Errorf("%w: unknown session type %q", ErrBadRequest, req.Type)
+	}
+	if len(req.KeyIDs) == 0 || len(req.KeyIDs) > maxKeyIDs {
+		return Request{}, nil, fmt.Errorf("%w: expected 1 to %d key IDs", ErrBadRequest, maxKeyIDs)
+	}
+	kids := make([][]byte, 0, len(req.KeyIDs))
+	for _, s := range req.KeyIDs {
+		kid, err := Decode(s)
+		if err != nil || len(kid) != KeyIDSize {
+			return Request{}, nil, fmt.Errorf("%w: invalid key ID %q", ErrBadRequest, s)
+		}
+		kids = append(kids, kid)
+	}
+	return req, kids, nil
+}
+
+// NewResponse builds a license for the given key IDs. keys maps the raw key
+// ID to the content key; unknown IDs are left out, as the format requires.
+func NewResponse(req Request, kids [][]byte, keys map[string][]byte) Response {
+	res := Response{Keys: []JWK{}, Type: req.Type}
+	for _, kid := range kids {
+		k, ok := keys[string(kid)]
+		if !ok {
+			continue
+		}
+		res.Keys = append(res.Keys, JWK{Kty: "oct", K: Encode(k), Kid: Encode(kid)})
+	}
+	return res
+}
+
+// Encode encodes bytes as unpadded base64url, as used throughout ClearKey.
+func Encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// Decode accepts base64url with or without padding; some CDMs pad key IDs.
+func Decode(s string) ([]byte, error) {
+	if n := len(s) % 4; n != 0 {
+		s += "===="[n:]
+	}
+	return base64.URLEncoding.DecodeString(s)
+}
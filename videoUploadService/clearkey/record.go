@@ -0,0 +1,94 @@
+package clearkey
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// Recording is a captured license request and the answer it got, so that
+// license handling can be replayed locally without a browser.
+type Recording struct {
+	VideoID    string          `json:"video_id"`
+	Token      string          `json:"token,omitempty"`
+	Request    json.RawMessage `json:"request"`
+	Status     int             `json:"status"`
+	Response   json.RawMessage `json:"response,omitempty"`
+	RecordedAt time.Time       `json:"recorded_at"`
+}
+
+// Recorder writes recordings as JSON files to a directory. The keys of
+// granted licenses are redacted, but recordings include the playback token,
+// so only enable it on development setups.
+type Recorder struct {
+	dir string
+	seq atomic.Uint64
+}
+
+// NewRecorder records into dir, creating it if needed.
+func NewRecorder(dir string) (*Recorder, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &Recorder{dir: dir}, nil
+}
+
+// Save stores one recording. A nil recorder does nothing.
+func (r *Recorder) Save(rec Recording) error {
+	if r == nil {
+		return nil
+	}
+	rec.Response = redactKeys(rec.Response)
+	data, err := json.MarshalIndent(rec, "", "  ")
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%d-%s.json", rec.RecordedAt.UTC().Format("20060102T150405"), r.seq.Add(1), rec.VideoID)
+	return os.WriteFile(filepath.Join(r.dir, name), data, 0o600)
+}
+
+// redactKeys removes the keys from a license response, keeping their IDs. A
+// response that cannot be parsed is dropped.
+func redactKeys(body json.RawMessage) json.RawMessage {
+	if len(body) == 0 {
+		return nil
+	}
+	var res Response
+	if err := json.Unmarshal(body, &res); err != nil {
+		return nil
+	}
+	for i := range res.Keys {
+		res.Keys[i].K = ""
+	}
+	redacted, err := json.Marshal(res)
+	if err != nil {
+		return nil
+	}
+	return redacted
+}
+
+// LoadRecordings reads all recordings in dir in the order they were made.
+func LoadRecordings(dir string) ([]Recording, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	var recs []Recording
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		var rec Recording
+		if err := json.Unmarshal(data, &rec); err != nil {
+			return nil, fmt.Errorf("%s: %w", f, err)
+		}
+		recs = append(recs, rec)
+	}
+	sort.SliceStable(recs, func(i, j int) bool { return recs[i].RecordedAt.Before(recs[j].RecordedAt) })
+	return recs, nil
+}
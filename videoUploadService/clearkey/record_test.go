@@ -0,0 +1,50 @@
+package clearkey
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecorderRedactsKeys(t *testing.T) {
+	dir := t.TempDir()
+	r, err := NewRecorder(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	granted, _ := json.Marshal(Response{Type: "temporary", Keys: []JWK{{Kty: "oct", K: "c2VjcmV0LWtleS0wMDAwMA", Kid: "a2lkLTA"}}})
+	for _, rec := range []Recording{
+		{VideoID: "v1", Request: json.RawMessage(`{}`), Status: 200, Response: granted, RecordedAt: at},
+		{VideoID: "v2", Request: json.RawMessage(`{}`), Status: 200, Response: json.RawMessage(`"k=c2VjcmV0"`), RecordedAt: at.Add(time.Second)},
+		{VideoID: "v3", Request: json.RawMessage(`{}`), Status: 401, RecordedAt: at.Add(2 * time.Second)},
+	} {
+		if err := r.Save(rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	recs, err := LoadRecordings(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recs) != 3 {
+		t.Fatalf("loaded %d recordings, want 3", len(recs))
+	}
+	var res Response
+	if err := json.Unmarshal(recs[0].Response, &res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Type != "temporary" || len(res.Keys) != 1 || res.Keys[0].Kid != "a2lkLTA" || res.Keys[0].K != "" {
+		t.Errorf("recorded license %s, want the key ID without the key", recs[0].Response)
+	}
+	for _, rec := range recs {
+		if strings.Contains(string(rec.Response), "c2VjcmV0") {
+			t.Errorf("recording of %s kept a key: %s", rec.VideoID, rec.Response)
+		}
+	}
+	if recs[1].Response != nil || recs[2].Response != nil {
+		t.Errorf("responses %s and %s, want none", recs[1].Response, recs[2].Response)
+	}
+}
@@ -0,0 +1,103 @@
+// Command clearkey-replay sends recorded ClearKey license requests to a
+// playback server and reports whether the answers still match.
+//
+// Record requests by starting the server with CLEARKEY_RECORD_DIR set, then
+//
+//	go run ./cmd/clearkey-replay -dir recordings -url http://localhost:3600
+//
+// Recorded tokens expire; pass -token to replay with a freshly issued one.
+package main
+
+import (
+	"VideoUploadService/clearkey"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+)
+
+func main() {
+	dir := flag.String("dir", "recordings", "directory of recorded license requests")
+	baseURL := flag.String("url", "http://localhost:3600", "playback server")
+	token := flag.String("token", "", "playback token to use instead of the recorded ones")
+	flag.Parse()
+
+	recs, err := clearkey.LoadRecordings(*dir)
+	if err != nil {
+		log.Fatalf("Loading recordings: %v", err)
+	}
+	if len(recs) == 0 {
+		log.Fatalf("No recordings in %s", *dir)
+	}
+
+	failed := 0
+	for i, rec := range recs {
+		if *token != "" {
+			rec.Token = *token
+		}
+		status, kids, err := replay(*baseURL, rec)
+		if err != nil {
+			log.Fatalf("Replaying request %d: %v", i+1, err)
+		}
+		want := grantedKIDs(rec.Response)
+		ok := status == rec.Status && slices.Equal(kids, want)
+		result := "ok"
+		if !ok {
+			result = "MISMATCH"
+			failed++
+		}
+		fmt.Printf("%3d %s video=%s status=%d (recorded %d) kids=%v (recorded %v)\n",
+			i+1, result, rec.VideoID, status, rec.Status, kids, want)
+	}
+	fmt.Printf("%d of %d requests matched\n", len(recs)-failed, len(recs))
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// replay posts one recorded request and returns the status and granted key
+// IDs of the answer.
+func replay(baseURL string, rec clearkey.Recording) (int, []string, error) {
+	url := strings.TrimSuffix(baseURL, "/") + "/drm/clearkey/" + rec.VideoID + "/license"
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(rec.Request))
+	if err != nil {
+		return 0, nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if rec.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+rec.Token)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return 0, nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		return res.StatusCode, nil, nil
+	}
+	return res.StatusCode, grantedKIDs(body), nil
+}
+
+// grantedKIDs lists the key IDs of a license response, sorted.
+func grantedKIDs(body []byte) []string {
+	var res clearkey.Response
+	if len(body) == 0 || json.Unmarshal(body, &res) != nil {
+		return nil
+	}
+	var kids []string
+	for _, k := range res.Keys {
+		kids = append(kids, k.Kid)
+	}
+	slices.Sort(kids)
+	return kids
+}
@@ -0,0 +1,71 @@
+// Command rtmp-publish publishes a recorded FLV file to an RTMP server, to
+// exercise the live ingest without an encoder:
+//
+//	go run ./cmd/rtmp-publish -file fixture.flv -url rtmp://localhost:1935/live/<stream-key>
+//
+// Tags are paced by their timestamps unless -fast is given. It exits
+// non-zero if the server rejects the stream or drops the connection.
+package main
+
+import (
+	"VideoUploadService/flv"
+	"VideoUploadService/rtmp"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+)
+
+func main() {
+	file := flag.String("file", "", "FLV file to publish")
+	url := flag.String("url", "rtmp://localhost:1935/live/test", "publish URL ending in the stream key")
+	fast := flag.Bool("fast", false, "send tags as fast as possible instead of in real time")
+	flag.Parse()
+
+	f, err := os.Open(*file)
+	if err != nil {
+		log.Fatalf("Opening fixture: %v", err)
+	}
+	defer f.Close()
+	r, err := flv.NewReader(f)
+	if err != nil {
+		log.Fatalf("Reading %s: %v", *file, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	client, err := rtmp.Publish(ctx, *url)
+	cancel()
+	if err != nil {
+		log.Fatalf("Publishing: %v", err)
+	}
+
+	start := time.Now()
+	tags, bytes := 0, 0
+	for {
+		tag, err := r.ReadTag()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			log.Fatalf("Reading %s: %v", *file, err)
+		}
+		if !*fast {
+			time.Sleep(time.Until(start.Add(time.Duration(tag.Timestamp) * time.Millisecond)))
+		}
+		select {
+		case <-client.Done():
+			log.Fatalf("Server closed the connection: %v", client.Err())
+		default:
+		}
+		if err := client.WriteTag(tag); err != nil {
+			log.Fatalf("Sending tag %d: %v", tags+1, err)
+		}
+		tags++
+		bytes += len(tag.Data)
+	}
+	client.Close()
+	fmt.Printf("Published %d tags (%d bytes) in %s\n", tags, bytes, time.Since(start).Round(time.Millisecond))
+}
@@ -0,0 +1,288 @@
+package contentkey
+
+import (
+	"VideoUploadService/schema"
+	pbt "VideoUploadService/transcoding"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// KeySize is the size of AES-128 content keys and their IVs.
+const KeySize = 16
+
+// DefaultRotation is how many segments share one key unless configured
+// otherwise.
+const DefaultRotation = 150
+
+// maxKeys bounds the number of keys generated for a single video.
+const maxKeys = 1000
+
+var (
+	ErrNotFound = errors.New("content key not found")
+	ErrSecret   = errors.New("content key secret must be 32 bytes")
+)
+
+// Key is the AES-128 key for one rotation period of a video.
+type Key struct {
+	VideoID   string
+	Index     uint32
+	Key       []byte
+	IV        []byte
+	CreatedAt time.Time
+}
+
+// URI is where players fetch the key, relative to the playback host.
+func (k Key) URI() string {
+	return "/hls/" + k.VideoID + "/keys/" + strconv.Itoa(int(k.Index)) + ".key"
+}
+
+// KID is the CENC key ID of the key. It is derived from the video and index,
+// so it needs no storage and is stable across re-packaging.
+func (k Key) KID() []byte {
+	sum := sha256.Sum256(additionalData(k.VideoID, k.Index))
+	return sum[:16]
+}
+
+// Service generates per-video content keys and stores them encrypted with a
+// master secret.
+type Service struct {
+	db       *sql.DB
+	aead     cipher.AEAD
+	rotation uint32
+	tiers    map[string]bool
+}
+
+// Default is nil unless a content key secret is configured in main.
+var Default *Service
+
+// migrations create the content_keys table. Earlier versions created it
+// in the catalog migrations, hence IF NOT EXISTS.
+var migrations = []string{
+	`CREATE TABLE IF NOT EXISTS content_keys (
+		video_id TEXT NOT NULL,
+		key_index INTEGER NOT NULL,
+		wrapped_key TEXT NOT NULL,
+		iv TEXT NOT NULL,
+		created_at TIMESTAMP NOT NULL,
+		PRIMARY KEY (video_id, key_index)
+	)`,
+}
+
+// Open stores keys in the content_keys table of db, migrating it first.
+// secret is the 32-byte master key that wraps content keys at rest.
+func Open(db *sql.DB, secret []byte) (*Service, error) {
+	if len(secret) != 32 {
+		return nil, ErrSecret
+	}
+	if err := schema.Migrate(context.Background(), db, "contentkey", migrations); err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(secret)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Service{
+		db:       db,
+		aead:     aead,
+		rotation: DefaultRotation,
+		tiers:    map[string]bool{"premium": true},
+	}, nil
+}
+
+// ParseSecret decodes a master secret given as hex or base64.
+func ParseSecret(s string) ([]byte, error) {
+	if b, err := hex.DecodeString(s); err == nil && len(b) == 32 {
+		return b, nil
+	}
+	if b, err := base64.StdEncoding.DecodeString(s); err == nil && len(b) == 32 {
+		return b, nil
+	}
+	return nil, ErrSecret
+}
+
+// SetPolicy sets how many segments share a key and which uploader tiers get
+// encrypted output. A "*" tier encrypts everything.
+func (s *Service) SetPolicy(rotation uint32, tiers []string) {
+	if rotation == 0 {
+		rotation = DefaultRotation
+	}
+	s.rotation = rotation
+	s.tiers = make(map[string]bool)
+	for _, t := range tiers {
+		if t = strings.TrimSpace(t); t != "" {
+			s.tiers[t] = true
+		}
+	}
+}
+
+// Required reports whether videos uploaded by the tier are encrypted.
+func (s *Service) Required(tier string) bool {
+	if s == nil {
+		return false
+	}
+	return s.tiers["*"] || s.tiers[tier]
+}
+
+// Prepare makes sure a video has enough keys for its estimated duration and
+// returns the encryption settings for the encoder. The settings only
+// reference the keys; workers fetch them with Material. Existing keys are
+// reused, so retries keep producing segments players can decrypt with cached
+// keys.
+func (s *Service) Prepare(ctx context.Context, videoID string, durationSeconds float64, segmentSeconds uint32) (*pbt.HlsEncryption, error) {
+	want := 1
+	if durationSeconds > 0 && segmentSeconds > 0 {
+		segments := math.Ceil(durationSeconds / float64(segmentSeconds))
+		want = int(math.Ceil(segments / float64(s.rotation)))
+	}
+	want = min(max(want, 1), maxKeys)
+
+	keys, err := s.List(ctx, videoID)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(keys); i < want; i++ {
+		k, err := s.create(ctx, videoID, uint32(i))
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+
+	enc := &pbt.HlsEncryption{Method: "AES-128", RotationSegments: s.rotation}
+	for _, k := range keys {
+		enc.Keys = append(enc.Keys, &pbt.ContentKey{Index: k.Index, Uri: k.URI(), Kid: k.KID()})
+	}
+	return enc, nil
+}
+
+// Material returns the keys of a video for an encoder to encrypt with.
+func (s *Service) Material(ctx context.Context, videoID string) ([]*pbt.ContentKeyMaterial, error) {
+	keys, err := s.List(ctx, videoID)
+	if err != nil {
+		return nil, err
+	}
+	material := make([]*pbt.ContentKeyMaterial, 0, len(keys))
+	for _, k := range keys {
+		material = append(material, &pbt.ContentKeyMaterial{Index: k.Index, Key: k.Key, Iv: k.IV})
+	}
+	return material, nil
+}
+
+// KeyIDs returns the hex key IDs of a video's keys ordered by index, for
+// manifests of output packaged without its profile.
+func (s *Service) KeyIDs(ctx context.Context, videoID string) ([]string, error) {
+	keys, err := s.List(ctx, videoID)
+	if err != nil {
+		return nil, err
+	}
+	kids := make([]string, 0, len(keys))
+	for _, k := range keys {
+		kids = append(kids, hex.EncodeToString(k.KID()))
+	}
+	return kids, nil
+}
+
+// Get returns one key of a video.
+func (s *Service) Get(ctx context.Context, videoID string, index uint32) (Key, error) {
+	var wrapped, iv string
+	k := Key{VideoID: videoID, Index: index}
+	err := s.db.QueryRowContext(ctx,
+		`SELECT wrapped_key, iv, created_at FROM content_keys WHERE video_id = $1 AND key_index = $2`,
+		videoID, index).Scan(&wrapped, &iv, &k.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Key{}, ErrNotFound
+	}
+	if err != nil {
+		return Key{}, err
+	}
+	return s.unwrap(k, wrapped, iv)
+}
+
+// List returns the keys of a video ordered by index.
+func (s *Service) List(ctx context.Context, videoID string) ([]Key, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT key_index, wrapped_key, iv, created_at FROM content_keys WHERE video_id = $1 ORDER BY key_index`,
+		videoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var keys []Key
+	for rows.Next() {
+		var wrapped, iv string
+		k := Key{VideoID: videoID}
+		if err := rows.Scan(&k.Index, &wrapped, &iv, &k.CreatedAt); err != nil {
+			return nil, err
+		}
+		if k, err = s.unwrap(k, wrapped, iv); err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+// Delete removes all keys of a video, which makes its segments unplayable.
+func (s *Service) Delete(ctx context.Context, videoID string) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM content_keys WHERE video_id = $1`, videoID)
+	return err
+}
+
+func (s *Service) create(ctx context.Context, videoID string, index uint32) (Key, error) {
+	k := Key{VideoID: videoID, Index: index, Key: make([]byte, KeySize), IV: make([]byte, KeySize), CreatedAt: time.Now().UTC()}
+	if _, err := rand.Read(k.Key); err != nil {
+		return Key{}, err
+	}
+	if _, err := rand.Read(k.IV); err != nil {
+		return Key{}, err
+	}
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return Key{}, err
+	}
+	wrapped := s.aead.Seal(nonce, nonce, k.Key, additionalData(videoID, index))
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO content_keys (video_id, key_index, wrapped_key, iv, created_at) VALUES ($1, $2, $3, $4, $5)`,
+		videoID, index, base64.StdEncoding.EncodeToString(wrapped), hex.EncodeToString(k.IV), k.CreatedAt)
+	if err != nil {
+		return Key{}, err
+	}
+	return k, nil
+}
+
+func (s *Service) unwrap(k Key, wrapped, iv string) (Key, error) {
+	sealed, err := base64.StdEncoding.DecodeString(wrapped)
+	if err != nil || len(sealed) < s.aead.NonceSize() {
+		return Key{}, fmt.Errorf("content key %s/%d: malformed", k.VideoID, k.Index)
+	}
+	n := s.aead.NonceSize()
+	if k.Key, err = s.aead.Open(nil, sealed[:n], sealed[n:], additionalData(k.VideoID, k.Index)); err != nil {
+		return Key{}, fmt.Errorf("content key %s/%d: %w", k.VideoID, k.Index, err)
+	}
+	if k.IV, err = hex.DecodeString(iv); err != nil {
+		return Key{}, fmt.Errorf("content key %s/%d: %w", k.VideoID, k.Index, err)
+	}
+	return k, nil
+}
+
+// additionalData binds a wrapped key to its video and index, so a key row
+// copied to another video does not decrypt.
+func additionalData(videoID string, index uint32) []byte {
+	return []byte(videoID + "/" + strconv.Itoa(int(index)))
+}
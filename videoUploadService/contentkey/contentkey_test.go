@@ -0,0 +1,79 @@
+package contentkey
+
+import (
+	"VideoUploadService/catalog"
+	"bytes"
+	"context"
+	"testing"
+)
+
+func openTestService(t *testing.T) *Service {
+	t.Helper()
+	store, err := catalog.Open("sqlite", "file::memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	s, err := Open(store.DB(), bytes.Repeat([]byte{7}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestPrepareReferencesKeys(t *testing.T) {
+	s := openTestService(t)
+	s.SetPolicy(10, []string{"premium"})
+	ctx := context.Background()
+
+	// 95s of 4s segments is 24 segments, three keys of 10 segments each.
+	enc, err := s.Prepare(ctx, "v1", 95, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if enc.Method != "AES-128" || enc.RotationSegments != 10 || len(enc.Keys) != 3 {
+		t.Fatalf("encryption = %v", enc)
+	}
+	material, err := s.Material(ctx, "v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, ref := range enc.Keys {
+		k, err := s.Get(ctx, "v1", ref.Index)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ref.Uri != k.URI() || !bytes.Equal(ref.Kid, k.KID()) {
+			t.Errorf("key %d reference = %v", i, ref)
+		}
+		if !bytes.Equal(material[i].Key, k.Key) || !bytes.Equal(material[i].Iv, k.IV) || len(k.Key) != KeySize {
+			t.Errorf("key %d material does not match the stored key", i)
+		}
+	}
+
+	// A retry reuses the keys and only adds what a longer estimate needs.
+	again, err := s.Prepare(ctx, "v1", 125, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(again.Keys) != 4 || !bytes.Equal(again.Keys[0].Kid, enc.Keys[0].Kid) {
+		t.Errorf("retry keys = %v", again.Keys)
+	}
+	if first, _ := s.Material(ctx, "v1"); !bytes.Equal(first[0].Key, material[0].Key) {
+		t.Error("retry replaced an existing key")
+	}
+}
+
+func TestWrappedKeysAreBoundToVideo(t *testing.T) {
+	s := openTestService(t)
+	ctx := context.Background()
+	if _, err := s.Prepare(ctx, "v1", 0, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.db.Exec(`UPDATE content_keys SET video_id = 'v2'`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Get(ctx, "v2", 0); err == nil {
+		t.Error("a key moved to another video still decrypts")
+	}
+}
@@ -0,0 +1,278 @@
+// Package flv reads FLV files and parses the audio and video tags carried
+// by FLV files and RTMP alike.
+package flv
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Tag types.
+const (
+	TagAudio  = 8
+	TagVideo  = 9
+	TagScript = 18
+)
+
+var ErrNotFLV = errors.New("not an FLV file")
+
+// Tag is one FLV tag. RTMP audio, video and data messages carry the same
+// bodies. Timestamp is in milliseconds.
+type Tag struct {
+	Type      uint8
+	Timestamp uint32
+	Data      []byte
+}
+
+// MaxTagSize bounds tag bodies; the FLV tag header allows 16 MiB.
+const MaxTagSize = 1<<24 - 1
+
+// Reader reads tags from an FLV file.
+type Reader struct {
+	r *bufio.Reader
+	// HasAudio and HasVideo are the flags of the file header.
+	HasAudio bool
+	HasVideo bool
+}
+
+// NewReader reads the FLV file header.
+func NewReader(r io.Reader) (*Reader, error) {
+	br := bufio.NewReader(r)
+	var header [9]byte
+	if _, err := io.ReadFull(br, header[:]); err != nil {
+		return nil, err
+	}
+	if string(header[:3]) != "FLV" {
+		return nil, ErrNotFLV
+	}
+	offset := binary.BigEndian.Uint32(header[5:])
+	if offset < 9 {
+		return nil, ErrNotFLV
+	}
+	// Skip the rest of the header and the first previous tag size.
+	if _, err := br.Discard(int(offset-9) + 4); err != nil {
+		return nil, err
+	}
+	return &Reader{r: br, HasAudio: header[4]&0x04 != 0, HasVideo: header[4]&0x01 != 0}, nil
+}
+
+// ReadTag returns the next tag, or io.EOF at the end of the file.
+func (r *Reader) ReadTag() (Tag, error) {
+	var header [11]byte
+	if _, err := io.ReadFull(r.r, header[:]); err != nil {
+		return Tag{}, err
+	}
+	if header[0]&0x20 != 0 {
+		return Tag{}, errors.New("flv: encrypted tags are not supported")
+	}
+	size := uint32(header[1])<<16 | uint32(header[2])<<8 | uint32(header[3])
+	ts := uint32(header[4])<<16 | uint32(header[5])<<8 | uint32(header[6]) | uint32(header[7])<<24
+	t := Tag{Type: header[0] & 0x1f, Timestamp: ts, Data: make([]byte, size)}
+	if _, err := io.ReadFull(r.r, t.Data); err != nil {
+		return Tag{}, unexpected(err)
+	}
+	if _, err := r.r.Discard(4); err != nil {
+		return Tag{}, unexpected(err)
+	}
+	return t, nil
+}
+
+func unexpected(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// Video codec IDs and frame types.
+const (
+	CodecAVC = 7
+
+	FrameKey   = 1
+	FrameInter = 2
+)
+
+// AVC packet types.
+const (
+	AVCSequenceHeader = 0
+	AVCNALU           = 1
+	AVCEndOfSequence  = 2
+)
+
+// VideoTag is a parsed video tag body.
+type VideoTag struct {
+	FrameType uint8
+	CodecID   uint8
+	// PacketType and CompositionTime are only set for AVC.
+	PacketType      uint8
+	CompositionTime int32
+	Data            []byte
+}
+
+// Keyframe reports whether the tag starts a group of pictures.
+func (v VideoTag) Keyframe() bool {
+	return v.FrameType == FrameKey
+}
+
+// ParseVideo parses a video tag body.
+func ParseVideo(data []byte) (VideoTag, error) {
+	if len(data) < 1 {
+		return VideoTag{}, errors.New("flv: empty video tag")
+	}
+	v := VideoTag{FrameType: data[0] >> 4, CodecID: data[0] & 0x0f, Data: data[1:]}
+	if v.CodecID != CodecAVC {
+		return v, nil
+	}
+	if len(data) < 5 {
+		return VideoTag{}, errors.New("flv: short AVC video tag")
+	}
+	v.PacketType = data[1]
+	// The composition time is a signed 24-bit value.
+	v.CompositionTime = int32(uint32(data[2])<<24|uint32(data[3])<<16|uint32(data[4])<<8) >> 8
+	v.Data = data[5:]
+	return v, nil
+}
+
+// AVCConfig is an AVCDecoderConfigurationRecord, the H.264 sequence header.
+type AVCConfig struct {
+	Profile        uint8
+	Compatibility  uint8
+	Level          uint8
+	NALULengthSize int
+	SPS            [][]byte
+	PPS            [][]byte
+}
+
+// ParseAVCConfig parses an AVCDecoderConfigurationRecord.
+func ParseAVCConfig(data []byte) (AVCConfig, error) {
+	bad := errors.New("flv: malformed AVC decoder configuration")
+	if len(data) < 7 || data[0] != 1 {
+		return AVCConfig{}, bad
+	}
+	c := AVCConfig{Profile: data[1], Compatibility: data[2], Level: data[3], NALULengthSize: int(data[4]&0x03) + 1}
+	rest := data[5:]
+	// readSets reads a parameter set count masked by mask and the sets
+	// following it.
+	readSets := func(mask byte) ([][]byte, error) {
+		if len(rest) < 1 {
+			return nil, bad
+		}
+		count := int(rest[0] & mask)
+		rest = rest[1:]
+		var sets [][]byte
+		for i := 0; i < count; i++ {
+			if len(rest) < 2 {
+				return nil, bad
+			}
+			n := int(binary.BigEndian.Uint16(rest))
+			if len(rest) < 2+n {
+				return nil, bad
+			}
+			sets = append(sets, rest[2:2+n])
+			rest = rest[2+n:]
+		}
+		return sets, nil
+	}
+	var err error
+	if c.SPS, err = readSets(0x1f); err != nil {
+		return AVCConfig{}, err
+	}
+	if c.PPS, err = readSets(0xff); err != nil {
+		return AVCConfig{}, err
+	}
+	return c, nil
+}
+
+// Codec returns the RFC 6381 codec string, e.g. "avc1.64001f".
+func (c AVCConfig) Codec() string {
+	return fmt.Sprintf("avc1.%02x%02x%02x", c.Profile, c.Compatibility, c.Level)
+}
+
+// SplitNALUs splits a length-prefixed AVC payload into NAL units.
+func SplitNALUs(data []byte, lengthSize int) ([][]byte, error) {
+	var nalus [][]byte
+	for len(data) > 0 {
+		if len(data) < lengthSize {
+			return nil, errors.New("flv: truncated NAL unit length")
+		}
+		n := 0
+		for _, b := range data[:lengthSize] {
+			n = n<<8 | int(b)
+		}
+		data = data[lengthSize:]
+		if n > len(data) {
+			return nil, errors.New("flv: truncated NAL unit")
+		}
+		nalus = append(nalus, data[:n])
+		data = data[n:]
+	}
+	return nalus, nil
+}
+
+// Audio formats and AAC packet types.
+const (
+	SoundAAC = 10
+
+	AACSequenceHeader = 0
+	AACRaw            = 1
+)
+
+// AudioTag is a parsed audio tag body.
+type AudioTag struct {
+	Format uint8
+	// PacketType is only set for AAC.
+	PacketType uint8
+	Data       []byte
+}
+
+// ParseAudio parses an audio tag body.
+func ParseAudio(data []byte) (AudioTag, error) {
+	if len(data) < 1 {
+		return AudioTag{}, errors.New("flv: empty audio tag")
+	}
+	a := AudioTag{Format: data[0] >> 4, Data: data[1:]}
+	if a.Format != SoundAAC {
+		return a, nil
+	}
+	if len(data) < 2 {
+		return AudioTag{}, errors.New("flv: short AAC audio tag")
+	}
+	a.PacketType = data[1]
+	a.Data = data[2:]
+	return a, nil
+}
+
+// AACConfig is the part of an AudioSpecificConfig players need.
+type AACConfig struct {
+	ObjectType      uint8
+	SampleRateIndex uint8
+	SampleRate      int
+	Channels        uint8
+}
+
+var sampleRates = []int{96000, 88200, 64000, 48000, 44100, 32000, 24000, 22050, 16000, 12000, 11025, 8000, 7350}
+
+// ParseAACConfig parses an AudioSpecificConfig.
+func ParseAACConfig(data []byte) (AACConfig, error) {
+	if len(data) < 2 {
+		return AACConfig{}, errors.New("flv: short AAC audio specific config")
+	}
+	c := AACConfig{
+		ObjectType:      data[0] >> 3,
+		SampleRateIndex: (data[0]&0x07)<<1 | data[1]>>7,
+		Channels:        (data[1] >> 3) & 0x0f,
+	}
+	if int(c.SampleRateIndex) >= len(sampleRates) {
+		return AACConfig{}, errors.New("flv: unsupported AAC sample rate index")
+	}
+	c.SampleRate = sampleRates[c.SampleRateIndex]
+	return c, nil
+}
+
+// Codec returns the RFC 6381 codec string, e.g. "mp4a.40.2".
+func (c AACConfig) Codec() string {
+	return fmt.Sprintf("mp4a.40.%d", c.ObjectType)
+}
@@ -0,0 +1,111 @@
+package flv
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestParseVideo(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		want    VideoTag
+		wantErr bool
+	}{
+		{"keyframe", []byte{0x17, 0x01, 0x00, 0x00, 0x00, 0xaa}, VideoTag{FrameType: FrameKey, CodecID: CodecAVC, PacketType: AVCNALU, Data: []byte{0xaa}}, false},
+		{"composition time", []byte{0x27, 0x01, 0x00, 0x00, 0xc8}, VideoTag{FrameType: FrameInter, CodecID: CodecAVC, PacketType: AVCNALU, CompositionTime: 200, Data: []byte{}}, false},
+		{"negative composition time", []byte{0x27, 0x01, 0xff, 0xff, 0xd8}, VideoTag{FrameType: FrameInter, CodecID: CodecAVC, PacketType: AVCNALU, CompositionTime: -40, Data: []byte{}}, false},
+		{"other codec", []byte{0x12, 0xbb}, VideoTag{FrameType: FrameKey, CodecID: 2, Data: []byte{0xbb}}, false},
+		{"short avc", []byte{0x17, 0x01}, VideoTag{}, true},
+		{"empty", nil, VideoTag{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseVideo(tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseAVCConfig(t *testing.T) {
+	record := []byte{1, 0x64, 0x00, 0x1f, 0xff, 0xe1, 0x00, 0x02, 0x67, 0x64, 0x01, 0x00, 0x02, 0x68, 0xeb}
+	c, err := ParseAVCConfig(record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := AVCConfig{Profile: 0x64, Level: 0x1f, NALULengthSize: 4, SPS: [][]byte{{0x67, 0x64}}, PPS: [][]byte{{0x68, 0xeb}}}
+	if !reflect.DeepEqual(c, want) || c.Codec() != "avc1.64001f" {
+		t.Errorf("got %+v (%s), want %+v", c, c.Codec(), want)
+	}
+	for _, bad := range [][]byte{nil, record[:6], record[:9], append([]byte{2}, record[1:]...)} {
+		if _, err := ParseAVCConfig(bad); err == nil {
+			t.Errorf("ParseAVCConfig(%x) succeeded", bad)
+		}
+	}
+}
+
+func TestParseAACConfig(t *testing.T) {
+	tests := []struct {
+		data    []byte
+		want    AACConfig
+		wantErr bool
+	}{
+		{[]byte{0x12, 0x10}, AACConfig{ObjectType: 2, SampleRateIndex: 4, SampleRate: 44100, Channels: 2}, false},
+		{[]byte{0x11, 0x88}, AACConfig{ObjectType: 2, SampleRateIndex: 3, SampleRate: 48000, Channels: 1}, false},
+		{[]byte{0x17, 0x90}, AACConfig{}, true},
+		{[]byte{0x12}, AACConfig{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseAACConfig(tt.data)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseAACConfig(%x) = %+v, %v; want %+v", tt.data, got, err, tt.want)
+		}
+	}
+}
+
+func TestSplitNALUs(t *testing.T) {
+	got, err := SplitNALUs([]byte{0, 2, 0x65, 0x88, 0, 1, 0x06}, 2)
+	if err != nil || !reflect.DeepEqual(got, [][]byte{{0x65, 0x88}, {0x06}}) {
+		t.Errorf("got %x, %v", got, err)
+	}
+	if _, err := SplitNALUs([]byte{0, 3, 0x65}, 2); err == nil {
+		t.Error("truncated NAL unit was accepted")
+	}
+}
+
+func TestReader(t *testing.T) {
+	file := []byte{'F', 'L', 'V', 1, 0x05, 0, 0, 0, 9, 0, 0, 0, 0,
+		// Video tag of 2 bytes at timestamp 0x01020304.
+		9, 0, 0, 2, 0x02, 0x03, 0x04, 0x01, 0, 0, 0, 0x17, 0x00, 0, 0, 0, 13,
+		// Audio tag cut short.
+		8, 0, 0, 4, 0, 0, 0, 0, 0, 0, 0, 0xaf}
+	r, err := NewReader(bytes.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.HasAudio || !r.HasVideo {
+		t.Errorf("flags: audio %v video %v", r.HasAudio, r.HasVideo)
+	}
+	tag, err := r.ReadTag()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tag.Type != TagVideo || tag.Timestamp != 0x01020304 || !bytes.Equal(tag.Data, []byte{0x17, 0x00}) {
+		t.Errorf("tag = %+v", tag)
+	}
+	if _, err := r.ReadTag(); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("truncated tag: err = %v", err)
+	}
+
+	if _, err := NewReader(bytes.NewReader([]byte("GIF89a\x00\x00\x00\x00\x00\x00\x00"))); !errors.Is(err, ErrNotFLV) {
+		t.Errorf("not an FLV file: err = %v", err)
+	}
+}
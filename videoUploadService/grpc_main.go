@@ -1,23 +1,259 @@
 package main
 
 import (
+	"VideoUploadService/catalog"
+	"VideoUploadService/clearkey"
+	"VideoUploadService/contentkey"
+	http_main "VideoUploadService/http_upload"
+	pba "VideoUploadService/jobadmin"
+	"VideoUploadService/jobqueue"
+	"VideoUploadService/live"
+	"VideoUploadService/livearchive"
+	pbl "VideoUploadService/livehealth"
+	"VideoUploadService/livehls"
+	"VideoUploadService/packager"
+	"VideoUploadService/playback"
+	"VideoUploadService/profile"
+	"VideoUploadService/restream"
+	"VideoUploadService/rtmp"
 	up "VideoUploadService/services"
+	"VideoUploadService/storage"
+	"VideoUploadService/streamkey"
+	"VideoUploadService/transcodectl"
+	"VideoUploadService/transcodestatus"
+	pbt "VideoUploadService/transcoding"
 	pb "VideoUploadService/upload"
+	"VideoUploadService/uploadstatus"
+	pbc "VideoUploadService/videocatalog"
+	"context"
 	"log"
 	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/gofiber/fiber/v2"
+	"github.com/joho/godotenv"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/reflection"
 )
 
 func main() {
+	godotenv.Load()
+	if path := os.Getenv("ENCODING_PRESETS"); path != "" {
+		if err := profile.Load(path); err != nil {
+			log.Fatalf("Failed to load encoding presets: %v", err)
+		}
+	}
+	if name := os.Getenv("ENCODING_PRESET"); name != "" {
+		if err := profile.SetDefault(name); err != nil {
+			log.Fatalf("Failed to select encoding preset: %v", err)
+		}
+	}
+
+	driver, dsn := os.Getenv("CATALOG_DRIVER"), os.Getenv("CATALOG_DSN")
+	if driver == "" {
+		driver, dsn = "sqlite", "catalog.db"
+	}
+	store, err := catalog.Open(driver, dsn)
+	if err != nil {
+		log.Fatalf("Failed to open catalog: %v", err)
+	}
+	catalog.Default = catalog.New(store)
+	videoOwner := func(videoID string) (string, bool) {
+		v, err := catalog.Default.Get(context.Background(), videoID)
+		if err != nil || v.State == catalog.StateDeleted {
+			return "", false
+		}
+		return v.Owner, true
+	}
+	transcodectl.Owner = videoOwner
+	transcodectl.Profile = func(ctx context.Context, videoID, tier string) (*pbt.EncodingProfile, error) {
+		return up.Profile(ctx, videoID, tier, os.Getenv("DEV_PATH")+videoID)
+	}
+	transcodestatus.Owner = videoOwner
+	transcodestatus.Default.OnUpdate(up.TrackTranscode)
+
+	if secret := os.Getenv("CONTENT_KEY_SECRET"); secret != "" {
+		// Encoders fetch the keys through the job queue, which is closed
+		// without a worker token.
+		if os.Getenv("TRANSCODE_WORKER_TOKEN") == "" {
+			log.Fatalf("CONTENT_KEY_SECRET requires TRANSCODE_WORKER_TOKEN")
+		}
+		master, err := contentkey.ParseSecret(secret)
+		if err != nil {
+			log.Fatalf("CONTENT_KEY_SECRET: %v", err)
+		}
+		contentkey.Default, err = contentkey.Open(store.DB(), master)
+		if err != nil {
+			log.Fatalf("Failed to open content keys: %v", err)
+		}
+		rotation, _ := strconv.Atoi(os.Getenv("CONTENT_KEY_ROTATION_SEGMENTS"))
+		tiers := []string{"premium"}
+		if t := os.Getenv("CONTENT_KEY_TIERS"); t != "" {
+			tiers = strings.Split(t, ",")
+		}
+		contentkey.Default.SetPolicy(uint32(rotation), tiers)
+		jobqueue.ContentKeys = contentkey.Default.Material
+		packager.KeyIDs = contentkey.Default.KeyIDs
+
+		clearkey.Default, err = clearkey.OpenAudit(store.DB())
+		if err != nil {
+			log.Fatalf("Failed to open license audit log: %v", err)
+		}
+	}
+
+	streamkey.Default, err = streamkey.Open(store.DB(), live.Default)
+	if err != nil {
+		log.Fatalf("Failed to open stream keys: %v", err)
+	}
+	live.Authenticate = streamkey.Default.Authenticate
+	restream.Default, err = restream.Open(store.DB(), live.Default)
+	if err != nil {
+		log.Fatalf("Failed to open restream destinations: %v", err)
+	}
+	if allow, _ := strconv.ParseBool(os.Getenv("RESTREAM_ALLOW_INTERNAL")); allow {
+		restream.Default.SetAllowInternal(true)
+	}
+
 	lis, err := net.Listen("tcp", ":50052")
 	if err != nil {
 		log.Fatalf("Failed to listen: %v", err)
 	}
 
-	grpcServer := grpc.NewServer()
+	media := storage.NewLocal(os.Getenv("DEV_PATH"))
+	packager.Default = packager.New(media)
+	packager.Default.SetSprites(packager.DefaultSprites, os.Getenv("DEV_PATH"))
+	playback.Default = playback.New(media, catalog.Default, os.Getenv("PLAYBACK_ALLOWED_ORIGIN"))
+	playback.Default.SetPackager(packager.Default)
+	catalog.TrickPlayFor = playback.Default.TrickPlay
+	livearchive.Default = livearchive.New(media, catalog.Default)
+	livearchive.HandOff = func(videoID string) {
+		up.HandOff(videoID, os.Getenv("DEV_PATH")+videoID)
+	}
+	if limits := os.Getenv("PLAYBACK_TIER_MAX_HEIGHT"); limits != "" {
+		// e.g. "free=720,premium=0"
+		maxHeight := make(map[string]uint32)
+		for _, entry := range strings.Split(limits, ",") {
+			tier, height, _ := strings.Cut(entry, "=")
+			h, err := strconv.Atoi(height)
+			if err != nil {
+				log.Fatalf("PLAYBACK_TIER_MAX_HEIGHT: invalid height for %q", tier)
+			}
+			maxHeight[strings.TrimSpace(tier)] = uint32(h)
+		}
+		playback.Default.SetTierLimits(maxHeight)
+	}
+	if key := os.Getenv("PLAYBACK_SIGNING_KEY"); key != "" {
+		ttl, _ := time.ParseDuration(os.Getenv("PLAYBACK_TOKEN_TTL"))
+		playback.Default.SetSigner(playback.NewSigner([]byte(key)), ttl, os.Getenv("PLAYBACK_BASE_URL"))
+	} else {
+		log.Printf("PLAYBACK_SIGNING_KEY is not set; only public and unlisted videos can be played")
+		if contentkey.Default != nil {
+			log.Printf("Content keys require playback tokens; encrypted videos cannot be played")
+		}
+	}
+	playback.Default.SetKeys(contentkey.Default)
+	if dir := os.Getenv("CLEARKEY_RECORD_DIR"); dir != "" {
+		recorder, err := clearkey.NewRecorder(dir)
+		if err != nil {
+			log.Fatalf("CLEARKEY_RECORD_DIR: %v", err)
+		}
+		playback.Default.SetRecorder(recorder)
+		log.Printf("Recording license requests to %s", dir)
+	}
+
+	app := fiber.New(fiber.Config{
+		BodyLimit: 5 * 1024 * 1024 * 1024,
+	})
+	http_main.SetupRoutes(app)
+	transcodestatus.SetupRoutes(app)
+	transcodectl.SetupRoutes(app)
+	jobqueue.SetupRoutes(app)
+	catalog.SetupRoutes(app)
+	playback.SetupAPIRoutes(app)
+	live.SetupRoutes(app)
+	streamkey.SetupRoutes(app)
+	livearchive.SetupRoutes(app)
+	restream.SetupRoutes(app)
+	go func() {
+		log.Fatal(app.Listen(":3500"))
+	}()
+
+	liveConfig := livehls.DefaultConfig
+	if liveConfig.Format, err = livehls.ParseFormat(os.Getenv("LIVE_SEGMENT_FORMAT")); err != nil {
+		log.Fatalf("LIVE_SEGMENT_FORMAT: %v", err)
+	}
+	if d, err := time.ParseDuration(os.Getenv("LIVE_SEGMENT_DURATION")); err == nil && d > 0 {
+		liveConfig.TargetDuration = d
+	}
+	if n, err := strconv.Atoi(os.Getenv("LIVE_PLAYLIST_WINDOW")); err == nil && n > 0 {
+		liveConfig.Window = n
+	}
+	if d, err := time.ParseDuration(os.Getenv("LIVE_DVR_WINDOW")); err == nil && d > 0 {
+		liveConfig.DVRWindow = d
+	}
+	if d, err := time.ParseDuration(os.Getenv("LIVE_RETENTION")); err == nil && d > 0 {
+		liveConfig.Retention = d
+	}
+	livehls.Default = livehls.New(liveConfig)
+	livehls.Default.SetStorage(media)
+	go livehls.Default.RunRetention(time.Hour)
+	livehls.Default.OnRecorded(livearchive.Default.Recorded)
+	live.Default.OnStart(livehls.Default.Start)
+	live.Default.OnStart(restream.Default.Start)
+	playback.Default.SetLive(livehls.Default)
+	rtmpServer := &rtmp.Server{Handler: live.Ingest{Hub: live.Default}}
+	go func() {
+		addr := os.Getenv("RTMP_ADDR")
+		if addr == "" {
+			addr = ":1935"
+		}
+		log.Fatal(rtmpServer.ListenAndServe(addr))
+	}()
+
+	playbackApp := fiber.New()
+	playback.SetupRoutes(playbackApp)
+	go func() {
+		addr := os.Getenv("PLAYBACK_ADDR")
+		if addr == "" {
+			addr = ":3600"
+		}
+		log.Fatal(playbackApp.Listen(addr))
+	}()
+
+	if d, err := time.ParseDuration(os.Getenv("UPLOAD_STATUS_RETENTION")); err == nil && d > 0 {
+		uploadstatus.Default.SetRetention(d)
+	}
+
+	queueConfig := jobqueue.DefaultConfig
+	if n, err := strconv.Atoi(os.Getenv("TRANSCODE_CONCURRENCY")); err == nil && n > 0 {
+		queueConfig.MaxActive = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("TRANSCODE_MAX_ATTEMPTS")); err == nil && n > 0 {
+		queueConfig.MaxAttempts = n
+	}
+	if d, err := time.ParseDuration(os.Getenv("TRANSCODE_JOB_RETENTION")); err == nil && d > 0 {
+		queueConfig.Retention = d
+	}
+	jobqueue.Default.SetConfig(queueConfig)
+	jobqueue.Default.OnChange(up.TrackJob)
+	jobqueue.Finalize = up.Finalize
+	// Encoders that don't lease jobs themselves are fed by push workers.
+	if os.Getenv("TRANSCODE_DISPATCH") != "pull" {
+		if err := jobqueue.RunPushWorkers(jobqueue.Default, "localhost:50051", queueConfig.MaxActive); err != nil {
+			log.Fatalf("Failed to start transcode workers: %v", err)
+		}
+	}
+
+	// Only workers holding the shared token may lease and settle jobs.
+	grpcServer := grpc.NewServer(grpc.ChainUnaryInterceptor(jobqueue.WorkerAuth(os.Getenv("TRANSCODE_WORKER_TOKEN"))))
 	pb.RegisterFileServiceServer(grpcServer, &up.FileServiceServer{})
+	pbt.RegisterTranscodeJobQueueServer(grpcServer, &jobqueue.Server{})
+	pba.RegisterJobAdminServer(grpcServer, &jobqueue.AdminServer{})
+	pbc.RegisterVideoCatalogServer(grpcServer, &catalog.Server{})
+	pbl.RegisterLiveHealthServer(grpcServer, &live.HealthServer{})
 	reflection.Register(grpcServer)
 	if err := grpcServer.Serve(lis); err != nil {
 		log.Fatalf("Failed to serve: %v", err)
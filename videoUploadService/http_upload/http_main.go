@@ -2,33 +2,21 @@
 package http_main
 
 import (
-	"context"
 	"fmt"
 	"io"
 	"log"
 	"os"
 	"path/filepath"
 	"regexp"
-	"sync"
-	"sync/atomic"
 	"time"
 
-	pb "VideoUploadService/transcoding"
+	"VideoUploadService/catalog"
+	"VideoUploadService/identity"
+	up "VideoUploadService/services"
+	"VideoUploadService/uploadstatus"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/websocket/v2"
 	"github.com/google/uuid"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
-)
-
-type ProgressTracker struct {
-	UploadedBytes int64
-	TotalBytes    int64
-}
-
-var (
-	progressTrackers = make(map[string]*ProgressTracker)
-	mu               sync.Mutex
 )
 
 func main() {
@@ -36,12 +24,23 @@ func main() {
 		BodyLimit: 5 * 1024 * 1024 * 1024,
 	})
 
-	app.Post("/upload", uploadFile)
-	app.Get("/progress/:id", websocket.New(handleProgress))
+	SetupRoutes(app)
 
 	log.Fatal(app.Listen(":3500"))
 }
 
+func SetupRoutes(app *fiber.App) {
+	app.Post("/upload", uploadFile)
+	app.Get("/progress/:id", requireViewer, websocket.New(handleProgress))
+	app.Get("/uploads/:id/status", requireViewer, uploadStatus)
+}
+
+// sourcePath is where an upload is stored for the encoder, next to the
+// uploads received over gRPC.
+func sourcePath(uploadID string) string {
+	return os.Getenv("DEV_PATH") + uploadID
+}
+
 func uploadFile(c *fiber.Ctx) error {
 	var re = regexp.MustCompile(`.*`)
 
@@ -78,16 +77,19 @@ func uploadFile(c *fiber.Ctx) error {
 			defer file.Close()
 
 			uploadID := uuid.NewString()
-
-			tracker := &ProgressTracker{
-				TotalBytes: fileHeader.Size,
+			if _, err := catalog.Default.Create(c.Context(), uploadID, identity.FromFiber(c)); err != nil {
+				return c.Status(500).SendString("Failed to create video")
 			}
-			mu.Lock()
-			progressTrackers[uploadID] = tracker
-			mu.Unlock()
 
-			saveFile(uploadID, tracker, file)
-			go grpc_calls(uploadID)
+			uploadstatus.Default.Start(uploadID, identity.FromFiber(c), uploadstatus.SourceHTTP, fileHeader.Size)
+			uploadstatus.Default.SetTier(uploadID, identity.TierFromFiber(c))
+
+			if err := saveFile(uploadID, file); err != nil {
+				catalog.Default.Advance(uploadID, catalog.StateFailed, err.Error())
+				return c.Status(500).SendString("Failed to save file")
+			}
+			catalog.Default.Advance(uploadID, catalog.StateUploaded, "")
+			go up.HandOff(uploadID, sourcePath(uploadID))
 
 			return c.JSON(fiber.Map{
 				"id": uploadID,
@@ -98,47 +100,39 @@ func uploadFile(c *fiber.Ctx) error {
 	return c.Status(400).SendString("Failed to process the upload")
 }
 
-func saveFile(uploadID string, tracker *ProgressTracker, file io.Reader) {
-	// dev path 
-	out, err := os.Create(filepath.Join("../videos", uploadID))
+func saveFile(uploadID string, file io.Reader) error {
+	out, err := os.Create(sourcePath(uploadID))
 	if err != nil {
 		log.Println("Failed to create file:", err)
-		mu.Lock()
-		delete(progressTrackers, uploadID)
-		mu.Unlock()
-		return
+		uploadstatus.Default.Fail(uploadID, err)
+		return err
 	}
 	defer out.Close()
 
-	reader := io.TeeReader(file, tracker)
+	reader := io.TeeReader(file, uploadstatus.Default.Writer(uploadID))
 	_, err = io.Copy(out, reader)
 	if err != nil {
 		log.Println("Failed to save file:", err)
-		mu.Lock()
-		delete(progressTrackers, uploadID)
-		mu.Unlock()
-		return
+		uploadstatus.Default.Fail(uploadID, err)
+		return err
 	}
 
-	mu.Lock()
-	delete(progressTrackers, uploadID)
-	mu.Unlock()
+	uploadstatus.Default.SetStage(uploadID, uploadstatus.StageStored)
+	return nil
 }
 
 func handleProgress(c *websocket.Conn) {
 	uploadID := c.Params("id")
 
 	for {
-		mu.Lock()
-		tracker, exists := progressTrackers[uploadID]
-		mu.Unlock()
+		st, exists := uploadstatus.Default.Get(uploadID)
 
-		if !exists {
+		if !exists || st.Stage != uploadstatus.StageReceiving || st.ExpectedBytes == 0 {
 			c.WriteMessage(websocket.CloseMessage, []byte{})
 			break
 		}
 
-		progress := float64(atomic.LoadInt64(&tracker.UploadedBytes)) / float64(tracker.TotalBytes) * 100
+		progress := float64(st.ReceivedBytes) / float64(st.ExpectedBytes) * 100
 		message := fmt.Sprintf("%.2f", progress)
 
 		if err := c.WriteMessage(websocket.TextMessage, []byte(message)); err != nil {
@@ -151,41 +145,27 @@ func handleProgress(c *websocket.Conn) {
 	c.Close()
 }
 
-func grpc_calls(uuid string) {
-    // Set a reasonable timeout
-    timeout := 30 * time.Second
-
-    // Create a context with timeout for both connection and RPC
-    ctx, cancel := context.WithTimeout(context.Background(), timeout)
-    defer cancel()
-
-    // Create the gRPC connection
-    conn, err := grpc.DialContext(ctx, "localhost:50051", 
-        grpc.WithTransportCredentials(insecure.NewCredentials()),
-        grpc.WithBlock())
-    if err != nil {
-        log.Printf("Failed to connect: %v", err)
-        return
-    }
-    defer conn.Close()
-
-    client := pb.NewTranscoderClient(conn)
-
-    req := &pb.UploadCompleteRequest{
-        Uuid: uuid, 
-    }
-
-    // Call the NotifyUploadComplete RPC
-    res, err := client.NotifyUploadComplete(ctx, req)
-    if err != nil {
-        log.Printf("Error calling NotifyUploadComplete: %v", err)
-        return
-    }
-
-    log.Printf("Response: StatusCode=%d", res.StatusCode)
+// requireViewer only lets the owner of an upload or an admin follow it.
+func requireViewer(c *fiber.Ctx) error {
+	caller := identity.FromFiber(c)
+	if caller == "" && !identity.IsAdminFiber(c) {
+		return c.Status(401).SendString("Authentication required")
+	}
+	st, ok := uploadstatus.Default.Get(c.Params("id"))
+	if !ok {
+		return c.Status(404).SendString("Upload not found")
+	}
+	if !st.MayView(caller, identity.IsAdminFiber(c)) {
+		return c.Status(403).SendString("not allowed to view this upload")
+	}
+	return c.Next()
 }
-func (p *ProgressTracker) Write(b []byte) (int, error) {
-	n := len(b)
-	atomic.AddInt64(&p.UploadedBytes, int64(n))
-	return n, nil
+
+// uploadStatus returns the shared upload status for uploads made over either path.
+func uploadStatus(c *fiber.Ctx) error {
+	st, ok := uploadstatus.Default.Get(c.Params("id"))
+	if !ok {
+		return c.Status(404).SendString("Upload not found")
+	}
+	return c.JSON(st)
 }
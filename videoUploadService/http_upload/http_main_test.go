@@ -0,0 +1,45 @@
+package http_main
+
+import (
+	"VideoUploadService/uploadstatus"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestRoutesRequireViewer(t *testing.T) {
+	uploadstatus.Default.Start("u1", "alice", uploadstatus.SourceHTTP, 100)
+	app := fiber.New()
+	SetupRoutes(app)
+
+	tests := []struct {
+		name, path, user, role string
+		want                   int
+	}{
+		{"anonymous", "/progress/u1", "", "", 401},
+		{"unknown upload", "/progress/u2", "alice", "", 404},
+		{"other user", "/progress/u1", "bob", "", 403},
+		// Let through, the plain request is then refused the upgrade.
+		{"owner", "/progress/u1", "alice", "", 426},
+		{"admin", "/progress/u1", "", "admin", 426},
+		{"status of another user", "/uploads/u1/status", "bob", "", 403},
+		{"status", "/uploads/u1/status", "alice", "", 200},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", tt.path, nil)
+		if tt.user != "" {
+			req.Header.Set("x-user-id", tt.user)
+		}
+		if tt.role != "" {
+			req.Header.Set("x-user-role", tt.role)
+		}
+		res, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.StatusCode != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, res.StatusCode, tt.want)
+		}
+	}
+}
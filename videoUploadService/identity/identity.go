@@ -0,0 +1,64 @@
+package identity
+
+import (
+	"context"
+
+	"github.com/gofiber/fiber/v2"
+	"google.golang.org/grpc/metadata"
+)
+
+// UserHeader carries the id of the authenticated caller. It is set by the
+// gateway in front of the service for both gRPC metadata and HTTP requests.
+const UserHeader = "x-user-id"
+
+// RoleHeader carries the role of the caller. Operators have the AdminRole.
+const RoleHeader = "x-user-role"
+
+const AdminRole = "admin"
+
+// TierHeader carries the account tier of the caller, e.g. "free" or "premium".
+const TierHeader = "x-user-tier"
+
+// FromContext returns the caller id attached to an incoming gRPC call, or an
+// empty string for anonymous callers.
+func FromContext(ctx context.Context) string {
+	return fromMetadata(ctx, UserHeader)
+}
+
+// TierFromContext returns the account tier of the caller of a gRPC call.
+func TierFromContext(ctx context.Context) string {
+	return fromMetadata(ctx, TierHeader)
+}
+
+// FromFiber returns the caller id of an HTTP request, or an empty string for
+// anonymous callers.
+func FromFiber(c *fiber.Ctx) string {
+	return c.Get(UserHeader)
+}
+
+// IsAdmin reports whether the caller of a gRPC call has the admin role.
+func IsAdmin(ctx context.Context) bool {
+	return fromMetadata(ctx, RoleHeader) == AdminRole
+}
+
+// IsAdminFiber reports whether the caller of an HTTP request has the admin
+// role.
+func IsAdminFiber(c *fiber.Ctx) bool {
+	return c.Get(RoleHeader) == AdminRole
+}
+
+// TierFromFiber returns the account tier of the caller of an HTTP request.
+func TierFromFiber(c *fiber.Ctx) string {
+	return c.Get(TierHeader)
+}
+
+func fromMetadata(ctx context.Context, key string) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
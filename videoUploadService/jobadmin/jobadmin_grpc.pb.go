@@ -0,0 +1,268 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.4.0
+// - protoc             v3.19.6
+// source: proto/jobadmin.proto
+
+package jobadmin
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.62.0 or later.
+const _ = grpc.SupportPackageIsVersion8
+
+const (
+	JobAdmin_ListJobs_FullMethodName        = "/jobadmin.JobAdmin/ListJobs"
+	JobAdmin_GetJob_FullMethodName          = "/jobadmin.JobAdmin/GetJob"
+	JobAdmin_RequeueJob_FullMethodName      = "/jobadmin.JobAdmin/RequeueJob"
+	JobAdmin_ReprioritizeJob_FullMethodName = "/jobadmin.JobAdmin/ReprioritizeJob"
+	JobAdmin_PurgeJobs_FullMethodName       = "/jobadmin.JobAdmin/PurgeJobs"
+)
+
+// JobAdminClient is the client API for JobAdmin service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// JobAdmin lets operators inspect and steer the transcoding queue. Every
+// call requires the admin role.
+type JobAdminClient interface {
+	ListJobs(ctx context.Context, in *ListJobsRequest, opts ...grpc.CallOption) (*ListJobsResponse, error)
+	GetJob(ctx context.Context, in *JobRequest, opts ...grpc.CallOption) (*JobDetail, error)
+	RequeueJob(ctx context.Context, in *JobRequest, opts ...grpc.CallOption) (*JobDetail, error)
+	ReprioritizeJob(ctx context.Context, in *ReprioritizeRequest, opts ...grpc.CallOption) (*JobDetail, error)
+	PurgeJobs(ctx context.Context, in *PurgeRequest, opts ...grpc.CallOption) (*PurgeResponse, error)
+}
+
+type jobAdminClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewJobAdminClient(cc grpc.ClientConnInterface) JobAdminClient {
+	return &jobAdminClient{cc}
+}
+
+func (c *jobAdminClient) ListJobs(ctx context.Context, in *ListJobsRequest, opts ...grpc.CallOption) (*ListJobsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListJobsResponse)
+	err := c.cc.Invoke(ctx, JobAdmin_ListJobs_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *jobAdminClient) GetJob(ctx context.Context, in *JobRequest, opts ...grpc.CallOption) (*JobDetail, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(JobDetail)
+	err := c.cc.Invoke(ctx, JobAdmin_GetJob_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *jobAdminClient) RequeueJob(ctx context.Context, in *JobRequest, opts ...grpc.CallOption) (*JobDetail, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(JobDetail)
+	err := c.cc.Invoke(ctx, JobAdmin_RequeueJob_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *jobAdminClient) ReprioritizeJob(ctx context.Context, in *ReprioritizeRequest, opts ...grpc.CallOption) (*JobDetail, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(JobDetail)
+	err := c.cc.Invoke(ctx, JobAdmin_ReprioritizeJob_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *jobAdminClient) PurgeJobs(ctx context.Context, in *PurgeRequest, opts ...grpc.CallOption) (*PurgeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PurgeResponse)
+	err := c.cc.Invoke(ctx, JobAdmin_PurgeJobs_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// JobAdminServer is the server API for JobAdmin service.
+// All implementations must embed UnimplementedJobAdminServer
+// for forward compatibility
+//
+// JobAdmin lets operators inspect and steer the transcoding queue. Every
+// call requires the admin role.
+type JobAdminServer interface {
+	ListJobs(context.Context, *ListJobsRequest) (*ListJobsResponse, error)
+	GetJob(context.Context, *JobRequest) (*JobDetail, error)
+	RequeueJob(context.Context, *JobRequest) (*JobDetail, error)
+	ReprioritizeJob(context.Context, *ReprioritizeRequest) (*JobDetail, error)
+	PurgeJobs(context.Context, *PurgeRequest) (*PurgeResponse, error)
+	mustEmbedUnimplementedJobAdminServer()
+}
+
+// UnimplementedJobAdminServer must be embedded to have forward compatible implementations.
+type UnimplementedJobAdminServer struct {
+}
+
+func (UnimplementedJobAdminServer) ListJobs(context.Context, *ListJobsRequest) (*ListJobsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListJobs not implemented")
+}
+func (UnimplementedJobAdminServer) GetJob(context.Context, *JobRequest) (*JobDetail, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetJob not implemented")
+}
+func (UnimplementedJobAdminServer) RequeueJob(context.Context, *JobRequest) (*JobDetail, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RequeueJob not implemented")
+}
+func (UnimplementedJobAdminServer) ReprioritizeJob(context.Context, *ReprioritizeRequest) (*JobDetail, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReprioritizeJob not implemented")
+}
+func (UnimplementedJobAdminServer) PurgeJobs(context.Context, *PurgeRequest) (*PurgeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PurgeJobs not implemented")
+}
+func (UnimplementedJobAdminServer) mustEmbedUnimplementedJobAdminServer() {}
+
+// UnsafeJobAdminServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to JobAdminServer will
+// result in compilation errors.
+type UnsafeJobAdminServer interface {
+	mustEmbedUnimplementedJobAdminServer()
+}
+
+func RegisterJobAdminServer(s grpc.ServiceRegistrar, srv JobAdminServer) {
+	s.RegisterService(&JobAdmin_ServiceDesc, srv)
+}
+
+func _JobAdmin_ListJobs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListJobsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(JobAdminServer).ListJobs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: JobAdmin_ListJobs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(JobAdminServer).ListJobs(ctx, req.(*ListJobsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _JobAdmin_GetJob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(JobRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(JobAdminServer).GetJob(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: JobAdmin_GetJob_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(JobAdminServer).GetJob(ctx, req.(*JobRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _JobAdmin_RequeueJob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(JobRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(JobAdminServer).RequeueJob(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: JobAdmin_RequeueJob_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(JobAdminServer).RequeueJob(ctx, req.(*JobRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _JobAdmin_ReprioritizeJob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReprioritizeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(JobAdminServer).ReprioritizeJob(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: JobAdmin_ReprioritizeJob_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(JobAdminServer).ReprioritizeJob(ctx, req.(*ReprioritizeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _JobAdmin_PurgeJobs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PurgeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(JobAdminServer).PurgeJobs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: JobAdmin_PurgeJobs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(JobAdminServer).PurgeJobs(ctx, req.(*PurgeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// JobAdmin_ServiceDesc is the grpc.ServiceDesc for JobAdmin service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var JobAdmin_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "jobadmin.JobAdmin",
+	HandlerType: (*JobAdminServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListJobs",
+			Handler:    _JobAdmin_ListJobs_Handler,
+		},
+		{
+			MethodName: "GetJob",
+			Handler:    _JobAdmin_GetJob_Handler,
+		},
+		{
+			MethodName: "RequeueJob",
+			Handler:    _JobAdmin_RequeueJob_Handler,
+		},
+		{
+			MethodName: "ReprioritizeJob",
+			Handler:    _JobAdmin_ReprioritizeJob_Handler,
+		},
+		{
+			MethodName: "PurgeJobs",
+			Handler:    _JobAdmin_PurgeJobs_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "proto/jobadmin.proto",
+}
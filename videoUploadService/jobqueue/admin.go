@@ -0,0 +1,137 @@
+package jobqueue
+
+import (
+	"VideoUploadService/identity"
+	pba "VideoUploadService/jobadmin"
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var ErrUnknownState = errors.New("unknown job state")
+
+// AdminServer exposes queue operations on the Default queue to operators.
+type AdminServer struct {
+	pba.UnimplementedJobAdminServer
+}
+
+func (s *AdminServer) ListJobs(ctx context.Context, req *pba.ListJobsRequest) (*pba.ListJobsResponse, error) {
+	if !identity.IsAdmin(ctx) {
+		return nil, status.Error(codes.PermissionDenied, "admin role required")
+	}
+	state, err := ParseState(req.State, true)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	res := &pba.ListJobsResponse{}
+	for _, job := range Default.List(state) {
+		res.Jobs = append(res.Jobs, toDetail(job))
+	}
+	return res, nil
+}
+
+func (s *AdminServer) GetJob(ctx context.Context, req *pba.JobRequest) (*pba.JobDetail, error) {
+	if !identity.IsAdmin(ctx) {
+		return nil, status.Error(codes.PermissionDenied, "admin role required")
+	}
+	job, ok := Default.Get(req.JobId)
+	if !ok {
+		return nil, toStatus(ErrNotFound)
+	}
+	return toDetail(job), nil
+}
+
+func (s *AdminServer) RequeueJob(ctx context.Context, req *pba.JobRequest) (*pba.JobDetail, error) {
+	if !identity.IsAdmin(ctx) {
+		return nil, status.Error(codes.PermissionDenied, "admin role required")
+	}
+	job, err := Default.Requeue(req.JobId)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toDetail(job), nil
+}
+
+func (s *AdminServer) ReprioritizeJob(ctx context.Context, req *pba.ReprioritizeRequest) (*pba.JobDetail, error) {
+	if !identity.IsAdmin(ctx) {
+		return nil, status.Error(codes.PermissionDenied, "admin role required")
+	}
+	job, err := Default.Reprioritize(req.JobId, int(req.Priority))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toDetail(job), nil
+}
+
+func (s *AdminServer) PurgeJobs(ctx context.Context, req *pba.PurgeRequest) (*pba.PurgeResponse, error) {
+	if !identity.IsAdmin(ctx) {
+		return nil, status.Error(codes.PermissionDenied, "admin role required")
+	}
+	if req.JobId != "" {
+		if err := Default.Purge(req.JobId); err != nil {
+			return nil, toStatus(err)
+		}
+		return &pba.PurgeResponse{Purged: 1}, nil
+	}
+	state, err := ParseState(req.State, false)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	n, err := Default.PurgeState(state)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pba.PurgeResponse{Purged: uint32(n)}, nil
+}
+
+// ParseState validates a job state given by an operator. An empty state is
+// only accepted if allowEmpty is set.
+func ParseState(s string, allowEmpty bool) (State, error) {
+	switch state := State(s); state {
+	case StateQueued, StateLeased, StateSucceeded, StateDead, StateCancelled:
+		return state, nil
+	case "":
+		if allowEmpty {
+			return "", nil
+		}
+	}
+	return "", ErrUnknownState
+}
+
+func toDetail(job Job) *pba.JobDetail {
+	d := &pba.JobDetail{
+		JobId:      job.ID,
+		VideoId:    job.VideoID,
+		Owner:      job.Owner,
+		Tier:       job.Tier,
+		Priority:   int32(job.Priority),
+		State:      string(job.State),
+		EnqueuedAt: job.EnqueuedAt.Unix(),
+		UpdatedAt:  job.UpdatedAt.Unix(),
+		Worker:     job.Worker,
+		Manifest:   job.Manifest,
+	}
+	if !job.NotBefore.IsZero() {
+		d.NotBefore = job.NotBefore.Unix()
+	}
+	if !job.LeaseUntil.IsZero() {
+		d.LeaseUntil = job.LeaseUntil.Unix()
+	}
+	for _, a := range job.Attempts {
+		attempt := &pba.JobAttempt{
+			Number:     uint32(a.Number),
+			Worker:     a.Worker,
+			StartedAt:  a.StartedAt.Unix(),
+			DurationMs: a.Duration,
+			ErrorCode:  a.ErrorCode,
+			Error:      a.Error,
+		}
+		if !a.EndedAt.IsZero() {
+			attempt.EndedAt = a.EndedAt.Unix()
+		}
+		d.Attempts = append(d.Attempts, attempt)
+	}
+	return d
+}
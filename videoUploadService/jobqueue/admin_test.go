@@ -0,0 +1,236 @@
+package jobqueue
+
+import (
+	"VideoUploadService/identity"
+	pba "VideoUploadService/jobadmin"
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// adminJobs replaces the Default queue with one job in each state and
+// returns their IDs by name. "stopping" is cancelled while its worker still
+// holds the lease, and "missing" is not in the queue.
+func adminJobs(t *testing.T) map[string]string {
+	t.Helper()
+	saved := Default
+	t.Cleanup(func() { Default = saved })
+	q := New(Config{MaxAttempts: 1, Visibility: time.Minute})
+	Default = q
+	ids := make(map[string]string)
+	lease := func(name string) Job {
+		q.Enqueue(name, "alice", "free", nil)
+		job, ok := q.Lease("worker", 0)
+		if !ok || job.VideoID != name {
+			t.Fatalf("leased %+v, want %s", job, name)
+		}
+		ids[name] = job.ID
+		return job
+	}
+
+	job := lease("succeeded")
+	if err := q.Complete(job.ID, job.LeaseID, "master.m3u8"); err != nil {
+		t.Fatal(err)
+	}
+	job = lease("dead")
+	if err := q.Fail(job.ID, job.LeaseID, "encoder_failed", "boom", true); err != nil {
+		t.Fatal(err)
+	}
+	job = lease("stopping")
+	if err := q.Cancel(job.ID); err != nil {
+		t.Fatal(err)
+	}
+	lease("leased")
+	ids["cancelled"] = q.Enqueue("cancelled", "alice", "free", nil).ID
+	if err := q.Cancel(ids["cancelled"]); err != nil {
+		t.Fatal(err)
+	}
+	ids["queued"] = q.Enqueue("queued", "alice", "free", nil).ID
+	ids["missing"] = "missing"
+	return ids
+}
+
+func asRole(role string) context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs(identity.UserHeader, "ops", identity.RoleHeader, role))
+}
+
+func TestAdminRequiresRole(t *testing.T) {
+	ids := adminJobs(t)
+	s := &AdminServer{}
+	calls := map[string]func(context.Context) error{
+		"ListJobs": func(ctx context.Context) error {
+			_, err := s.ListJobs(ctx, &pba.ListJobsRequest{})
+			return err
+		},
+		"GetJob": func(ctx context.Context) error {
+			_, err := s.GetJob(ctx, &pba.JobRequest{JobId: ids["dead"]})
+			return err
+		},
+		"RequeueJob": func(ctx context.Context) error {
+			_, err := s.RequeueJob(ctx, &pba.JobRequest{JobId: ids["dead"]})
+			return err
+		},
+		"ReprioritizeJob": func(ctx context.Context) error {
+			_, err := s.ReprioritizeJob(ctx, &pba.ReprioritizeRequest{JobId: ids["dead"], Priority: 9})
+			return err
+		},
+		"PurgeJobs": func(ctx context.Context) error {
+			_, err := s.PurgeJobs(ctx, &pba.PurgeRequest{State: string(StateDead)})
+			return err
+		},
+	}
+	for name, call := range calls {
+		for _, ctx := range []context.Context{context.Background(), asRole("creator")} {
+			if err := call(ctx); status.Code(err) != codes.PermissionDenied {
+				t.Errorf("%s without the admin role: err = %v, want PermissionDenied", name, err)
+			}
+		}
+	}
+	if job, _ := Default.Get(ids["dead"]); job.State != StateDead || job.Priority != 0 {
+		t.Errorf("refused calls changed the job: %s with priority %d", job.State, job.Priority)
+	}
+}
+
+func TestAdminRequeue(t *testing.T) {
+	tests := []struct {
+		job  string
+		want codes.Code
+	}{
+		{"queued", codes.FailedPrecondition},
+		{"leased", codes.FailedPrecondition},
+		{"stopping", codes.FailedPrecondition},
+		{"succeeded", codes.OK},
+		{"dead", codes.OK},
+		{"cancelled", codes.OK},
+		{"missing", codes.NotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.job, func(t *testing.T) {
+			ids := adminJobs(t)
+			before, _ := Default.Get(ids[tt.job])
+			d, err := (&AdminServer{}).RequeueJob(asRole(identity.AdminRole), &pba.JobRequest{JobId: ids[tt.job]})
+			if status.Code(err) != tt.want {
+				t.Fatalf("err = %v, want %s", err, tt.want)
+			}
+			job, _ := Default.Get(ids[tt.job])
+			if tt.want != codes.OK {
+				if job.State != before.State {
+					t.Errorf("refused requeue moved the job from %s to %s", before.State, job.State)
+				}
+				return
+			}
+			if d.State != string(StateQueued) || job.State != StateQueued {
+				t.Errorf("requeued job is %s (reported %s), want queued", job.State, d.State)
+			}
+		})
+	}
+}
+
+func TestAdminReprioritize(t *testing.T) {
+	ids := adminJobs(t)
+	s := &AdminServer{}
+	// Any job can be reprioritized, including one being encoded.
+	for _, name := range []string{"queued", "leased", "dead"} {
+		d, err := s.ReprioritizeJob(asRole(identity.AdminRole), &pba.ReprioritizeRequest{JobId: ids[name], Priority: 50})
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if job, _ := Default.Get(ids[name]); d.Priority != 50 || job.Priority != 50 || job.State != State(name) {
+			t.Errorf("%s job is %s with priority %d, want priority 50", name, job.State, job.Priority)
+		}
+	}
+	if _, err := s.ReprioritizeJob(asRole(identity.AdminRole), &pba.ReprioritizeRequest{JobId: "missing"}); status.Code(err) != codes.NotFound {
+		t.Errorf("missing job: err = %v, want NotFound", err)
+	}
+}
+
+func TestAdminPurgeJob(t *testing.T) {
+	tests := []struct {
+		job  string
+		want codes.Code
+	}{
+		{"leased", codes.FailedPrecondition},
+		{"queued", codes.OK},
+		{"stopping", codes.OK},
+		{"dead", codes.OK},
+		{"missing", codes.NotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.job, func(t *testing.T) {
+			ids := adminJobs(t)
+			res, err := (&AdminServer{}).PurgeJobs(asRole(identity.AdminRole), &pba.PurgeRequest{JobId: ids[tt.job]})
+			if status.Code(err) != tt.want {
+				t.Fatalf("err = %v, want %s", err, tt.want)
+			}
+			_, kept := Default.Get(ids[tt.job])
+			if tt.want == codes.OK && (res.Purged != 1 || kept) {
+				t.Errorf("purged %d, job kept = %v", res.Purged, kept)
+			}
+			if tt.want == codes.FailedPrecondition && !kept {
+				t.Error("refused purge removed the job")
+			}
+		})
+	}
+}
+
+func TestAdminPurgeState(t *testing.T) {
+	tests := []struct {
+		state      string
+		want       codes.Code
+		wantPurged uint32
+	}{
+		{"", codes.InvalidArgument, 0},
+		{"stuck", codes.InvalidArgument, 0},
+		{string(StateLeased), codes.FailedPrecondition, 0},
+		// The cancelled job and the one still stopping.
+		{string(StateCancelled), codes.OK, 2},
+		{string(StateSucceeded), codes.OK, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.state, func(t *testing.T) {
+			adminJobs(t)
+			res, err := (&AdminServer{}).PurgeJobs(asRole(identity.AdminRole), &pba.PurgeRequest{State: tt.state})
+			if status.Code(err) != tt.want {
+				t.Fatalf("err = %v, want %s", err, tt.want)
+			}
+			if tt.want == codes.OK && (res.Purged != tt.wantPurged || len(Default.List(State(tt.state))) != 0) {
+				t.Errorf("purged %d, want %d with none left", res.Purged, tt.wantPurged)
+			}
+			if n := len(Default.List("")); n != 6-int(tt.wantPurged) {
+				t.Errorf("%d jobs left, want %d", n, 6-tt.wantPurged)
+			}
+		})
+	}
+}
+
+func TestAdminListAndGet(t *testing.T) {
+	ids := adminJobs(t)
+	s := &AdminServer{}
+	ctx := asRole(identity.AdminRole)
+
+	res, err := s.ListJobs(ctx, &pba.ListJobsRequest{State: string(StateCancelled)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Jobs) != 2 || res.Jobs[0].JobId != ids["stopping"] || res.Jobs[1].JobId != ids["cancelled"] {
+		t.Errorf("cancelled jobs %v, want stopping and cancelled in order", res.Jobs)
+	}
+	if _, err := s.ListJobs(ctx, &pba.ListJobsRequest{State: "stuck"}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("unknown state: err = %v, want InvalidArgument", err)
+	}
+
+	d, err := s.GetJob(ctx, &pba.JobRequest{JobId: ids["dead"]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(d.Attempts) != 1 || d.Attempts[0].Worker != "worker" || d.Attempts[0].ErrorCode != "encoder_failed" || d.Attempts[0].EndedAt == 0 {
+		t.Errorf("dead job attempts %v", d.Attempts)
+	}
+	if _, err := s.GetJob(ctx, &pba.JobRequest{JobId: "missing"}); status.Code(err) != codes.NotFound {
+		t.Errorf("missing job: err = %v, want NotFound", err)
+	}
+}
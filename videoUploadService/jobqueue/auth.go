@@ -0,0 +1,49 @@
+package jobqueue
+
+import (
+	"context"
+	"crypto/subtle"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// WorkerTokenHeader carries the shared secret encoder workers present to
+// lease and settle jobs.
+const WorkerTokenHeader = "x-worker-token"
+
+// queueMethods prefixes the full method names of the TranscodeJobQueue
+// service.
+const queueMethods = "/transcoding.TranscodeJobQueue/"
+
+// WorkerAuth returns an interceptor that only lets callers presenting token
+// reach the TranscodeJobQueue service; other services are left alone. With
+// an empty token the queue is closed to all callers, so a server without
+// pull workers never exposes it.
+func WorkerAuth(token string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if strings.HasPrefix(info.FullMethod, queueMethods) && !validWorker(ctx, token) {
+			return nil, status.Error(codes.Unauthenticated, "worker token required")
+		}
+		return handler(ctx, req)
+	}
+}
+
+func validWorker(ctx context.Context, token string) bool {
+	if token == "" {
+		return false
+	}
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return false
+	}
+	for _, v := range md.Get(WorkerTokenHeader) {
+		if subtle.ConstantTimeCompare([]byte(v), []byte(token)) == 1 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,42 @@
+package jobqueue
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestWorkerAuth(t *testing.T) {
+	tests := []struct {
+		name   string
+		token  string
+		method string
+		sent   []string
+		want   codes.Code
+	}{
+		{"valid token", "secret", "/transcoding.TranscodeJobQueue/LeaseJob", []string{"secret"}, codes.OK},
+		{"missing token", "secret", "/transcoding.TranscodeJobQueue/CompleteJob", nil, codes.Unauthenticated},
+		{"wrong token", "secret", "/transcoding.TranscodeJobQueue/Heartbeat", []string{"guess"}, codes.Unauthenticated},
+		{"unconfigured", "", "/transcoding.TranscodeJobQueue/FailJob", []string{""}, codes.Unauthenticated},
+		{"other service", "secret", "/fileservice.FileService/GetUploadStatus", nil, codes.OK},
+	}
+	handler := func(ctx context.Context, req any) (any, error) { return "ok", nil }
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.sent != nil {
+				md := metadata.MD{}
+				md.Append(WorkerTokenHeader, tt.sent...)
+				ctx = metadata.NewIncomingContext(ctx, md)
+			}
+			_, err := WorkerAuth(tt.token)(ctx, nil, &grpc.UnaryServerInfo{FullMethod: tt.method}, handler)
+			if got := status.Code(err); got != tt.want {
+				t.Errorf("code = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
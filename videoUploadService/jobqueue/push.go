@@ -0,0 +1,181 @@
+package jobqueue
+
+import (
+	"VideoUploadService/transcodestatus"
+	pbt "VideoUploadService/transcoding"
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// CodeEncryptionUnsupported is the error code of encoders asked to encrypt
+// output they can only produce in the clear. Retrying does not help.
+const CodeEncryptionUnsupported = "encryption_unsupported"
+
+// CodePackagingFailed is the error code of jobs whose output was encoded
+// but could not be prepared for playback. Encoding again does not help.
+const CodePackagingFailed = "packaging_failed"
+
+// Finalize prepares the output of a job for playback, e.g. by packaging
+// it, before the job is marked succeeded. It is set up in main.
+var Finalize func(ctx context.Context, job Job) error
+
+// RunPushWorkers leases jobs on behalf of an encoder that only implements
+// the push-style NotifyUploadComplete RPC. Each worker hands one job at a
+// time to the encoder and follows its StatusVideo stream until the job
+// finishes, so at most n encodes run at once.
+func RunPushWorkers(q *Queue, addr string, n int) error {
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return err
+	}
+	client := pbt.NewTranscoderClient(conn)
+	for i := 0; i < n; i++ {
+		go pushWorker(q, client, fmt.Sprintf("push-%d", i))
+	}
+	return nil
+}
+
+func pushWorker(q *Queue, client pbt.TranscoderClient, worker string) {
+	for {
+		job, ok := q.Lease(worker, 0)
+		if !ok {
+			select {
+			case <-q.Ready():
+			case <-time.After(5 * time.Second):
+			}
+			continue
+		}
+		runPush(q, client, job)
+	}
+}
+
+func runPush(q *Queue, client pbt.TranscoderClient, job Job) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	// The lease lets the encoder fetch the content keys of encrypted jobs.
+	res, err := client.NotifyUploadComplete(ctx, &pbt.UploadCompleteRequest{
+		Uuid:    job.VideoID,
+		Profile: job.Profile,
+		JobId:   job.ID,
+		LeaseId: job.LeaseID,
+	})
+	cancel()
+	if err != nil {
+		log.Printf("Job %s: NotifyUploadComplete: %v", job.ID, err)
+		settle(job, q.Fail(job.ID, job.LeaseID, "handoff_failed", err.Error(), true))
+		return
+	}
+	if res.Stage == pbt.TranscodeStage_TRANSCODE_STAGE_FAILED {
+		retryable := res.ErrorCode != CodeEncryptionUnsupported
+		settle(job, q.Fail(job.ID, job.LeaseID, res.ErrorCode, res.ErrorMessage, retryable))
+		return
+	}
+
+	hub := transcodestatus.Default
+	hub.Rewatch(job.VideoID)
+	updates, unsubscribe := hub.Subscribe(job.VideoID)
+	defer unsubscribe()
+
+	lost := make(chan bool, 1)
+	stop := make(chan struct{})
+	defer close(stop)
+	go heartbeat(q, client, job, lost, stop)
+
+	for {
+		select {
+		case u, ok := <-updates:
+			if !ok {
+				return
+			}
+			switch u.State {
+			case transcodestatus.StateComplete:
+				failure, err := complete(context.Background(), q, job.ID, job.LeaseID, u.Manifest)
+				settle(job, err)
+				if failure != nil {
+					reportFailure(job.VideoID, CodePackagingFailed, failure.Error())
+				}
+				return
+			case transcodestatus.StateFailed:
+				settle(job, q.Fail(job.ID, job.LeaseID, u.ErrorCode, u.Error, true))
+				return
+			}
+		case cancelled := <-lost:
+			if cancelled {
+				// The job keeps its state; this only ends the attempt and
+				// releases the lease.
+				settle(job, q.Fail(job.ID, job.LeaseID, "cancelled", "transcode cancelled", false))
+			}
+			return
+		}
+	}
+}
+
+// heartbeat keeps the lease of a job handed to the encoder, whether or not
+// the encoder reports progress, until stop is closed. If the job is cancelled
+// or the lease is lost, it sends on lost whether the job was cancelled. A job
+// whose lease was lost may already run elsewhere, so the encoder is told to
+// stop.
+func heartbeat(q *Queue, client pbt.TranscoderClient, job Job, lost chan<- bool, stop chan struct{}) {
+	ticker := time.NewTicker(q.heartbeatInterval())
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-stop:
+			return
+		}
+		_, cancelled, err := q.Heartbeat(job.ID, job.LeaseID, 0)
+		if cancelled {
+			// The encoder's own cancel RPC is driven by transcodectl.
+			lost <- true
+			return
+		}
+		if err == nil {
+			continue
+		}
+		log.Printf("Job %s: lease lost, stopping the encoder: %v", job.ID, err)
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		if _, err := client.CancelTranscode(ctx, &pbt.VideoUuidRequest{Uuid: job.VideoID}); err != nil {
+			log.Printf("Job %s: CancelTranscode: %v", job.ID, err)
+		}
+		cancel()
+		lost <- false
+		return
+	}
+}
+
+// complete finalizes the output of a leased job and marks it succeeded. If
+// the output cannot be finalized, the job fails with CodePackagingFailed
+// instead and failure says why.
+func complete(ctx context.Context, q *Queue, jobID, leaseID, manifest string) (failure, err error) {
+	job, ok := q.Get(jobID)
+	if ok && Finalize != nil && job.State == StateLeased && job.LeaseID == leaseID {
+		if failure := Finalize(ctx, job); failure != nil {
+			log.Printf("Job %s: finalizing %s: %v", job.ID, job.VideoID, failure)
+			return failure, q.Fail(jobID, leaseID, CodePackagingFailed, failure.Error(), false)
+		}
+	}
+	return nil, q.Complete(jobID, leaseID, manifest)
+}
+
+// reportFailure tells the status subscribers of a video that its transcode
+// failed.
+func reportFailure(videoID, code, message string) {
+	transcodestatus.Default.Report(videoID, &pbt.VideoStatusResponse{
+		Stage:        pbt.TranscodeStage_TRANSCODE_STAGE_FAILED,
+		ErrorCode:    code,
+		ErrorMessage: message,
+	})
+}
+
+// settle logs a failure to record the outcome of a job. The lease has run
+// out or the job was cancelled meanwhile; the queue has already moved on.
+func settle(job Job, err error) {
+	if err != nil {
+		log.Printf("Job %s: recording the result: %v", job.ID, err)
+	}
+}
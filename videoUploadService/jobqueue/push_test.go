@@ -0,0 +1,170 @@
+package jobqueue
+
+import (
+	"VideoUploadService/transcodestatus"
+	pbt "VideoUploadService/transcoding"
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+)
+
+// keyedEncoder stands in for an encoder that fetches the keys of encrypted
+// jobs from the queue before it reports them complete.
+type keyedEncoder struct {
+	pbt.UnimplementedTranscoderServer
+	pbt.UnimplementedVideoStatusServiceServer
+	queue pbt.TranscodeJobQueueClient
+	token string
+	// keys receives the material fetched for each job.
+	keys chan []*pbt.ContentKeyMaterial
+}
+
+func (e *keyedEncoder) NotifyUploadComplete(ctx context.Context, req *pbt.UploadCompleteRequest) (*pbt.TranscodeResponse, error) {
+	if req.Profile.GetEncryption() == nil {
+		return &pbt.TranscodeResponse{Stage: pbt.TranscodeStage_TRANSCODE_STAGE_FAILED, ErrorCode: "clear"}, nil
+	}
+	ctx = metadata.AppendToOutgoingContext(ctx, WorkerTokenHeader, e.token)
+	res, err := e.queue.FetchContentKeys(ctx, &pbt.FetchContentKeysRequest{JobId: req.JobId, LeaseId: req.LeaseId})
+	if err != nil {
+		return &pbt.TranscodeResponse{Stage: pbt.TranscodeStage_TRANSCODE_STAGE_FAILED, ErrorCode: "keys", ErrorMessage: err.Error()}, nil
+	}
+	e.keys <- res.Keys
+	return &pbt.TranscodeResponse{StatusCode: 200}, nil
+}
+
+func (e *keyedEncoder) StatusVideo(req *pbt.VideoUuidRequest, stream pbt.VideoStatusService_StatusVideoServer) error {
+	return stream.Send(&pbt.VideoStatusResponse{
+		Status:           100,
+		Stage:            pbt.TranscodeStage_TRANSCODE_STAGE_COMPLETE,
+		ManifestLocation: "encoded/" + req.Uuid + "/master.m3u8",
+	})
+}
+
+func listen(t *testing.T, srv *grpc.Server) *grpc.ClientConn {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestPushEncryptedJob(t *testing.T) {
+	saved, savedKeys, savedHub := Default, ContentKeys, transcodestatus.Default
+	defer func() { Default, ContentKeys, transcodestatus.Default = saved, savedKeys, savedHub }()
+	Default = New(DefaultConfig)
+	ContentKeys = func(ctx context.Context, videoID string) ([]*pbt.ContentKeyMaterial, error) {
+		return []*pbt.ContentKeyMaterial{{Index: 0, Key: []byte(videoID + "-key")}}, nil
+	}
+
+	const token = "secret"
+	uploads := grpc.NewServer(grpc.UnaryInterceptor(WorkerAuth(token)))
+	pbt.RegisterTranscodeJobQueueServer(uploads, &Server{})
+	enc := &keyedEncoder{queue: pbt.NewTranscodeJobQueueClient(listen(t, uploads)), token: token, keys: make(chan []*pbt.ContentKeyMaterial, 1)}
+	encoders := grpc.NewServer()
+	pbt.RegisterTranscoderServer(encoders, enc)
+	pbt.RegisterVideoStatusServiceServer(encoders, enc)
+	conn := listen(t, encoders)
+	transcodestatus.Default = transcodestatus.NewHub(conn.Target())
+
+	profile := &pbt.EncodingProfile{Encryption: &pbt.HlsEncryption{Method: "AES-128", Keys: []*pbt.ContentKey{{Index: 0, Uri: "/keys/v1/0"}}}}
+	queued := Default.Enqueue("v1", "alice", "premium", profile)
+	job, ok := Default.Lease("push-0", 0)
+	if !ok || job.ID != queued.ID {
+		t.Fatal("premium job was not leased")
+	}
+
+	done := make(chan struct{})
+	go func() {
+		runPush(Default, pbt.NewTranscoderClient(conn), job)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("push did not finish")
+	}
+
+	select {
+	case keys := <-enc.keys:
+		if len(keys) != 1 || string(keys[0].Key) != "v1-key" {
+			t.Errorf("encoder fetched keys %v", keys)
+		}
+	default:
+		t.Fatal("encoder did not fetch the keys")
+	}
+	got, _ := Default.Get(job.ID)
+	if got.State != StateSucceeded || got.Manifest != "encoded/v1/master.m3u8" {
+		t.Errorf("job is %s with manifest %q, want %s", got.State, got.Manifest, StateSucceeded)
+	}
+	// The lease ended with the job, and with it access to the keys.
+	if _, err := (&Server{}).FetchContentKeys(context.Background(), &pbt.FetchContentKeysRequest{JobId: job.ID, LeaseId: job.LeaseID}); err == nil {
+		t.Error("keys fetched after the job completed")
+	}
+}
+
+// stallingEncoder accepts jobs and reports progress until the status stream
+// is closed.
+type stallingEncoder struct {
+	pbt.UnimplementedTranscoderServer
+	pbt.UnimplementedVideoStatusServiceServer
+	started chan string
+}
+
+func (e *stallingEncoder) NotifyUploadComplete(ctx context.Context, req *pbt.UploadCompleteRequest) (*pbt.TranscodeResponse, error) {
+	e.started <- req.JobId
+	return &pbt.TranscodeResponse{StatusCode: 200}, nil
+}
+
+func (e *stallingEncoder) StatusVideo(req *pbt.VideoUuidRequest, stream pbt.VideoStatusService_StatusVideoServer) error {
+	stream.Send(&pbt.VideoStatusResponse{Status: 10, Stage: pbt.TranscodeStage_TRANSCODE_STAGE_ENCODING})
+	<-stream.Context().Done()
+	return nil
+}
+
+func TestPushCancelledJobEndsAttempt(t *testing.T) {
+	savedHub := transcodestatus.Default
+	defer func() { transcodestatus.Default = savedHub }()
+	enc := &stallingEncoder{started: make(chan string, 1)}
+	encoders := grpc.NewServer()
+	pbt.RegisterTranscoderServer(encoders, enc)
+	pbt.RegisterVideoStatusServiceServer(encoders, enc)
+	conn := listen(t, encoders)
+	transcodestatus.Default = transcodestatus.NewHub(conn.Target())
+
+	// Leases are extended every second.
+	q := New(Config{MaxActive: 1, MaxAttempts: 3, Visibility: 3 * time.Second})
+	q.Enqueue("v1", "alice", "free", &pbt.EncodingProfile{})
+	job, _ := q.Lease("push-0", 0)
+	done := make(chan struct{})
+	go func() {
+		runPush(q, pbt.NewTranscoderClient(conn), job)
+		close(done)
+	}()
+	<-enc.started
+	if err := q.Cancel(job.ID); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("push did not stop with the cancelled job")
+	}
+
+	got, _ := q.Get(job.ID)
+	if got.State != StateCancelled || got.LeaseID != "" || got.Attempts[0].EndedAt.IsZero() {
+		t.Errorf("job %+v, want cancelled with its attempt ended", got)
+	}
+}
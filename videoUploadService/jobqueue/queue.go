@@ -0,0 +1,597 @@
+package jobqueue
+
+import (
+	pbt "VideoUploadService/transcoding"
+	"errors"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type State string
+
+const (
+	StateQueued    State = "queued"
+	StateLeased    State = "leased"
+	StateSucceeded State = "succeeded"
+	StateDead      State = "dead"
+	StateCancelled State = "cancelled"
+)
+
+var (
+	ErrNotFound  = errors.New("job not found")
+	ErrLeaseLost = errors.New("lease expired or held by another worker")
+	ErrActive    = errors.New("job is queued or running")
+	ErrFinished  = errors.New("job has already finished")
+)
+
+// TierPriority maps account tiers to job priorities. Higher runs first;
+// unknown tiers get zero.
+var TierPriority = map[string]int{
+	"free":    0,
+	"creator": 10,
+	"premium": 20,
+}
+
+// Attempt is one lease of a job by a worker.
+type Attempt struct {
+	Number    int       `json:"number"`
+	Worker    string    `json:"worker"`
+	StartedAt time.Time `json:"started_at"`
+	EndedAt   time.Time `json:"ended_at,omitempty"`
+	Duration  int64     `json:"duration_ms,omitempty"`
+	ErrorCode string    `json:"error_code,omitempty"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// Job is a request to transcode one video.
+type Job struct {
+	ID         string               `json:"id"`
+	VideoID    string               `json:"video_id"`
+	Owner      string               `json:"owner"`
+	Tier       string               `json:"tier,omitempty"`
+	Priority   int                  `json:"priority"`
+	Profile    *pbt.EncodingProfile `json:"-"`
+	State      State                `json:"state"`
+	Attempts   []Attempt            `json:"attempts"`
+	EnqueuedAt time.Time            `json:"enqueued_at"`
+	UpdatedAt  time.Time            `json:"updated_at"`
+	NotBefore  time.Time            `json:"not_before,omitempty"`
+	Worker     string               `json:"worker,omitempty"`
+	LeaseID    string               `json:"-"`
+	LeaseUntil time.Time            `json:"lease_until,omitempty"`
+	Manifest   string               `json:"manifest,omitempty"`
+
+	// attemptBase is the number of attempts made before the job was last
+	// requeued by hand; only later attempts count towards MaxAttempts.
+	attemptBase int
+}
+
+type Config struct {
+	// MaxActive bounds how many jobs may be leased at the same time.
+	MaxActive int
+	// MaxAttempts is the number of failed attempts after which a job is
+	// moved to the dead-letter list.
+	MaxAttempts int
+	Visibility  time.Duration
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	// Retention is how long succeeded and cancelled jobs are kept before
+	// they are dropped; zero keeps them. Dead letters stay until purged.
+	Retention time.Duration
+}
+
+var DefaultConfig = Config{
+	MaxActive:   2,
+	MaxAttempts: 3,
+	Visibility:  5 * time.Minute,
+	BaseBackoff: 30 * time.Second,
+	MaxBackoff:  30 * time.Minute,
+	Retention:   24 * time.Hour,
+}
+
+// Queue holds transcoding jobs in memory. Jobs are leased by workers for a
+// visibility timeout that heartbeats extend; a job whose lease runs out
+// counts as a failed attempt and becomes available again.
+type Queue struct {
+	cfg Config
+
+	mu       sync.Mutex
+	jobs     map[string]*Job
+	ready    chan struct{}
+	onChange func(Job)
+}
+
+var Default = New(DefaultConfig)
+
+func New(cfg Config) *Queue {
+	q := &Queue{
+		cfg:   cfg,
+		jobs:  make(map[string]*Job),
+		ready: make(chan struct{}, 1),
+	}
+	go q.reapLoop()
+	return q
+}
+
+// SetConfig replaces the queue configuration. Already leased jobs keep their
+// current lease.
+func (q *Queue) SetConfig(cfg Config) {
+	q.mu.Lock()
+	q.cfg = cfg
+	q.mu.Unlock()
+	q.signal()
+}
+
+// OnChange registers fn to be called with a copy of a job after every state
+// change. fn is called without the queue lock held.
+func (q *Queue) OnChange(fn func(Job)) {
+	q.mu.Lock()
+	q.onChange = fn
+	q.mu.Unlock()
+}
+
+// Enqueue adds a transcoding job for a video.
+func (q *Queue) Enqueue(videoID, owner, tier string, profile *pbt.EncodingProfile) Job {
+	now := time.Now()
+	job := &Job{
+		ID:         uuid.NewString(),
+		VideoID:    videoID,
+		Owner:      owner,
+		Tier:       tier,
+		Priority:   TierPriority[tier],
+		Profile:    profile,
+		State:      StateQueued,
+		EnqueuedAt: now,
+		UpdatedAt:  now,
+	}
+	q.mu.Lock()
+	q.jobs[job.ID] = job
+	snapshot := job.copy()
+	q.mu.Unlock()
+
+	q.changed(snapshot)
+	q.signal()
+	return snapshot
+}
+
+// Ready returns a channel that receives when a job may have become
+// available, so that idle workers don't have to poll.
+func (q *Queue) Ready() <-chan struct{} {
+	return q.ready
+}
+
+// Lease hands the highest priority available job to a worker. It returns
+// false if no job is available or the concurrency limit is reached.
+func (q *Queue) Lease(worker string, visibility time.Duration) (Job, bool) {
+	now := time.Now()
+	q.mu.Lock()
+	expired := q.reapLocked(now)
+
+	if visibility <= 0 {
+		visibility = q.cfg.Visibility
+	}
+	active := 0
+	var best *Job
+	for _, job := range q.jobs {
+		switch {
+		case job.LeaseID != "":
+			// Cancelled jobs hold their slot until the worker stops.
+			active++
+		case job.State == StateQueued && !job.NotBefore.After(now):
+			if best == nil || job.Priority > best.Priority ||
+				(job.Priority == best.Priority && job.EnqueuedAt.Before(best.EnqueuedAt)) {
+				best = job
+			}
+		}
+	}
+	if best == nil || (q.cfg.MaxActive > 0 && active >= q.cfg.MaxActive) {
+		q.mu.Unlock()
+		q.changed(expired...)
+		return Job{}, false
+	}
+
+	best.State = StateLeased
+	best.Worker = worker
+	best.LeaseID = uuid.NewString()
+	best.LeaseUntil = now.Add(visibility)
+	best.UpdatedAt = now
+	best.Attempts = append(best.Attempts, Attempt{
+		Number:    len(best.Attempts) + 1,
+		Worker:    worker,
+		StartedAt: now,
+	})
+	snapshot := best.copy()
+	q.mu.Unlock()
+
+	q.changed(append(expired, snapshot)...)
+	return snapshot, true
+}
+
+// Heartbeat extends the lease of a job. It returns ErrLeaseLost if the lease
+// already expired, and reports whether the job was cancelled meanwhile.
+func (q *Queue) Heartbeat(jobID, leaseID string, extend time.Duration) (time.Time, bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job, ok := q.jobs[jobID]
+	if !ok {
+		return time.Time{}, false, ErrNotFound
+	}
+	if job.State == StateCancelled && job.LeaseID == leaseID {
+		return time.Time{}, true, nil
+	}
+	if job.State != StateLeased || job.LeaseID != leaseID {
+		return time.Time{}, false, ErrLeaseLost
+	}
+	if extend <= 0 {
+		extend = q.cfg.Visibility
+	}
+	job.LeaseUntil = time.Now().Add(extend)
+	return job.LeaseUntil, false, nil
+}
+
+// Leased returns a job if leaseID is its current lease, or ErrLeaseLost.
+func (q *Queue) Leased(jobID, leaseID string) (Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job, ok := q.jobs[jobID]
+	if !ok {
+		return Job{}, ErrNotFound
+	}
+	if job.State != StateLeased || job.LeaseID != leaseID {
+		return Job{}, ErrLeaseLost
+	}
+	return job.copy(), nil
+}
+
+// heartbeatInterval is how often a lease is extended, a third of the
+// visibility timeout so that a missed beat or two does not lose it.
+func (q *Queue) heartbeatInterval() time.Duration {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return max(q.cfg.Visibility/3, time.Second)
+}
+
+// Complete marks a leased job as done.
+func (q *Queue) Complete(jobID, leaseID, manifest string) error {
+	return q.finish(jobID, leaseID, func(job *Job, now time.Time) {
+		job.State = StateSucceeded
+		job.Manifest = manifest
+	})
+}
+
+// Fail records a failed attempt. Retryable failures are queued again with
+// exponential backoff until MaxAttempts is reached, after which the job is
+// dead-lettered.
+func (q *Queue) Fail(jobID, leaseID, code, message string, retryable bool) error {
+	return q.finish(jobID, leaseID, func(job *Job, now time.Time) {
+		last := &job.Attempts[len(job.Attempts)-1]
+		last.ErrorCode = code
+		last.Error = message
+		q.retryLocked(job, now, retryable)
+	})
+}
+
+// Cancel stops a queued or leased job. A leased job's worker learns about it
+// on its next heartbeat. Jobs that already finished return ErrFinished.
+func (q *Queue) Cancel(jobID string) error {
+	q.mu.Lock()
+	job, ok := q.jobs[jobID]
+	if !ok {
+		q.mu.Unlock()
+		return ErrNotFound
+	}
+	if job.State != StateQueued && job.State != StateLeased {
+		q.mu.Unlock()
+		return ErrFinished
+	}
+	job.State = StateCancelled
+	job.UpdatedAt = time.Now()
+	snapshot := job.copy()
+	q.mu.Unlock()
+
+	q.changed(snapshot)
+	q.signal()
+	return nil
+}
+
+// Get returns a copy of a job.
+func (q *Queue) Get(jobID string) (Job, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job, ok := q.jobs[jobID]
+	if !ok {
+		return Job{}, false
+	}
+	return job.copy(), true
+}
+
+// ForVideo returns the most recently enqueued job of a video.
+func (q *Queue) ForVideo(videoID string) (Job, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var latest *Job
+	for _, job := range q.jobs {
+		if job.VideoID == videoID && (latest == nil || job.EnqueuedAt.After(latest.EnqueuedAt)) {
+			latest = job
+		}
+	}
+	if latest == nil {
+		return Job{}, false
+	}
+	return latest.copy(), true
+}
+
+// DeadLetters returns the jobs that exhausted their attempts.
+func (q *Queue) DeadLetters() []Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var jobs []Job
+	for _, job := range q.jobs {
+		if job.State == StateDead {
+			jobs = append(jobs, job.copy())
+		}
+	}
+	return jobs
+}
+
+// List returns the jobs in the given state, or every job for an empty state,
+// oldest first.
+func (q *Queue) List(state State) []Job {
+	q.mu.Lock()
+	jobs := make([]Job, 0, len(q.jobs))
+	for _, job := range q.jobs {
+		if state == "" || job.State == state {
+			jobs = append(jobs, job.copy())
+		}
+	}
+	q.mu.Unlock()
+
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].EnqueuedAt.Before(jobs[j].EnqueuedAt) })
+	return jobs
+}
+
+// Requeue makes a finished, dead or cancelled job available again right
+// away with a fresh set of attempts. Cancelled jobs can only be requeued once
+// their worker has stopped.
+func (q *Queue) Requeue(jobID string) (Job, error) {
+	return q.RequeueWith(jobID, nil)
+}
+
+// RequeueWith requeues a job like Requeue, encoding with profile instead of
+// the job's current profile unless it is nil.
+func (q *Queue) RequeueWith(jobID string, profile *pbt.EncodingProfile) (Job, error) {
+	return q.modify(jobID, func(job *Job) error {
+		// A cancelled job's worker may still be running it.
+		if job.State == StateQueued || job.State == StateLeased || job.LeaseID != "" {
+			return ErrActive
+		}
+		if profile != nil {
+			job.Profile = profile
+		}
+		job.State = StateQueued
+		job.NotBefore = time.Time{}
+		job.Worker = ""
+		job.attemptBase = len(job.Attempts)
+		return nil
+	})
+}
+
+// Reprioritize changes the priority of a job.
+func (q *Queue) Reprioritize(jobID string, priority int) (Job, error) {
+	return q.modify(jobID, func(job *Job) error {
+		job.Priority = priority
+		return nil
+	})
+}
+
+// Purge removes a job that is not currently leased.
+func (q *Queue) Purge(jobID string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job, ok := q.jobs[jobID]
+	if !ok {
+		return ErrNotFound
+	}
+	if job.State == StateLeased {
+		return ErrActive
+	}
+	delete(q.jobs, jobID)
+	return nil
+}
+
+// PurgeState removes every job in a state other than leased and returns
+// how many were removed.
+func (q *Queue) PurgeState(state State) (int, error) {
+	if state == StateLeased {
+		return 0, ErrActive
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	n := 0
+	for id, job := range q.jobs {
+		if job.State == state {
+			delete(q.jobs, id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (q *Queue) modify(jobID string, fn func(*Job) error) (Job, error) {
+	q.mu.Lock()
+	job, ok := q.jobs[jobID]
+	if !ok {
+		q.mu.Unlock()
+		return Job{}, ErrNotFound
+	}
+	if err := fn(job); err != nil {
+		q.mu.Unlock()
+		return Job{}, err
+	}
+	job.UpdatedAt = time.Now()
+	snapshot := job.copy()
+	q.mu.Unlock()
+
+	q.changed(snapshot)
+	q.signal()
+	return snapshot, nil
+}
+
+func (q *Queue) finish(jobID, leaseID string, fn func(*Job, time.Time)) error {
+	now := time.Now()
+	q.mu.Lock()
+	job, ok := q.jobs[jobID]
+	if !ok {
+		q.mu.Unlock()
+		return ErrNotFound
+	}
+	if job.LeaseID != leaseID || (job.State != StateLeased && job.State != StateCancelled) {
+		q.mu.Unlock()
+		return ErrLeaseLost
+	}
+	job.endAttempt(now)
+	if job.State == StateLeased {
+		fn(job, now)
+	}
+	job.LeaseID = ""
+	job.LeaseUntil = time.Time{}
+	job.UpdatedAt = now
+	snapshot := job.copy()
+	q.mu.Unlock()
+
+	q.changed(snapshot)
+	q.signal()
+	return nil
+}
+
+// retryLocked queues the job again after a failed attempt, or dead-letters
+// it. q.mu must be held.
+func (q *Queue) retryLocked(job *Job, now time.Time, retryable bool) {
+	job.Worker = ""
+	failures := len(job.Attempts) - job.attemptBase
+	if !retryable || failures >= q.cfg.MaxAttempts {
+		job.State = StateDead
+		return
+	}
+	job.State = StateQueued
+	job.NotBefore = now.Add(q.backoff(failures))
+}
+
+// backoff is the delay before retrying a job after its nth failed attempt:
+// BaseBackoff doubled for every attempt after the first, up to MaxBackoff if
+// set.
+func (q *Queue) backoff(attempts int) time.Duration {
+	d := q.cfg.BaseBackoff
+	for i := 1; i < attempts && d < math.MaxInt64/2; i++ {
+		if q.cfg.MaxBackoff > 0 && d >= q.cfg.MaxBackoff {
+			break
+		}
+		d *= 2
+	}
+	if q.cfg.MaxBackoff > 0 && d > q.cfg.MaxBackoff {
+		d = q.cfg.MaxBackoff
+	}
+	return d
+}
+
+// reapLocked returns jobs whose lease expired to the queue, and ends the
+// attempts of cancelled jobs whose worker never stopped. q.mu must be held.
+func (q *Queue) reapLocked(now time.Time) []Job {
+	var expired []Job
+	for _, job := range q.jobs {
+		if job.LeaseID == "" || job.LeaseUntil.After(now) {
+			continue
+		}
+		if job.State == StateCancelled {
+			job.endAttempt(now)
+			job.LeaseID = ""
+			job.LeaseUntil = time.Time{}
+			continue
+		}
+		last := job.endAttempt(now)
+		last.ErrorCode = "lease_expired"
+		last.Error = "worker stopped sending heartbeats"
+		job.LeaseID = ""
+		job.LeaseUntil = time.Time{}
+		job.UpdatedAt = now
+		q.retryLocked(job, now, true)
+		expired = append(expired, job.copy())
+	}
+	return expired
+}
+
+// pruneLocked drops succeeded and cancelled jobs that finished longer than
+// the retention ago. q.mu must be held.
+func (q *Queue) pruneLocked(now time.Time) {
+	if q.cfg.Retention <= 0 {
+		return
+	}
+	for id, job := range q.jobs {
+		if (job.State == StateSucceeded || job.State == StateCancelled) && now.Sub(job.UpdatedAt) > q.cfg.Retention {
+			delete(q.jobs, id)
+		}
+	}
+}
+
+func (q *Queue) reapLoop() {
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		q.mu.Lock()
+		expired := q.reapLocked(now)
+		q.pruneLocked(now)
+		due := false
+		for _, job := range q.jobs {
+			if job.State == StateQueued && !job.NotBefore.After(now) {
+				due = true
+				break
+			}
+		}
+		q.mu.Unlock()
+
+		q.changed(expired...)
+		if due {
+			q.signal()
+		}
+	}
+}
+
+func (q *Queue) signal() {
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+}
+
+func (q *Queue) changed(jobs ...Job) {
+	if len(jobs) == 0 {
+		return
+	}
+	q.mu.Lock()
+	fn := q.onChange
+	q.mu.Unlock()
+	if fn == nil {
+		return
+	}
+	for _, job := range jobs {
+		fn(job)
+	}
+}
+
+// endAttempt closes the current attempt and returns it.
+func (j *Job) endAttempt(now time.Time) *Attempt {
+	last := &j.Attempts[len(j.Attempts)-1]
+	last.EndedAt = now
+	last.Duration = now.Sub(last.StartedAt).Milliseconds()
+	return last
+}
+
+func (j *Job) copy() Job {
+	c := *j
+	c.Attempts = append([]Attempt(nil), j.Attempts...)
+	return c
+}
@@ -0,0 +1,253 @@
+package jobqueue
+
+import (
+	pbt "VideoUploadService/transcoding"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLeasePriority(t *testing.T) {
+	q := New(Config{MaxAttempts: 3, Visibility: time.Minute})
+	free := q.Enqueue("v1", "alice", "free", nil)
+	premium := q.Enqueue("v2", "bob", "premium", nil)
+	creator := q.Enqueue("v3", "carol", "creator", nil)
+	laterPremium := q.Enqueue("v4", "dave", "premium", nil)
+
+	// Higher priorities first, and jobs of the same priority in the order
+	// they were queued.
+	for _, want := range []Job{premium, laterPremium, creator, free} {
+		job, ok := q.Lease("worker", 0)
+		if !ok || job.ID != want.ID {
+			t.Fatalf("leased %s (%s), want %s (%s)", job.VideoID, job.Tier, want.VideoID, want.Tier)
+		}
+	}
+	if _, ok := q.Lease("worker", 0); ok {
+		t.Error("leased a job from an empty queue")
+	}
+}
+
+func TestLeaseConcurrencyBound(t *testing.T) {
+	q := New(Config{MaxActive: 2, MaxAttempts: 3, Visibility: time.Minute})
+	for _, v := range []string{"v1", "v2", "v3"} {
+		q.Enqueue(v, "alice", "free", nil)
+	}
+	first, _ := q.Lease("worker", 0)
+	if _, ok := q.Lease("worker", 0); !ok {
+		t.Fatal("second lease refused")
+	}
+	if _, ok := q.Lease("worker", 0); ok {
+		t.Fatal("leased a third job with MaxActive 2")
+	}
+	if err := q.Complete(first.ID, first.LeaseID, "master.m3u8"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := q.Lease("worker", 0); !ok {
+		t.Error("lease refused after a job completed")
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      Config
+		attempts int
+		want     time.Duration
+	}{
+		{"first retry", Config{BaseBackoff: time.Second, MaxBackoff: time.Minute}, 1, time.Second},
+		{"doubles", Config{BaseBackoff: time.Second, MaxBackoff: time.Minute}, 4, 8 * time.Second},
+		{"capped", Config{BaseBackoff: time.Second, MaxBackoff: time.Minute}, 10, time.Minute},
+		{"uncapped", Config{BaseBackoff: time.Second}, 10, 512 * time.Second},
+		{"uncapped without overflow", Config{BaseBackoff: time.Second}, 100, time.Second << 33},
+	}
+	for _, tt := range tests {
+		q := &Queue{cfg: tt.cfg}
+		if got := q.backoff(tt.attempts); got != tt.want {
+			t.Errorf("%s: backoff(%d) = %s, want %s", tt.name, tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestFailRetriesThenDeadLetters(t *testing.T) {
+	q := New(Config{MaxAttempts: 2, Visibility: time.Minute, BaseBackoff: time.Hour})
+	queued := q.Enqueue("v1", "alice", "free", nil)
+
+	job, _ := q.Lease("worker", 0)
+	if err := q.Fail(job.ID, job.LeaseID, "encoder_failed", "boom", true); err != nil {
+		t.Fatal(err)
+	}
+	job, _ = q.Get(queued.ID)
+	if job.State != StateQueued || time.Until(job.NotBefore) < 59*time.Minute {
+		t.Fatalf("after a retryable failure the job is %s until %s, want queued for an hour", job.State, job.NotBefore)
+	}
+	if _, ok := q.Lease("worker", 0); ok {
+		t.Fatal("leased a job still backing off")
+	}
+
+	if _, err := q.Requeue(job.ID); !errors.Is(err, ErrActive) {
+		t.Errorf("requeue of a queued job: err = %v, want ErrActive", err)
+	}
+
+	// The second failure exhausts the attempts.
+	q.modify(job.ID, func(j *Job) error { j.NotBefore = time.Time{}; return nil })
+	job, _ = q.Lease("worker", 0)
+	if err := q.Fail(job.ID, job.LeaseID, "encoder_failed", "boom", true); err != nil {
+		t.Fatal(err)
+	}
+	job, _ = q.Get(queued.ID)
+	if job.State != StateDead || len(job.Attempts) != 2 {
+		t.Fatalf("job is %s after %d attempts, want dead after 2", job.State, len(job.Attempts))
+	}
+	if dead := q.DeadLetters(); len(dead) != 1 || dead[0].ID != job.ID {
+		t.Errorf("dead letters %v", dead)
+	}
+
+	// Requeueing by hand skips the backoff and starts a fresh set of
+	// attempts.
+	job, err := q.Requeue(job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.State != StateQueued || !job.NotBefore.IsZero() {
+		t.Errorf("requeued job is %s until %s, want queued now", job.State, job.NotBefore)
+	}
+	job, _ = q.Lease("worker", 0)
+	if err := q.Fail(job.ID, job.LeaseID, "encoder_failed", "boom", true); err != nil {
+		t.Fatal(err)
+	}
+	if job, _ = q.Get(job.ID); job.State != StateQueued {
+		t.Fatalf("job is %s after the first failure since it was requeued, want queued", job.State)
+	}
+	q.modify(job.ID, func(j *Job) error { j.NotBefore = time.Time{}; return nil })
+	job, _ = q.Lease("worker", 0)
+	if err := q.Fail(job.ID, job.LeaseID, "encryption_unsupported", "clear only", false); err != nil {
+		t.Fatal(err)
+	}
+	if job, _ = q.Get(job.ID); job.State != StateDead || len(job.Attempts) != 4 {
+		t.Errorf("job is %s after a non-retryable failure, want dead", job.State)
+	}
+}
+
+func TestLeaseExpiry(t *testing.T) {
+	q := New(Config{MaxAttempts: 3, Visibility: time.Minute})
+	q.Enqueue("v1", "alice", "free", nil)
+	job, _ := q.Lease("worker-1", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	// The next lease returns the expired job to the queue first.
+	again, ok := q.Lease("worker-2", time.Minute)
+	if !ok || again.ID != job.ID || again.LeaseID == job.LeaseID {
+		t.Fatalf("expired job was not leased again: %+v", again)
+	}
+	if len(again.Attempts) != 2 || again.Attempts[0].ErrorCode != "lease_expired" || again.Attempts[0].EndedAt.IsZero() {
+		t.Errorf("attempts %+v, want the first ended by its expiry", again.Attempts)
+	}
+	if _, _, err := q.Heartbeat(job.ID, job.LeaseID, 0); !errors.Is(err, ErrLeaseLost) {
+		t.Errorf("heartbeat with the expired lease: err = %v, want ErrLeaseLost", err)
+	}
+	if err := q.Complete(job.ID, job.LeaseID, "master.m3u8"); !errors.Is(err, ErrLeaseLost) {
+		t.Errorf("complete with the expired lease: err = %v, want ErrLeaseLost", err)
+	}
+	if err := q.Complete(again.ID, again.LeaseID, "master.m3u8"); err != nil {
+		t.Errorf("complete with the current lease: err = %v", err)
+	}
+}
+
+func TestCancel(t *testing.T) {
+	q := New(Config{MaxAttempts: 3, Visibility: time.Minute})
+	queued := q.Enqueue("v1", "alice", "free", nil)
+	if err := q.Cancel(queued.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Cancel(queued.ID); !errors.Is(err, ErrFinished) {
+		t.Errorf("cancel of a cancelled job: err = %v, want ErrFinished", err)
+	}
+
+	q.Enqueue("v2", "alice", "free", nil)
+	leased, _ := q.Lease("worker", 0)
+	if err := q.Cancel(leased.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, cancelled, err := q.Heartbeat(leased.ID, leased.LeaseID, 0); !cancelled || err != nil {
+		t.Errorf("heartbeat of a cancelled job = %v, %v; want cancelled", cancelled, err)
+	}
+	// The worker ends its attempt; the job stays cancelled.
+	if err := q.Fail(leased.ID, leased.LeaseID, "cancelled", "", false); err != nil {
+		t.Fatal(err)
+	}
+	job, _ := q.Get(leased.ID)
+	if job.State != StateCancelled || job.LeaseID != "" || job.Attempts[0].EndedAt.IsZero() {
+		t.Errorf("job %+v, want cancelled with its attempt ended", job)
+	}
+
+	q.Enqueue("v3", "alice", "free", nil)
+	done, _ := q.Lease("worker", 0)
+	q.Complete(done.ID, done.LeaseID, "master.m3u8")
+	if err := q.Cancel(done.ID); !errors.Is(err, ErrFinished) {
+		t.Errorf("cancel of a succeeded job: err = %v, want ErrFinished", err)
+	}
+	if job, _ := q.Get(done.ID); job.State != StateSucceeded {
+		t.Errorf("refused cancel left the job %s", job.State)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	q := New(Config{MaxAttempts: 1, Retention: time.Hour})
+	profile := &pbt.EncodingProfile{}
+	ids := make(map[State]string)
+	for _, state := range []State{StateSucceeded, StateCancelled, StateDead, StateLeased, StateQueued} {
+		job := q.Enqueue("v-"+string(state), "alice", "free", profile)
+		ids[state] = job.ID
+		if state == StateQueued {
+			continue
+		}
+		job, _ = q.Lease("worker", time.Hour)
+		switch state {
+		case StateSucceeded:
+			q.Complete(job.ID, job.LeaseID, "master.m3u8")
+		case StateCancelled:
+			q.Cancel(job.ID)
+			q.Fail(job.ID, job.LeaseID, "cancelled", "", false)
+		case StateDead:
+			q.Fail(job.ID, job.LeaseID, "encoder_failed", "boom", true)
+		}
+	}
+
+	q.mu.Lock()
+	q.pruneLocked(time.Now().Add(30 * time.Minute))
+	n := len(q.jobs)
+	q.pruneLocked(time.Now().Add(2 * time.Hour))
+	q.mu.Unlock()
+	if n != 5 {
+		t.Errorf("pruned %d jobs within the retention", 5-n)
+	}
+	for state, id := range ids {
+		_, kept := q.Get(id)
+		if want := state != StateSucceeded && state != StateCancelled; kept != want {
+			t.Errorf("%s job kept = %v, want %v", state, kept, want)
+		}
+	}
+}
+
+func TestCancelledJobHoldsSlot(t *testing.T) {
+	q := New(Config{MaxActive: 1, MaxAttempts: 3, Visibility: time.Minute})
+	q.Enqueue("v1", "alice", "free", nil)
+	q.Enqueue("v2", "alice", "free", nil)
+	job, _ := q.Lease("worker", time.Millisecond)
+	q.Cancel(job.ID)
+
+	if _, err := q.Requeue(job.ID); !errors.Is(err, ErrActive) {
+		t.Errorf("requeue while the worker runs the job: err = %v, want ErrActive", err)
+	}
+	// Heartbeats of cancelled jobs don't extend the lease, so a worker that
+	// never stops only holds the slot until it runs out.
+	q.Heartbeat(job.ID, job.LeaseID, time.Hour)
+	time.Sleep(5 * time.Millisecond)
+	next, ok := q.Lease("worker", 0)
+	if !ok || next.VideoID != "v2" {
+		t.Fatalf("leased %+v after the cancelled lease ran out, want v2", next)
+	}
+	if got, _ := q.Get(job.ID); got.State != StateCancelled || got.LeaseID != "" || got.Attempts[0].EndedAt.IsZero() {
+		t.Errorf("cancelled job %+v, want its attempt ended", got)
+	}
+}
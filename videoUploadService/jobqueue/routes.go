@@ -0,0 +1,95 @@
+package jobqueue
+
+import (
+	"VideoUploadService/identity"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func SetupRoutes(app *fiber.App) {
+	admin := app.Group("/admin/jobs", requireAdmin)
+	admin.Get("/", listJobsHandler)
+	admin.Delete("/", purgeStateHandler)
+	admin.Get("/:id", getJobHandler)
+	admin.Post("/:id/requeue", requeueHandler)
+	admin.Post("/:id/priority", reprioritizeHandler)
+	admin.Delete("/:id", purgeJobHandler)
+}
+
+func requireAdmin(c *fiber.Ctx) error {
+	if !identity.IsAdminFiber(c) {
+		return c.Status(403).SendString("Admin role required")
+	}
+	return c.Next()
+}
+
+// listJobsHandler lists jobs, optionally filtered with ?state=.
+func listJobsHandler(c *fiber.Ctx) error {
+	state, err := ParseState(c.Query("state"), true)
+	if err != nil {
+		return c.Status(400).SendString(err.Error())
+	}
+	return c.JSON(Default.List(state))
+}
+
+func getJobHandler(c *fiber.Ctx) error {
+	job, ok := Default.Get(c.Params("id"))
+	if !ok {
+		return c.Status(404).SendString(ErrNotFound.Error())
+	}
+	return c.JSON(job)
+}
+
+func requeueHandler(c *fiber.Ctx) error {
+	job, err := Default.Requeue(c.Params("id"))
+	if err != nil {
+		return respondErr(c, err)
+	}
+	return c.JSON(job)
+}
+
+func reprioritizeHandler(c *fiber.Ctx) error {
+	var body struct {
+		Priority int `json:"priority"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(400).SendString("Invalid request body")
+	}
+	job, err := Default.Reprioritize(c.Params("id"), body.Priority)
+	if err != nil {
+		return respondErr(c, err)
+	}
+	return c.JSON(job)
+}
+
+func purgeJobHandler(c *fiber.Ctx) error {
+	if err := Default.Purge(c.Params("id")); err != nil {
+		return respondErr(c, err)
+	}
+	return c.SendStatus(204)
+}
+
+// purgeStateHandler removes every job in the state given with ?state=.
+func purgeStateHandler(c *fiber.Ctx) error {
+	state, err := ParseState(c.Query("state"), false)
+	if err != nil {
+		return c.Status(400).SendString(err.Error())
+	}
+	n, err := Default.PurgeState(state)
+	if err != nil {
+		return respondErr(c, err)
+	}
+	return c.JSON(fiber.Map{"purged": n})
+}
+
+func respondErr(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return c.Status(404).SendString(err.Error())
+	case errors.Is(err, ErrActive):
+		return c.Status(409).SendString(err.Error())
+	default:
+		return c.Status(500).SendString(err.Error())
+	}
+}
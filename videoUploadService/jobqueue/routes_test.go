@@ -0,0 +1,70 @@
+package jobqueue
+
+import (
+	"VideoUploadService/identity"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestAdminRoutes(t *testing.T) {
+	tests := []struct {
+		name       string
+		role       string
+		method     string
+		path       string // "{name}" is replaced with that job's ID
+		body       string
+		wantStatus int
+	}{
+		{"list without the admin role", "creator", "GET", "/admin/jobs/", "", fiber.StatusForbidden},
+		{"requeue without the admin role", "", "POST", "/admin/jobs/{dead}/requeue", "", fiber.StatusForbidden},
+		{"purge without the admin role", "creator", "DELETE", "/admin/jobs/{dead}", "", fiber.StatusForbidden},
+		{"list", identity.AdminRole, "GET", "/admin/jobs/?state=dead", "", fiber.StatusOK},
+		{"list an unknown state", identity.AdminRole, "GET", "/admin/jobs/?state=stuck", "", fiber.StatusBadRequest},
+		{"get", identity.AdminRole, "GET", "/admin/jobs/{dead}", "", fiber.StatusOK},
+		{"get a missing job", identity.AdminRole, "GET", "/admin/jobs/missing", "", fiber.StatusNotFound},
+		{"requeue a dead job", identity.AdminRole, "POST", "/admin/jobs/{dead}/requeue", "", fiber.StatusOK},
+		{"requeue a queued job", identity.AdminRole, "POST", "/admin/jobs/{queued}/requeue", "", fiber.StatusConflict},
+		{"requeue a job still stopping", identity.AdminRole, "POST", "/admin/jobs/{stopping}/requeue", "", fiber.StatusConflict},
+		{"requeue a missing job", identity.AdminRole, "POST", "/admin/jobs/missing/requeue", "", fiber.StatusNotFound},
+		{"reprioritize", identity.AdminRole, "POST", "/admin/jobs/{leased}/priority", `{"priority": 50}`, fiber.StatusOK},
+		{"reprioritize without a body", identity.AdminRole, "POST", "/admin/jobs/{leased}/priority", `{`, fiber.StatusBadRequest},
+		{"purge a job", identity.AdminRole, "DELETE", "/admin/jobs/{dead}", "", fiber.StatusNoContent},
+		{"purge a leased job", identity.AdminRole, "DELETE", "/admin/jobs/{leased}", "", fiber.StatusConflict},
+		{"purge a state", identity.AdminRole, "DELETE", "/admin/jobs/?state=succeeded", "", fiber.StatusOK},
+		{"purge leased jobs", identity.AdminRole, "DELETE", "/admin/jobs/?state=leased", "", fiber.StatusConflict},
+		{"purge without a state", identity.AdminRole, "DELETE", "/admin/jobs/", "", fiber.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ids := adminJobs(t)
+			app := fiber.New()
+			SetupRoutes(app)
+
+			path := tt.path
+			for name, id := range ids {
+				path = strings.ReplaceAll(path, "{"+name+"}", id)
+			}
+			req := httptest.NewRequest(tt.method, path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set(identity.UserHeader, "ops")
+			req.Header.Set(identity.RoleHeader, tt.role)
+			res, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.StatusCode != tt.wantStatus {
+				body, _ := io.ReadAll(res.Body)
+				t.Fatalf("status = %d (%s), want %d", res.StatusCode, body, tt.wantStatus)
+			}
+			if tt.wantStatus == fiber.StatusForbidden {
+				if job, _ := Default.Get(ids["dead"]); len(Default.List("")) != 6 || job.State != StateDead {
+					t.Error("refused call changed the jobs")
+				}
+			}
+		})
+	}
+}
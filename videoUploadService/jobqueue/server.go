@@ -0,0 +1,123 @@
+package jobqueue
+
+import (
+	"VideoUploadService/transcodestatus"
+	pbt "VideoUploadService/transcoding"
+	"context"
+	"errors"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Server exposes the Default queue to encoder workers over gRPC.
+type Server struct {
+	pbt.UnimplementedTranscodeJobQueueServer
+}
+
+func (s *Server) LeaseJob(ctx context.Context, req *pbt.LeaseJobRequest) (*pbt.LeaseJobResponse, error) {
+	if req.WorkerId == "" {
+		return nil, status.Error(codes.InvalidArgument, "worker_id is required")
+	}
+	job, ok := Default.Lease(req.WorkerId, time.Duration(req.VisibilityTimeoutSeconds)*time.Second)
+	if !ok {
+		return &pbt.LeaseJobResponse{}, nil
+	}
+	return &pbt.LeaseJobResponse{
+		Job:            toProto(job),
+		LeaseId:        job.LeaseID,
+		LeaseExpiresAt: job.LeaseUntil.Unix(),
+	}, nil
+}
+
+func (s *Server) Heartbeat(ctx context.Context, req *pbt.JobHeartbeatRequest) (*pbt.JobHeartbeatResponse, error) {
+	until, cancelled, err := Default.Heartbeat(req.JobId, req.LeaseId, time.Duration(req.ExtendSeconds)*time.Second)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	if req.Status != nil && !cancelled {
+		if job, ok := Default.Get(req.JobId); ok {
+			transcodestatus.Default.Report(job.VideoID, req.Status)
+		}
+	}
+	return &pbt.JobHeartbeatResponse{LeaseExpiresAt: until.Unix(), Cancelled: cancelled}, nil
+}
+
+// CompleteJob finalizes the output of a job and marks it succeeded. Output
+// that cannot be finalized fails the job; the worker's part is still done,
+// so the call succeeds.
+func (s *Server) CompleteJob(ctx context.Context, req *pbt.CompleteJobRequest) (*pbt.JobAck, error) {
+	failure, err := complete(ctx, Default, req.JobId, req.LeaseId, req.ManifestLocation)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	job, ok := Default.Get(req.JobId)
+	switch {
+	case !ok:
+	case failure != nil:
+		reportFailure(job.VideoID, CodePackagingFailed, failure.Error())
+	default:
+		transcodestatus.Default.Report(job.VideoID, &pbt.VideoStatusResponse{
+			Status:           100,
+			Stage:            pbt.TranscodeStage_TRANSCODE_STAGE_COMPLETE,
+			ManifestLocation: req.ManifestLocation,
+		})
+	}
+	return &pbt.JobAck{}, nil
+}
+
+func (s *Server) FailJob(ctx context.Context, req *pbt.FailJobRequest) (*pbt.JobAck, error) {
+	if err := Default.Fail(req.JobId, req.LeaseId, req.ErrorCode, req.ErrorMessage, req.Retryable); err != nil {
+		return nil, toStatus(err)
+	}
+	if job, ok := Default.Get(req.JobId); ok && job.State == StateDead {
+		reportFailure(job.VideoID, req.ErrorCode, req.ErrorMessage)
+	}
+	return &pbt.JobAck{}, nil
+}
+
+// ContentKeys returns the key material of a video. It is set up in main
+// when content keys are configured.
+var ContentKeys func(ctx context.Context, videoID string) ([]*pbt.ContentKeyMaterial, error)
+
+// FetchContentKeys hands the keys of an encrypted job to the worker holding
+// its lease, so they never travel in the job itself.
+func (s *Server) FetchContentKeys(ctx context.Context, req *pbt.FetchContentKeysRequest) (*pbt.FetchContentKeysResponse, error) {
+	job, err := Default.Leased(req.JobId, req.LeaseId)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	if job.Profile.GetEncryption() == nil {
+		return nil, status.Error(codes.FailedPrecondition, "job is not encrypted")
+	}
+	if ContentKeys == nil {
+		return nil, status.Error(codes.FailedPrecondition, "content keys are not configured")
+	}
+	keys, err := ContentKeys(ctx, job.VideoID)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &pbt.FetchContentKeysResponse{Keys: keys}, nil
+}
+
+func toProto(job Job) *pbt.TranscodeJob {
+	return &pbt.TranscodeJob{
+		JobId:    job.ID,
+		Uuid:     job.VideoID,
+		Profile:  job.Profile,
+		Attempt:  uint32(len(job.Attempts)),
+		Priority: int32(job.Priority),
+	}
+}
+
+func toStatus(err error) error {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, ErrLeaseLost), errors.Is(err, ErrActive), errors.Is(err, ErrFinished):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
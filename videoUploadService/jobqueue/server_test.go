@@ -0,0 +1,98 @@
+package jobqueue
+
+import (
+	pbt "VideoUploadService/transcoding"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestFetchContentKeys(t *testing.T) {
+	saved, savedKeys := Default, ContentKeys
+	defer func() { Default, ContentKeys = saved, savedKeys }()
+	Default = New(DefaultConfig)
+	ContentKeys = func(ctx context.Context, videoID string) ([]*pbt.ContentKeyMaterial, error) {
+		return []*pbt.ContentKeyMaterial{{Index: 0, Key: []byte(videoID + "-key")}}, nil
+	}
+
+	encrypted := &pbt.EncodingProfile{Encryption: &pbt.HlsEncryption{Method: "AES-128"}}
+	Default.Enqueue("v1", "alice", "premium", encrypted)
+	job, ok := Default.Lease("worker", 0)
+	if !ok {
+		t.Fatal("no job to lease")
+	}
+	Default.Enqueue("v2", "alice", "free", &pbt.EncodingProfile{})
+	clear, _ := Default.Lease("worker", 0)
+
+	tests := []struct {
+		name       string
+		job, lease string
+		want       codes.Code
+	}{
+		{"lease holder", job.ID, job.LeaseID, codes.OK},
+		{"wrong lease", job.ID, "stale", codes.FailedPrecondition},
+		{"unknown job", "missing", job.LeaseID, codes.NotFound},
+		{"clear job", clear.ID, clear.LeaseID, codes.FailedPrecondition},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := (&Server{}).FetchContentKeys(context.Background(), &pbt.FetchContentKeysRequest{JobId: tt.job, LeaseId: tt.lease})
+			if got := status.Code(err); got != tt.want {
+				t.Fatalf("code = %v, want %v", got, tt.want)
+			}
+			if err == nil && (len(res.Keys) != 1 || string(res.Keys[0].Key) != "v1-key") {
+				t.Errorf("keys = %v", res.Keys)
+			}
+		})
+	}
+}
+
+func TestCompleteJobFinalizes(t *testing.T) {
+	tests := []struct {
+		name          string
+		stale         bool
+		finalizeErr   error
+		want          codes.Code
+		wantState     State
+		wantFinalized int
+	}{
+		{name: "finalized", want: codes.OK, wantState: StateSucceeded, wantFinalized: 1},
+		{name: "finalizing fails", finalizeErr: errors.New("no playlists"), want: codes.OK, wantState: StateDead, wantFinalized: 1},
+		{name: "stale lease", stale: true, want: codes.FailedPrecondition, wantState: StateLeased},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			saved, savedFinalize := Default, Finalize
+			defer func() { Default, Finalize = saved, savedFinalize }()
+			// Packaging failures are not retried, whatever attempts are left.
+			Default = New(Config{MaxAttempts: 3, Visibility: time.Minute})
+			finalized := 0
+			Finalize = func(ctx context.Context, job Job) error {
+				finalized++
+				return tt.finalizeErr
+			}
+
+			Default.Enqueue("v1", "alice", "free", &pbt.EncodingProfile{})
+			job, _ := Default.Lease("worker", 0)
+			lease := job.LeaseID
+			if tt.stale {
+				lease = "stale"
+			}
+			_, err := (&Server{}).CompleteJob(context.Background(), &pbt.CompleteJobRequest{JobId: job.ID, LeaseId: lease, ManifestLocation: "master.m3u8"})
+			if got := status.Code(err); got != tt.want {
+				t.Fatalf("code = %v, want %v", got, tt.want)
+			}
+			got, _ := Default.Get(job.ID)
+			if got.State != tt.wantState || finalized != tt.wantFinalized {
+				t.Errorf("job is %s after %d finalizations, want %s after %d", got.State, finalized, tt.wantState, tt.wantFinalized)
+			}
+			if tt.finalizeErr != nil && got.Attempts[0].ErrorCode != CodePackagingFailed {
+				t.Errorf("attempt failed with %q, want %q", got.Attempts[0].ErrorCode, CodePackagingFailed)
+			}
+		})
+	}
+}
@@ -0,0 +1,245 @@
+package live
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// HealthInterval is how often the health of a broadcast is reported.
+	HealthInterval = time.Second
+	// healthWindow is how many intervals rates are averaged over.
+	healthWindow = 5
+	// MaxKeyframeInterval is the longest keyframe interval that lets
+	// packagers cut segments close to their target duration.
+	MaxKeyframeInterval = 4 * time.Second
+	// maxDrift is how far audio and video timestamps may drift apart.
+	maxDrift = time.Second
+)
+
+// Warning codes of a health report.
+const (
+	WarnKeyframeInterval = "keyframe_interval"
+	WarnAVDrift          = "av_drift"
+	WarnDroppedFrames    = "dropped_frames"
+	WarnStalled          = "stalled"
+)
+
+// Warning is a problem with an incoming stream the broadcaster can fix.
+type Warning struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Health reports how the incoming stream of a channel is doing.
+type Health struct {
+	Channel  string `json:"channel"`
+	StreamID string `json:"stream_id,omitempty"`
+	// Live is false in reports about a channel that is offline; the
+	// measurements are then empty.
+	Live bool      `json:"live"`
+	At   time.Time `json:"at"`
+	// Bitrate is the incoming bits per second, of which VideoBitrate and
+	// AudioBitrate carry the media.
+	Bitrate      int64   `json:"bitrate"`
+	VideoBitrate int64   `json:"video_bitrate"`
+	AudioBitrate int64   `json:"audio_bitrate"`
+	FrameRate    float64 `json:"frame_rate"`
+	// KeyframeInterval is the time between the last two keyframes, or
+	// since the last one when that is longer.
+	KeyframeInterval float64 `json:"keyframe_interval_seconds"`
+	// AVDrift is how far audio timestamps run ahead of video ones, in
+	// milliseconds; negative if they lag behind.
+	AVDrift float64 `json:"av_drift_ms"`
+	// DroppedFrames counts the video frames missing from the broadcast so
+	// far: gaps in the publisher's timestamps and frames sent before the
+	// decoder configuration.
+	DroppedFrames int64     `json:"dropped_frames"`
+	Warnings      []Warning `json:"warnings,omitempty"`
+}
+
+// meter measures the incoming stream of a broadcast. The ingest feeds it
+// tags and frames; the hub takes a report every HealthInterval.
+type meter struct {
+	channel, streamID string
+
+	mu sync.Mutex
+	// cur is counted since the last report, window holds the last reports'
+	// counts.
+	cur    counts
+	window []counts
+
+	video, audio       time.Duration
+	sawVideo, sawAudio bool
+	// frameDuration is the running average spacing of video frames.
+	frameDuration time.Duration
+	keyframe      time.Duration
+	sawKeyframe   bool
+	keyInterval   time.Duration
+	dropped       int64
+	last          *Health
+}
+
+type counts struct {
+	bytes, videoBytes, audioBytes int64
+	frames                        int
+	dropped                       int64
+	drift                         time.Duration
+	driftSamples                  int
+}
+
+// tag counts the size of an incoming tag.
+func (m *meter) tag(size int) {
+	m.mu.Lock()
+	m.cur.bytes += int64(size)
+	m.mu.Unlock()
+}
+
+// videoFrame records a video frame with its decode time.
+func (m *meter) videoFrame(dts time.Duration, keyframe bool, size int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.cur.videoBytes += int64(size)
+	m.cur.frames++
+	if delta := dts - m.video; m.sawVideo && delta > 0 {
+		m.spacing(delta)
+	}
+	m.video, m.sawVideo = dts, true
+	if keyframe {
+		if m.sawKeyframe {
+			m.keyInterval = dts - m.keyframe
+		}
+		m.keyframe, m.sawKeyframe = dts, true
+	}
+	m.sampleDrift()
+}
+
+// spacing tracks the time between frames. A gap of several frame durations
+// means the frames in between never arrived.
+func (m *meter) spacing(delta time.Duration) {
+	fd := m.frameDuration
+	if fd > 0 && delta > fd*3/2 {
+		missing := int64((delta+fd/2)/fd) - 1
+		m.cur.dropped += missing
+		m.dropped += missing
+		return
+	}
+	if fd == 0 {
+		m.frameDuration = delta
+	} else {
+		m.frameDuration = (fd*7 + delta) / 8
+	}
+}
+
+// audioFrame records an audio frame with its decode time.
+func (m *meter) audioFrame(dts time.Duration, size int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.cur.audioBytes += int64(size)
+	m.audio, m.sawAudio = dts, true
+	m.sampleDrift()
+}
+
+// droppedFrame counts a video frame that could not be used.
+func (m *meter) droppedFrame() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.cur.dropped++
+	m.dropped++
+}
+
+func (m *meter) sampleDrift() {
+	if m.sawVideo && m.sawAudio {
+		m.cur.drift += m.audio - m.video
+		m.cur.driftSamples++
+	}
+}
+
+// report closes the current interval and reports on the window ending with
+// it.
+func (m *meter) report(now time.Time) Health {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.window = append(m.window, m.cur)
+	if len(m.window) > healthWindow {
+		m.window = m.window[1:]
+	}
+	m.cur = counts{}
+
+	var sum counts
+	for _, c := range m.window {
+		sum.bytes += c.bytes
+		sum.videoBytes += c.videoBytes
+		sum.audioBytes += c.audioBytes
+		sum.frames += c.frames
+		sum.dropped += c.dropped
+		sum.drift += c.drift
+		sum.driftSamples += c.driftSamples
+	}
+	seconds := (time.Duration(len(m.window)) * HealthInterval).Seconds()
+	h := Health{
+		Channel:       m.channel,
+		StreamID:      m.streamID,
+		Live:          true,
+		At:            now.UTC(),
+		Bitrate:       int64(float64(sum.bytes*8) / seconds),
+		VideoBitrate:  int64(float64(sum.videoBytes*8) / seconds),
+		AudioBitrate:  int64(float64(sum.audioBytes*8) / seconds),
+		FrameRate:     float64(sum.frames) / seconds,
+		DroppedFrames: m.dropped,
+	}
+	interval := m.keyInterval
+	if m.sawVideo {
+		interval = max(interval, m.video-m.keyframe)
+	}
+	h.KeyframeInterval = interval.Seconds()
+	var drift time.Duration
+	if sum.driftSamples > 0 {
+		drift = sum.drift / time.Duration(sum.driftSamples)
+		h.AVDrift = float64(drift) / float64(time.Millisecond)
+	}
+
+	if interval > MaxKeyframeInterval {
+		h.Warnings = append(h.Warnings, Warning{
+			Code: WarnKeyframeInterval,
+			Message: fmt.Sprintf("keyframes are %.1fs apart; segments can only be cut on keyframes, so set the encoder's keyframe interval to %s or less",
+				interval.Seconds(), MaxKeyframeInterval/2),
+		})
+	}
+	if drift > maxDrift {
+		h.Warnings = append(h.Warnings, Warning{
+			Code:    WarnAVDrift,
+			Message: fmt.Sprintf("audio timestamps run %s ahead of video", drift.Round(time.Millisecond)),
+		})
+	} else if drift < -maxDrift {
+		h.Warnings = append(h.Warnings, Warning{
+			Code:    WarnAVDrift,
+			Message: fmt.Sprintf("audio timestamps run %s behind video", (-drift).Round(time.Millisecond)),
+		})
+	}
+	if sum.dropped > 0 {
+		h.Warnings = append(h.Warnings, Warning{
+			Code:    WarnDroppedFrames,
+			Message: fmt.Sprintf("video frames dropped in the last %.0fs: %d", seconds, sum.dropped),
+		})
+	}
+	if m.window[len(m.window)-1].bytes == 0 {
+		h.Warnings = append(h.Warnings, Warning{
+			Code:    WarnStalled,
+			Message: fmt.Sprintf("no data arrived in the last %s", HealthInterval),
+		})
+	}
+	m.last = &h
+	return h
+}
+
+// latest returns the last report, if any.
+func (m *meter) latest() (Health, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.last == nil {
+		return Health{}, false
+	}
+	return *m.last, true
+}
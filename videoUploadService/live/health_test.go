@@ -0,0 +1,170 @@
+package live
+
+import (
+	"math"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSpacing(t *testing.T) {
+	const ms = time.Millisecond
+	tests := []struct {
+		name string
+		// deltas are the decode time steps between frames.
+		deltas        []time.Duration
+		dropped       int64
+		frameDuration time.Duration
+	}{
+		{name: "steady", deltas: []time.Duration{40 * ms, 40 * ms, 40 * ms}, frameDuration: 40 * ms},
+		{name: "first step sets the spacing", deltas: []time.Duration{33 * ms}, frameDuration: 33 * ms},
+		{name: "jitter is averaged", deltas: []time.Duration{40 * ms, 56 * ms}, frameDuration: 42 * ms},
+		{name: "two frames missing", deltas: []time.Duration{40 * ms, 120 * ms}, dropped: 2, frameDuration: 40 * ms},
+		{name: "gap rounds to frames", deltas: []time.Duration{40 * ms, 61 * ms, 139 * ms}, dropped: 1 + 2, frameDuration: 40 * ms},
+		{name: "long gap", deltas: []time.Duration{40 * ms, 2 * time.Second}, dropped: 49, frameDuration: 40 * ms},
+		{name: "repeated timestamps", deltas: []time.Duration{40 * ms, 0, 40 * ms}, frameDuration: 40 * ms},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &meter{}
+			dts := time.Second
+			m.videoFrame(dts, true, 0)
+			for _, d := range tt.deltas {
+				dts += d
+				m.videoFrame(dts, false, 0)
+			}
+			if m.dropped != tt.dropped || m.cur.dropped != tt.dropped {
+				t.Errorf("dropped %d frames (%d this interval), want %d", m.dropped, m.cur.dropped, tt.dropped)
+			}
+			if m.frameDuration != tt.frameDuration {
+				t.Errorf("frame duration %s, want %s", m.frameDuration, tt.frameDuration)
+			}
+		})
+	}
+}
+
+// feed sends a second of 25 fps video, with a keyframe every keyInterval,
+// and audio frames running offset ahead of it, each audio frame just before
+// the video frame it goes with.
+func feed(m *meter, from time.Duration, keyInterval, offset time.Duration) time.Duration {
+	const frame = 40 * time.Millisecond
+	for dts := from; dts < from+time.Second; dts += frame {
+		m.tag(111)
+		m.audioFrame(dts+offset, 100)
+		m.tag(1011)
+		m.videoFrame(dts, dts%keyInterval == 0, 1000)
+	}
+	return from + time.Second
+}
+
+func warningCodes(h Health) []string {
+	var codes []string
+	for _, w := range h.Warnings {
+		codes = append(codes, w.Code)
+	}
+	return codes
+}
+
+func TestHealthReport(t *testing.T) {
+	m := &meter{channel: "ch", streamID: "s1"}
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	dts := feed(m, 0, 2*time.Second, 0)
+
+	h := m.report(now)
+	if h.Channel != "ch" || h.StreamID != "s1" || !h.Live || !h.At.Equal(now) {
+		t.Errorf("report %+v", h)
+	}
+	if h.Bitrate != 25*1122*8 || h.VideoBitrate != 25*1000*8 || h.AudioBitrate != 25*100*8 || h.FrameRate != 25 {
+		t.Errorf("bitrate %d (video %d, audio %d), frame rate %.1f; want %d (%d, %d), 25",
+			h.Bitrate, h.VideoBitrate, h.AudioBitrate, h.FrameRate, 25*1122*8, 25*1000*8, 25*100*8)
+	}
+	if len(h.Warnings) != 0 {
+		t.Errorf("warnings %v for a healthy stream", warningCodes(h))
+	}
+
+	// A stalled interval halves the rates averaged over the window.
+	now = now.Add(HealthInterval)
+	h = m.report(now)
+	if h.VideoBitrate != 25*1000*8/2 || h.FrameRate != 12.5 || !slices.Contains(warningCodes(h), WarnStalled) {
+		t.Errorf("after a stall: video bitrate %d, frame rate %.1f, warnings %v", h.VideoBitrate, h.FrameRate, warningCodes(h))
+	}
+	// The keyframe interval is the time since the last keyframe while that
+	// is longer than the last interval.
+	if want := (time.Second - 40*time.Millisecond).Seconds(); math.Abs(h.KeyframeInterval-want) > 1e-9 {
+		t.Errorf("keyframe interval %.3fs, want %.3fs", h.KeyframeInterval, want)
+	}
+
+	// Three frames go missing, and one arrives before its decoder
+	// configuration.
+	dts += 120 * time.Millisecond
+	m.droppedFrame()
+	dts = feed(m, dts, 2*time.Second, 0)
+	now = now.Add(HealthInterval)
+	h = m.report(now)
+	if h.DroppedFrames != 4 || !slices.Contains(warningCodes(h), WarnDroppedFrames) {
+		t.Errorf("dropped %d frames with warnings %v, want 4 and %s", h.DroppedFrames, warningCodes(h), WarnDroppedFrames)
+	}
+
+	// Once the drops leave the window, only the total remembers them.
+	for i := 0; i < healthWindow; i++ {
+		dts = feed(m, dts, 2*time.Second, 0)
+		now = now.Add(HealthInterval)
+		h = m.report(now)
+	}
+	if h.DroppedFrames != 4 || len(h.Warnings) != 0 {
+		t.Errorf("dropped %d frames with warnings %v, want 4 and none", h.DroppedFrames, warningCodes(h))
+	}
+	if h.FrameRate != 25 || h.KeyframeInterval != 2 {
+		t.Errorf("frame rate %.1f, keyframe interval %.1fs; want 25, 2s", h.FrameRate, h.KeyframeInterval)
+	}
+	if last, ok := m.latest(); !ok || !last.At.Equal(now) {
+		t.Errorf("latest report at %s, want %s", last.At, now)
+	}
+}
+
+func TestHealthKeyframeInterval(t *testing.T) {
+	m := &meter{}
+	var dts time.Duration
+	var h Health
+	for i := 0; i < 6; i++ {
+		dts = feed(m, dts, 5*time.Second, 0)
+		h = m.report(time.Now())
+	}
+	if h.KeyframeInterval != 5 || !slices.Contains(warningCodes(h), WarnKeyframeInterval) {
+		t.Errorf("keyframe interval %.1fs with warnings %v, want 5s and %s", h.KeyframeInterval, warningCodes(h), WarnKeyframeInterval)
+	}
+}
+
+func TestHealthDrift(t *testing.T) {
+	tests := []struct {
+		offset time.Duration
+		// message is part of the drift warning, if any.
+		message string
+	}{
+		{offset: 0},
+		{offset: 200 * time.Millisecond},
+		{offset: -900 * time.Millisecond},
+		{offset: 1500 * time.Millisecond, message: "ahead of video"},
+		{offset: -1500 * time.Millisecond, message: "behind video"},
+	}
+	for _, tt := range tests {
+		m := &meter{}
+		feed(m, 0, 2*time.Second, tt.offset)
+		h := m.report(time.Now())
+		// Samples alternate between the offset and the offset plus a frame,
+		// as audio is a frame ahead until the video frame arrives.
+		if want := float64(tt.offset / time.Millisecond); h.AVDrift < want || h.AVDrift > want+40 {
+			t.Errorf("offset %s: drift %.1fms", tt.offset, h.AVDrift)
+		}
+		var message string
+		for _, w := range h.Warnings {
+			if w.Code == WarnAVDrift {
+				message = w.Message
+			}
+		}
+		if (message == "") != (tt.message == "") || !strings.Contains(message, tt.message) {
+			t.Errorf("offset %s: drift warning %q, want one saying %q", tt.offset, message, tt.message)
+		}
+	}
+}
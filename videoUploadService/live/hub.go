@@ -0,0 +1,193 @@
+package live
+
+import (
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Hub tracks the broadcasts that are live right now, one per channel.
+type Hub struct {
+	mu      sync.Mutex
+	streams map[string]*Stream
+	onStart []func(*Stream)
+	// watchers receive the health reports of a channel.
+	watchers map[string]map[chan Health]struct{}
+}
+
+// Default is the hub fed by the RTMP ingest.
+var Default = NewHub()
+
+func NewHub() *Hub {
+	return &Hub{streams: make(map[string]*Stream), watchers: make(map[string]map[chan Health]struct{})}
+}
+
+// OnStart registers fn to be called with every broadcast that starts,
+// before its first packet is published. Packagers subscribe here.
+func (h *Hub) OnStart(fn func(*Stream)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.onStart = append(h.onStart, fn)
+}
+
+// Start begins a broadcast on a channel.
+func (h *Hub) Start(ch Channel) (*Stream, error) {
+	id := uuid.NewString()
+	s := &Stream{
+		ID:        id,
+		Channel:   ch.ID,
+		Owner:     ch.Owner,
+		Tier:      ch.Tier,
+		KeyID:     ch.KeyID,
+		Settings:  ch.Settings,
+		StartedAt: time.Now().UTC(),
+		hub:       h,
+		health:    &meter{channel: ch.ID, streamID: id},
+		subs:      make(map[*Subscription]struct{}),
+		done:      make(chan struct{}),
+	}
+	h.mu.Lock()
+	if _, ok := h.streams[ch.ID]; ok {
+		h.mu.Unlock()
+		return nil, ErrAlreadyLive
+	}
+	h.streams[ch.ID] = s
+	hooks := append([]func(*Stream){}, h.onStart...)
+	h.mu.Unlock()
+
+	for _, fn := range hooks {
+		fn(s)
+	}
+	go h.reportHealth(s)
+	return s, nil
+}
+
+// Get returns the live broadcast of a channel.
+func (h *Hub) Get(channel string) (*Stream, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s, ok := h.streams[channel]
+	return s, ok
+}
+
+// List returns the live broadcasts, oldest first.
+func (h *Hub) List() []*Stream {
+	h.mu.Lock()
+	streams := make([]*Stream, 0, len(h.streams))
+	for _, s := range h.streams {
+		streams = append(streams, s)
+	}
+	h.mu.Unlock()
+	sort.Slice(streams, func(i, j int) bool { return streams[i].StartedAt.Before(streams[j].StartedAt) })
+	return streams
+}
+
+// StopKey disconnects the broadcasts published with a stream key, e.g.
+// because it was revoked. It returns how many were stopped.
+func (h *Hub) StopKey(keyID string, err error) int {
+	n := 0
+	for _, s := range h.List() {
+		if s.KeyID == keyID {
+			s.Stop(err)
+			n++
+		}
+	}
+	return n
+}
+
+func (h *Hub) remove(s *Stream) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.streams[s.Channel] == s {
+		delete(h.streams, s.Channel)
+	}
+}
+
+// WatchHealth returns a channel of the health reports of a channel,
+// starting with its current state. It only ever holds the newest report,
+// so slow readers skip reports rather than hold up the others. Call the
+// returned function to stop watching.
+func (h *Hub) WatchHealth(channel string) (<-chan Health, func()) {
+	ch := make(chan Health, 1)
+	h.mu.Lock()
+	current := Health{Channel: channel, At: time.Now().UTC()}
+	if s, ok := h.streams[channel]; ok {
+		if last, ok := s.health.latest(); ok {
+			current = last
+		} else {
+			current.StreamID, current.Live = s.ID, true
+		}
+	}
+	ch <- current
+	if h.watchers[channel] == nil {
+		h.watchers[channel] = make(map[chan Health]struct{})
+	}
+	h.watchers[channel][ch] = struct{}{}
+	h.mu.Unlock()
+
+	return ch, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if _, ok := h.watchers[channel][ch]; ok {
+			delete(h.watchers[channel], ch)
+			if len(h.watchers[channel]) == 0 {
+				delete(h.watchers, channel)
+			}
+			close(ch)
+		}
+	}
+}
+
+// Health returns the last health report of a channel's broadcast.
+func (h *Hub) Health(channel string) (Health, bool) {
+	s, ok := h.Get(channel)
+	if !ok {
+		return Health{}, false
+	}
+	return s.health.latest()
+}
+
+// reportHealth reports the health of a broadcast every HealthInterval
+// until it ends, logging warnings as they come up.
+func (h *Hub) reportHealth(s *Stream) {
+	ticker := time.NewTicker(HealthInterval)
+	defer ticker.Stop()
+	warned := make(map[string]bool)
+	for {
+		select {
+		case now := <-ticker.C:
+			report := s.health.report(now)
+			current := make(map[string]bool)
+			for _, w := range report.Warnings {
+				current[w.Code] = true
+				if !warned[w.Code] {
+					log.Printf("Live %s: %s", s.ID, w.Message)
+				}
+			}
+			warned = current
+			h.publishHealth(report)
+		case <-s.done:
+			h.publishHealth(Health{Channel: s.Channel, StreamID: s.ID, At: time.Now().UTC()})
+			return
+		}
+	}
+}
+
+func (h *Hub) publishHealth(report Health) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if s, ok := h.streams[report.Channel]; ok && s.ID != report.StreamID {
+		// A report about a broadcast that has already been replaced.
+		return
+	}
+	for ch := range h.watchers[report.Channel] {
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- report
+	}
+}
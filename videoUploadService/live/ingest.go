@@ -0,0 +1,227 @@
+package live
+
+import (
+	"VideoUploadService/flv"
+	"VideoUploadService/rtmp"
+	"context"
+	"encoding/binary"
+	"errors"
+	"log"
+	"time"
+)
+
+// App is the RTMP application broadcasts are published to, as in
+// rtmp://host/live/<stream-key>.
+const App = "live"
+
+var (
+	ErrUnknownKey       = errors.New("unknown stream key")
+	ErrUnknownApp       = errors.New("unknown application, publish to /" + App + "/<stream-key>")
+	ErrUnsupportedCodec = errors.New("only H.264 video and AAC audio are supported")
+)
+
+// LatencyMode trades latency for stability of playback.
+type LatencyMode string
+
+const (
+	LatencyNormal LatencyMode = "normal"
+	LatencyLow    LatencyMode = "low"
+)
+
+// Settings are the per-key options of a broadcast.
+type Settings struct {
+	Latency LatencyMode `json:"latency_mode"`
+	// Record keeps the broadcast as a video once it ends.
+	Record bool `json:"record"`
+}
+
+// Channel is what a stream key publishes to.
+type Channel struct {
+	ID    string
+	Owner string
+	// Tier is the account tier of the owner.
+	Tier string
+	// KeyID identifies the stream key used, so that broadcasts can be
+	// stopped when it is revoked.
+	KeyID    string
+	Settings Settings
+}
+
+// Authenticate resolves a stream key to its channel, returning
+// ErrUnknownKey for keys that are not valid. It is a variable so that main
+// can plug in the key store; by default every key is rejected.
+var Authenticate = func(ctx context.Context, key string) (Channel, error) {
+	return Channel{}, ErrUnknownKey
+}
+
+// Ingest is the RTMP handler starting broadcasts on a hub.
+type Ingest struct {
+	Hub *Hub
+}
+
+func (in Ingest) Publish(ctx context.Context, req rtmp.PublishRequest) (rtmp.Publisher, error) {
+	if req.App != App {
+		return nil, ErrUnknownApp
+	}
+	ch, err := Authenticate(ctx, req.Name)
+	if err != nil && !errors.Is(err, ErrUnknownKey) {
+		// Do not tell clients more than that the key did not work.
+		log.Printf("Checking stream key from %s: %v", req.RemoteAddr, err)
+		return nil, errors.New("stream key could not be checked")
+	}
+	if err != nil {
+		return nil, err
+	}
+	s, err := in.Hub.Start(ch)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Live %s: channel %s went live from %s", s.ID, ch.ID, req.RemoteAddr)
+	return &publisher{stream: s}, nil
+}
+
+// publisher turns the FLV tags of a publish session into packets.
+type publisher struct {
+	stream *Stream
+	codecs *Codecs
+	// base is the timestamp of the first media tag, which becomes zero.
+	base    uint32
+	started bool
+}
+
+func (p *publisher) WriteTag(t flv.Tag) error {
+	if err := p.stream.stopped(); err != nil {
+		return err
+	}
+	p.stream.health.tag(len(t.Data))
+	switch t.Type {
+	case flv.TagVideo:
+		return p.video(t)
+	case flv.TagAudio:
+		return p.audio(t)
+	}
+	// Script data such as onMetaData is not needed to package the stream.
+	return nil
+}
+
+func (p *publisher) Close(err error) {
+	if err != nil {
+		log.Printf("Live %s: channel %s disconnected: %v", p.stream.ID, p.stream.Channel, err)
+	} else {
+		log.Printf("Live %s: channel %s ended", p.stream.ID, p.stream.Channel)
+	}
+	p.stream.end(err)
+}
+
+func (p *publisher) video(t flv.Tag) error {
+	v, err := flv.ParseVideo(t.Data)
+	if err != nil {
+		return err
+	}
+	if v.CodecID != flv.CodecAVC {
+		return ErrUnsupportedCodec
+	}
+	switch v.PacketType {
+	case flv.AVCSequenceHeader:
+		cfg, err := flv.ParseAVCConfig(v.Data)
+		if err != nil {
+			return err
+		}
+		next := p.nextCodecs()
+		next.Video, next.AVCRecord = &cfg, v.Data
+		p.setCodecs(next)
+	case flv.AVCNALU:
+		if p.codecs == nil || p.codecs.Video == nil {
+			// Nothing can be decoded before the sequence header.
+			p.stream.health.droppedFrame()
+			return nil
+		}
+		data := v.Data
+		if size := p.codecs.Video.NALULengthSize; size != 4 {
+			if data, err = lengthPrefix4(data, size); err != nil {
+				return err
+			}
+		}
+		dts := p.time(t.Timestamp)
+		p.stream.health.videoFrame(dts, v.Keyframe(), len(data))
+		p.stream.publish(Packet{
+			Kind:     Video,
+			DTS:      dts,
+			PTS:      dts + time.Duration(v.CompositionTime)*time.Millisecond,
+			Keyframe: v.Keyframe(),
+			Data:     data,
+			Codecs:   p.codecs,
+		})
+	}
+	return nil
+}
+
+func (p *publisher) audio(t flv.Tag) error {
+	a, err := flv.ParseAudio(t.Data)
+	if err != nil {
+		return err
+	}
+	if a.Format != flv.SoundAAC {
+		return ErrUnsupportedCodec
+	}
+	switch a.PacketType {
+	case flv.AACSequenceHeader:
+		cfg, err := flv.ParseAACConfig(a.Data)
+		if err != nil {
+			return err
+		}
+		next := p.nextCodecs()
+		next.Audio, next.AACRecord = &cfg, a.Data
+		p.setCodecs(next)
+	case flv.AACRaw:
+		if p.codecs == nil || p.codecs.Audio == nil {
+			return nil
+		}
+		dts := p.time(t.Timestamp)
+		p.stream.health.audioFrame(dts, len(a.Data))
+		p.stream.publish(Packet{Kind: Audio, DTS: dts, PTS: dts, Keyframe: true, Data: a.Data, Codecs: p.codecs})
+	}
+	return nil
+}
+
+// nextCodecs returns a copy of the current codecs to modify.
+func (p *publisher) nextCodecs() *Codecs {
+	next := &Codecs{}
+	if p.codecs != nil {
+		*next = *p.codecs
+	}
+	return next
+}
+
+func (p *publisher) setCodecs(c *Codecs) {
+	p.codecs = c
+	p.stream.setCodecs(c)
+}
+
+// time converts a tag timestamp to the time since the first media tag.
+// Tags slightly older than the first one are clamped to zero.
+func (p *publisher) time(ts uint32) time.Duration {
+	if !p.started {
+		p.base, p.started = ts, true
+	}
+	d := int64(ts) - int64(p.base)
+	if d < 0 {
+		d = 0
+	}
+	return time.Duration(d) * time.Millisecond
+}
+
+// lengthPrefix4 rewrites NAL units prefixed with size-byte lengths to
+// 4-byte lengths.
+func lengthPrefix4(data []byte, size int) ([]byte, error) {
+	nalus, err := flv.SplitNALUs(data, size)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, 0, len(data)+len(nalus)*(4-size))
+	for _, n := range nalus {
+		out = binary.BigEndian.AppendUint32(out, uint32(len(n)))
+		out = append(out, n...)
+	}
+	return out, nil
+}
@@ -0,0 +1,175 @@
+package live
+
+import (
+	"VideoUploadService/flv"
+	"VideoUploadService/rtmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"os"
+	"testing"
+	"time"
+)
+
+// startIngest serves RTMP publishes to hub on a loopback listener and
+// returns the publish URL prefix, e.g. "rtmp://127.0.0.1:1234/live/".
+func startIngest(t *testing.T, hub *Hub) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &rtmp.Server{Handler: Ingest{Hub: hub}, Timeout: 5 * time.Second}
+	go srv.Serve(l)
+	t.Cleanup(func() { srv.Close() })
+
+	orig := Authenticate
+	Authenticate = func(ctx context.Context, key string) (Channel, error) {
+		if key != "secret" {
+			return Channel{}, ErrUnknownKey
+		}
+		return Channel{ID: "ch", Owner: "alice", KeyID: "k1"}, nil
+	}
+	t.Cleanup(func() { Authenticate = orig })
+	return "rtmp://" + l.Addr().String() + "/" + App + "/"
+}
+
+// publishFile publishes the tags of an FLV file and unpublishes.
+func publishFile(t *testing.T, url, file string) {
+	t.Helper()
+	f, err := os.Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	r, err := flv.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	cl, err := rtmp.Publish(ctx, url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for {
+		tag, err := r.ReadTag()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := cl.WriteTag(tag); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cl.Close()
+}
+
+// testdata/publish.flv holds H.264 at 10 fps with a keyframe every 5 frames
+// and 200ms composition offsets on the others, and AAC-LC at 44.1kHz stereo,
+// both starting at 1000ms. A frame precedes the first sequence header, and a
+// new H.264 sequence header (level 4.0 instead of 3.1) precedes frame 5.
+func TestIngestReplaysFLV(t *testing.T) {
+	hub := NewHub()
+	subs := make(chan *Subscription, 1)
+	hub.OnStart(func(s *Stream) { subs <- s.Subscribe(1024) })
+	url := startIngest(t, hub)
+
+	publishFile(t, url+"secret", "testdata/publish.flv")
+
+	var sub *Subscription
+	select {
+	case sub = <-subs:
+	case <-time.After(5 * time.Second):
+		t.Fatal("broadcast did not start")
+	}
+	var video, audio []Packet
+	timeout := time.After(5 * time.Second)
+	for done := false; !done; {
+		select {
+		case p, ok := <-sub.Packets():
+			if !ok {
+				done = true
+			} else if p.Kind == Video {
+				video = append(video, p)
+			} else {
+				audio = append(audio, p)
+			}
+		case <-timeout:
+			t.Fatal("broadcast did not end")
+		}
+	}
+	if err := sub.Err(); err != nil {
+		t.Errorf("broadcast ended with %v", err)
+	}
+	if _, ok := hub.Get("ch"); ok {
+		t.Error("channel still live after unpublishing")
+	}
+
+	if len(video) != 10 {
+		t.Fatalf("got %d video packets, want 10", len(video))
+	}
+	for i, p := range video {
+		dts := time.Duration(i) * 100 * time.Millisecond
+		pts := dts + 200*time.Millisecond
+		if i%5 == 0 {
+			pts = dts
+		}
+		if p.DTS != dts || p.PTS != pts || p.Keyframe != (i%5 == 0) {
+			t.Errorf("video %d: dts %v pts %v keyframe %v, want %v %v %v", i, p.DTS, p.PTS, p.Keyframe, dts, pts, i%5 == 0)
+		}
+		if n := len(p.Data); n < 5 || int(p.Data[3]) != n-4 {
+			t.Errorf("video %d: data %x is not one length-prefixed NAL unit", i, p.Data)
+		}
+		level := uint8(0x1f)
+		if i >= 5 {
+			level = 0x28
+		}
+		c := p.Codecs
+		if c == nil || c.Video == nil || c.Video.Level != level || c.Video.Codec() != fmt.Sprintf("avc1.6400%02x", level) {
+			t.Fatalf("video %d: codecs %+v, want level %#x", i, c, level)
+		}
+		if len(c.AVCRecord) == 0 || c.AVCRecord[3] != level || len(c.Video.SPS) != 1 || len(c.Video.PPS) != 1 {
+			t.Errorf("video %d: AVC record %x", i, c.AVCRecord)
+		}
+		if c.Audio == nil || c.Audio.SampleRate != 44100 || c.Audio.Channels != 2 {
+			t.Errorf("video %d: audio config %+v", i, c.Audio)
+		}
+	}
+	if video[4].Codecs == video[5].Codecs {
+		t.Error("new sequence header did not create new codecs")
+	}
+
+	if len(audio) != 44 {
+		t.Fatalf("got %d audio packets, want 44", len(audio))
+	}
+	for j, p := range audio {
+		dts := time.Duration(math.Round(float64(j)*1024000/44100)) * time.Millisecond
+		if p.DTS != dts || p.PTS != dts || !p.Keyframe || len(p.Data) != 4 || p.Data[3] != byte(j) {
+			t.Errorf("audio %d: dts %v pts %v keyframe %v data %x, want dts %v", j, p.DTS, p.PTS, p.Keyframe, p.Data, dts)
+		}
+		if p.Codecs == nil || p.Codecs.Audio == nil || p.Codecs.Audio.Codec() != "mp4a.40.2" || string(p.Codecs.AACRecord) != "\x12\x10" {
+			t.Errorf("audio %d: codecs %+v", j, p.Codecs)
+		}
+	}
+}
+
+func TestIngestRejectsUnknownKey(t *testing.T) {
+	hub := NewHub()
+	url := startIngest(t, hub)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	cl, err := rtmp.Publish(ctx, url+"guess")
+	if err == nil {
+		cl.Close()
+		t.Fatal("publish with an unknown key succeeded")
+	}
+	if len(hub.List()) != 0 {
+		t.Error("rejected publish started a broadcast")
+	}
+}
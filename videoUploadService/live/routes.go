@@ -0,0 +1,88 @@
+package live
+
+import (
+	"VideoUploadService/identity"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/websocket/v2"
+)
+
+func SetupRoutes(app *fiber.App) {
+	app.Get("/live/streams", listStreamsHandler)
+	app.Get("/live/channels/:channel/health", requireWatcher, healthHandler)
+	app.Get("/live/channels/:channel/health/ws", requireWatcher, websocket.New(healthWebSocket))
+}
+
+// streamInfo is the public view of a broadcast.
+type streamInfo struct {
+	ID         string    `json:"id"`
+	Channel    string    `json:"channel"`
+	StartedAt  time.Time `json:"started_at"`
+	VideoCodec string    `json:"video_codec,omitempty"`
+	AudioCodec string    `json:"audio_codec,omitempty"`
+}
+
+func listStreamsHandler(c *fiber.Ctx) error {
+	streams := []streamInfo{}
+	for _, s := range Default.List() {
+		info := streamInfo{ID: s.ID, Channel: s.Channel, StartedAt: s.StartedAt}
+		if codecs := s.Codecs(); codecs != nil {
+			if codecs.Video != nil {
+				info.VideoCodec = codecs.Video.Codec()
+			}
+			if codecs.Audio != nil {
+				info.AudioCodec = codecs.Audio.Codec()
+			}
+		}
+		streams = append(streams, info)
+	}
+	return c.JSON(fiber.Map{"streams": streams})
+}
+
+// requireWatcher lets only those who may watch a channel's health through.
+func requireWatcher(c *fiber.Ctx) error {
+	user := identity.FromFiber(c)
+	if mayWatch(user, identity.IsAdminFiber(c), c.Params("channel")) {
+		return c.Next()
+	}
+	if user == "" {
+		return c.Status(401).SendString("Authentication required")
+	}
+	return c.Status(403).SendString("not allowed to watch this channel")
+}
+
+// healthHandler returns the last health report of a live channel.
+func healthHandler(c *fiber.Ctx) error {
+	h, ok := Default.Health(c.Params("channel"))
+	if !ok {
+		return c.Status(404).SendString("no health report for this channel yet")
+	}
+	return c.JSON(h)
+}
+
+// healthWebSocket streams the health reports of a channel as JSON text
+// messages until the client goes away.
+func healthWebSocket(c *websocket.Conn) {
+	reports, stop := Default.WatchHealth(c.Params("channel"))
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		// Clients send nothing; reading notices when they disconnect.
+		for {
+			if _, _, err := c.ReadMessage(); err != nil {
+				stop()
+				return
+			}
+		}
+	}()
+	for h := range reports {
+		if err := c.WriteJSON(h); err != nil {
+			break
+		}
+	}
+	stop()
+	c.WriteMessage(websocket.CloseMessage, []byte{})
+	c.Close()
+	<-closed
+}
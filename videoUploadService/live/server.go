@@ -0,0 +1,66 @@
+package live
+
+import (
+	"VideoUploadService/identity"
+	pbl "VideoUploadService/livehealth"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// HealthServer streams the health of the Default hub's channels over gRPC.
+type HealthServer struct {
+	pbl.UnimplementedLiveHealthServer
+}
+
+func (s *HealthServer) WatchHealth(req *pbl.WatchHealthRequest, stream pbl.LiveHealth_WatchHealthServer) error {
+	ctx := stream.Context()
+	if req.Channel == "" {
+		return status.Error(codes.InvalidArgument, "channel is required")
+	}
+	user := identity.FromContext(ctx)
+	if !mayWatch(user, identity.IsAdmin(ctx), req.Channel) {
+		if user == "" {
+			return status.Error(codes.Unauthenticated, "authentication required")
+		}
+		return status.Error(codes.PermissionDenied, "not allowed to watch this channel")
+	}
+	reports, stop := Default.WatchHealth(req.Channel)
+	defer stop()
+	for {
+		select {
+		case h := <-reports:
+			if err := stream.Send(healthToProto(h)); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// mayWatch reports whether a caller may see the health of a channel. A
+// channel belongs to the user of the same ID.
+func mayWatch(user string, admin bool, channel string) bool {
+	return admin || (user != "" && user == channel)
+}
+
+func healthToProto(h Health) *pbl.HealthReport {
+	res := &pbl.HealthReport{
+		Channel:                 h.Channel,
+		StreamId:                h.StreamID,
+		Live:                    h.Live,
+		At:                      h.At.UnixMilli(),
+		Bitrate:                 h.Bitrate,
+		VideoBitrate:            h.VideoBitrate,
+		AudioBitrate:            h.AudioBitrate,
+		FrameRate:               h.FrameRate,
+		KeyframeIntervalSeconds: h.KeyframeInterval,
+		AvDriftMs:               h.AVDrift,
+		DroppedFrames:           h.DroppedFrames,
+	}
+	for _, w := range h.Warnings {
+		res.Warnings = append(res.Warnings, &pbl.HealthWarning{Code: w.Code, Message: w.Message})
+	}
+	return res
+}
@@ -0,0 +1,208 @@
+// Package live tracks broadcasts ingested over RTMP and hands their
+// elementary streams to packagers.
+package live
+
+import (
+	"VideoUploadService/flv"
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+var (
+	ErrAlreadyLive    = errors.New("channel is already live")
+	ErrSlowSubscriber = errors.New("subscriber fell behind the stream")
+	ErrStopped        = errors.New("broadcast was stopped")
+)
+
+type Kind uint8
+
+const (
+	Video Kind = iota + 1
+	Audio
+)
+
+func (k Kind) String() string {
+	if k == Video {
+		return "video"
+	}
+	return "audio"
+}
+
+// Codecs is the decoder configuration of a stream. A new Codecs value is
+// created whenever the publisher sends new sequence headers, so packets can
+// be compared by pointer to notice changes.
+type Codecs struct {
+	// Video and AVCRecord are the parsed and raw H.264 sequence header.
+	Video     *flv.AVCConfig
+	AVCRecord []byte
+	// Audio and AACRecord are the parsed and raw AudioSpecificConfig.
+	Audio     *flv.AACConfig
+	AACRecord []byte
+}
+
+// Packet is one H.264 access unit or AAC frame.
+type Packet struct {
+	Kind Kind
+	// DTS and PTS are the decode and presentation times since the start
+	// of the broadcast.
+	DTS      time.Duration
+	PTS      time.Duration
+	Keyframe bool
+	// Data holds the NAL units of a video access unit, each prefixed with
+	// a 4-byte length, or one raw AAC frame.
+	Data   []byte
+	Codecs *Codecs
+}
+
+// Stream is one live broadcast of a channel.
+type Stream struct {
+	// ID identifies the broadcast; a channel gets a new one every time it
+	// goes live.
+	ID        string
+	Channel   string
+	Owner     string
+	Tier      string
+	KeyID     string
+	Settings  Settings
+	StartedAt time.Time
+
+	hub    *Hub
+	health *meter
+
+	mu      sync.Mutex
+	codecs  *Codecs
+	subs    map[*Subscription]struct{}
+	stopErr error
+	ended   bool
+	err     error
+	done    chan struct{}
+}
+
+// Codecs returns the current decoder configuration, or nil before the
+// publisher sent any.
+func (s *Stream) Codecs() *Codecs {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.codecs
+}
+
+// Done is closed when the broadcast ends.
+func (s *Stream) Done() <-chan struct{} {
+	return s.done
+}
+
+// Err returns why the broadcast ended; nil if the publisher stopped
+// cleanly or it is still live.
+func (s *Stream) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Stop makes the ingest disconnect the publisher with err, which ends the
+// broadcast with that error.
+func (s *Stream) Stop(err error) {
+	if err == nil {
+		err = ErrStopped
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopErr == nil {
+		s.stopErr = err
+	}
+}
+
+func (s *Stream) stopped() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stopErr
+}
+
+// Subscription delivers the packets of a stream to one consumer.
+type Subscription struct {
+	stream *Stream
+	ch     chan Packet
+	err    error
+}
+
+// Subscribe starts delivering packets. buffer is how many packets may be
+// queued; a subscriber that falls further behind is dropped.
+func (s *Stream) Subscribe(buffer int) *Subscription {
+	sub := &Subscription{stream: s, ch: make(chan Packet, buffer)}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ended {
+		sub.err = s.err
+		close(sub.ch)
+		return sub
+	}
+	s.subs[sub] = struct{}{}
+	return sub
+}
+
+// Packets returns the channel of packets. It is closed when the broadcast
+// ends, the subscriber falls behind or Close is called.
+func (sub *Subscription) Packets() <-chan Packet {
+	return sub.ch
+}
+
+// Err returns why the packet channel was closed.
+func (sub *Subscription) Err() error {
+	sub.stream.mu.Lock()
+	defer sub.stream.mu.Unlock()
+	return sub.err
+}
+
+// Close stops the delivery of packets.
+func (sub *Subscription) Close() {
+	s := sub.stream
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.subs[sub]; ok {
+		delete(s.subs, sub)
+		close(sub.ch)
+	}
+}
+
+func (s *Stream) setCodecs(c *Codecs) {
+	s.mu.Lock()
+	s.codecs = c
+	s.mu.Unlock()
+}
+
+// publish fans a packet out to the subscribers without blocking the
+// ingest connection.
+func (s *Stream) publish(p Packet) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for sub := range s.subs {
+		select {
+		case sub.ch <- p:
+		default:
+			log.Printf("Live %s: dropping a subscriber that fell behind", s.ID)
+			sub.err = ErrSlowSubscriber
+			delete(s.subs, sub)
+			close(sub.ch)
+		}
+	}
+}
+
+// end finishes the broadcast and closes all subscriptions.
+func (s *Stream) end(err error) {
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended, s.err = true, err
+	for sub := range s.subs {
+		sub.err = err
+		close(sub.ch)
+	}
+	s.subs = nil
+	close(s.done)
+	s.mu.Unlock()
+	s.hub.remove(s)
+}
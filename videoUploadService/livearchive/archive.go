@@ -0,0 +1,258 @@
+// Package livearchive turns recorded live broadcasts into catalog videos.
+// The recorded MPEG-TS segments are concatenated into a single source file
+// that is handed to transcoding like a finished upload.
+package livearchive
+
+import (
+	"VideoUploadService/catalog"
+	"VideoUploadService/livehls"
+	"VideoUploadService/storage"
+	"VideoUploadService/uploadstatus"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidTrim = errors.New("trim must not be negative")
+	ErrEmpty       = errors.New("nothing is left to archive after trimming")
+	ErrStillLive   = errors.New("broadcast has not ended yet")
+	ErrArchiving   = errors.New("broadcast is still being archived")
+)
+
+// Trim removes time from the start and end of a broadcast, in seconds.
+// Cuts are made at segment boundaries, rounding outwards so that nothing
+// inside the kept range is lost.
+type Trim struct {
+	Start float64 `json:"trim_start_seconds"`
+	End   float64 `json:"trim_end_seconds"`
+}
+
+// HandOff passes an archived video's source file on to transcoding. It is
+// set in main.
+var HandOff func(videoID string)
+
+// Archiver archives recordings into the raw upload location of a storage.
+// Each broadcast has at most one archive video; archiving it again replaces
+// the source of that video.
+type Archiver struct {
+	store   storage.Storage
+	catalog *catalog.Catalog
+
+	mu sync.Mutex
+	// assembling holds the videos whose source is being written.
+	assembling map[string]bool
+}
+
+// Default is set up in main.
+var Default *Archiver
+
+func New(store storage.Storage, cat *catalog.Catalog) *Archiver {
+	return &Archiver{store: store, catalog: cat, assembling: make(map[string]bool)}
+}
+
+// Recorded archives a broadcast in full once it has ended. It is meant to
+// be registered with livehls.Packager.OnRecorded.
+func (a *Archiver) Recorded(rec livehls.Recording) {
+	v, err := a.Archive(context.Background(), rec, Trim{})
+	if err != nil {
+		log.Printf("Archiving broadcast %s: %v", rec.BroadcastID, err)
+		return
+	}
+	log.Printf("Archiving broadcast %s as video %s", rec.BroadcastID, v.ID)
+}
+
+// Archive makes the kept part of a recording the source of the broadcast's
+// archive video, creating the video, owned by the broadcaster, the first
+// time. An archive that is still being assembled or transcoded is not
+// replaced. The source file is assembled and handed off in the background;
+// failures show in the video's state.
+func (a *Archiver) Archive(ctx context.Context, rec livehls.Recording, trim Trim) (catalog.Video, error) {
+	if !rec.Ended {
+		return catalog.Video{}, ErrStillLive
+	}
+	segments, err := trimmed(rec, trim)
+	if err != nil {
+		return catalog.Video{}, err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	v, err := a.existing(ctx, rec)
+	if err != nil {
+		return catalog.Video{}, err
+	}
+	if v.ID == "" {
+		if v, err = a.create(ctx, rec); err != nil {
+			return catalog.Video{}, err
+		}
+	}
+
+	var size int64
+	for _, seg := range segments {
+		if obj, err := a.store.Open(ctx, seg.Key); err == nil {
+			size += obj.Size()
+			obj.Close()
+		}
+	}
+	uploadstatus.Default.Start(v.ID, rec.Owner, uploadstatus.SourceLive, size)
+	// The hand-off queues the archive at the tier recorded for uploads.
+	uploadstatus.Default.SetTier(v.ID, rec.Tier)
+	a.assembling[v.ID] = true
+	go a.assemble(v.ID, segments, v.State == catalog.StateUploading)
+	return v, nil
+}
+
+// existing returns the archive video of a broadcast, or a zero video if it
+// has none or it was deleted. It fails with ErrArchiving while the video's
+// source is being assembled or transcoded.
+func (a *Archiver) existing(ctx context.Context, rec livehls.Recording) (catalog.Video, error) {
+	data, err := a.read(ctx, archiveIndex(rec))
+	if errors.Is(err, storage.ErrNotFound) {
+		return catalog.Video{}, nil
+	}
+	if err != nil {
+		return catalog.Video{}, fmt.Errorf("load archive of %s: %w", rec.BroadcastID, err)
+	}
+	var idx archive
+	if err := json.Unmarshal(data, &idx); err != nil {
+		return catalog.Video{}, fmt.Errorf("load archive of %s: %w", rec.BroadcastID, err)
+	}
+	v, err := a.catalog.Get(ctx, idx.VideoID)
+	if errors.Is(err, catalog.ErrNotFound) {
+		return catalog.Video{}, nil
+	}
+	if err != nil {
+		return catalog.Video{}, err
+	}
+	switch {
+	case v.State == catalog.StateDeleted:
+		return catalog.Video{}, nil
+	case a.assembling[v.ID], v.State != catalog.StateReady && v.State != catalog.StateFailed:
+		return catalog.Video{}, ErrArchiving
+	}
+	return v, nil
+}
+
+// create makes the archive video of a broadcast and records it next to the
+// recording.
+func (a *Archiver) create(ctx context.Context, rec livehls.Recording) (catalog.Video, error) {
+	id := uuid.NewString()
+	v, err := a.catalog.Create(ctx, id, rec.Owner)
+	if err != nil {
+		return catalog.Video{}, fmt.Errorf("create video: %w", err)
+	}
+	v.Metadata.Title = "Live broadcast of " + rec.StartedAt.Format("2006-01-02 15:04 MST")
+	v.Metadata.Description = fmt.Sprintf("Recorded from broadcast %s.", rec.BroadcastID)
+	if err := a.catalog.Store().SetMetadata(ctx, id, v.Metadata, time.Now().UTC()); err != nil {
+		log.Printf("Titling archive %s: %v", id, err)
+	}
+	data, _ := json.Marshal(archive{VideoID: id})
+	if err := a.write(ctx, archiveIndex(rec), data); err != nil {
+		// Archiving again would create another video; say so.
+		log.Printf("Recording archive %s of broadcast %s: %v", id, rec.BroadcastID, err)
+	}
+	return v, nil
+}
+
+// archive is stored next to a recording and names its archive video.
+type archive struct {
+	VideoID string `json:"video_id"`
+}
+
+func archiveIndex(rec livehls.Recording) string {
+	return livehls.StoragePrefix(rec.Channel) + rec.BroadcastID + "/archive.json"
+}
+
+func (a *Archiver) read(ctx context.Context, key string) ([]byte, error) {
+	obj, err := a.store.Open(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer obj.Close()
+	return io.ReadAll(obj)
+}
+
+func (a *Archiver) write(ctx context.Context, key string, data []byte) error {
+	w, err := a.store.Create(ctx, key)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// assemble concatenates segments into the source file of a video and hands
+// it off. fresh is set for new videos, which still have to be marked
+// uploaded; archives replacing an earlier source go straight to the queue.
+func (a *Archiver) assemble(id string, segments []livehls.RecordedSegment, fresh bool) {
+	err := a.concat(context.Background(), id, segments)
+	a.mu.Lock()
+	delete(a.assembling, id)
+	a.mu.Unlock()
+	if err != nil {
+		err = fmt.Errorf("assemble recording: %w", err)
+		uploadstatus.Default.Fail(id, err)
+		a.catalog.Advance(id, catalog.StateFailed, err.Error())
+		return
+	}
+	uploadstatus.Default.SetStage(id, uploadstatus.StageStored)
+	if fresh {
+		a.catalog.Advance(id, catalog.StateUploaded, "")
+	}
+	if HandOff != nil {
+		HandOff(id)
+	}
+}
+
+// concat writes segments back to back. MPEG-TS segments of one broadcast
+// share their timeline, so the result is a single continuous stream.
+func (a *Archiver) concat(ctx context.Context, key string, segments []livehls.RecordedSegment) error {
+	w, err := a.store.Create(ctx, key)
+	if err != nil {
+		return err
+	}
+	out := io.MultiWriter(w, uploadstatus.Default.Writer(key))
+	for _, seg := range segments {
+		obj, err := a.store.Open(ctx, seg.Key)
+		if err != nil {
+			w.Close()
+			return fmt.Errorf("segment %s: %w", seg.Key, err)
+		}
+		_, err = io.Copy(out, obj)
+		obj.Close()
+		if err != nil {
+			w.Close()
+			return err
+		}
+	}
+	return w.Close()
+}
+
+// trimmed returns the segments of a recording that overlap the kept range.
+func trimmed(rec livehls.Recording, trim Trim) ([]livehls.RecordedSegment, error) {
+	if trim.Start < 0 || trim.End < 0 {
+		return nil, ErrInvalidTrim
+	}
+	from, to := trim.Start, rec.Duration-trim.End
+	var kept []livehls.RecordedSegment
+	for _, seg := range rec.Segments {
+		if seg.Start+seg.Duration > from && seg.Start < to {
+			kept = append(kept, seg)
+		}
+	}
+	if len(kept) == 0 {
+		return nil, ErrEmpty
+	}
+	return kept, nil
+}
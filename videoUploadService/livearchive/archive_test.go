@@ -0,0 +1,182 @@
+package livearchive
+
+import (
+	"VideoUploadService/catalog"
+	"VideoUploadService/livehls"
+	"VideoUploadService/storage"
+	"VideoUploadService/uploadstatus"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestArchiver(t *testing.T) (*Archiver, chan string) {
+	t.Helper()
+	store, err := catalog.Open("sqlite", "file::memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	a := New(storage.NewLocal(t.TempDir()), catalog.New(store))
+
+	handedOff := make(chan string, 4)
+	old := HandOff
+	HandOff = func(id string) {
+		a.catalog.Advance(id, catalog.StateQueued, "")
+		handedOff <- id
+	}
+	t.Cleanup(func() { HandOff = old })
+	return a, handedOff
+}
+
+// recording stores three 4 second segments of a broadcast of a premium
+// creator, holding "a", "b" and "c".
+func recording(t *testing.T, a *Archiver) livehls.Recording {
+	t.Helper()
+	rec := livehls.Recording{BroadcastID: "b1", Channel: "alice", Owner: "alice", StartedAt: time.Now().UTC(), Ended: true, Duration: 12, Tier: "premium"}
+	for i, data := range []string{"a", "b", "c"} {
+		key := livehls.StoragePrefix(rec.Channel) + rec.BroadcastID + "/" + data + ".ts"
+		if err := a.write(context.Background(), key, []byte(data)); err != nil {
+			t.Fatal(err)
+		}
+		rec.Segments = append(rec.Segments, livehls.RecordedSegment{Key: key, Start: float64(4 * i), Duration: 4})
+	}
+	return rec
+}
+
+func waitHandOff(t *testing.T, handedOff chan string) string {
+	t.Helper()
+	select {
+	case id := <-handedOff:
+		return id
+	case <-time.After(5 * time.Second):
+		t.Fatal("archive was not handed off")
+		return ""
+	}
+}
+
+func TestArchiveAgainReplacesSource(t *testing.T) {
+	a, handedOff := newTestArchiver(t)
+	ctx := context.Background()
+	rec := recording(t, a)
+
+	first, err := a.Archive(ctx, rec, Trim{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id := waitHandOff(t, handedOff); id != first.ID {
+		t.Fatalf("handed off %s, want %s", id, first.ID)
+	}
+	// The hand-off queues the archive at the owner's tier.
+	if st, _ := uploadstatus.Default.Get(first.ID); st.Tier != "premium" {
+		t.Errorf("archive handed off at tier %q, want premium", st.Tier)
+	}
+	if _, err := a.Archive(ctx, rec, Trim{Start: 4}); !errors.Is(err, ErrArchiving) {
+		t.Fatalf("archive while transcoding: err = %v, want ErrArchiving", err)
+	}
+
+	a.catalog.Advance(first.ID, catalog.StateTranscoding, "")
+	a.catalog.Advance(first.ID, catalog.StateReady, "")
+	again, err := a.Archive(ctx, rec, Trim{Start: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.ID != first.ID {
+		t.Fatalf("archived again as %s, want the existing archive %s", again.ID, first.ID)
+	}
+	waitHandOff(t, handedOff)
+
+	v, err := a.catalog.Get(ctx, first.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.State != catalog.StateQueued {
+		t.Errorf("state = %s, want %s", v.State, catalog.StateQueued)
+	}
+	if got := source(t, a, first.ID); got != "bc" {
+		t.Errorf("source = %q, want %q", got, "bc")
+	}
+	videos, err := a.catalog.Store().List(ctx, catalog.ListFilter{Owner: "alice", Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(videos) != 1 {
+		t.Errorf("alice has %d videos, want 1", len(videos))
+	}
+}
+
+func TestArchiveDeletedCreatesNewVideo(t *testing.T) {
+	a, handedOff := newTestArchiver(t)
+	ctx := context.Background()
+	rec := recording(t, a)
+
+	first, err := a.Archive(ctx, rec, Trim{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitHandOff(t, handedOff)
+	a.catalog.Advance(first.ID, catalog.StateDeleted, "")
+
+	again, err := a.Archive(ctx, rec, Trim{End: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.ID == first.ID {
+		t.Fatal("archived again into the deleted video")
+	}
+	waitHandOff(t, handedOff)
+	if got := source(t, a, again.ID); got != "ab" {
+		t.Errorf("source = %q, want %q", got, "ab")
+	}
+}
+
+func TestTrimmed(t *testing.T) {
+	rec := livehls.Recording{Duration: 12}
+	for i, key := range []string{"a", "b", "c"} {
+		rec.Segments = append(rec.Segments, livehls.RecordedSegment{Key: key, Start: float64(4 * i), Duration: 4})
+	}
+	tests := []struct {
+		trim Trim
+		want string
+		err  error
+	}{
+		{Trim{}, "abc", nil},
+		{Trim{Start: 4}, "bc", nil},
+		{Trim{Start: 5}, "bc", nil},
+		{Trim{Start: 3.9, End: 4.1}, "ab", nil},
+		{Trim{Start: 6, End: 6}, "b", nil},
+		{Trim{Start: 12}, "", ErrEmpty},
+		{Trim{Start: -1}, "", ErrInvalidTrim},
+	}
+	for _, tt := range tests {
+		segs, err := trimmed(rec, tt.trim)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("trim %+v: err = %v, want %v", tt.trim, err, tt.err)
+			continue
+		}
+		var got strings.Builder
+		for _, seg := range segs {
+			got.WriteString(seg.Key)
+		}
+		if got.String() != tt.want {
+			t.Errorf("trim %+v kept %q, want %q", tt.trim, got.String(), tt.want)
+		}
+	}
+}
+
+func source(t *testing.T, a *Archiver, id string) string {
+	t.Helper()
+	obj, err := a.store.Open(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer obj.Close()
+	data, err := io.ReadAll(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
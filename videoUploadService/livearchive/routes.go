@@ -0,0 +1,80 @@
+package livearchive
+
+import (
+	"VideoUploadService/identity"
+	"VideoUploadService/livehls"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func SetupRoutes(app *fiber.App) {
+	app.Get("/live/channels/:channel/recordings", listRecordingsHandler)
+	app.Post("/live/channels/:channel/recordings/:broadcast/archive", archiveHandler)
+}
+
+// mayManage reports whether the caller may manage a channel's recordings.
+// A channel belongs to the user of the same ID.
+func mayManage(c *fiber.Ctx, channel string) bool {
+	user := identity.FromFiber(c)
+	return identity.IsAdminFiber(c) || (user != "" && user == channel)
+}
+
+func deny(c *fiber.Ctx) error {
+	if identity.FromFiber(c) == "" {
+		return c.Status(401).SendString("Authentication required")
+	}
+	return c.Status(403).SendString("not allowed to manage this channel")
+}
+
+func listRecordingsHandler(c *fiber.Ctx) error {
+	channel := c.Params("channel")
+	if !mayManage(c, channel) {
+		return deny(c)
+	}
+	recs, err := livehls.ListRecordings(c.Context(), Default.store, channel)
+	if err != nil {
+		return c.Status(500).SendString(err.Error())
+	}
+	for i := range recs {
+		recs[i].Segments = nil
+	}
+	return c.JSON(fiber.Map{"recordings": recs})
+}
+
+// archiveHandler archives a recorded broadcast again, optionally trimmed,
+// replacing the source of its archive video.
+func archiveHandler(c *fiber.Ctx) error {
+	channel := c.Params("channel")
+	if !mayManage(c, channel) {
+		return deny(c)
+	}
+	var trim Trim
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&trim); err != nil {
+			return c.Status(400).SendString("Invalid request body")
+		}
+	}
+	rec, err := livehls.LoadRecording(c.Context(), Default.store, channel, c.Params("broadcast"))
+	if err != nil {
+		return respondErr(c, err)
+	}
+	v, err := Default.Archive(c.Context(), rec, trim)
+	if err != nil {
+		return respondErr(c, err)
+	}
+	return c.Status(202).JSON(v)
+}
+
+func respondErr(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, livehls.ErrNoRecording):
+		return c.Status(404).SendString(err.Error())
+	case errors.Is(err, ErrInvalidTrim), errors.Is(err, ErrEmpty):
+		return c.Status(400).SendString(err.Error())
+	case errors.Is(err, ErrStillLive), errors.Is(err, ErrArchiving):
+		return c.Status(409).SendString(err.Error())
+	default:
+		return c.Status(500).SendString(err.Error())
+	}
+}
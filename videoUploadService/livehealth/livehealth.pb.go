@@ -0,0 +1,405 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.2
+// 	protoc        v3.19.6
+// source: proto/livehealth.proto
+
+package livehealth
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type WatchHealthRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Channel string `protobuf:"bytes,1,opt,name=channel,proto3" json:"channel,omitempty"`
+}
+
+func (x *WatchHealthRequest) Reset() {
+	*x = WatchHealthRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_livehealth_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *WatchHealthRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchHealthRequest) ProtoMessage() {}
+
+func (x *WatchHealthRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_livehealth_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchHealthRequest.ProtoReflect.Descriptor instead.
+func (*WatchHealthRequest) Descriptor() ([]byte, []int) {
+	return file_proto_livehealth_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *WatchHealthRequest) GetChannel() string {
+	if x != nil {
+		return x.Channel
+	}
+	return ""
+}
+
+type HealthReport struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Channel  string `protobuf:"bytes,1,opt,name=channel,proto3" json:"channel,omitempty"`
+	StreamId string `protobuf:"bytes,2,opt,name=stream_id,json=streamId,proto3" json:"stream_id,omitempty"`
+	Live     bool   `protobuf:"varint,3,opt,name=live,proto3" json:"live,omitempty"`
+	// Unix time in milliseconds.
+	At int64 `protobuf:"varint,4,opt,name=at,proto3" json:"at,omitempty"`
+	// Incoming bits per second, of which video_bitrate and audio_bitrate
+	// carry the media.
+	Bitrate                 int64   `protobuf:"varint,5,opt,name=bitrate,proto3" json:"bitrate,omitempty"`
+	VideoBitrate            int64   `protobuf:"varint,6,opt,name=video_bitrate,json=videoBitrate,proto3" json:"video_bitrate,omitempty"`
+	AudioBitrate            int64   `protobuf:"varint,7,opt,name=audio_bitrate,json=audioBitrate,proto3" json:"audio_bitrate,omitempty"`
+	FrameRate               float64 `protobuf:"fixed64,8,opt,name=frame_rate,json=frameRate,proto3" json:"frame_rate,omitempty"`
+	KeyframeIntervalSeconds float64 `protobuf:"fixed64,9,opt,name=keyframe_interval_seconds,json=keyframeIntervalSeconds,proto3" json:"keyframe_interval_seconds,omitempty"`
+	// How far audio timestamps run ahead of video ones; negative if behind.
+	AvDriftMs float64 `protobuf:"fixed64,10,opt,name=av_drift_ms,json=avDriftMs,proto3" json:"av_drift_ms,omitempty"`
+	// Video frames missing from the broadcast so far.
+	DroppedFrames int64            `protobuf:"varint,11,opt,name=dropped_frames,json=droppedFrames,proto3" json:"dropped_frames,omitempty"`
+	Warnings      []*HealthWarning `protobuf:"bytes,12,rep,name=warnings,proto3" json:"warnings,omitempty"`
+}
+
+func (x *HealthReport) Reset() {
+	*x = HealthReport{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_livehealth_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *HealthReport) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HealthReport) ProtoMessage() {}
+
+func (x *HealthReport) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_livehealth_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HealthReport.ProtoReflect.Descriptor instead.
+func (*HealthReport) Descriptor() ([]byte, []int) {
+	return file_proto_livehealth_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *HealthReport) GetChannel() string {
+	if x != nil {
+		return x.Channel
+	}
+	return ""
+}
+
+func (x *HealthReport) GetStreamId() string {
+	if x != nil {
+		return x.StreamId
+	}
+	return ""
+}
+
+func (x *HealthReport) GetLive() bool {
+	if x != nil {
+		return x.Live
+	}
+	return false
+}
+
+func (x *HealthReport) GetAt() int64 {
+	if x != nil {
+		return x.At
+	}
+	return 0
+}
+
+func (x *HealthReport) GetBitrate() int64 {
+	if x != nil {
+		return x.Bitrate
+	}
+	return 0
+}
+
+func (x *HealthReport) GetVideoBitrate() int64 {
+	if x != nil {
+		return x.VideoBitrate
+	}
+	return 0
+}
+
+func (x *HealthReport) GetAudioBitrate() int64 {
+	if x != nil {
+		return x.AudioBitrate
+	}
+	return 0
+}
+
+func (x *HealthReport) GetFrameRate() float64 {
+	if x != nil {
+		return x.FrameRate
+	}
+	return 0
+}
+
+func (x *HealthReport) GetKeyframeIntervalSeconds() float64 {
+	if x != nil {
+		return x.KeyframeIntervalSeconds
+	}
+	return 0
+}
+
+func (x *HealthReport) GetAvDriftMs() float64 {
+	if x != nil {
+		return x.AvDriftMs
+	}
+	return 0
+}
+
+func (x *HealthReport) GetDroppedFrames() int64 {
+	if x != nil {
+		return x.DroppedFrames
+	}
+	return 0
+}
+
+func (x *HealthReport) GetWarnings() []*HealthWarning {
+	if x != nil {
+		return x.Warnings
+	}
+	return nil
+}
+
+type HealthWarning struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// keyframe_interval, av_drift, dropped_frames or stalled.
+	Code    string `protobuf:"bytes,1,opt,name=code,proto3" json:"code,omitempty"`
+	Message string `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+}
+
+func (x *HealthWarning) Reset() {
+	*x = HealthWarning{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_livehealth_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *HealthWarning) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HealthWarning) ProtoMessage() {}
+
+func (x *HealthWarning) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_livehealth_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HealthWarning.ProtoReflect.Descriptor instead.
+func (*HealthWarning) Descriptor() ([]byte, []int) {
+	return file_proto_livehealth_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *HealthWarning) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *HealthWarning) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+var File_proto_livehealth_proto protoreflect.FileDescriptor
+
+var file_proto_livehealth_proto_rawDesc = []byte{
+	0x0a, 0x16, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x6c, 0x69, 0x76, 0x65, 0x68, 0x65, 0x61, 0x6c,
+	0x74, 0x68, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x0a, 0x6c, 0x69, 0x76, 0x65, 0x68, 0x65,
+	0x61, 0x6c, 0x74, 0x68, 0x22, 0x2e, 0x0a, 0x12, 0x57, 0x61, 0x74, 0x63, 0x68, 0x48, 0x65, 0x61,
+	0x6c, 0x74, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x63, 0x68,
+	0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x63, 0x68, 0x61,
+	0x6e, 0x6e, 0x65, 0x6c, 0x22, 0xa6, 0x03, 0x0a, 0x0c, 0x48, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x52,
+	0x65, 0x70, 0x6f, 0x72, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x63, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x63, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x12,
+	0x1b, 0x0a, 0x09, 0x73, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x08, 0x73, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x49, 0x64, 0x12, 0x12, 0x0a, 0x04,
+	0x6c, 0x69, 0x76, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x04, 0x6c, 0x69, 0x76, 0x65,
+	0x12, 0x0e, 0x0a, 0x02, 0x61, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x02, 0x61, 0x74,
+	0x12, 0x18, 0x0a, 0x07, 0x62, 0x69, 0x74, 0x72, 0x61, 0x74, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x07, 0x62, 0x69, 0x74, 0x72, 0x61, 0x74, 0x65, 0x12, 0x23, 0x0a, 0x0d, 0x76, 0x69,
+	0x64, 0x65, 0x6f, 0x5f, 0x62, 0x69, 0x74, 0x72, 0x61, 0x74, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x0c, 0x76, 0x69, 0x64, 0x65, 0x6f, 0x42, 0x69, 0x74, 0x72, 0x61, 0x74, 0x65, 0x12,
+	0x23, 0x0a, 0x0d, 0x61, 0x75, 0x64, 0x69, 0x6f, 0x5f, 0x62, 0x69, 0x74, 0x72, 0x61, 0x74, 0x65,
+	0x18, 0x07, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0c, 0x61, 0x75, 0x64, 0x69, 0x6f, 0x42, 0x69, 0x74,
+	0x72, 0x61, 0x74, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x66, 0x72, 0x61, 0x6d, 0x65, 0x5f, 0x72, 0x61,
+	0x74, 0x65, 0x18, 0x08, 0x20, 0x01, 0x28, 0x01, 0x52, 0x09, 0x66, 0x72, 0x61, 0x6d, 0x65, 0x52,
+	0x61, 0x74, 0x65, 0x12, 0x3a, 0x0a, 0x19, 0x6b, 0x65, 0x79, 0x66, 0x72, 0x61, 0x6d, 0x65, 0x5f,
+	0x69, 0x6e, 0x74, 0x65, 0x72, 0x76, 0x61, 0x6c, 0x5f, 0x73, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73,
+	0x18, 0x09, 0x20, 0x01, 0x28, 0x01, 0x52, 0x17, 0x6b, 0x65, 0x79, 0x66, 0x72, 0x61, 0x6d, 0x65,
+	0x49, 0x6e, 0x74, 0x65, 0x72, 0x76, 0x61, 0x6c, 0x53, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x12,
+	0x1e, 0x0a, 0x0b, 0x61, 0x76, 0x5f, 0x64, 0x72, 0x69, 0x66, 0x74, 0x5f, 0x6d, 0x73, 0x18, 0x0a,
+	0x20, 0x01, 0x28, 0x01, 0x52, 0x09, 0x61, 0x76, 0x44, 0x72, 0x69, 0x66, 0x74, 0x4d, 0x73, 0x12,
+	0x25, 0x0a, 0x0e, 0x64, 0x72, 0x6f, 0x70, 0x70, 0x65, 0x64, 0x5f, 0x66, 0x72, 0x61, 0x6d, 0x65,
+	0x73, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0d, 0x64, 0x72, 0x6f, 0x70, 0x70, 0x65, 0x64,
+	0x46, 0x72, 0x61, 0x6d, 0x65, 0x73, 0x12, 0x35, 0x0a, 0x08, 0x77, 0x61, 0x72, 0x6e, 0x69, 0x6e,
+	0x67, 0x73, 0x18, 0x0c, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x6c, 0x69, 0x76, 0x65, 0x68,
+	0x65, 0x61, 0x6c, 0x74, 0x68, 0x2e, 0x48, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x57, 0x61, 0x72, 0x6e,
+	0x69, 0x6e, 0x67, 0x52, 0x08, 0x77, 0x61, 0x72, 0x6e, 0x69, 0x6e, 0x67, 0x73, 0x22, 0x3d, 0x0a,
+	0x0d, 0x48, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x57, 0x61, 0x72, 0x6e, 0x69, 0x6e, 0x67, 0x12, 0x12,
+	0x0a, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x63, 0x6f,
+	0x64, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x32, 0x57, 0x0a, 0x0a,
+	0x4c, 0x69, 0x76, 0x65, 0x48, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x12, 0x49, 0x0a, 0x0b, 0x57, 0x61,
+	0x74, 0x63, 0x68, 0x48, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x12, 0x1e, 0x2e, 0x6c, 0x69, 0x76, 0x65,
+	0x68, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x2e, 0x57, 0x61, 0x74, 0x63, 0x68, 0x48, 0x65, 0x61, 0x6c,
+	0x74, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x18, 0x2e, 0x6c, 0x69, 0x76, 0x65,
+	0x68, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x2e, 0x48, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x52, 0x65, 0x70,
+	0x6f, 0x72, 0x74, 0x30, 0x01, 0x42, 0x21, 0x5a, 0x1f, 0x2e, 0x2f, 0x76, 0x69, 0x64, 0x65, 0x6f,
+	0x55, 0x70, 0x6c, 0x6f, 0x61, 0x64, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2f, 0x6c, 0x69,
+	0x76, 0x65, 0x68, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_proto_livehealth_proto_rawDescOnce sync.Once
+	file_proto_livehealth_proto_rawDescData = file_proto_livehealth_proto_rawDesc
+)
+
+func file_proto_livehealth_proto_rawDescGZIP() []byte {
+	file_proto_livehealth_proto_rawDescOnce.Do(func() {
+		file_proto_livehealth_proto_rawDescData = protoimpl.X.CompressGZIP(file_proto_livehealth_proto_rawDescData)
+	})
+	return file_proto_livehealth_proto_rawDescData
+}
+
+var file_proto_livehealth_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_proto_livehealth_proto_goTypes = []any{
+	(*WatchHealthRequest)(nil), // 0: livehealth.WatchHealthRequest
+	(*HealthReport)(nil),       // 1: livehealth.HealthReport
+	(*HealthWarning)(nil),      // 2: livehealth.HealthWarning
+}
+var file_proto_livehealth_proto_depIdxs = []int32{
+	2, // 0: livehealth.HealthReport.warnings:type_name -> livehealth.HealthWarning
+	0, // 1: livehealth.LiveHealth.WatchHealth:input_type -> livehealth.WatchHealthRequest
+	1, // 2: livehealth.LiveHealth.WatchHealth:output_type -> livehealth.HealthReport
+	2, // [2:3] is the sub-list for method output_type
+	1, // [1:2] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_proto_livehealth_proto_init() }
+func file_proto_livehealth_proto_init() {
+	if File_proto_livehealth_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_proto_livehealth_proto_msgTypes[0].Exporter = func(v any, i int) any {
+			switch v := v.(*WatchHealthRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_livehealth_proto_msgTypes[1].Exporter = func(v any, i int) any {
+			switch v := v.(*HealthReport); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_livehealth_proto_msgTypes[2].Exporter = func(v any, i int) any {
+			switch v := v.(*HealthWarning); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_proto_livehealth_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   3,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_proto_livehealth_proto_goTypes,
+		DependencyIndexes: file_proto_livehealth_proto_depIdxs,
+		MessageInfos:      file_proto_livehealth_proto_msgTypes,
+	}.Build()
+	File_proto_livehealth_proto = out.File
+	file_proto_livehealth_proto_rawDesc = nil
+	file_proto_livehealth_proto_goTypes = nil
+	file_proto_livehealth_proto_depIdxs = nil
+}
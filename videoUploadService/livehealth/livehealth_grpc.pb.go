@@ -0,0 +1,151 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.4.0
+// - protoc             v3.19.6
+// source: proto/livehealth.proto
+
+package livehealth
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.62.0 or later.
+const _ = grpc.SupportPackageIsVersion8
+
+const (
+	LiveHealth_WatchHealth_FullMethodName = "/livehealth.LiveHealth/WatchHealth"
+)
+
+// LiveHealthClient is the client API for LiveHealth service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// LiveHealth reports how the streams sent to the RTMP ingest are doing.
+// Callers are identified by the x-user-id metadata key; a channel may be
+// watched by the user of the same ID and by admins.
+type LiveHealthClient interface {
+	// WatchHealth sends a report about every second while the channel is
+	// live, and one with live unset whenever it is offline, starting with its
+	// current state. It runs until the caller cancels.
+	WatchHealth(ctx context.Context, in *WatchHealthRequest, opts ...grpc.CallOption) (LiveHealth_WatchHealthClient, error)
+}
+
+type liveHealthClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewLiveHealthClient(cc grpc.ClientConnInterface) LiveHealthClient {
+	return &liveHealthClient{cc}
+}
+
+func (c *liveHealthClient) WatchHealth(ctx context.Context, in *WatchHealthRequest, opts ...grpc.CallOption) (LiveHealth_WatchHealthClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &LiveHealth_ServiceDesc.Streams[0], LiveHealth_WatchHealth_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &liveHealthWatchHealthClient{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type LiveHealth_WatchHealthClient interface {
+	Recv() (*HealthReport, error)
+	grpc.ClientStream
+}
+
+type liveHealthWatchHealthClient struct {
+	grpc.ClientStream
+}
+
+func (x *liveHealthWatchHealthClient) Recv() (*HealthReport, error) {
+	m := new(HealthReport)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// LiveHealthServer is the server API for LiveHealth service.
+// All implementations must embed UnimplementedLiveHealthServer
+// for forward compatibility
+//
+// LiveHealth reports how the streams sent to the RTMP ingest are doing.
+// Callers are identified by the x-user-id metadata key; a channel may be
+// watched by the user of the same ID and by admins.
+type LiveHealthServer interface {
+	// WatchHealth sends a report about every second while the channel is
+	// live, and one with live unset whenever it is offline, starting with its
+	// current state. It runs until the caller cancels.
+	WatchHealth(*WatchHealthRequest, LiveHealth_WatchHealthServer) error
+	mustEmbedUnimplementedLiveHealthServer()
+}
+
+// UnimplementedLiveHealthServer must be embedded to have forward compatible implementations.
+type UnimplementedLiveHealthServer struct {
+}
+
+func (UnimplementedLiveHealthServer) WatchHealth(*WatchHealthRequest, LiveHealth_WatchHealthServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchHealth not implemented")
+}
+func (UnimplementedLiveHealthServer) mustEmbedUnimplementedLiveHealthServer() {}
+
+// UnsafeLiveHealthServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to LiveHealthServer will
+// result in compilation errors.
+type UnsafeLiveHealthServer interface {
+	mustEmbedUnimplementedLiveHealthServer()
+}
+
+func RegisterLiveHealthServer(s grpc.ServiceRegistrar, srv LiveHealthServer) {
+	s.RegisterService(&LiveHealth_ServiceDesc, srv)
+}
+
+func _LiveHealth_WatchHealth_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchHealthRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(LiveHealthServer).WatchHealth(m, &liveHealthWatchHealthServer{ServerStream: stream})
+}
+
+type LiveHealth_WatchHealthServer interface {
+	Send(*HealthReport) error
+	grpc.ServerStream
+}
+
+type liveHealthWatchHealthServer struct {
+	grpc.ServerStream
+}
+
+func (x *liveHealthWatchHealthServer) Send(m *HealthReport) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// LiveHealth_ServiceDesc is the grpc.ServiceDesc for LiveHealth service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var LiveHealth_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "livehealth.LiveHealth",
+	HandlerType: (*LiveHealthServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchHealth",
+			Handler:       _LiveHealth_WatchHealth_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "proto/livehealth.proto",
+}
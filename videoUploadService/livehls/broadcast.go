@@ -0,0 +1,502 @@
+package livehls
+
+import (
+	"VideoUploadService/live"
+	"VideoUploadService/storage"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"sync"
+	"time"
+)
+
+// broadcast cuts the packets of one live stream into segments, and those
+// into partial segments for low-latency playback.
+type broadcast struct {
+	stream *live.Stream
+	cfg    Config
+	ts     *tsMuxer
+	// store keeps segments beyond the live edge for DVR and recording; nil
+	// if neither applies.
+	store storage.Storage
+	// dvr is how far back segments stay in the playlist; zero for a
+	// sliding window of cfg.Window segments.
+	dvr time.Duration
+	// rec indexes the segments of recorded broadcasts; nil otherwise.
+	rec *recorder
+
+	// Assembly state, only used by the goroutine feeding packets. pending
+	// holds the packets of the part being assembled.
+	pending   []live.Packet
+	start     time.Duration
+	partStart time.Duration
+	// parts counts the parts of the segment being assembled.
+	parts int
+	// lastDTS and frameDuration track the stream that segments are cut on,
+	// to estimate when the last segment ends.
+	lastDTS       time.Duration
+	frameDuration time.Duration
+	lastCodecs    *live.Codecs
+	// codecs is the configuration of the last segment, segCodecs that of
+	// the one being assembled.
+	codecs        *live.Codecs
+	segCodecs     *live.Codecs
+	init          *mapSegment
+	seq           int
+	fragments     int
+	discontinuity bool
+	// recData is the MPEG-TS copy of an fMP4 segment being recorded.
+	recData []byte
+
+	mu       sync.Mutex
+	segments []*segment
+	// building is the segment being assembled once its first part is
+	// out; nil otherwise.
+	building *segment
+	// changed is closed and replaced whenever a part or segment is added
+	// or the broadcast ends, waking blocked requests.
+	changed chan struct{}
+	// evictedDiscontinuities counts the discontinuities of segments that
+	// are no longer retained.
+	evictedDiscontinuities int
+	targetDuration         int
+	ended                  bool
+}
+
+type segment struct {
+	seq           int
+	start         time.Duration
+	duration      time.Duration
+	discontinuity bool
+	// init is the initialization segment of fMP4 segments.
+	init *mapSegment
+	// parts are released once the segment is too old to be listed with
+	// its parts.
+	parts []*part
+	// data is released once the segment is behind the live edge and
+	// stored.
+	data   []byte
+	stored bool
+}
+
+// part is a partial segment. Segments are the concatenation of their parts.
+type part struct {
+	duration    time.Duration
+	independent bool
+	data        []byte
+}
+
+type mapSegment struct {
+	id   int
+	data []byte
+}
+
+func newBroadcast(s *live.Stream, cfg Config) *broadcast {
+	return &broadcast{
+		stream:         s,
+		cfg:            cfg,
+		ts:             newTSMuxer(),
+		changed:        make(chan struct{}),
+		targetDuration: int(math.Ceil(cfg.TargetDuration.Seconds())),
+	}
+}
+
+// write adds a packet to the segment being assembled. Segments of streams
+// with video start on keyframes; audio-only streams are cut on any frame.
+// Parts are cut on any frame of the lead stream so that they do not exceed
+// the part target.
+func (b *broadcast) write(p live.Packet) {
+	lead := live.Video
+	if p.Codecs.Video == nil {
+		lead = live.Audio
+	}
+	if p.Kind == lead {
+		if d := p.DTS - b.lastDTS; d > 0 && b.lastDTS > 0 {
+			b.frameDuration = d
+		}
+		b.lastDTS = p.DTS
+	}
+	boundary := p.Kind == lead && (lead == live.Audio || p.Keyframe)
+	open := len(b.pending) > 0 || b.parts > 0
+	if !open && !boundary {
+		return
+	}
+	if open && boundary {
+		if p.DTS-b.start >= b.cfg.TargetDuration || !sameCodecs(b.lastCodecs, p.Codecs) {
+			b.flush(p.DTS)
+			open = false
+		}
+	}
+	if open && b.cfg.PartTarget > 0 && p.Kind == lead && len(b.pending) > 0 &&
+		p.DTS-b.partStart+b.frameDuration > b.cfg.PartTarget {
+		b.flushPart(p.DTS)
+	}
+	if !open {
+		b.start = p.DTS
+	}
+	if len(b.pending) == 0 {
+		b.partStart = p.DTS
+	}
+	b.pending = append(b.pending, p)
+	b.lastCodecs = p.Codecs
+}
+
+// discontinue drops the part being assembled after packets were lost. The
+// parts already out end their segment.
+func (b *broadcast) discontinue() {
+	b.pending = b.pending[:0]
+	b.flush(b.partStart)
+	b.discontinuity = true
+}
+
+// finish flushes the last segment and ends the playlist.
+func (b *broadcast) finish() {
+	if len(b.pending) > 0 {
+		b.flush(max(b.lastDTS+b.frameDuration, b.partStart+time.Millisecond))
+	} else {
+		b.flush(b.partStart)
+	}
+	b.mu.Lock()
+	b.ended = true
+	b.notifyLocked()
+	b.mu.Unlock()
+}
+
+// flushPart muxes the pending packets into a part lasting until end,
+// opening a segment for the first.
+func (b *broadcast) flushPart(end time.Duration) {
+	if b.parts == 0 {
+		b.open()
+	}
+	codecs := b.segCodecs
+	pt := &part{
+		duration:    end - b.partStart,
+		independent: b.pending[0].Keyframe || b.pending[0].Kind == live.Audio,
+	}
+	switch b.cfg.Format {
+	case FormatFMP4:
+		b.fragments++
+		pt.data = fragment(b.fragments, b.pending, codecs, ticks(end, videoTimescale))
+		if b.rec != nil {
+			b.recData = append(b.recData, b.rec.ts.segment(b.pending, codecs)...)
+		}
+	default:
+		pt.data = b.ts.segment(b.pending, codecs)
+	}
+	b.parts++
+	b.pending = b.pending[:0]
+	b.partStart = end
+
+	b.mu.Lock()
+	b.building.parts = append(b.building.parts, pt)
+	b.notifyLocked()
+	b.mu.Unlock()
+}
+
+// open starts a segment with the pending packets.
+func (b *broadcast) open() {
+	// The last packet has the most complete codecs, as sequence headers
+	// may arrive after the first packets.
+	codecs := b.pending[len(b.pending)-1].Codecs
+	seg := &segment{
+		seq:           b.seq,
+		start:         b.start,
+		discontinuity: b.discontinuity || (b.codecs != nil && !sameCodecs(b.codecs, codecs)),
+	}
+	if b.cfg.Format == FormatFMP4 {
+		if b.init == nil || seg.discontinuity {
+			id := 0
+			if b.init != nil {
+				id = b.init.id + 1
+			}
+			b.init = &mapSegment{id: id, data: initSegment(codecs)}
+		}
+		seg.init = b.init
+	}
+	b.segCodecs = codecs
+	b.mu.Lock()
+	b.building = seg
+	b.mu.Unlock()
+}
+
+// flush completes the segment being assembled, which lasts until end.
+func (b *broadcast) flush(end time.Duration) {
+	if len(b.pending) > 0 {
+		b.flushPart(end)
+	}
+	if b.parts == 0 {
+		return
+	}
+	// Only this goroutine changes the parts of the segment being built.
+	seg := b.building
+	seg.duration = end - seg.start
+	if len(seg.parts) == 1 {
+		seg.data = seg.parts[0].data
+	} else {
+		for _, pt := range seg.parts {
+			seg.data = append(seg.data, pt.data...)
+		}
+	}
+	if b.store != nil {
+		b.persist(seg)
+	}
+	b.seq++
+	b.codecs, b.discontinuity = b.segCodecs, false
+	b.parts, b.recData = 0, nil
+	b.add(seg)
+}
+
+func (b *broadcast) notifyLocked() {
+	close(b.changed)
+	b.changed = make(chan struct{})
+}
+
+// wait blocks until ready, called with b.mu held, reports true, the
+// broadcast ends or ctx is done.
+func (b *broadcast) wait(ctx context.Context, ready func() bool) error {
+	for {
+		b.mu.Lock()
+		done, ended, changed := ready(), b.ended, b.changed
+		b.mu.Unlock()
+		if done || ended {
+			return nil
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// persist stores a segment for DVR playback and its MPEG-TS form for the
+// recording. Both are the same object for MPEG-TS broadcasts.
+func (b *broadcast) persist(seg *segment) {
+	if b.dvr > 0 {
+		seg.stored = b.put(b.segmentName(seg.seq), seg.data)
+	}
+	if b.rec == nil {
+		return
+	}
+	name := fmt.Sprintf("%d.ts", seg.seq)
+	switch {
+	case b.cfg.Format == FormatTS && b.dvr > 0:
+		if !seg.stored {
+			return
+		}
+	case b.cfg.Format == FormatTS:
+		if !b.put(name, seg.data) {
+			return
+		}
+	default:
+		if !b.put(name, b.recData) {
+			return
+		}
+	}
+	b.rec.add(seg, b.key(name))
+}
+
+// put stores an object of the broadcast. A segment that cannot be stored
+// leaves a gap in what is kept rather than stopping the broadcast.
+func (b *broadcast) put(name string, data []byte) bool {
+	if err := put(b.store, b.key(name), data); err != nil {
+		log.Printf("Live %s: storing %s: %v", b.stream.ID, name, err)
+		return false
+	}
+	return true
+}
+
+// key is the storage key of an object of the broadcast.
+func (b *broadcast) key(name string) string {
+	return StoragePrefix(b.stream.Channel) + b.stream.ID + "/" + name
+}
+
+func (b *broadcast) add(seg *segment) {
+	b.mu.Lock()
+	b.segments = append(b.segments, seg)
+	b.building = nil
+	b.notifyLocked()
+	if i := len(b.segments) - 1 - partSegments; i >= 0 {
+		b.segments[i].parts = nil
+	}
+	n := len(b.segments) - (b.cfg.Window + retainExtra)
+	if b.dvr > 0 {
+		// Segments behind the live edge are kept in the playlist while they
+		// end within the DVR window, and read back from storage.
+		behind := n
+		end := seg.start + seg.duration
+		n = 0
+		for n < len(b.segments)-b.cfg.Window && end-(b.segments[n].start+b.segments[n].duration) > b.dvr {
+			n++
+		}
+		// The playlist must not have gaps, so a segment that could not be
+		// stored is dropped together with all before it.
+		for i := n; i < behind; i++ {
+			if !b.segments[i].stored {
+				n = i + 1
+			}
+		}
+		for i := n; i < behind; i++ {
+			b.segments[i].data = nil
+		}
+	}
+	var expired []string
+	if n > 0 {
+		for _, old := range b.segments[:n] {
+			if old.discontinuity {
+				b.evictedDiscontinuities++
+			}
+			// Recordings of MPEG-TS broadcasts share the stored segments.
+			if old.stored && (b.rec == nil || b.cfg.Format != FormatTS) {
+				expired = append(expired, b.key(b.segmentName(old.seq)))
+			}
+		}
+		b.segments = append([]*segment(nil), b.segments[n:]...)
+	}
+	// Segment durations rounded to the nearest second must not exceed the
+	// target duration.
+	b.targetDuration = max(b.targetDuration, int(math.Round(seg.duration.Seconds())))
+	b.mu.Unlock()
+
+	for _, key := range expired {
+		if err := b.store.Delete(context.Background(), key); err != nil && !errors.Is(err, storage.ErrNotFound) {
+			log.Printf("Live %s: deleting %s: %v", b.stream.ID, key, err)
+		}
+	}
+}
+
+// segment returns the data of a segment, reading segments behind the live
+// edge back from storage.
+func (b *broadcast) segment(seq int) ([]byte, error) {
+	b.mu.Lock()
+	var stored bool
+	for _, seg := range b.segments {
+		if seg.seq == seq {
+			if seg.data != nil {
+				b.mu.Unlock()
+				return seg.data, nil
+			}
+			stored = seg.stored
+		}
+	}
+	b.mu.Unlock()
+	if !stored {
+		return nil, ErrNotFound
+	}
+	obj, err := b.store.Open(context.Background(), b.key(b.segmentName(seq)))
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer obj.Close()
+	return io.ReadAll(obj)
+}
+
+// part returns a part of a segment. Parts of the segment being assembled
+// that are not out yet are waited for.
+func (b *broadcast) part(ctx context.Context, seq, index int) ([]byte, error) {
+	find := func() *part {
+		if b.building != nil && b.building.seq == seq && index < len(b.building.parts) {
+			return b.building.parts[index]
+		}
+		for _, seg := range b.segments {
+			if seg.seq == seq && index < len(seg.parts) {
+				return seg.parts[index]
+			}
+		}
+		return nil
+	}
+	b.mu.Lock()
+	pt := find()
+	upcoming := pt == nil && seq == b.nextSeqLocked() && !b.ended
+	timeout := b.blockTimeoutLocked()
+	b.mu.Unlock()
+	if !upcoming {
+		if pt == nil {
+			return nil, ErrNotFound
+		}
+		return pt.data, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	err := b.wait(ctx, func() bool {
+		pt = find()
+		return pt != nil || seq < b.nextSeqLocked()
+	})
+	if err != nil || pt == nil {
+		return nil, ErrNotFound
+	}
+	return pt.data, nil
+}
+
+// waitPlaylist blocks until the segment msn, or its part, is in the
+// playlist.
+func (b *broadcast) waitPlaylist(ctx context.Context, msn int, part *int) error {
+	b.mu.Lock()
+	next := b.nextSeqLocked()
+	timeout := b.blockTimeoutLocked()
+	b.mu.Unlock()
+	// Requests may only be for up to two segments past the last one.
+	if msn > next+1 {
+		return ErrBadRequest
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	err := b.wait(ctx, func() bool {
+		if msn < b.nextSeqLocked() {
+			return true
+		}
+		return part != nil && b.building != nil && b.building.seq == msn && *part < len(b.building.parts)
+	})
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrTimeout
+	}
+	return err
+}
+
+// nextSeqLocked returns the sequence number of the first segment that is
+// not complete.
+func (b *broadcast) nextSeqLocked() int {
+	if len(b.segments) > 0 {
+		return b.segments[len(b.segments)-1].seq + 1
+	}
+	if b.building != nil {
+		return b.building.seq
+	}
+	return 0
+}
+
+func (b *broadcast) blockTimeoutLocked() time.Duration {
+	return blockingTargets * time.Duration(b.targetDuration) * time.Second
+}
+
+func (b *broadcast) mapData(id int) ([]byte, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.building != nil && b.building.init != nil && b.building.init.id == id {
+		return b.building.init.data, nil
+	}
+	for _, seg := range b.segments {
+		if seg.init != nil && seg.init.id == id {
+			return seg.init.data, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// sameCodecs reports whether two codec configurations decode alike.
+func sameCodecs(a, b *live.Codecs) bool {
+	if a == b {
+		return true
+	}
+	return (a.Video == nil) == (b.Video == nil) && (a.Audio == nil) == (b.Audio == nil) &&
+		bytes.Equal(a.AVCRecord, b.AVCRecord) && bytes.Equal(a.AACRecord, b.AACRecord)
+}
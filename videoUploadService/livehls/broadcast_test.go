@@ -0,0 +1,300 @@
+package livehls
+
+import (
+	"VideoUploadService/live"
+	"VideoUploadService/storage"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestBroadcast(cfg Config) *broadcast {
+	s := &live.Stream{ID: "s1", Channel: "ch", StartedAt: time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)}
+	return newBroadcast(s, cfg)
+}
+
+// addSegment completes a segment lasting d after the last one, storing it
+// for DVR playback if the broadcast keeps segments. Its data is its
+// sequence number.
+func addSegment(b *broadcast, d time.Duration) *segment {
+	seg := &segment{seq: b.seq, start: b.start, duration: d, data: []byte(fmt.Sprint(b.seq))}
+	b.seq++
+	b.start += d
+	if b.store != nil {
+		b.persist(seg)
+	}
+	b.add(seg)
+	return seg
+}
+
+func TestDVREviction(t *testing.T) {
+	b := newTestBroadcast(Config{Format: FormatTS, TargetDuration: 2 * time.Second, Window: 2})
+	b.store = storage.NewLocal(t.TempDir())
+	b.dvr = 10 * time.Second
+	for i := 0; i < 10; i++ {
+		seg := addSegment(b, 2*time.Second)
+		seg.discontinuity = i == 2
+	}
+
+	// The last segment ends at 20s, so those ending more than 10s before
+	// are evicted: 0 to 3.
+	var seqs []int
+	for _, seg := range b.segments {
+		seqs = append(seqs, seg.seq)
+		// Only the live window and the few segments retained past it are
+		// held in memory.
+		if inMemory := seg.seq >= 10-(2+retainExtra); (seg.data != nil) != inMemory {
+			t.Errorf("segment %d held in memory = %v, want %v", seg.seq, seg.data != nil, inMemory)
+		}
+	}
+	if fmt.Sprint(seqs) != "[4 5 6 7 8 9]" {
+		t.Fatalf("retained segments %v, want [4 5 6 7 8 9]", seqs)
+	}
+	if b.evictedDiscontinuities != 1 {
+		t.Errorf("evicted discontinuities = %d, want 1", b.evictedDiscontinuities)
+	}
+	for seq := 0; seq < 10; seq++ {
+		_, err := b.store.Open(context.Background(), b.key(b.segmentName(seq)))
+		if stored := err == nil; stored != (seq >= 4) {
+			t.Errorf("segment %d stored = %v, want %v", seq, stored, seq >= 4)
+		}
+	}
+	if data, err := b.segment(4); err != nil || string(data) != "4" {
+		t.Errorf("segment 4 = %q, %v; want it read back from storage", data, err)
+	}
+	if _, err := b.segment(3); !errors.Is(err, ErrNotFound) {
+		t.Errorf("evicted segment 3: err = %v, want ErrNotFound", err)
+	}
+
+	data, err := b.playlist(PlaylistRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	playlist := string(data)
+	for _, want := range []string{"#EXT-X-MEDIA-SEQUENCE:4\n", "#EXT-X-DISCONTINUITY-SEQUENCE:1\n", "\ns1/4.ts\n", "\ns1/9.ts\n"} {
+		if !strings.Contains(playlist, want) {
+			t.Errorf("playlist lacks %q:\n%s", want, playlist)
+		}
+	}
+	if n := strings.Count(playlist, "#EXTINF:"); n != 6 {
+		t.Errorf("playlist lists %d segments, want 6", n)
+	}
+}
+
+func TestDVREvictsUnstoredSegments(t *testing.T) {
+	b := newTestBroadcast(Config{Format: FormatTS, TargetDuration: 2 * time.Second, Window: 1})
+	b.store = storage.NewLocal(t.TempDir())
+	b.dvr = time.Hour
+	for i := 0; i < 9; i++ {
+		if i == 3 {
+			// A segment that could not be stored.
+			seg := &segment{seq: b.seq, start: b.start, duration: 2 * time.Second, data: []byte("3")}
+			b.seq++
+			b.start += seg.duration
+			b.add(seg)
+			continue
+		}
+		addSegment(b, 2*time.Second)
+	}
+
+	// Once segment 3 falls behind what is held in memory, it is dropped
+	// together with all before it rather than leave a gap.
+	if first := b.segments[0].seq; first != 4 {
+		t.Errorf("first retained segment %d, want 4", first)
+	}
+	for seq := 0; seq < 3; seq++ {
+		if _, err := b.store.Open(context.Background(), b.key(b.segmentName(seq))); !errors.Is(err, storage.ErrNotFound) {
+			t.Errorf("evicted segment %d: err = %v, want it deleted", seq, err)
+		}
+	}
+}
+
+func TestSlidingWindow(t *testing.T) {
+	b := newTestBroadcast(Config{Format: FormatTS, TargetDuration: 2 * time.Second, Window: 3})
+	for i := 0; i < 10; i++ {
+		addSegment(b, 2*time.Second)
+	}
+	if n := len(b.segments); n != 3+retainExtra {
+		t.Errorf("retained %d segments, want %d", n, 3+retainExtra)
+	}
+	data, err := b.playlist(PlaylistRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "#EXT-X-MEDIA-SEQUENCE:7\n") || strings.Count(string(data), "#EXTINF:") != 3 {
+		t.Errorf("playlist is not the last 3 segments:\n%s", data)
+	}
+}
+
+// addPart adds a part to the segment being assembled, opening it if needed.
+func addPart(b *broadcast) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.building == nil {
+		b.building = &segment{seq: b.seq, start: b.start}
+	}
+	b.building.parts = append(b.building.parts, &part{duration: time.Second / 3, data: []byte("part")})
+	b.notifyLocked()
+}
+
+func lowLatencyConfig() Config {
+	return Config{Format: FormatTS, TargetDuration: time.Second, PartTarget: time.Second / 3, Window: 20}
+}
+
+func TestWaitPlaylist(t *testing.T) {
+	intp := func(n int) *int { return &n }
+	tests := []struct {
+		name string
+		msn  int
+		part *int
+		// publish is called after the request started waiting.
+		publish func(b *broadcast)
+		want    error
+	}{
+		{name: "listed segment", msn: 1},
+		{name: "next segment", msn: 2, publish: func(b *broadcast) { addSegment(b, time.Second) }},
+		{name: "segment after next", msn: 3, publish: func(b *broadcast) {
+			addSegment(b, time.Second)
+			addSegment(b, time.Second)
+		}},
+		{name: "too far ahead", msn: 4, want: ErrBadRequest},
+		{name: "part", msn: 2, part: intp(1), publish: func(b *broadcast) {
+			addPart(b)
+			addPart(b)
+		}},
+		{name: "part of completed segment", msn: 2, part: intp(5), publish: func(b *broadcast) { addSegment(b, time.Second) }},
+		{name: "ended", msn: 2, publish: func(b *broadcast) { b.finish() }},
+		{name: "nothing arrives", msn: 2, part: intp(0), want: ErrTimeout},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newTestBroadcast(lowLatencyConfig())
+			addSegment(b, time.Second)
+			addSegment(b, time.Second)
+			ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+			defer cancel()
+
+			done := make(chan error, 1)
+			go func() { done <- b.waitPlaylist(ctx, tt.msn, tt.part) }()
+			if tt.publish != nil {
+				select {
+				case err := <-done:
+					t.Fatalf("returned %v before the playlist caught up", err)
+				case <-time.After(20 * time.Millisecond):
+				}
+				tt.publish(b)
+			}
+			if err := <-done; !errors.Is(err, tt.want) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestWaitPlaylistTimesOut(t *testing.T) {
+	b := newTestBroadcast(lowLatencyConfig())
+	addSegment(b, time.Second)
+	// Blocking requests wait blockingTargets target durations at most.
+	b.targetDuration = 0
+	if err := b.waitPlaylist(context.Background(), 1, nil); !errors.Is(err, ErrTimeout) {
+		t.Errorf("err = %v, want ErrTimeout", err)
+	}
+}
+
+func TestBlockingPart(t *testing.T) {
+	b := newTestBroadcast(lowLatencyConfig())
+	addSegment(b, time.Second)
+	addPart(b)
+	ctx := context.Background()
+
+	if data, err := b.part(ctx, 1, 0); err != nil || string(data) != "part" {
+		t.Errorf("part 1.0 = %q, %v", data, err)
+	}
+	if _, err := b.part(ctx, 0, 5); !errors.Is(err, ErrNotFound) {
+		t.Errorf("part 0.5 of a completed segment: err = %v, want ErrNotFound", err)
+	}
+	if _, err := b.part(ctx, 3, 0); !errors.Is(err, ErrNotFound) {
+		t.Errorf("part of a future segment: err = %v, want ErrNotFound", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := b.part(ctx, 1, 1)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		t.Fatalf("part 1.1 returned %v before it was out", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+	addPart(b)
+	if err := <-done; err != nil {
+		t.Errorf("part 1.1: err = %v", err)
+	}
+}
+
+func TestDeltaUpdate(t *testing.T) {
+	b := newTestBroadcast(lowLatencyConfig())
+	for i := 0; i < 12; i++ {
+		addSegment(b, time.Second)
+	}
+	addPart(b)
+
+	full, err := b.playlist(PlaylistRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(full), "#EXT-X-SKIP") || strings.Count(string(full), "#EXTINF:") != 12 {
+		t.Errorf("full playlist is not complete:\n%s", full)
+	}
+
+	delta, err := b.playlist(PlaylistRequest{Skip: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// With a 1s target duration, segments ending 6s or more before the
+	// last one are skipped: 0 to 5, which end at 1s to 6s.
+	playlist := string(delta)
+	for _, want := range []string{
+		"#EXT-X-SERVER-CONTROL:CAN-BLOCK-RELOAD=YES,CAN-SKIP-UNTIL=6.0,",
+		"#EXT-X-MEDIA-SEQUENCE:0\n",
+		"#EXT-X-SKIP:SKIPPED-SEGMENTS=6\n",
+		"#EXTINF:1.000,\ns1/6.ts\n",
+		"#EXT-X-PART:DURATION=0.333,URI=\"s1/12.0.ts\"\n",
+		"#EXT-X-PRELOAD-HINT:TYPE=PART,URI=\"s1/12.1.ts\"\n",
+	} {
+		if !strings.Contains(playlist, want) {
+			t.Errorf("delta update lacks %q:\n%s", want, playlist)
+		}
+	}
+	if n := strings.Count(playlist, "#EXTINF:"); n != 6 {
+		t.Errorf("delta update lists %d segments, want 6", n)
+	}
+	// The first listed segment carries the tags a player needs to place it.
+	if i, j := strings.Index(playlist, "#EXT-X-PROGRAM-DATE-TIME:2026-01-01T12:00:06.000Z"), strings.Index(playlist, "s1/6.ts"); i < 0 || i > j {
+		t.Errorf("delta update does not date its first segment:\n%s", playlist)
+	}
+
+	// Playlists too short to skip anything and those of normal latency
+	// broadcasts are always complete.
+	short := newTestBroadcast(lowLatencyConfig())
+	for i := 0; i < 6; i++ {
+		addSegment(short, time.Second)
+	}
+	normal := newTestBroadcast(Config{Format: FormatTS, TargetDuration: time.Second, Window: 20})
+	for i := 0; i < 12; i++ {
+		addSegment(normal, time.Second)
+	}
+	for _, b := range []*broadcast{short, normal} {
+		data, err := b.playlist(PlaylistRequest{Skip: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(string(data), "#EXT-X-SKIP") {
+			t.Errorf("unexpected delta update:\n%s", data)
+		}
+	}
+}
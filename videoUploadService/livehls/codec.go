@@ -0,0 +1,228 @@
+package livehls
+
+import (
+	"VideoUploadService/flv"
+	"encoding/binary"
+	"time"
+)
+
+// NAL unit types.
+const (
+	naluSPS = 7
+	naluAUD = 9
+)
+
+// ticks converts a stream time to units of a timescale.
+func ticks(d time.Duration, timescale int) int64 {
+	return d.Microseconds() * int64(timescale) / 1e6
+}
+
+// annexB converts an access unit of NAL units with 4-byte length prefixes
+// to the start code format of MPEG-TS. It begins with an access unit
+// delimiter, and keyframes get the parameter sets so that players can
+// start decoding at every segment.
+func annexB(au []byte, cfg *flv.AVCConfig, keyframe bool) []byte {
+	startCode := []byte{0, 0, 0, 1}
+	out := make([]byte, 0, len(au)+64)
+	hasAUD, hasSPS := false, false
+	for rest := au; len(rest) >= 5; {
+		n := int(binary.BigEndian.Uint32(rest))
+		switch rest[4] & 0x1f {
+		case naluAUD:
+			hasAUD = true
+		case naluSPS:
+			hasSPS = true
+		}
+		if n > len(rest)-4 {
+			break
+		}
+		rest = rest[4+n:]
+	}
+	if !hasAUD {
+		out = append(out, startCode...)
+		out = append(out, naluAUD, 0xf0)
+	}
+	if keyframe && !hasSPS {
+		for _, set := range append(append([][]byte{}, cfg.SPS...), cfg.PPS...) {
+			out = append(out, startCode...)
+			out = append(out, set...)
+		}
+	}
+	for len(au) >= 4 {
+		n := int(binary.BigEndian.Uint32(au))
+		if n > len(au)-4 {
+			break
+		}
+		out = append(out, startCode...)
+		out = append(out, au[4:4+n]...)
+		au = au[4+n:]
+	}
+	return out
+}
+
+// adtsHeader returns the ADTS header of an AAC frame of n bytes. ADTS can
+// only signal the first four object types; others are sent as AAC LC,
+// which HE-AAC decoders handle through implicit signalling.
+func adtsHeader(c *flv.AACConfig, n int) []byte {
+	profile := c.ObjectType - 1
+	if c.ObjectType < 1 || c.ObjectType > 4 {
+		profile = 1
+	}
+	size := 7 + n
+	return []byte{
+		0xff, 0xf1, // MPEG-4, no CRC
+		profile<<6 | c.SampleRateIndex<<2 | c.Channels>>2&1,
+		c.Channels&3<<6 | byte(size>>11),
+		byte(size >> 3),
+		byte(size<<5) | 0x1f,
+		0xfc,
+	}
+}
+
+// resolution reads the picture size from an H.264 sequence parameter set.
+func resolution(sps []byte) (width, height int, ok bool) {
+	r := &bitReader{data: unescape(sps)}
+	r.skip(8) // NAL unit header
+	profile := r.bits(8)
+	r.skip(16) // constraint flags and level
+	r.ue()     // seq_parameter_set_id
+	chroma := 1
+	switch profile {
+	case 100, 110, 122, 244, 44, 83, 86, 118, 128, 138, 139, 134, 135:
+		chroma = r.ue()
+		if chroma == 3 {
+			r.skip(1) // separate_colour_plane_flag
+		}
+		r.ue()    // bit_depth_luma_minus8
+		r.ue()    // bit_depth_chroma_minus8
+		r.skip(1) // qpprime_y_zero_transform_bypass_flag
+		if r.bits(1) == 1 {
+			lists := 8
+			if chroma == 3 {
+				lists = 12
+			}
+			for i := 0; i < lists; i++ {
+				if r.bits(1) == 0 {
+					continue
+				}
+				size := 16
+				if i >= 6 {
+					size = 64
+				}
+				last, next := 8, 8
+				for j := 0; j < size && !r.failed; j++ {
+					if next != 0 {
+						next = (last + r.se() + 256) % 256
+					}
+					if next != 0 {
+						last = next
+					}
+				}
+			}
+		}
+	}
+	r.ue() // log2_max_frame_num_minus4
+	switch r.ue() {
+	case 0:
+		r.ue() // log2_max_pic_order_cnt_lsb_minus4
+	case 1:
+		r.skip(1) // delta_pic_order_always_zero_flag
+		r.se()
+		r.se()
+		for n := r.ue(); n > 0 && !r.failed; n-- {
+			r.se()
+		}
+	}
+	r.ue()    // max_num_ref_frames
+	r.skip(1) // gaps_in_frame_num_value_allowed_flag
+	widthMBs := r.ue() + 1
+	heightMaps := r.ue() + 1
+	frameMBsOnly := r.bits(1)
+	if frameMBsOnly == 0 {
+		r.skip(1) // mb_adaptive_frame_field_flag
+	}
+	r.skip(1) // direct_8x8_inference_flag
+	width, height = widthMBs*16, (2-frameMBsOnly)*heightMaps*16
+	if r.bits(1) == 1 {
+		left, right, top, bottom := r.ue(), r.ue(), r.ue(), r.ue()
+		cropX, cropY := 1, 2-frameMBsOnly
+		switch chroma {
+		case 1:
+			cropX, cropY = 2, 2*(2-frameMBsOnly)
+		case 2:
+			cropX = 2
+		}
+		width -= (left + right) * cropX
+		height -= (top + bottom) * cropY
+	}
+	if r.failed || width <= 0 || height <= 0 {
+		return 0, 0, false
+	}
+	return width, height, true
+}
+
+// unescape removes the emulation prevention bytes of a NAL unit.
+func unescape(nal []byte) []byte {
+	out := make([]byte, 0, len(nal))
+	zeros := 0
+	for _, b := range nal {
+		if zeros >= 2 && b == 3 {
+			zeros = 0
+			continue
+		}
+		out = append(out, b)
+		if b == 0 {
+			zeros++
+		} else {
+			zeros = 0
+		}
+	}
+	return out
+}
+
+// bitReader reads the fields of a parameter set. Reading past the end sets
+// failed and returns zeros.
+type bitReader struct {
+	data   []byte
+	pos    int
+	failed bool
+}
+
+func (r *bitReader) bits(n int) int {
+	v := 0
+	for i := 0; i < n; i++ {
+		if r.pos >= len(r.data)*8 {
+			r.failed = true
+			return 0
+		}
+		v = v<<1 | int(r.data[r.pos/8]>>(7-r.pos%8)&1)
+		r.pos++
+	}
+	return v
+}
+
+func (r *bitReader) skip(n int) {
+	r.bits(n)
+}
+
+// ue reads an unsigned Exp-Golomb code.
+func (r *bitReader) ue() int {
+	zeros := 0
+	for r.bits(1) == 0 {
+		if r.failed || zeros == 31 {
+			r.failed = true
+			return 0
+		}
+		zeros++
+	}
+	return 1<<zeros - 1 + r.bits(zeros)
+}
+
+// se reads a signed Exp-Golomb code.
+func (r *bitReader) se() int {
+	v := r.ue()
+	if v%2 == 1 {
+		return (v + 1) / 2
+	}
+	return -v / 2
+}
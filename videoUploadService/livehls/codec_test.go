@@ -0,0 +1,155 @@
+package livehls
+
+import (
+	"VideoUploadService/flv"
+	"bytes"
+	"testing"
+	"time"
+)
+
+// fixtureSPS is the sequence parameter set of ../live/testdata/publish.flv:
+// High profile, 320x240.
+var fixtureSPS = []byte{0x67, 0x64, 0x00, 0x1f, 0xac, 0xd9, 0x41, 0x41, 0xfb, 0x01, 0x10, 0x00, 0x00, 0x03,
+	0x00, 0x10, 0x00, 0x00, 0x03, 0x01, 0x40, 0xf1, 0x83, 0x19, 0x60}
+
+// bitWriter builds parameter sets for the tests.
+type bitWriter struct {
+	data []byte
+	n    int
+}
+
+func (w *bitWriter) bits(v, n int) {
+	for i := n - 1; i >= 0; i-- {
+		if w.n%8 == 0 {
+			w.data = append(w.data, 0)
+		}
+		w.data[len(w.data)-1] |= byte(v>>i&1) << (7 - w.n%8)
+		w.n++
+	}
+}
+
+func (w *bitWriter) ue(v int) {
+	zeros := 0
+	for x := v + 1; x > 1; x >>= 1 {
+		zeros++
+	}
+	w.bits(0, zeros)
+	w.bits(v+1, zeros+1)
+}
+
+// baselineSPS returns a Baseline profile SPS of the given size in
+// macroblocks, cropped at the bottom by cropBottom chroma rows.
+func baselineSPS(widthMBs, heightMBs, cropBottom int) []byte {
+	w := &bitWriter{}
+	w.bits(0x67, 8)
+	w.bits(66, 8) // profile
+	w.bits(0, 8)  // constraint flags
+	w.bits(40, 8) // level
+	w.ue(0)       // seq_parameter_set_id
+	w.ue(0)       // log2_max_frame_num_minus4
+	w.ue(0)       // pic_order_cnt_type
+	w.ue(0)       // log2_max_pic_order_cnt_lsb_minus4
+	w.ue(1)       // max_num_ref_frames
+	w.bits(0, 1)  // gaps_in_frame_num_value_allowed_flag
+	w.ue(widthMBs - 1)
+	w.ue(heightMBs - 1)
+	w.bits(1, 1) // frame_mbs_only_flag
+	w.bits(1, 1) // direct_8x8_inference_flag
+	if cropBottom > 0 {
+		w.bits(1, 1)
+		w.ue(0)
+		w.ue(0)
+		w.ue(0)
+		w.ue(cropBottom)
+	} else {
+		w.bits(0, 1)
+	}
+	w.bits(0, 1) // vui_parameters_present_flag
+	w.bits(1, 1) // stop bit
+	return w.data
+}
+
+func TestResolution(t *testing.T) {
+	tests := []struct {
+		name          string
+		sps           []byte
+		width, height int
+		ok            bool
+	}{
+		{"high profile with emulation prevention", fixtureSPS, 320, 240, true},
+		{"cropped 1080p", baselineSPS(120, 68, 4), 1920, 1080, true},
+		{"uncropped 720p", baselineSPS(80, 45, 0), 1280, 720, true},
+		{"truncated", fixtureSPS[:8], 0, 0, false},
+		{"empty", nil, 0, 0, false},
+	}
+	for _, tt := range tests {
+		w, h, ok := resolution(tt.sps)
+		if w != tt.width || h != tt.height || ok != tt.ok {
+			t.Errorf("%s: resolution = %dx%d, %v; want %dx%d, %v", tt.name, w, h, ok, tt.width, tt.height, tt.ok)
+		}
+	}
+}
+
+func TestUnescape(t *testing.T) {
+	in := []byte{0x67, 0x00, 0x00, 0x03, 0x01, 0x00, 0x00, 0x03, 0x00, 0x03, 0x00, 0x03}
+	want := []byte{0x67, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03}
+	if got := unescape(in); !bytes.Equal(got, want) {
+		t.Errorf("unescape = % x, want % x", got, want)
+	}
+}
+
+func TestADTSHeader(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  flv.AACConfig
+		n    int
+		want []byte
+	}{
+		// 107 bytes with the header: 0b0000001101011 across bytes 3 to 5.
+		{"LC 44.1kHz stereo", flv.AACConfig{ObjectType: 2, SampleRateIndex: 4, Channels: 2}, 100, []byte{0xff, 0xf1, 0x50, 0x80, 0x0d, 0x7f, 0xfc}},
+		{"HE-AAC as LC", flv.AACConfig{ObjectType: 5, SampleRateIndex: 6, Channels: 2}, 100, []byte{0xff, 0xf1, 0x58, 0x80, 0x0d, 0x7f, 0xfc}},
+		// 8192 bytes sets the highest bit of the length.
+		{"main 48kHz 5.1", flv.AACConfig{ObjectType: 1, SampleRateIndex: 3, Channels: 6}, 8185, []byte{0xff, 0xf1, 0x0d, 0x84, 0x00, 0x1f, 0xfc}},
+	}
+	for _, tt := range tests {
+		if got := adtsHeader(&tt.cfg, tt.n); !bytes.Equal(got, tt.want) {
+			t.Errorf("%s: adtsHeader = % x, want % x", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAnnexB(t *testing.T) {
+	cfg := &flv.AVCConfig{SPS: [][]byte{{0x67, 1}}, PPS: [][]byte{{0x68, 2}}}
+	idr := []byte{0, 0, 0, 2, 0x65, 0xaa}
+	slice := []byte{0, 0, 0, 2, 0x41, 0xbb}
+	aud := []byte{0, 0, 0, 2, 0x09, 0xf0}
+	sc := []byte{0, 0, 0, 1}
+	join := func(parts ...[]byte) []byte { return bytes.Join(parts, nil) }
+
+	tests := []struct {
+		name     string
+		au       []byte
+		keyframe bool
+		want     []byte
+	}{
+		{"keyframe", idr, true, join(sc, []byte{0x09, 0xf0}, sc, []byte{0x67, 1}, sc, []byte{0x68, 2}, sc, []byte{0x65, 0xaa})},
+		{"inter frame", slice, false, join(sc, []byte{0x09, 0xf0}, sc, []byte{0x41, 0xbb})},
+		{"own delimiter", join(aud, slice), false, join(sc, []byte{0x09, 0xf0}, sc, []byte{0x41, 0xbb})},
+		{"own parameter sets", join([]byte{0, 0, 0, 2, 0x67, 9}, idr), true, join(sc, []byte{0x09, 0xf0}, sc, []byte{0x67, 9}, sc, []byte{0x65, 0xaa})},
+		{"truncated unit", join(slice, []byte{0, 0, 0, 9, 0x41}), false, join(sc, []byte{0x09, 0xf0}, sc, []byte{0x41, 0xbb})},
+	}
+	for _, tt := range tests {
+		if got := annexB(tt.au, cfg, tt.keyframe); !bytes.Equal(got, tt.want) {
+			t.Errorf("%s: annexB = % x, want % x", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestTicks(t *testing.T) {
+	if got := ticks(1500*time.Millisecond, 90000); got != 135000 {
+		t.Errorf("ticks(1.5s, 90kHz) = %d, want 135000", got)
+	}
+	if got := ticks(100*time.Millisecond, 44100); got != 4410 {
+		t.Errorf("ticks(100ms, 44.1kHz) = %d, want 4410", got)
+	}
+}
@@ -0,0 +1,235 @@
+package livehls
+
+import (
+	"VideoUploadService/live"
+	"encoding/binary"
+)
+
+// Track IDs and timescales of fMP4 output.
+const (
+	videoTrack     = 1
+	audioTrack     = 2
+	videoTimescale = 90000
+	// aacFrameSamples is the duration of an AAC frame in samples.
+	aacFrameSamples = 1024
+)
+
+// Sample flags of trun entries.
+const (
+	syncSampleFlags    = 0x02000000 // depends on no other sample
+	nonSyncSampleFlags = 0x01010000 // depends on others, not a sync sample
+)
+
+// box serializes an ISO BMFF box.
+func box(typ string, parts ...[]byte) []byte {
+	n := 8
+	for _, p := range parts {
+		n += len(p)
+	}
+	b := make([]byte, 8, n)
+	binary.BigEndian.PutUint32(b, uint32(n))
+	copy(b[4:], typ)
+	for _, p := range parts {
+		b = append(b, p...)
+	}
+	return b
+}
+
+// fullBox serializes a box with a version and flags.
+func fullBox(typ string, version byte, flags uint32, parts ...[]byte) []byte {
+	header := []byte{version, byte(flags >> 16), byte(flags >> 8), byte(flags)}
+	return box(typ, append([][]byte{header}, parts...)...)
+}
+
+func u16(v int) []byte   { return binary.BigEndian.AppendUint16(nil, uint16(v)) }
+func u32(v int) []byte   { return binary.BigEndian.AppendUint32(nil, uint32(v)) }
+func u64(v int64) []byte { return binary.BigEndian.AppendUint64(nil, uint64(v)) }
+
+var unityMatrix = []byte{
+	0, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0x40, 0, 0, 0,
+}
+
+// initSegment builds the initialization segment of the tracks in codecs.
+func initSegment(codecs *live.Codecs) []byte {
+	var traks [][]byte
+	var trexs [][]byte
+	if codecs.Video != nil {
+		traks = append(traks, videoTrak(codecs))
+		trexs = append(trexs, trex(videoTrack))
+	}
+	if codecs.Audio != nil {
+		traks = append(traks, audioTrak(codecs))
+		trexs = append(trexs, trex(audioTrack))
+	}
+	mvhd := fullBox("mvhd", 0, 0,
+		u32(0), u32(0), // creation and modification time
+		u32(1000), u32(0), // timescale, duration
+		u32(0x00010000), u16(0x0100), make([]byte, 10),
+		unityMatrix, make([]byte, 24),
+		u32(audioTrack+1), // next track ID
+	)
+	moov := box("moov", append(append([][]byte{mvhd}, traks...), box("mvex", trexs...))...)
+	ftyp := box("ftyp", []byte("iso5"), u32(0), []byte("iso5iso6mp41"))
+	return append(ftyp, moov...)
+}
+
+func trex(track int) []byte {
+	return fullBox("trex", 0, 0, u32(track), u32(1), u32(0), u32(0), u32(0))
+}
+
+func videoTrak(codecs *live.Codecs) []byte {
+	width, height := 0, 0
+	if len(codecs.Video.SPS) > 0 {
+		width, height, _ = resolution(codecs.Video.SPS[0])
+	}
+	// Access units are always passed on with 4-byte NAL unit lengths.
+	avcC := append([]byte{}, codecs.AVCRecord...)
+	if len(avcC) > 4 {
+		avcC[4] |= 0x03
+	}
+	avc1 := box("avc1",
+		make([]byte, 6), u16(1), // reserved, data reference index
+		make([]byte, 16),
+		u16(width), u16(height),
+		u32(0x00480000), u32(0x00480000), // 72 dpi
+		u32(0), u16(1), // reserved, frame count
+		make([]byte, 32), // compressor name
+		u16(0x0018), u16(0xffff),
+		box("avcC", avcC),
+	)
+	return trak(videoTrack, videoTimescale, "vide", width, height, avc1)
+}
+
+func audioTrak(codecs *live.Codecs) []byte {
+	a := codecs.Audio
+	asc := codecs.AACRecord
+	// ES_Descriptor with a DecoderConfigDescriptor for MPEG-4 audio and
+	// the AudioSpecificConfig.
+	decoderSpecific := append([]byte{0x05, byte(len(asc))}, asc...)
+	decoderConfig := append([]byte{0x04, byte(13 + len(decoderSpecific)), 0x40, 0x15, 0, 0, 0}, make([]byte, 8)...)
+	decoderConfig = append(decoderConfig, decoderSpecific...)
+	es := []byte{0x03, byte(3 + len(decoderConfig) + 3), 0, 0, 0}
+	es = append(es, decoderConfig...)
+	es = append(es, 0x06, 0x01, 0x02)
+	mp4a := box("mp4a",
+		make([]byte, 6), u16(1),
+		make([]byte, 8),
+		u16(int(a.Channels)), u16(16),
+		u32(0),
+		u32(a.SampleRate<<16),
+		fullBox("esds", 0, 0, es),
+	)
+	return trak(audioTrack, a.SampleRate, "soun", 0, 0, mp4a)
+}
+
+// trak builds a track with one sample entry and empty sample tables, as
+// the samples are in the fragments.
+func trak(id, timescale int, handler string, width, height int, entry []byte) []byte {
+	volume, name := 0, "VideoHandler"
+	header := fullBox("vmhd", 0, 1, make([]byte, 8))
+	if handler == "soun" {
+		volume, name = 0x0100, "SoundHandler"
+		header = fullBox("smhd", 0, 0, make([]byte, 4))
+	}
+	tkhd := fullBox("tkhd", 0, 3, // enabled, in movie
+		u32(0), u32(0), u32(id), u32(0), u32(0),
+		make([]byte, 8), u16(0), u16(0), u16(volume), u16(0),
+		unityMatrix, u32(width<<16), u32(height<<16),
+	)
+	mdhd := fullBox("mdhd", 0, 0, u32(0), u32(0), u32(timescale), u32(0), u16(0x55c4), u16(0)) // "und"
+	hdlr := fullBox("hdlr", 0, 0, u32(0), []byte(handler), make([]byte, 12), []byte(name+"\x00"))
+	dinf := box("dinf", fullBox("dref", 0, 0, u32(1), fullBox("url ", 0, 1)))
+	stbl := box("stbl",
+		fullBox("stsd", 0, 0, u32(1), entry),
+		fullBox("stts", 0, 0, u32(0)),
+		fullBox("stsc", 0, 0, u32(0)),
+		fullBox("stsz", 0, 0, u32(0), u32(0)),
+		fullBox("stco", 0, 0, u32(0)),
+	)
+	return box("trak", tkhd, box("mdia", mdhd, hdlr, box("minf", header, dinf, stbl)))
+}
+
+// fragment builds a media segment of one moof and mdat holding packets.
+// end is the decode time the segment lasts until, which gives the
+// duration of its last video sample.
+func fragment(seq int, packets []live.Packet, codecs *live.Codecs, end int64) []byte {
+	var video, audio []live.Packet
+	for _, p := range packets {
+		switch {
+		case p.Kind == live.Video && codecs.Video != nil:
+			video = append(video, p)
+		case p.Kind == live.Audio && codecs.Audio != nil:
+			audio = append(audio, p)
+		}
+	}
+
+	// The moof size does not depend on the data offsets, so build it once
+	// to learn its size and again with the offsets filled in.
+	build := func(base int) []byte {
+		trafs := [][]byte{fullBox("mfhd", 0, 0, u32(seq))}
+		offset := base
+		if len(video) > 0 {
+			trafs = append(trafs, videoTraf(video, end, offset))
+			for _, p := range video {
+				offset += len(p.Data)
+			}
+		}
+		if len(audio) > 0 {
+			trafs = append(trafs, audioTraf(audio, codecs.Audio.SampleRate, offset))
+		}
+		return box("moof", trafs...)
+	}
+	moof := build(0)
+	moof = build(len(moof) + 8)
+
+	var data [][]byte
+	for _, p := range video {
+		data = append(data, p.Data)
+	}
+	for _, p := range audio {
+		data = append(data, p.Data)
+	}
+	return append(moof, box("mdat", data...)...)
+}
+
+func videoTraf(samples []live.Packet, end int64, offset int) []byte {
+	entries := make([]byte, 0, len(samples)*16)
+	for i, p := range samples {
+		dts := ticks(p.DTS, videoTimescale)
+		next := end
+		if i+1 < len(samples) {
+			next = ticks(samples[i+1].DTS, videoTimescale)
+		}
+		flags := nonSyncSampleFlags
+		if p.Keyframe {
+			flags = syncSampleFlags
+		}
+		entries = append(entries, u32(int(max(next-dts, 0)))...)
+		entries = append(entries, u32(len(p.Data))...)
+		entries = append(entries, u32(flags)...)
+		entries = append(entries, u32(int(ticks(p.PTS, videoTimescale)-dts))...)
+	}
+	return box("traf",
+		fullBox("tfhd", 0, 0x020000, u32(videoTrack)), // default-base-is-moof
+		fullBox("tfdt", 1, 0, u64(ticks(samples[0].DTS, videoTimescale))),
+		// data offset, duration, size, flags and composition offset
+		fullBox("trun", 1, 0x000f01, u32(len(samples)), u32(offset), entries),
+	)
+}
+
+// audioTraf gives every AAC frame its nominal duration; the decode time of
+// the first frame keeps the track in sync with the video.
+func audioTraf(samples []live.Packet, sampleRate int, offset int) []byte {
+	entries := make([]byte, 0, len(samples)*4)
+	for _, p := range samples {
+		entries = append(entries, u32(len(p.Data))...)
+	}
+	return box("traf",
+		fullBox("tfhd", 0, 0x020008, u32(audioTrack), u32(aacFrameSamples)), // default duration
+		fullBox("tfdt", 1, 0, u64(ticks(samples[0].DTS, sampleRate))),
+		// data offset and size
+		fullBox("trun", 0, 0x000201, u32(len(samples)), u32(offset), entries),
+	)
+}
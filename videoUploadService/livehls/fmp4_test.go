@@ -0,0 +1,191 @@
+package livehls
+
+import (
+	"VideoUploadService/live"
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// children splits the payload of a box into its child boxes by type. Boxes
+// of the same type are kept in order.
+func children(t *testing.T, b []byte) map[string][][]byte {
+	t.Helper()
+	boxes := make(map[string][][]byte)
+	for len(b) > 0 {
+		if len(b) < 8 {
+			t.Fatalf("%d trailing bytes", len(b))
+		}
+		n := int(binary.BigEndian.Uint32(b))
+		if n < 8 || n > len(b) {
+			t.Fatalf("%q box of %d bytes in %d", b[4:8], n, len(b))
+		}
+		boxes[string(b[4:8])] = append(boxes[string(b[4:8])], b[8:n])
+		b = b[n:]
+	}
+	return boxes
+}
+
+// path follows a chain of single child boxes.
+func path(t *testing.T, b []byte, types ...string) []byte {
+	t.Helper()
+	for _, typ := range types {
+		found := children(t, b)[typ]
+		if len(found) != 1 {
+			t.Fatalf("%d %q boxes, want 1", len(found), typ)
+		}
+		b = found[0]
+	}
+	return b
+}
+
+func be32(b []byte) int { return int(binary.BigEndian.Uint32(b)) }
+
+func TestInitSegment(t *testing.T) {
+	packets := publishPackets(t)
+	codecs := packets[0].Codecs
+	init := initSegment(codecs)
+	if top := children(t, init); len(top["ftyp"]) != 1 || len(top["moov"]) != 1 {
+		t.Fatalf("init segment has boxes %v", top)
+	}
+	moov := path(t, init, "moov")
+	traks := children(t, moov)["trak"]
+	if len(traks) != 2 {
+		t.Fatalf("%d tracks, want 2", len(traks))
+	}
+
+	tkhd := path(t, traks[0], "tkhd")
+	if id, w, h := be32(tkhd[12:]), be32(tkhd[76:])>>16, be32(tkhd[80:])>>16; id != videoTrack || w != 320 || h != 240 {
+		t.Errorf("video tkhd: track %d of %dx%d, want %d of 320x240", id, w, h, videoTrack)
+	}
+	if ts := be32(path(t, traks[0], "mdia", "mdhd")[12:]); ts != videoTimescale {
+		t.Errorf("video timescale %d, want %d", ts, videoTimescale)
+	}
+	stsd := path(t, traks[0], "mdia", "minf", "stbl", "stsd")
+	avc1 := path(t, stsd[8:], "avc1")
+	if w, h := binary.BigEndian.Uint16(avc1[24:]), binary.BigEndian.Uint16(avc1[26:]); w != 320 || h != 240 {
+		t.Errorf("avc1 of %dx%d, want 320x240", w, h)
+	}
+	avcC := path(t, avc1[78:], "avcC")
+	if !bytes.Equal(avcC[5:], codecs.AVCRecord[5:]) || avcC[4]&0x03 != 0x03 {
+		t.Errorf("avcC % x does not carry the record % x with 4-byte lengths", avcC, codecs.AVCRecord)
+	}
+
+	if id := be32(path(t, traks[1], "tkhd")[12:]); id != audioTrack {
+		t.Errorf("audio track %d, want %d", id, audioTrack)
+	}
+	if ts := be32(path(t, traks[1], "mdia", "mdhd")[12:]); ts != 44100 {
+		t.Errorf("audio timescale %d, want 44100", ts)
+	}
+	stsd = path(t, traks[1], "mdia", "minf", "stbl", "stsd")
+	mp4a := path(t, stsd[8:], "mp4a")
+	if ch, rate := binary.BigEndian.Uint16(mp4a[16:]), be32(mp4a[24:])>>16; ch != 2 || rate != 44100 {
+		t.Errorf("mp4a: %d channels at %d Hz, want 2 at 44100", ch, rate)
+	}
+	if esds := path(t, mp4a[28:], "esds"); !bytes.Contains(esds, append([]byte{0x05, byte(len(codecs.AACRecord))}, codecs.AACRecord...)) {
+		t.Errorf("esds % x does not carry the AudioSpecificConfig % x", esds, codecs.AACRecord)
+	}
+
+	trexs := children(t, path(t, moov, "mvex"))["trex"]
+	if len(trexs) != 2 || be32(trexs[0][4:]) != videoTrack || be32(trexs[1][4:]) != audioTrack {
+		t.Errorf("trex boxes %x, want one per track", trexs)
+	}
+}
+
+func TestFragment(t *testing.T) {
+	packets := publishPackets(t)
+	var video, audio []live.Packet
+	for _, p := range packets {
+		if p.Kind == live.Video {
+			video = append(video, p)
+		} else {
+			audio = append(audio, p)
+		}
+	}
+	const end = 100000 // 1.11s, after the last frame at 0.9s
+	seg := fragment(7, packets, packets[0].Codecs, end)
+	top := children(t, seg)
+	if len(top["moof"]) != 1 || len(top["mdat"]) != 1 {
+		t.Fatalf("fragment has boxes %v", top)
+	}
+	moof := top["moof"][0]
+	if seq := be32(path(t, moof, "mfhd")[4:]); seq != 7 {
+		t.Errorf("sequence number %d, want 7", seq)
+	}
+	trafs := children(t, moof)["traf"]
+	if len(trafs) != 2 {
+		t.Fatalf("%d trafs, want 2", len(trafs))
+	}
+
+	// Video: duration, size, flags and composition offset per sample. Data
+	// offsets count from the moof, which starts the segment.
+	tfhd := path(t, trafs[0], "tfhd")
+	if be32(tfhd)&0xffffff != 0x020000 || be32(tfhd[4:]) != videoTrack {
+		t.Errorf("video tfhd % x", tfhd)
+	}
+	if dt := binary.BigEndian.Uint64(path(t, trafs[0], "tfdt")[4:]); dt != 0 {
+		t.Errorf("video decode time %d, want 0", dt)
+	}
+	trun := path(t, trafs[0], "trun")
+	if be32(trun)&0xffffff != 0x000f01 || be32(trun[4:]) != len(video) {
+		t.Fatalf("video trun flags %#x with %d samples", be32(trun)&0xffffff, be32(trun[4:]))
+	}
+	offset := be32(trun[8:])
+	for i, p := range video {
+		e := trun[12+16*i:]
+		dur, size, flags, cto := be32(e), be32(e[4:]), be32(e[8:]), int32(be32(e[12:]))
+		next := int64(end)
+		if i+1 < len(video) {
+			next = ticks(video[i+1].DTS, videoTimescale)
+		}
+		if want := next - ticks(p.DTS, videoTimescale); int64(dur) != want {
+			t.Errorf("video sample %d: duration %d, want %d", i, dur, want)
+		}
+		if want := map[bool]int{true: syncSampleFlags, false: nonSyncSampleFlags}[p.Keyframe]; flags != want {
+			t.Errorf("video sample %d: flags %#x, want %#x", i, flags, want)
+		}
+		if want := ticks(p.PTS-p.DTS, videoTimescale); int64(cto) != want {
+			t.Errorf("video sample %d: composition offset %d, want %d", i, cto, want)
+		}
+		if size != len(p.Data) || !bytes.Equal(seg[offset:offset+size], p.Data) {
+			t.Errorf("video sample %d: %d bytes at %d do not hold the frame", i, size, offset)
+		}
+		offset += size
+	}
+
+	// Audio: sizes only, with the default duration of an AAC frame.
+	tfhd = path(t, trafs[1], "tfhd")
+	if be32(tfhd[4:]) != audioTrack || be32(tfhd[8:]) != aacFrameSamples {
+		t.Errorf("audio tfhd % x", tfhd)
+	}
+	if dt := binary.BigEndian.Uint64(path(t, trafs[1], "tfdt")[4:]); dt != 0 {
+		t.Errorf("audio decode time %d, want 0", dt)
+	}
+	trun = path(t, trafs[1], "trun")
+	if be32(trun)&0xffffff != 0x000201 || be32(trun[4:]) != len(audio) {
+		t.Fatalf("audio trun flags %#x with %d samples", be32(trun)&0xffffff, be32(trun[4:]))
+	}
+	if be32(trun[8:]) != offset {
+		t.Errorf("audio data offset %d, want %d after the video", be32(trun[8:]), offset)
+	}
+	for i, p := range audio {
+		size := be32(trun[12+4*i:])
+		if size != len(p.Data) || !bytes.Equal(seg[offset:offset+size], p.Data) {
+			t.Errorf("audio sample %d: %d bytes at %d do not hold the frame", i, size, offset)
+		}
+		offset += size
+	}
+	if offset != len(seg) {
+		t.Errorf("samples end at %d of %d bytes", offset, len(seg))
+	}
+
+	// A later fragment starts at the decode time of its first samples.
+	later := fragment(8, []live.Packet{video[5], audio[40]}, packets[0].Codecs, end)
+	trafs = children(t, path(t, later, "moof"))["traf"]
+	if dt := binary.BigEndian.Uint64(path(t, trafs[0], "tfdt")[4:]); int64(dt) != ticks(video[5].DTS, videoTimescale) {
+		t.Errorf("later video decode time %d, want %d", dt, ticks(video[5].DTS, videoTimescale))
+	}
+	if dt := binary.BigEndian.Uint64(path(t, trafs[1], "tfdt")[4:]); int64(dt) != ticks(audio[40].DTS, 44100) {
+		t.Errorf("later audio decode time %d, want %d", dt, ticks(audio[40].DTS, 44100))
+	}
+}
@@ -0,0 +1,360 @@
+// Package livehls packages live broadcasts for HLS playback without
+// re-encoding: access units are cut into MPEG-TS or fMP4 segments on
+// keyframes and listed in a sliding-window playlist, optionally reaching back
+// over a DVR window kept in storage.
+package livehls
+
+import (
+	"VideoUploadService/live"
+	"VideoUploadService/storage"
+	"context"
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrNotLive    = errors.New("channel is not live")
+	ErrStarting   = errors.New("broadcast has no segments yet")
+	ErrNotFound   = errors.New("segment not found")
+	ErrBadFormat  = errors.New("unknown live segment format")
+	ErrBadRequest = errors.New("invalid playlist request")
+	ErrTimeout    = errors.New("playlist update did not arrive in time")
+)
+
+// Format is the container of live segments.
+type Format string
+
+const (
+	FormatTS   Format = "ts"
+	FormatFMP4 Format = "fmp4"
+)
+
+// ParseFormat parses a format name; the empty string selects MPEG-TS.
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(s)); f {
+	case "", FormatTS:
+		return FormatTS, nil
+	case FormatFMP4:
+		return f, nil
+	}
+	return "", ErrBadFormat
+}
+
+type Config struct {
+	Format Format
+	// TargetDuration is the segment duration aimed for. Segments are cut
+	// on the first keyframe after it, so sparse keyframes make them longer.
+	TargetDuration time.Duration
+	// Window is the number of segments in the live playlist.
+	Window int
+	// LowLatencyTarget replaces TargetDuration for broadcasts in the low
+	// latency mode.
+	LowLatencyTarget time.Duration
+	// PartTarget is the duration of the partial segments of broadcasts in
+	// the low latency mode, which are played as Low-Latency HLS. Zero
+	// disables partial segments.
+	PartTarget time.Duration
+	// Linger is how long the playlist of an ended broadcast stays
+	// available, so that players can play out its last segments.
+	Linger time.Duration
+	// DVRWindow is how far back viewers can rewind a broadcast. Segments
+	// behind the live edge are kept in storage, and the playlist grows from
+	// the start of the broadcast until it outlasts the window. Zero plays
+	// only the live Window.
+	DVRWindow time.Duration
+	// Retention is how long stored segments are kept, recordings included;
+	// zero keeps them. It bounds the DVR window.
+	Retention time.Duration
+}
+
+var DefaultConfig = Config{
+	Format:           FormatTS,
+	TargetDuration:   2 * time.Second,
+	LowLatencyTarget: time.Second,
+	PartTarget:       time.Second / 3,
+	Window:           6,
+	Linger:           time.Minute,
+}
+
+const (
+	// retainExtra is how many segments are kept beyond the playlist window
+	// for players that are a little behind.
+	retainExtra = 3
+	// packetBuffer is how many packets may queue up for a broadcast before
+	// the packager is dropped as too slow.
+	packetBuffer = 4096
+	// partSegments is how many of the last segments are listed with their
+	// parts.
+	partSegments = 3
+	// blockingTargets is how many target durations a blocking playlist or
+	// part request may wait.
+	blockingTargets = 3
+)
+
+// Packager packages the live broadcasts of a hub, keeping the segments of
+// each in memory. Broadcasts with recording enabled are also kept in
+// storage.
+type Packager struct {
+	cfg   Config
+	store storage.Storage
+
+	mu         sync.Mutex
+	broadcasts map[string]*broadcast
+	onRecorded []func(Recording)
+}
+
+// Default is set up in main.
+var Default *Packager
+
+func New(cfg Config) *Packager {
+	return &Packager{cfg: cfg, broadcasts: make(map[string]*broadcast)}
+}
+
+// SetStorage sets where recorded broadcasts are kept. Without it nothing is
+// recorded.
+func (p *Packager) SetStorage(store storage.Storage) {
+	p.store = store
+}
+
+// OnRecorded registers fn to be called with the recording of every recorded
+// broadcast once it has ended.
+func (p *Packager) OnRecorded(fn func(Recording)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.onRecorded = append(p.onRecorded, fn)
+}
+
+// Start packages a broadcast until it ends. It is meant to be registered
+// with live.Hub.OnStart.
+func (p *Packager) Start(s *live.Stream) {
+	cfg := p.cfg
+	if s.Settings.Latency == live.LatencyLow {
+		if cfg.LowLatencyTarget > 0 {
+			cfg.TargetDuration = min(cfg.TargetDuration, cfg.LowLatencyTarget)
+		}
+	} else {
+		cfg.PartTarget = 0
+	}
+	b := newBroadcast(s, cfg)
+	if p.store != nil {
+		b.dvr = cfg.DVRWindow
+		if cfg.Retention > 0 {
+			b.dvr = min(b.dvr, cfg.Retention)
+		}
+		if s.Settings.Record {
+			b.rec = newRecorder(p.store, s)
+		}
+		if b.dvr > 0 || b.rec != nil {
+			b.store = p.store
+		}
+	} else if s.Settings.Record {
+		log.Printf("Live %s: recording requested but no storage is configured", s.ID)
+	}
+	p.mu.Lock()
+	p.broadcasts[s.Channel] = b
+	p.mu.Unlock()
+
+	go p.run(b, s.Subscribe(packetBuffer))
+}
+
+// run feeds a broadcast's packets to b. A packager that falls behind
+// resubscribes and continues after a discontinuity rather than ending the
+// broadcast for its viewers.
+func (p *Packager) run(b *broadcast, sub *live.Subscription) {
+	s := b.stream
+	for {
+		for pkt := range sub.Packets() {
+			b.write(pkt)
+		}
+		if !errors.Is(sub.Err(), live.ErrSlowSubscriber) || ended(s) {
+			break
+		}
+		log.Printf("Live %s: packager fell behind, resuming after a discontinuity", s.ID)
+		b.discontinue()
+		sub = s.Subscribe(packetBuffer)
+	}
+	b.finish()
+	if b.rec != nil {
+		rec := b.rec.finish()
+		p.mu.Lock()
+		hooks := append([]func(Recording){}, p.onRecorded...)
+		p.mu.Unlock()
+		for _, fn := range hooks {
+			fn(rec)
+		}
+	}
+	time.AfterFunc(p.cfg.Linger, func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if p.broadcasts[s.Channel] == b {
+			delete(p.broadcasts, s.Channel)
+		}
+	})
+}
+
+func ended(s *live.Stream) bool {
+	select {
+	case <-s.Done():
+		return true
+	default:
+		return false
+	}
+}
+
+func (p *Packager) broadcast(channel string) (*broadcast, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	b, ok := p.broadcasts[channel]
+	if !ok {
+		return nil, ErrNotLive
+	}
+	return b, nil
+}
+
+// PlaylistRequest holds the options a player may add to a playlist
+// request.
+type PlaylistRequest struct {
+	// Start, if set, asks players to begin this many seconds from the start
+	// of the playlist, or from its end if negative.
+	Start *float64
+	// MSN and Part make a blocking request, answered once the playlist has
+	// the segment with media sequence number MSN or, with Part, that part
+	// of it.
+	MSN  *int
+	Part *int
+	// Skip asks for a delta update that leaves out older segments.
+	Skip bool
+}
+
+// Playlist returns the live media playlist of a channel. Segment URIs are
+// relative to it, of the form "<broadcast>/<name>". Blocking requests wait
+// for the playlist to catch up, up to a few target durations.
+func (p *Packager) Playlist(ctx context.Context, channel string, req PlaylistRequest) ([]byte, error) {
+	if req.Part != nil && req.MSN == nil || req.MSN != nil && *req.MSN < 0 || req.Part != nil && *req.Part < 0 {
+		return nil, ErrBadRequest
+	}
+	b, err := p.broadcast(channel)
+	if err != nil {
+		return nil, err
+	}
+	if req.MSN != nil {
+		if err := b.waitPlaylist(ctx, *req.MSN, req.Part); err != nil {
+			return nil, err
+		}
+	}
+	return b.playlist(req)
+}
+
+// Segment returns a segment, part or initialization segment of a channel's
+// broadcast by the name used in its playlist. A request for the next part,
+// as announced by the preload hint, waits for it.
+func (p *Packager) Segment(ctx context.Context, channel, broadcastID, name string) ([]byte, error) {
+	b, err := p.broadcast(channel)
+	if err != nil {
+		return nil, err
+	}
+	if b.stream.ID != broadcastID {
+		return nil, ErrNotFound
+	}
+	if id, ok := strings.CutPrefix(name, "init-"); ok {
+		n, err := strconv.Atoi(strings.TrimSuffix(id, ".mp4"))
+		if err != nil {
+			return nil, ErrNotFound
+		}
+		return b.mapData(n)
+	}
+	base, ok := strings.CutSuffix(name, b.ext())
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if s, pt, ok := strings.Cut(base, "."); ok {
+		seq, err := strconv.Atoi(s)
+		index, err2 := strconv.Atoi(pt)
+		if err != nil || err2 != nil {
+			return nil, ErrNotFound
+		}
+		return b.part(ctx, seq, index)
+	}
+	seq, err := strconv.Atoi(base)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+	return b.segment(seq)
+}
+
+// RunRetention deletes expired stored broadcasts every interval. It does not
+// return.
+func (p *Packager) RunRetention(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		if err := p.Sweep(context.Background(), now); err != nil {
+			log.Printf("Sweeping stored broadcasts: %v", err)
+		}
+	}
+}
+
+// Sweep deletes the stored segments and recordings of broadcasts that were
+// last written to more than Retention before now. Broadcasts still being
+// packaged are kept whatever their age.
+func (p *Packager) Sweep(ctx context.Context, now time.Time) error {
+	if p.store == nil || p.cfg.Retention <= 0 {
+		return nil
+	}
+	keys, err := p.store.List(ctx, "live")
+	if err != nil {
+		return err
+	}
+	// Group the keys by broadcast, "live/<channel>/<broadcast>/".
+	stored := make(map[string][]string)
+	for _, key := range keys {
+		parts := strings.SplitN(key, "/", 4)
+		if len(parts) < 4 {
+			continue
+		}
+		dir := StoragePrefix(parts[1]) + parts[2] + "/"
+		stored[dir] = append(stored[dir], key)
+	}
+	p.mu.Lock()
+	for _, b := range p.broadcasts {
+		delete(stored, StoragePrefix(b.stream.Channel)+b.stream.ID+"/")
+	}
+	p.mu.Unlock()
+
+	cutoff := now.Add(-p.cfg.Retention)
+	for dir, keys := range stored {
+		if !p.olderThan(ctx, keys, cutoff) {
+			continue
+		}
+		for _, key := range keys {
+			if err := p.store.Delete(ctx, key); err != nil && !errors.Is(err, storage.ErrNotFound) {
+				log.Printf("Deleting %s: %v", key, err)
+			}
+		}
+		log.Printf("Deleted stored broadcast %s after retention of %s", dir, p.cfg.Retention)
+	}
+	return nil
+}
+
+// olderThan reports whether all objects were last modified before t.
+func (p *Packager) olderThan(ctx context.Context, keys []string, t time.Time) bool {
+	for _, key := range keys {
+		obj, err := p.store.Open(ctx, key)
+		if errors.Is(err, storage.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return false
+		}
+		modified := obj.ModTime()
+		obj.Close()
+		if !modified.Before(t) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,144 @@
+package livehls
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// programDateTime is the EXT-X-PROGRAM-DATE-TIME layout.
+const programDateTime = "2006-01-02T15:04:05.000Z07:00"
+
+// playlist renders the live media playlist of the last Window segments, or
+// of all segments in the DVR window.
+func (b *broadcast) playlist(req PlaylistRequest) ([]byte, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.segments) == 0 {
+		if b.ended {
+			return nil, ErrNotLive
+		}
+		return nil, ErrStarting
+	}
+	first := max(len(b.segments)-b.cfg.Window, 0)
+	if b.dvr > 0 {
+		// Event-style, but without EXT-X-PLAYLIST-TYPE:EVENT: event
+		// playlists may never drop segments, and DVR playlists do once the
+		// broadcast outlasts the window.
+		first = 0
+	}
+	discontinuities := b.evictedDiscontinuities
+	for _, seg := range b.segments[:first] {
+		if seg.discontinuity {
+			discontinuities++
+		}
+	}
+	window := b.segments[first:]
+	lowLatency := b.cfg.PartTarget > 0
+	skipUntil := 6 * time.Duration(b.targetDuration) * time.Second
+	skipped := 0
+	if lowLatency && req.Skip {
+		// Delta updates leave out the segments that end more than
+		// CAN-SKIP-UNTIL before the end of the playlist.
+		last := window[len(window)-1]
+		for skipped < len(window)-1 && last.start+last.duration-(window[skipped].start+window[skipped].duration) >= skipUntil {
+			skipped++
+		}
+	}
+
+	var sb strings.Builder
+	version := 3
+	switch {
+	case lowLatency:
+		version = 9
+	case b.cfg.Format == FormatFMP4:
+		version = 6
+	}
+	fmt.Fprintf(&sb, "#EXTM3U\n#EXT-X-VERSION:%d\n", version)
+	fmt.Fprintf(&sb, "#EXT-X-TARGETDURATION:%d\n", b.targetDuration)
+	if lowLatency {
+		fmt.Fprintf(&sb, "#EXT-X-SERVER-CONTROL:CAN-BLOCK-RELOAD=YES,CAN-SKIP-UNTIL=%.1f,PART-HOLD-BACK=%.3f\n",
+			skipUntil.Seconds(), 3*b.cfg.PartTarget.Seconds())
+		fmt.Fprintf(&sb, "#EXT-X-PART-INF:PART-TARGET=%.3f\n", b.cfg.PartTarget.Seconds())
+	}
+	fmt.Fprintf(&sb, "#EXT-X-MEDIA-SEQUENCE:%d\n", window[0].seq)
+	if discontinuities > 0 {
+		fmt.Fprintf(&sb, "#EXT-X-DISCONTINUITY-SEQUENCE:%d\n", discontinuities)
+	}
+	if req.Start != nil {
+		fmt.Fprintf(&sb, "#EXT-X-START:TIME-OFFSET=%.3f\n", *req.Start)
+	}
+	if skipped > 0 {
+		fmt.Fprintf(&sb, "#EXT-X-SKIP:SKIPPED-SEGMENTS=%d\n", skipped)
+	}
+	var prev *segment
+	for i, seg := range window[skipped:] {
+		b.writeSegmentTags(&sb, seg, prev)
+		if lowLatency && skipped+i >= len(window)-partSegments {
+			b.writeParts(&sb, seg)
+		}
+		fmt.Fprintf(&sb, "#EXTINF:%.3f,\n%s\n", seg.duration.Seconds(), b.segmentURI(seg.seq))
+		prev = seg
+	}
+	if b.ended {
+		sb.WriteString("#EXT-X-ENDLIST\n")
+	} else if lowLatency {
+		// The parts of the segment being assembled follow the last
+		// segment, and a hint names the part to come.
+		seq, index := window[len(window)-1].seq+1, 0
+		if seg := b.building; seg != nil {
+			b.writeSegmentTags(&sb, seg, prev)
+			b.writeParts(&sb, seg)
+			seq, index = seg.seq, len(seg.parts)
+		}
+		fmt.Fprintf(&sb, "#EXT-X-PRELOAD-HINT:TYPE=PART,URI=\"%s\"\n", b.partURI(seq, index))
+	}
+	return []byte(sb.String()), nil
+}
+
+// writeSegmentTags writes the tags that precede a segment listed after
+// prev, which is nil for the first.
+func (b *broadcast) writeSegmentTags(sb *strings.Builder, seg, prev *segment) {
+	if seg.discontinuity && prev != nil {
+		sb.WriteString("#EXT-X-DISCONTINUITY\n")
+	}
+	if prev == nil || seg.discontinuity {
+		fmt.Fprintf(sb, "#EXT-X-PROGRAM-DATE-TIME:%s\n", b.stream.StartedAt.Add(seg.start).Format(programDateTime))
+	}
+	if seg.init != nil && (prev == nil || seg.init != prev.init) {
+		fmt.Fprintf(sb, "#EXT-X-MAP:URI=\"%s/init-%d.mp4\"\n", b.stream.ID, seg.init.id)
+	}
+}
+
+func (b *broadcast) writeParts(sb *strings.Builder, seg *segment) {
+	for i, pt := range seg.parts {
+		fmt.Fprintf(sb, "#EXT-X-PART:DURATION=%.3f,URI=\"%s\"", pt.duration.Seconds(), b.partURI(seg.seq, i))
+		if pt.independent {
+			sb.WriteString(",INDEPENDENT=YES")
+		}
+		sb.WriteString("\n")
+	}
+}
+
+// segmentURI is the URI of a segment relative to the playlist.
+func (b *broadcast) segmentURI(seq int) string {
+	return b.stream.ID + "/" + b.segmentName(seq)
+}
+
+// partURI is the URI of a part relative to the playlist.
+func (b *broadcast) partURI(seq, index int) string {
+	return fmt.Sprintf("%s/%d.%d%s", b.stream.ID, seq, index, b.ext())
+}
+
+// segmentName is the name of a segment within its broadcast.
+func (b *broadcast) segmentName(seq int) string {
+	return fmt.Sprintf("%d%s", seq, b.ext())
+}
+
+// ext is the file extension of media segments and parts.
+func (b *broadcast) ext() string {
+	if b.cfg.Format == FormatFMP4 {
+		return ".m4s"
+	}
+	return ".ts"
+}
@@ -0,0 +1,166 @@
+package livehls
+
+import (
+	"VideoUploadService/live"
+	"VideoUploadService/storage"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+var ErrNoRecording = errors.New("recording not found")
+
+// indexInterval is how many recorded segments may be added before the
+// recording index is saved again, bounding what a crash loses.
+const indexInterval = 30
+
+// Recording lists the stored segments of a recorded broadcast. Segments are
+// always kept as MPEG-TS, whatever the live format, so that they can be
+// concatenated into a single file.
+type Recording struct {
+	BroadcastID string            `json:"broadcast_id"`
+	Channel     string            `json:"channel"`
+	Owner       string            `json:"owner"`
+	StartedAt   time.Time         `json:"started_at"`
+	Ended       bool              `json:"ended"`
+	Duration    float64           `json:"duration_seconds"`
+	Segments    []RecordedSegment `json:"segments,omitempty"`
+	// Tier is the account tier of the owner when the broadcast started.
+	Tier string `json:"tier,omitempty"`
+}
+
+type RecordedSegment struct {
+	Key string `json:"key"`
+	// Start is the offset of the segment from the start of the broadcast.
+	Start    float64 `json:"start_seconds"`
+	Duration float64 `json:"duration_seconds"`
+}
+
+// StoragePrefix is the storage prefix of the stored broadcasts of a
+// channel, each below a directory named by its ID.
+func StoragePrefix(channel string) string {
+	return "live/" + channel + "/"
+}
+
+func recordingIndex(channel, broadcastID string) string {
+	return StoragePrefix(channel) + broadcastID + "/recording.json"
+}
+
+// LoadRecording reads the index of a recorded broadcast.
+func LoadRecording(ctx context.Context, store storage.Storage, channel, broadcastID string) (Recording, error) {
+	if channel == "" || broadcastID == "" || strings.Contains(broadcastID, "/") {
+		return Recording{}, ErrNoRecording
+	}
+	obj, err := store.Open(ctx, recordingIndex(channel, broadcastID))
+	if errors.Is(err, storage.ErrNotFound) || errors.Is(err, storage.ErrInvalidKey) {
+		return Recording{}, ErrNoRecording
+	}
+	if err != nil {
+		return Recording{}, err
+	}
+	defer obj.Close()
+	var rec Recording
+	if err := json.NewDecoder(obj).Decode(&rec); err != nil {
+		return Recording{}, fmt.Errorf("read recording index: %w", err)
+	}
+	return rec, nil
+}
+
+// ListRecordings returns the recordings of a channel, newest first.
+func ListRecordings(ctx context.Context, store storage.Storage, channel string) ([]Recording, error) {
+	if channel == "" {
+		return nil, nil
+	}
+	keys, err := store.List(ctx, StoragePrefix(channel))
+	if errors.Is(err, storage.ErrInvalidKey) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	recs := []Recording{}
+	for _, key := range keys {
+		rest := strings.TrimPrefix(key, StoragePrefix(channel))
+		id, name, ok := strings.Cut(rest, "/")
+		if !ok || name != "recording.json" {
+			continue
+		}
+		rec, err := LoadRecording(ctx, store, channel, id)
+		if err != nil {
+			log.Printf("Loading recording %s: %v", key, err)
+			continue
+		}
+		recs = append(recs, rec)
+	}
+	sort.Slice(recs, func(i, j int) bool { return recs[i].StartedAt.After(recs[j].StartedAt) })
+	return recs, nil
+}
+
+// recorder indexes the stored segments of a recorded broadcast.
+type recorder struct {
+	store storage.Storage
+	// ts muxes the recorded copy of fMP4 broadcasts.
+	ts  *tsMuxer
+	rec Recording
+}
+
+func newRecorder(store storage.Storage, s *live.Stream) *recorder {
+	return &recorder{
+		store: store,
+		ts:    newTSMuxer(),
+		rec: Recording{
+			BroadcastID: s.ID,
+			Channel:     s.Channel,
+			Owner:       s.Owner,
+			StartedAt:   s.StartedAt,
+			Tier:        s.Tier,
+		},
+	}
+}
+
+// add adds a segment stored under key to the recording.
+func (r *recorder) add(seg *segment, key string) {
+	r.rec.Segments = append(r.rec.Segments, RecordedSegment{
+		Key:      key,
+		Start:    seg.start.Seconds(),
+		Duration: seg.duration.Seconds(),
+	})
+	r.rec.Duration = (seg.start + seg.duration).Seconds()
+	if len(r.rec.Segments)%indexInterval == 0 {
+		r.save()
+	}
+}
+
+// finish saves the final index of the recording and returns it.
+func (r *recorder) finish() Recording {
+	r.rec.Ended = true
+	r.save()
+	return r.rec
+}
+
+func (r *recorder) save() {
+	data, err := json.Marshal(r.rec)
+	if err == nil {
+		err = put(r.store, recordingIndex(r.rec.Channel, r.rec.BroadcastID), data)
+	}
+	if err != nil {
+		log.Printf("Live %s: saving recording index: %v", r.rec.BroadcastID, err)
+	}
+}
+
+func put(store storage.Storage, key string, data []byte) error {
+	w, err := store.Create(context.Background(), key)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
@@ -0,0 +1,227 @@
+package livehls
+
+import (
+	"VideoUploadService/live"
+	"bytes"
+)
+
+const tsPacketSize = 188
+
+// PIDs and stream types of the single program live segments carry.
+const (
+	pmtPID   = 0x1000
+	videoPID = 0x100
+	audioPID = 0x101
+
+	streamTypeH264 = 0x1b
+	streamTypeAAC  = 0x0f
+
+	streamIDVideo = 0xe0
+	streamIDAudio = 0xc0
+)
+
+// tsMuxer writes MPEG-TS segments. Continuity counters carry over from one
+// segment to the next, so players see one continuous transport stream.
+type tsMuxer struct {
+	cc map[uint16]byte
+}
+
+func newTSMuxer() *tsMuxer {
+	return &tsMuxer{cc: make(map[uint16]byte)}
+}
+
+// segment muxes packets into a segment starting with a PAT and PMT. Video
+// access units are converted to Annex B with parameter sets in front of
+// keyframes; AAC frames get ADTS headers.
+func (m *tsMuxer) segment(packets []live.Packet, codecs *live.Codecs) []byte {
+	var buf bytes.Buffer
+	hasVideo, hasAudio := codecs.Video != nil, codecs.Audio != nil
+	m.psi(&buf, 0, patSection())
+	m.psi(&buf, pmtPID, pmtSection(hasVideo, hasAudio))
+	for _, p := range packets {
+		switch {
+		case p.Kind == live.Video && p.Codecs.Video != nil && hasVideo:
+			es := annexB(p.Data, p.Codecs.Video, p.Keyframe)
+			m.pes(&buf, videoPID, streamIDVideo, ticks(p.PTS, 90000), ticks(p.DTS, 90000), true, p.Keyframe, es)
+		case p.Kind == live.Audio && p.Codecs.Audio != nil && hasAudio:
+			es := append(adtsHeader(p.Codecs.Audio, len(p.Data)), p.Data...)
+			// Audio carries the clock of audio-only streams.
+			m.pes(&buf, audioPID, streamIDAudio, ticks(p.PTS, 90000), -1, !hasVideo, true, es)
+		}
+	}
+	return buf.Bytes()
+}
+
+func (m *tsMuxer) counter(pid uint16) byte {
+	cc := m.cc[pid]
+	m.cc[pid] = (cc + 1) & 0x0f
+	return cc
+}
+
+// psi writes a PSI section in a single TS packet.
+func (m *tsMuxer) psi(buf *bytes.Buffer, pid uint16, section []byte) {
+	pkt := bytes.Repeat([]byte{0xff}, tsPacketSize)
+	pkt[0] = 0x47
+	pkt[1] = 0x40 | byte(pid>>8)
+	pkt[2] = byte(pid)
+	pkt[3] = 0x10 | m.counter(pid)
+	pkt[4] = 0 // pointer field
+	copy(pkt[5:], section)
+	buf.Write(pkt)
+}
+
+// pes writes one PES packet split across TS packets. dts is omitted when
+// negative. The first TS packet carries the PCR if pcr is set and the
+// random access indicator for keyframes.
+func (m *tsMuxer) pes(buf *bytes.Buffer, pid uint16, streamID byte, pts, dts int64, pcr, randomAccess bool, data []byte) {
+	header := []byte{0, 0, 1, streamID, 0, 0, 0x80, 0x80, 5}
+	if dts >= 0 && dts != pts {
+		header[7], header[8] = 0xc0, 10
+		header = append(header, timestamp(0x3, pts)...)
+		header = append(header, timestamp(0x1, dts)...)
+	} else {
+		header = append(header, timestamp(0x2, pts)...)
+		dts = pts
+	}
+	// Video PES packets are usually too large to give a length, which is
+	// allowed for video only.
+	if n := len(header) - 6 + len(data); streamID != streamIDVideo && n <= 0xffff {
+		header[4], header[5] = byte(n>>8), byte(n)
+	}
+	payload := append(header, data...)
+
+	first := true
+	for len(payload) > 0 {
+		var af []byte // adaptation field after its length byte
+		if first && (pcr || randomAccess) {
+			flags := byte(0)
+			if randomAccess {
+				flags |= 0x40
+			}
+			af = append(af, flags)
+			if pcr {
+				af[0] |= 0x10
+				af = append(af, pcrBytes(dts)...)
+			}
+		}
+		hasAF := af != nil
+		avail := tsPacketSize - 4
+		if hasAF {
+			avail -= 1 + len(af)
+		}
+		if len(payload) < avail {
+			// Pad the last packet with adaptation field stuffing.
+			stuff := avail - len(payload)
+			if !hasAF {
+				hasAF = true
+				stuff--
+				if stuff > 0 {
+					af = []byte{0}
+					stuff--
+				}
+			}
+			af = append(af, bytes.Repeat([]byte{0xff}, stuff)...)
+			avail = len(payload)
+		}
+
+		control := byte(0x10)
+		if hasAF {
+			control |= 0x20
+		}
+		start := byte(0)
+		if first {
+			start = 0x40
+		}
+		buf.Write([]byte{0x47, start | byte(pid>>8), byte(pid), control | m.counter(pid)})
+		if hasAF {
+			buf.WriteByte(byte(len(af)))
+			buf.Write(af)
+		}
+		buf.Write(payload[:avail])
+		payload = payload[avail:]
+		first = false
+	}
+}
+
+func patSection() []byte {
+	return psiSection(0x00, []byte{
+		0x00, 0x01, // program number
+		0xe0 | pmtPID>>8, pmtPID & 0xff,
+	})
+}
+
+func pmtSection(hasVideo, hasAudio bool) []byte {
+	pcrPID := videoPID
+	if !hasVideo {
+		pcrPID = audioPID
+	}
+	body := []byte{
+		0xe0 | byte(pcrPID>>8), byte(pcrPID),
+		0xf0, 0x00, // no program descriptors
+	}
+	if hasVideo {
+		body = append(body, streamTypeH264, 0xe0|videoPID>>8, videoPID&0xff, 0xf0, 0x00)
+	}
+	if hasAudio {
+		body = append(body, streamTypeAAC, 0xe0|audioPID>>8, audioPID&0xff, 0xf0, 0x00)
+	}
+	return psiSection(0x02, body)
+}
+
+// psiSection wraps a PAT or PMT body of program or transport stream 1 in
+// a section with a CRC.
+func psiSection(tableID byte, body []byte) []byte {
+	n := 5 + len(body) + 4
+	s := []byte{
+		tableID, 0xb0 | byte(n>>8), byte(n),
+		0x00, 0x01, // transport stream or program number
+		0xc1,       // version 0, current
+		0x00, 0x00, // section numbers
+	}
+	s = append(s, body...)
+	crc := crc32MPEG(s)
+	return append(s, byte(crc>>24), byte(crc>>16), byte(crc>>8), byte(crc))
+}
+
+// timestamp encodes a 33-bit PTS or DTS with a 4-bit prefix.
+func timestamp(prefix byte, ts int64) []byte {
+	ts &= 1<<33 - 1
+	return []byte{
+		prefix<<4 | byte(ts>>29)&0x0e | 1,
+		byte(ts >> 22),
+		byte(ts>>14)&0xfe | 1,
+		byte(ts >> 7),
+		byte(ts<<1)&0xfe | 1,
+	}
+}
+
+// pcrBytes encodes a program clock reference with a zero extension.
+func pcrBytes(base int64) []byte {
+	base &= 1<<33 - 1
+	return []byte{byte(base >> 25), byte(base >> 17), byte(base >> 9), byte(base >> 1), byte(base&1)<<7 | 0x7e, 0x00}
+}
+
+var crcTable = func() [256]uint32 {
+	var t [256]uint32
+	for i := range t {
+		c := uint32(i) << 24
+		for j := 0; j < 8; j++ {
+			if c&0x80000000 != 0 {
+				c = c<<1 ^ 0x04c11db7
+			} else {
+				c <<= 1
+			}
+		}
+		t[i] = c
+	}
+	return t
+}()
+
+// crc32MPEG is the CRC-32/MPEG-2 of PSI sections.
+func crc32MPEG(data []byte) uint32 {
+	crc := uint32(0xffffffff)
+	for _, b := range data {
+		crc = crc<<8 ^ crcTable[byte(crc>>24)^b]
+	}
+	return crc
+}
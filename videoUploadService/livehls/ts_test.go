@@ -0,0 +1,273 @@
+package livehls
+
+import (
+	"VideoUploadService/flv"
+	"VideoUploadService/live"
+	"VideoUploadService/rtmp"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"testing"
+	"time"
+)
+
+// publishPackets publishes ../live/testdata/publish.flv and returns the
+// packets of the broadcast. It holds H.264 at 10 fps with a keyframe every
+// 5 frames and AAC, both starting at 1000ms. A frame precedes the first
+// sequence header, and a new H.264 sequence header precedes frame 5.
+func publishPackets(t *testing.T) []live.Packet {
+	t.Helper()
+	orig := live.Authenticate
+	live.Authenticate = func(ctx context.Context, key string) (live.Channel, error) {
+		return live.Channel{ID: "ch", Owner: "ch"}, nil
+	}
+	defer func() { live.Authenticate = orig }()
+	hub := live.NewHub()
+	var sub *live.Subscription
+	hub.OnStart(func(s *live.Stream) { sub = s.Subscribe(256) })
+	pub, err := live.Ingest{Hub: hub}.Publish(context.Background(), rtmp.PublishRequest{App: live.App, Name: "key"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open("../live/testdata/publish.flv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	r, err := flv.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for {
+		tag, err := r.ReadTag()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := pub.WriteTag(tag); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pub.Close(nil)
+	var packets []live.Packet
+	for p := range sub.Packets() {
+		packets = append(packets, p)
+	}
+	return packets
+}
+
+func TestCRC32MPEG(t *testing.T) {
+	if got := crc32MPEG([]byte("123456789")); got != 0x0376e6e7 {
+		t.Errorf("crc32MPEG(123456789) = %#08x, want 0x0376e6e7", got)
+	}
+	// A section followed by its CRC checks to zero.
+	if got := crc32MPEG(patSection()); got != 0 {
+		t.Errorf("CRC over the PAT and its CRC = %#08x, want 0", got)
+	}
+}
+
+// parseTimestamp decodes a PTS or DTS written by timestamp.
+func parseTimestamp(b []byte) int64 {
+	return int64(b[0]>>1&7)<<30 | int64(b[1])<<22 | int64(b[2]>>1)<<15 | int64(b[3])<<7 | int64(b[4]>>1)
+}
+
+func TestTimestamp(t *testing.T) {
+	for _, ts := range []int64{0, 1, 90000, 1<<32 + 12345, 1<<33 - 1} {
+		b := timestamp(0x3, ts)
+		if b[0]>>4 != 0x3 || b[0]&1 != 1 || b[2]&1 != 1 || b[4]&1 != 1 {
+			t.Errorf("timestamp(%d) = % x: wrong prefix or marker bits", ts, b)
+		}
+		if got := parseTimestamp(b); got != ts {
+			t.Errorf("timestamp(%d) decodes to %d", ts, got)
+		}
+	}
+	// Timestamps wrap at 33 bits.
+	if got := parseTimestamp(timestamp(0x2, 1<<33+5)); got != 5 {
+		t.Errorf("timestamp(2^33+5) decodes to %d, want 5", got)
+	}
+}
+
+func TestPCRBytes(t *testing.T) {
+	for _, base := range []int64{0, 90000, 1<<33 - 1} {
+		b := pcrBytes(base)
+		got := int64(b[0])<<25 | int64(b[1])<<17 | int64(b[2])<<9 | int64(b[3])<<1 | int64(b[4]>>7)
+		if got != base || b[4]&0x7e != 0x7e || b[4]&1 != 0 || b[5] != 0 {
+			t.Errorf("pcrBytes(%d) = % x", base, b)
+		}
+	}
+}
+
+// pesUnit is a PES packet reassembled from a transport stream.
+type pesUnit struct {
+	pid          uint16
+	randomAccess bool
+	pcr          int64 // -1 without a PCR
+	data         []byte
+}
+
+// demux splits a segment into its PSI sections and PES packets, checking
+// the packet structure and continuity counters on the way.
+func demux(t *testing.T, seg []byte, cc map[uint16]int) (psi map[uint16][]byte, pes []*pesUnit) {
+	t.Helper()
+	if len(seg)%tsPacketSize != 0 {
+		t.Fatalf("segment of %d bytes is not made of TS packets", len(seg))
+	}
+	psi = make(map[uint16][]byte)
+	open := make(map[uint16]*pesUnit)
+	for off := 0; off < len(seg); off += tsPacketSize {
+		pkt := seg[off : off+tsPacketSize]
+		if pkt[0] != 0x47 {
+			t.Fatalf("packet at %d has no sync byte", off)
+		}
+		pid := uint16(pkt[1]&0x1f)<<8 | uint16(pkt[2])
+		start := pkt[1]&0x40 != 0
+		if want, ok := cc[pid]; ok && int(pkt[3]&0x0f) != want {
+			t.Fatalf("PID %#x continuity counter %d, want %d", pid, pkt[3]&0x0f, want)
+		}
+		cc[pid] = int(pkt[3]+1) & 0x0f
+
+		payload := pkt[4:]
+		unit := &pesUnit{pid: pid, pcr: -1}
+		if pkt[3]&0x20 != 0 {
+			af := payload[1 : 1+payload[0]]
+			payload = payload[1+payload[0]:]
+			if len(af) > 0 {
+				unit.randomAccess = af[0]&0x40 != 0
+				if af[0]&0x10 != 0 {
+					unit.pcr = int64(af[1])<<25 | int64(af[2])<<17 | int64(af[3])<<9 | int64(af[4])<<1 | int64(af[5]>>7)
+				}
+			}
+		}
+		switch {
+		case pid == 0 || pid == pmtPID:
+			n := int(payload[2]&0x0f)<<8 | int(payload[3])
+			psi[pid] = payload[1 : 4+n]
+		case start:
+			unit.data = append([]byte(nil), payload...)
+			open[pid] = unit
+			pes = append(pes, unit)
+		case open[pid] != nil:
+			open[pid].data = append(open[pid].data, payload...)
+		default:
+			t.Fatalf("PID %#x continues a PES packet that never started", pid)
+		}
+	}
+	return psi, pes
+}
+
+func TestTSSegment(t *testing.T) {
+	packets := publishPackets(t)
+	var video, audio []live.Packet
+	for _, p := range packets {
+		if p.Kind == live.Video {
+			video = append(video, p)
+		} else {
+			audio = append(audio, p)
+		}
+	}
+	if len(video) != 10 || len(audio) != 44 {
+		t.Fatalf("published %d video and %d audio packets, want 10 and 44", len(video), len(audio))
+	}
+
+	m := newTSMuxer()
+	cc := make(map[uint16]int)
+	psi, pes := demux(t, m.segment(packets, packets[0].Codecs), cc)
+	for pid, section := range psi {
+		if crc32MPEG(section) != 0 {
+			t.Errorf("PSI section of PID %#x fails its CRC", pid)
+		}
+	}
+	if want := pmtSection(true, true); !bytes.Equal(psi[pmtPID], want) {
+		t.Errorf("PMT = % x, want % x", psi[pmtPID], want)
+	}
+
+	var gotVideo, gotAudio int
+	for _, u := range pes {
+		h := u.data
+		if !bytes.HasPrefix(h, []byte{0, 0, 1}) {
+			t.Fatalf("PES packet of PID %#x without start code", u.pid)
+		}
+		flags, headerLen := h[7], int(h[8])
+		es := h[9+headerLen:]
+		pts := parseTimestamp(h[9:])
+		switch u.pid {
+		case videoPID:
+			p := video[gotVideo]
+			gotVideo++
+			if h[3] != streamIDVideo || h[4] != 0 || h[5] != 0 {
+				t.Errorf("video PES stream %#x length %d, want %#x without a length", h[3], int(h[4])<<8|int(h[5]), streamIDVideo)
+			}
+			dts := pts
+			if p.PTS != p.DTS {
+				if flags != 0xc0 {
+					t.Fatalf("video frame at %s with a composition offset has PTS/DTS flags %#x", p.DTS, flags)
+				}
+				dts = parseTimestamp(h[14:])
+			}
+			if pts != ticks(p.PTS, 90000) || dts != ticks(p.DTS, 90000) {
+				t.Errorf("video frame at %s: PTS %d DTS %d, want %d and %d", p.DTS, pts, dts, ticks(p.PTS, 90000), ticks(p.DTS, 90000))
+			}
+			if u.pcr != dts {
+				t.Errorf("video frame at %s: PCR %d, want its DTS %d", p.DTS, u.pcr, dts)
+			}
+			if u.randomAccess != p.Keyframe {
+				t.Errorf("video frame at %s: random access %v, want %v", p.DTS, u.randomAccess, p.Keyframe)
+			}
+			if !bytes.HasPrefix(es, []byte{0, 0, 0, 1, naluAUD}) {
+				t.Errorf("video frame at %s does not start with a delimiter", p.DTS)
+			}
+			sps := append([]byte{0, 0, 0, 1}, p.Codecs.Video.SPS[0]...)
+			if bytes.Contains(es, sps) != p.Keyframe {
+				t.Errorf("video frame at %s carries the SPS = %v, want %v", p.DTS, !p.Keyframe, p.Keyframe)
+			}
+		case audioPID:
+			p := audio[gotAudio]
+			gotAudio++
+			if n := int(h[4])<<8 | int(h[5]); h[3] != streamIDAudio || n != len(h)-6 {
+				t.Errorf("audio PES stream %#x length %d, want %#x of %d", h[3], n, streamIDAudio, len(h)-6)
+			}
+			if flags != 0x80 || pts != ticks(p.PTS, 90000) {
+				t.Errorf("audio frame at %s: flags %#x PTS %d, want PTS only of %d", p.DTS, flags, pts, ticks(p.PTS, 90000))
+			}
+			if u.pcr != -1 {
+				t.Errorf("audio frame at %s carries a PCR next to video", p.DTS)
+			}
+			if !bytes.Equal(es, append(adtsHeader(p.Codecs.Audio, len(p.Data)), p.Data...)) {
+				t.Errorf("audio frame at %s: payload % x", p.DTS, es)
+			}
+		}
+	}
+	if gotVideo != len(video) || gotAudio != len(audio) {
+		t.Errorf("muxed %d video and %d audio frames, want %d and %d", gotVideo, gotAudio, len(video), len(audio))
+	}
+
+	// The next segment continues the continuity counters.
+	demux(t, m.segment(packets[len(packets)-2:], packets[0].Codecs), cc)
+}
+
+func TestTSSegmentAudioOnly(t *testing.T) {
+	cfg := &flv.AACConfig{ObjectType: 2, SampleRateIndex: 4, SampleRate: 44100, Channels: 2}
+	codecs := &live.Codecs{Audio: cfg}
+	packets := []live.Packet{
+		{Kind: live.Audio, DTS: 0, PTS: 0, Keyframe: true, Data: []byte{1, 2, 3}, Codecs: codecs},
+		{Kind: live.Audio, DTS: 23 * time.Millisecond, PTS: 23 * time.Millisecond, Keyframe: true, Data: bytes.Repeat([]byte{4}, 400), Codecs: codecs},
+	}
+	psi, pes := demux(t, newTSMuxer().segment(packets, codecs), make(map[uint16]int))
+	if want := pmtSection(false, true); !bytes.Equal(psi[pmtPID], want) {
+		t.Errorf("PMT = % x, want % x", psi[pmtPID], want)
+	}
+	if len(pes) != 2 {
+		t.Fatalf("%d PES packets, want 2", len(pes))
+	}
+	// Audio carries the clock without video.
+	for i, u := range pes {
+		if want := ticks(packets[i].DTS, 90000); u.pcr != want {
+			t.Errorf("frame %d: PCR %d, want %d", i, u.pcr, want)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package packager
+
+import "fmt"
+
+// VideoCodecs returns the RFC 6381 codecs string of a video stream encoded
+// with the given ffmpeg encoder or codec name. H.264 is assumed to use the
+// main profile, as the encoder does; the level follows from the picture size
+// and frame rate.
+func VideoCodecs(codec string, height uint32, frameRate float64) string {
+	fast := frameRate > 30
+	switch codec {
+	case "libx265", "hevc", "h265":
+		level := 93 // 3.1
+		switch {
+		case height > 1080:
+			level = 153
+		case height > 720 || fast:
+			level = 120
+		}
+		return fmt.Sprintf("hvc1.1.6.L%d.90", level)
+	case "libvpx-vp9", "vp9":
+		return "vp09.00.40.08"
+	case "libaom-av1", "libsvtav1", "av1":
+		return "av01.0.08M.08"
+	default:
+		level := 0x33 // 5.1
+		switch {
+		case height <= 480:
+			level = 0x1e
+		case height <= 720 && !fast:
+			level = 0x1f
+		case height <= 720:
+			level = 0x20
+		case height <= 1080 && !fast:
+			level = 0x28
+		case height <= 1080:
+			level = 0x2a
+		case height <= 1440:
+			level = 0x32
+		}
+		return fmt.Sprintf("avc1.4d40%02x", level)
+	}
+}
+
+// AudioCodecs returns the RFC 6381 codecs string of an audio stream.
+func AudioCodecs(codec string) string {
+	switch codec {
+	case "":
+		return ""
+	case "mp3", "libmp3lame":
+		return "mp4a.40.34"
+	case "opus", "libopus":
+		return "opus"
+	case "ac3":
+		return "ac-3"
+	case "eac3":
+		return "ec-3"
+	default:
+		return "mp4a.40.2"
+	}
+}
+
+// Codecs joins the video and audio codecs strings of a rendition.
+func Codecs(video, audio string) string {
+	if audio == "" {
+		return video
+	}
+	return video + "," + audio
+}
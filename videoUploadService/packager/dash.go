@@ -0,0 +1,207 @@
+package packager
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// ErrDASHUnsupported is returned for videos whose segments DASH players
+// cannot play, i.e. HLS AES-128 encrypted output.
+var ErrDASHUnsupported = errors.New("video is not available over DASH")
+
+// clearKeySystem is the DASH-IF system ID of W3C ClearKey.
+const clearKeySystem = "urn:uuid:e2719d58-a985-b3c9-781a-b030af78d30e"
+
+// DASHOptions adjust a generated MPD.
+type DASHOptions struct {
+	// MapURI rewrites segment URIs, e.g. to add a playback token.
+	MapURI func(uri string) string
+	// LicenseURL is the ClearKey license server of CENC-encrypted videos.
+	LicenseURL string
+}
+
+type mpd struct {
+	XMLName                   xml.Name `xml:"MPD"`
+	Xmlns                     string   `xml:"xmlns,attr"`
+	XmlnsCenc                 string   `xml:"xmlns:cenc,attr,omitempty"`
+	XmlnsDashif               string   `xml:"xmlns:dashif,attr,omitempty"`
+	Profiles                  string   `xml:"profiles,attr"`
+	Type                      string   `xml:"type,attr"`
+	MediaPresentationDuration string   `xml:"mediaPresentationDuration,attr"`
+	MinBufferTime             string   `xml:"minBufferTime,attr"`
+	Periods                   []period `xml:"Period"`
+}
+
+type period struct {
+	ID             string          `xml:"id,attr"`
+	Start          string          `xml:"start,attr"`
+	AdaptationSets []adaptationSet `xml:"AdaptationSet"`
+}
+
+type adaptationSet struct {
+	ID                 int                 `xml:"id,attr"`
+	ContentType        string              `xml:"contentType,attr"`
+	MimeType           string              `xml:"mimeType,attr"`
+	SegmentAlignment   bool                `xml:"segmentAlignment,attr"`
+	StartWithSAP       int                 `xml:"startWithSAP,attr"`
+	MaxWidth           uint32              `xml:"maxWidth,attr,omitempty"`
+	MaxHeight          uint32              `xml:"maxHeight,attr,omitempty"`
+	ContentProtections []contentProtection `xml:"ContentProtection"`
+	Representations    []representation    `xml:"Representation"`
+}
+
+type contentProtection struct {
+	SchemeIDURI string `xml:"schemeIdUri,attr"`
+	Value       string `xml:"value,attr,omitempty"`
+	DefaultKID  string `xml:"cenc:default_KID,attr,omitempty"`
+	Laurl       *laurl `xml:"dashif:Laurl,omitempty"`
+}
+
+type laurl struct {
+	URL string `xml:",chardata"`
+}
+
+type representation struct {
+	ID          string      `xml:"id,attr"`
+	Bandwidth   int64       `xml:"bandwidth,attr"`
+	Width       uint32      `xml:"width,attr,omitempty"`
+	Height      uint32      `xml:"height,attr,omitempty"`
+	FrameRate   string      `xml:"frameRate,attr,omitempty"`
+	Codecs      string      `xml:"codecs,attr"`
+	SegmentList segmentList `xml:"SegmentList"`
+}
+
+type segmentList struct {
+	Timescale      int              `xml:"timescale,attr"`
+	Initialization *initialization  `xml:"Initialization,omitempty"`
+	Timeline       segmentTimeline  `xml:"SegmentTimeline"`
+	SegmentURLs    []segmentURLElem `xml:"SegmentURL"`
+}
+
+type initialization struct {
+	SourceURL string `xml:"sourceURL,attr"`
+}
+
+type segmentTimeline struct {
+	S []timelineEntry `xml:"S"`
+}
+
+type timelineEntry struct {
+	T *int64 `xml:"t,attr"`
+	D int64  `xml:"d,attr"`
+	R int    `xml:"r,attr,omitempty"`
+}
+
+type segmentURLElem struct {
+	Media string `xml:"media,attr"`
+}
+
+// DASH renders a static MPD for a packaged video. Segments are referenced
+// where the HLS output put them, so both protocols share the same files.
+func DASH(m Metadata, opt DASHOptions) ([]byte, error) {
+	if m.Encrypted || len(m.Renditions) == 0 {
+		return nil, ErrDASHUnsupported
+	}
+	mapURI := opt.MapURI
+	if mapURI == nil {
+		mapURI = func(uri string) string { return uri }
+	}
+
+	doc := mpd{
+		Xmlns:                     "urn:mpeg:dash:schema:mpd:2011",
+		Type:                      "static",
+		MediaPresentationDuration: isoDuration(m.DurationSeconds),
+		MinBufferTime:             "PT2S",
+	}
+	set := adaptationSet{ContentType: "video", SegmentAlignment: true, StartWithSAP: 1}
+	if m.Format == FormatFMP4 {
+		doc.Profiles = "urn:mpeg:dash:profile:isoff-main:2011"
+		set.MimeType = "video/mp4"
+	} else {
+		doc.Profiles = "urn:mpeg:dash:profile:mp2t-simple:2011"
+		set.MimeType = "video/mp2t"
+	}
+	if len(m.KeyIDs) > 0 {
+		if m.Format != FormatFMP4 {
+			return nil, ErrDASHUnsupported
+		}
+		kid, err := uuidString(m.KeyIDs[0])
+		if err != nil {
+			return nil, err
+		}
+		doc.XmlnsCenc = "urn:mpeg:cenc:2013"
+		doc.XmlnsDashif = "https://dashif.org/CPS"
+		set.ContentProtections = []contentProtection{
+			{SchemeIDURI: "urn:mpeg:dash:mp4protection:2011", Value: "cenc", DefaultKID: kid},
+			{SchemeIDURI: clearKeySystem, Value: "ClearKey1.0", Laurl: &laurl{URL: opt.LicenseURL}},
+		}
+	}
+
+	for _, r := range m.Renditions {
+		set.MaxWidth = max(set.MaxWidth, r.Width)
+		set.MaxHeight = max(set.MaxHeight, r.Height)
+		rep := representation{
+			ID:        r.Name,
+			Bandwidth: r.Bandwidth,
+			Width:     r.Width,
+			Height:    r.Height,
+			Codecs:    r.Codecs,
+			SegmentList: segmentList{
+				Timescale: 1000,
+				Timeline:  timeline(r.Segments),
+			},
+		}
+		if r.FrameRate > 0 {
+			rep.FrameRate = strconv.FormatFloat(r.FrameRate, 'f', -1, 64)
+		}
+		if r.Init != "" {
+			rep.SegmentList.Initialization = &initialization{SourceURL: mapURI(r.Init)}
+		}
+		for _, s := range r.Segments {
+			rep.SegmentList.SegmentURLs = append(rep.SegmentList.SegmentURLs, segmentURLElem{Media: mapURI(s.URI)})
+		}
+		set.Representations = append(set.Representations, rep)
+	}
+	doc.Periods = []period{{ID: "0", Start: "PT0S", AdaptationSets: []adaptationSet{set}}}
+
+	out, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(out, '\n')...), nil
+}
+
+// timeline run-length encodes segment durations in milliseconds.
+func timeline(segments []Segment) segmentTimeline {
+	var tl segmentTimeline
+	zero := int64(0)
+	for i, s := range segments {
+		d := int64(math.Round(s.Duration * 1000))
+		if n := len(tl.S); n > 0 && tl.S[n-1].D == d {
+			tl.S[n-1].R++
+			continue
+		}
+		e := timelineEntry{D: d}
+		if i == 0 {
+			e.T = &zero
+		}
+		tl.S = append(tl.S, e)
+	}
+	return tl
+}
+
+// isoDuration formats seconds as an ISO 8601 duration, e.g. "PT63.5S".
+func isoDuration(seconds float64) string {
+	return "PT" + strconv.FormatFloat(math.Round(seconds*1000)/1000, 'f', -1, 64) + "S"
+}
+
+// uuidString formats a hex key ID as a UUID, as cenc:default_KID expects.
+func uuidString(kid string) (string, error) {
+	if len(kid) != 32 {
+		return "", fmt.Errorf("invalid key ID %q", kid)
+	}
+	return kid[0:8] + "-" + kid[8:12] + "-" + kid[12:16] + "-" + kid[16:20] + "-" + kid[20:], nil
+}
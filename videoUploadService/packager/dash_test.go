@@ -0,0 +1,168 @@
+package packager
+
+import (
+	"encoding/xml"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTimeline(t *testing.T) {
+	zero := int64(0)
+	tests := []struct {
+		name     string
+		segments []Segment
+		want     []timelineEntry
+	}{
+		{"empty", nil, nil},
+		{"one", []Segment{{Duration: 4}}, []timelineEntry{{T: &zero, D: 4000}}},
+		{
+			"repeats",
+			[]Segment{{Duration: 4}, {Duration: 4}, {Duration: 4}, {Duration: 2.5}},
+			[]timelineEntry{{T: &zero, D: 4000, R: 2}, {D: 2500}},
+		},
+		{
+			"runs",
+			[]Segment{{Duration: 6}, {Duration: 4}, {Duration: 4}, {Duration: 6}},
+			[]timelineEntry{{T: &zero, D: 6000}, {D: 4000, R: 1}, {D: 6000}},
+		},
+		{
+			"rounded to milliseconds",
+			[]Segment{{Duration: 4.0004}, {Duration: 3.9996}, {Duration: 4.0016}},
+			[]timelineEntry{{T: &zero, D: 4000, R: 1}, {D: 4002}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := timeline(tt.segments).S; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDASH(t *testing.T) {
+	rendition := func(name string, height uint32, init string, ext string) Rendition {
+		return Rendition{
+			Name: name, Width: height * 16 / 9, Height: height, FrameRate: 29.97,
+			Bandwidth: int64(height) * 4000, Codecs: "avc1.64001f,mp4a.40.2", Init: init,
+			Segments: []Segment{{URI: name + "/seg0" + ext, Duration: 4}, {URI: name + "/seg1" + ext, Duration: 2}},
+		}
+	}
+	ts := Metadata{VideoID: "v", Format: FormatTS, DurationSeconds: 6,
+		Renditions: []Rendition{rendition("360p", 360, "", ".ts"), rendition("720p", 720, "", ".ts")}}
+	fmp4 := Metadata{VideoID: "v", Format: FormatFMP4, DurationSeconds: 6,
+		Renditions: []Rendition{rendition("360p", 360, "360p/init.mp4", ".m4s")}}
+	cenc := fmp4
+	cenc.KeyIDs = []string{"0123456789abcdef0123456789abcdef"}
+	tsKIDs := ts
+	tsKIDs.KeyIDs = cenc.KeyIDs
+	badKID := cenc
+	badKID.KeyIDs = []string{"0123"}
+	aes := ts
+	aes.Encrypted = true
+
+	tests := []struct {
+		name     string
+		meta     Metadata
+		opt      DASHOptions
+		wantErr  error
+		check    func(t *testing.T, doc mpd)
+		contains []string
+	}{
+		{
+			name: "mpeg-ts",
+			meta: ts,
+			check: func(t *testing.T, doc mpd) {
+				set := doc.Periods[0].AdaptationSets[0]
+				if doc.Profiles != "urn:mpeg:dash:profile:mp2t-simple:2011" || set.MimeType != "video/mp2t" {
+					t.Errorf("profile %q, mime type %q", doc.Profiles, set.MimeType)
+				}
+				if doc.MediaPresentationDuration != "PT6S" {
+					t.Errorf("duration = %q", doc.MediaPresentationDuration)
+				}
+				if len(set.Representations) != 2 || set.MaxWidth != 1280 || set.MaxHeight != 720 {
+					t.Errorf("representations = %d, max %dx%d", len(set.Representations), set.MaxWidth, set.MaxHeight)
+				}
+				rep := set.Representations[0]
+				if rep.ID != "360p" || rep.FrameRate != "29.97" || rep.SegmentList.Initialization != nil {
+					t.Errorf("representation = %+v", rep)
+				}
+				if len(set.ContentProtections) != 0 {
+					t.Errorf("clear video has content protection %+v", set.ContentProtections)
+				}
+			},
+		},
+		{
+			name: "fmp4 with mapped uris",
+			meta: fmp4,
+			opt:  DASHOptions{MapURI: func(uri string) string { return uri + "?token=t" }},
+			check: func(t *testing.T, doc mpd) {
+				set := doc.Periods[0].AdaptationSets[0]
+				if doc.Profiles != "urn:mpeg:dash:profile:isoff-main:2011" || set.MimeType != "video/mp4" {
+					t.Errorf("profile %q, mime type %q", doc.Profiles, set.MimeType)
+				}
+				list := set.Representations[0].SegmentList
+				if list.Initialization == nil || list.Initialization.SourceURL != "360p/init.mp4?token=t" {
+					t.Errorf("initialization = %+v", list.Initialization)
+				}
+				if len(list.SegmentURLs) != 2 || list.SegmentURLs[1].Media != "360p/seg1.m4s?token=t" {
+					t.Errorf("segment urls = %+v", list.SegmentURLs)
+				}
+			},
+		},
+		{
+			name: "cenc",
+			meta: cenc,
+			opt:  DASHOptions{LicenseURL: "https://example.com/drm/clearkey/v/license"},
+			check: func(t *testing.T, doc mpd) {
+				cp := doc.Periods[0].AdaptationSets[0].ContentProtections
+				if len(cp) != 2 || cp[0].Value != "cenc" || cp[1].SchemeIDURI != clearKeySystem {
+					t.Errorf("content protections = %+v", cp)
+				}
+			},
+			// encoding/xml does not read prefixed names back, so look for
+			// them in the output.
+			contains: []string{
+				`xmlns:cenc="urn:mpeg:cenc:2013"`,
+				`cenc:default_KID="01234567-89ab-cdef-0123-456789abcdef"`,
+				`<dashif:Laurl>https://example.com/drm/clearkey/v/license</dashif:Laurl>`,
+			},
+		},
+		{name: "hls aes-128", meta: aes, wantErr: ErrDASHUnsupported},
+		{name: "no renditions", meta: Metadata{VideoID: "v", Format: FormatTS}, wantErr: ErrDASHUnsupported},
+		{name: "cenc mpeg-ts", meta: tsKIDs, wantErr: ErrDASHUnsupported},
+		{name: "invalid key id", meta: badKID},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := DASH(tt.meta, tt.opt)
+			if tt.check == nil {
+				if err == nil || (tt.wantErr != nil && !errors.Is(err, tt.wantErr)) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(string(out), xml.Header) {
+				t.Errorf("MPD does not start with the XML header")
+			}
+			var doc mpd
+			if err := xml.Unmarshal(out, &doc); err != nil {
+				t.Fatal(err)
+			}
+			if doc.Type != "static" || len(doc.Periods) != 1 || len(doc.Periods[0].AdaptationSets) != 1 {
+				t.Fatalf("MPD = %+v", doc)
+			}
+			tt.check(t, doc)
+			for _, want := range tt.contains {
+				if !strings.Contains(string(out), want) {
+					t.Errorf("MPD lacks %s", want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package packager
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"path"
+	"strings"
+)
+
+// IFramePlaylistName is the I-frame playlist next to each media playlist.
+const IFramePlaylistName = "iframes.m3u8"
+
+type iframe struct {
+	uri      string
+	keyframe Keyframe
+	duration float64
+}
+
+// iframes lists every keyframe of the rendition with the time until the
+// next one.
+func (r Rendition) iframes() []iframe {
+	var out []iframe
+	for _, seg := range r.Segments {
+		for i, k := range seg.Keyframes {
+			next := seg.Duration
+			if i+1 < len(seg.Keyframes) {
+				next = seg.Keyframes[i+1].Time
+			}
+			if next <= k.Time || k.Size == 0 {
+				continue
+			}
+			out = append(out, iframe{uri: seg.URI, keyframe: k, duration: next - k.Time})
+		}
+	}
+	return out
+}
+
+// HasIFrames reports whether keyframes were recorded for the rendition.
+func (r Rendition) HasIFrames() bool {
+	return len(r.iframes()) > 0
+}
+
+// IFrameBandwidth is the peak bitrate of fetching keyframes only.
+func (r Rendition) IFrameBandwidth() int64 {
+	var peak int64
+	for _, f := range r.iframes() {
+		peak = max(peak, int64(float64(f.keyframe.Size*8)/f.duration))
+	}
+	return peak
+}
+
+// IFramePlaylistURI is where the rendition's I-frame playlist is served,
+// relative to the video directory.
+func (r Rendition) IFramePlaylistURI() string {
+	return path.Join(path.Dir(r.Playlist), IFramePlaylistName)
+}
+
+// IFramePlaylist renders the EXT-X-I-FRAMES-ONLY playlist of a rendition.
+// Segment URIs are written relative to the rendition's playlist directory,
+// where the I-frame playlist is served. mapURI may be nil.
+func IFramePlaylist(r Rendition, mapURI func(uri string) string) []byte {
+	frames := r.iframes()
+	target := 1.0
+	for _, f := range frames {
+		target = math.Max(target, f.duration)
+	}
+
+	if mapURI == nil {
+		mapURI = func(uri string) string { return uri }
+	}
+	dir := path.Dir(r.Playlist)
+
+	// Keyframes past the start of a segment lack the PAT and PMT, so point
+	// players at the tables ahead of the first keyframe.
+	version, header := 4, ""
+	if len(frames) > 0 && frames[0].keyframe.Offset > 0 {
+		version = 5
+		header = fmt.Sprintf("#EXT-X-MAP:URI=%q,BYTERANGE=\"%d@0\"\n",
+			mapURI(relativeTo(dir, frames[0].uri)), frames[0].keyframe.Offset)
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "#EXTM3U\n#EXT-X-VERSION:%d\n#EXT-X-TARGETDURATION:%d\n", version, int(math.Ceil(target)))
+	b.WriteString("#EXT-X-PLAYLIST-TYPE:VOD\n#EXT-X-I-FRAMES-ONLY\n" + header)
+	for _, f := range frames {
+		uri := mapURI(relativeTo(dir, f.uri))
+		fmt.Fprintf(&b, "#EXTINF:%.3f,\n#EXT-X-BYTERANGE:%d@%d\n%s\n", f.duration, f.keyframe.Size, f.keyframe.Offset, uri)
+	}
+	b.WriteString("#EXT-X-ENDLIST\n")
+	return b.Bytes()
+}
+
+// relativeTo turns a URI relative to the video directory into one relative
+// to dir, which is itself relative to the video directory.
+func relativeTo(dir, uri string) string {
+	if dir == "." || strings.Contains(uri, "://") || strings.HasPrefix(uri, "/") {
+		return uri
+	}
+	if rest, ok := strings.CutPrefix(uri, dir+"/"); ok {
+		return rest
+	}
+	return strings.Repeat("../", strings.Count(dir, "/")+1) + uri
+}
+
+// videoCodecs drops the audio codec from a rendition's codecs, since
+// I-frame playlists carry video only.
+func videoCodecs(codecs string) string {
+	video, _, _ := strings.Cut(codecs, ",")
+	return video
+}
@@ -0,0 +1,127 @@
+package packager
+
+import (
+	"strings"
+	"testing"
+)
+
+// iframeRendition has keyframes in two segments. The last keyframe was not
+// measured and is left out, but still ends the one before it.
+var iframeRendition = Rendition{
+	Name: "720p", Width: 1280, Height: 720, Bandwidth: 2800000,
+	Codecs: "avc1.64001f,mp4a.40.2", Playlist: "720p/720p.m3u8",
+	Segments: []Segment{
+		{URI: "720p/seg0.ts", Duration: 4, Keyframes: []Keyframe{{Offset: 376, Size: 1000}, {Offset: 5000, Size: 800, Time: 2}}},
+		{URI: "720p/seg1.ts", Duration: 4, Keyframes: []Keyframe{{Offset: 376, Size: 1200}, {Offset: 4000, Time: 3}}},
+	},
+}
+
+func TestIFramePlaylist(t *testing.T) {
+	withToken := func(uri string) string { return uri + "?token=t" }
+	atStart := iframeRendition
+	atStart.Segments = []Segment{{URI: "shared/seg0.ts", Duration: 6.5, Keyframes: []Keyframe{{Size: 1000}}}}
+
+	tests := []struct {
+		name   string
+		r      Rendition
+		mapURI func(string) string
+		want   string
+	}{
+		{
+			name:   "keyframes past the tables",
+			r:      iframeRendition,
+			mapURI: withToken,
+			want: `#EXTM3U
+#EXT-X-VERSION:5
+#EXT-X-TARGETDURATION:3
+#EXT-X-PLAYLIST-TYPE:VOD
+#EXT-X-I-FRAMES-ONLY
+#EXT-X-MAP:URI="seg0.ts?token=t",BYTERANGE="376@0"
+#EXTINF:2.000,
+#EXT-X-BYTERANGE:1000@376
+seg0.ts?token=t
+#EXTINF:2.000,
+#EXT-X-BYTERANGE:800@5000
+seg0.ts?token=t
+#EXTINF:3.000,
+#EXT-X-BYTERANGE:1200@376
+seg1.ts?token=t
+#EXT-X-ENDLIST
+`,
+		},
+		{
+			name: "keyframe starting the segment",
+			r:    atStart,
+			want: `#EXTM3U
+#EXT-X-VERSION:4
+#EXT-X-TARGETDURATION:7
+#EXT-X-PLAYLIST-TYPE:VOD
+#EXT-X-I-FRAMES-ONLY
+#EXTINF:6.500,
+#EXT-X-BYTERANGE:1000@0
+../shared/seg0.ts
+#EXT-X-ENDLIST
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(IFramePlaylist(tt.r, tt.mapURI)); got != tt.want {
+				t.Errorf("IFramePlaylist =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIFrameBandwidth(t *testing.T) {
+	// The peak is the first keyframe: 1000 bytes over 2 seconds.
+	if got := iframeRendition.IFrameBandwidth(); got != 4000 {
+		t.Errorf("IFrameBandwidth = %d, want 4000", got)
+	}
+	if r := (Rendition{Segments: []Segment{{Duration: 4}}}); r.HasIFrames() || r.IFrameBandwidth() != 0 {
+		t.Error("rendition without keyframes has I-frames")
+	}
+}
+
+func TestMasterIFrameStreams(t *testing.T) {
+	low := Rendition{Name: "360p", Width: 640, Height: 360, Bandwidth: 800000, Codecs: "avc1.4d401e,mp4a.40.2", Playlist: "360p/360p.m3u8"}
+	m := Metadata{Renditions: []Rendition{iframeRendition, low}}
+
+	got := string(Master(m, Filter{}, nil))
+	want := `#EXTM3U
+#EXT-X-VERSION:4
+#EXT-X-INDEPENDENT-SEGMENTS
+#EXT-X-STREAM-INF:BANDWIDTH=800000,CODECS="avc1.4d401e,mp4a.40.2",RESOLUTION=640x360
+360p/360p.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=2800000,CODECS="avc1.64001f,mp4a.40.2",RESOLUTION=1280x720
+720p/720p.m3u8
+#EXT-X-I-FRAME-STREAM-INF:BANDWIDTH=4000,CODECS="avc1.64001f",RESOLUTION=1280x720,URI="720p/iframes.m3u8"
+`
+	if got != want {
+		t.Errorf("Master =\n%s\nwant\n%s", got, want)
+	}
+
+	// Renditions filtered out take their I-frame playlists with them.
+	got = string(Master(m, Filter{MaxHeight: 360}, nil))
+	if strings.Contains(got, "I-FRAME") || !strings.Contains(got, "#EXT-X-VERSION:3\n") {
+		t.Errorf("Master for 360p =\n%s", got)
+	}
+}
+
+func TestRelativeTo(t *testing.T) {
+	tests := []struct {
+		dir, uri, want string
+	}{
+		{"720p", "720p/seg0.ts", "seg0.ts"},
+		{"720p", "shared/seg0.ts", "../shared/seg0.ts"},
+		{"a/b", "c/seg0.ts", "../../c/seg0.ts"},
+		{".", "seg0.ts", "seg0.ts"},
+		{"720p", "/encoded/v/seg0.ts", "/encoded/v/seg0.ts"},
+		{"720p", "https://cdn.example.com/seg0.ts", "https://cdn.example.com/seg0.ts"},
+	}
+	for _, tt := range tests {
+		if got := relativeTo(tt.dir, tt.uri); got != tt.want {
+			t.Errorf("relativeTo(%q, %q) = %q, want %q", tt.dir, tt.uri, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package packager
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// Filter restricts which renditions a viewer gets. Zero fields do not
+// restrict anything.
+type Filter struct {
+	MaxWidth     uint32
+	MaxHeight    uint32
+	MaxBandwidth int64
+}
+
+// Allows reports whether the rendition passes the filter.
+func (f Filter) Allows(r Rendition) bool {
+	return (f.MaxWidth == 0 || r.Width <= f.MaxWidth) &&
+		(f.MaxHeight == 0 || r.Height <= f.MaxHeight) &&
+		(f.MaxBandwidth == 0 || r.Bandwidth <= f.MaxBandwidth)
+}
+
+// Select returns the renditions passing the filter ordered by bandwidth. If
+// none pass, the lowest rendition is returned so players always have
+// something to play.
+func Select(renditions []Rendition, f Filter) []Rendition {
+	sorted := append([]Rendition(nil), renditions...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Bandwidth < sorted[j].Bandwidth })
+	var out []Rendition
+	for _, r := range sorted {
+		if f.Allows(r) {
+			out = append(out, r)
+		}
+	}
+	if len(out) == 0 && len(sorted) > 0 {
+		out = sorted[:1]
+	}
+	return out
+}
+
+// Master renders an HLS master playlist of the selected renditions and
+// their I-frame playlists. mapURI may rewrite variant URIs and can be nil.
+func Master(m Metadata, f Filter, mapURI func(uri string) string) []byte {
+	if mapURI == nil {
+		mapURI = func(uri string) string { return uri }
+	}
+	selected := Select(m.Renditions, f)
+	var iframes []Rendition
+	for _, r := range selected {
+		if r.HasIFrames() {
+			iframes = append(iframes, r)
+		}
+	}
+	version := 3
+	switch {
+	case m.Format == FormatFMP4:
+		version = 7
+	case len(iframes) > 0:
+		version = 4
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "#EXTM3U\n#EXT-X-VERSION:%d\n#EXT-X-INDEPENDENT-SEGMENTS\n", version)
+	for _, r := range selected {
+		fmt.Fprintf(&b, "#EXT-X-STREAM-INF:BANDWIDTH=%d", r.Bandwidth)
+		if r.AverageBandwidth > 0 {
+			fmt.Fprintf(&b, ",AVERAGE-BANDWIDTH=%d", r.AverageBandwidth)
+		}
+		if r.Codecs != "" {
+			fmt.Fprintf(&b, ",CODECS=%q", r.Codecs)
+		}
+		if r.Width > 0 && r.Height > 0 {
+			fmt.Fprintf(&b, ",RESOLUTION=%dx%d", r.Width, r.Height)
+		}
+		if r.FrameRate > 0 {
+			b.WriteString(",FRAME-RATE=" + strconv.FormatFloat(r.FrameRate, 'f', 3, 64))
+		}
+		b.WriteString("\n" + mapURI(r.Playlist) + "\n")
+	}
+	for _, r := range iframes {
+		fmt.Fprintf(&b, "#EXT-X-I-FRAME-STREAM-INF:BANDWIDTH=%d", r.IFrameBandwidth())
+		if codecs := videoCodecs(r.Codecs); codecs != "" {
+			fmt.Fprintf(&b, ",CODECS=%q", codecs)
+		}
+		if r.Width > 0 && r.Height > 0 {
+			fmt.Fprintf(&b, ",RESOLUTION=%dx%d", r.Width, r.Height)
+		}
+		fmt.Fprintf(&b, ",URI=%q\n", mapURI(r.IFramePlaylistURI()))
+	}
+	return b.Bytes()
+}
@@ -0,0 +1,134 @@
+package packager
+
+import (
+	"VideoUploadService/storage"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+)
+
+// MetadataFile is stored next to the encoded output of a video.
+const MetadataFile = "renditions.json"
+
+// Segment formats.
+const (
+	FormatTS   = "ts"
+	FormatFMP4 = "fmp4"
+)
+
+// Segment is one media segment of a rendition. URIs are relative to the
+// video's output directory.
+type Segment struct {
+	URI      string  `json:"uri"`
+	Duration float64 `json:"duration"`
+	Size     int64   `json:"size,omitempty"`
+	// Keyframes are recorded for clear MPEG-TS segments and back the
+	// I-frame playlists.
+	Keyframes []Keyframe `json:"keyframes,omitempty"`
+}
+
+// Rendition describes one encoded rendition of a video.
+type Rendition struct {
+	Name   string `json:"name"`
+	Width  uint32 `json:"width,omitempty"`
+	Height uint32 `json:"height,omitempty"`
+	// FrameRate is zero when unknown.
+	FrameRate float64 `json:"frame_rate,omitempty"`
+	// Bandwidth is the peak segment bitrate and AverageBandwidth the mean
+	// bitrate, both in bits per second.
+	Bandwidth        int64     `json:"bandwidth"`
+	AverageBandwidth int64     `json:"average_bandwidth"`
+	Codecs           string    `json:"codecs"`
+	Playlist         string    `json:"playlist"`
+	Init             string    `json:"init,omitempty"`
+	Segments         []Segment `json:"segments"`
+	// MP4 is the progressive download of the rendition, if the encoder
+	// wrote one, and MP4Size its size in bytes.
+	MP4     string `json:"mp4,omitempty"`
+	MP4Size int64  `json:"mp4_size,omitempty"`
+}
+
+// Duration is the sum of the segment durations.
+func (r Rendition) Duration() float64 {
+	var d float64
+	for _, s := range r.Segments {
+		d += s.Duration
+	}
+	return d
+}
+
+// Metadata describes the packaged output of a video. Manifests are generated
+// from it.
+type Metadata struct {
+	VideoID         string  `json:"video_id"`
+	Format          string  `json:"format"`
+	DurationSeconds float64 `json:"duration_seconds"`
+	// Encrypted is set when only HLS players can decrypt the segments: for
+	// HLS AES-128 encryption and for CENC output without known key IDs.
+	Encrypted bool `json:"encrypted,omitempty"`
+	// KeyIDs are the hex CENC key IDs of CENC-encrypted fMP4 output.
+	KeyIDs     []string    `json:"key_ids,omitempty"`
+	Renditions []Rendition `json:"renditions"`
+	// Thumbnails is set once sprite sheets have been generated.
+	Thumbnails *Thumbnails `json:"thumbnails,omitempty"`
+	CreatedAt  time.Time   `json:"created_at"`
+}
+
+// Rendition returns the rendition with the given name.
+func (m Metadata) Rendition(name string) (Rendition, bool) {
+	for _, r := range m.Renditions {
+		if r.Name == name {
+			return r, true
+		}
+	}
+	return Rendition{}, false
+}
+
+// Dir is the storage prefix of a video's encoded output.
+func Dir(videoID string) string {
+	return "encoded/" + videoID + "/"
+}
+
+// Save stores the metadata of a video.
+func Save(ctx context.Context, store storage.Storage, m Metadata) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	w, err := store.Create(ctx, Dir(m.VideoID)+MetadataFile)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// Load reads the metadata of a video. It returns storage.ErrNotFound for
+// videos that were never packaged.
+func Load(ctx context.Context, store storage.Storage, videoID string) (Metadata, error) {
+	data, err := readAll(ctx, store, Dir(videoID)+MetadataFile)
+	if err != nil {
+		return Metadata{}, err
+	}
+	var m Metadata
+	if err := json.Unmarshal(data, &m); err != nil {
+		return Metadata{}, err
+	}
+	return m, nil
+}
+
+func readAll(ctx context.Context, store storage.Storage, key string) ([]byte, error) {
+	obj, err := store.Open(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer obj.Close()
+	return io.ReadAll(obj)
+}
+
+var errNoRenditions = errors.New("no renditions found")
@@ -0,0 +1,364 @@
+package packager
+
+import (
+	"VideoUploadService/storage"
+	pbt "VideoUploadService/transcoding"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// metadataTTL is how long loaded metadata is cached, so that per-request
+// checks don't read it from storage every time.
+const metadataTTL = 10 * time.Second
+
+// failedTTL is how long a failure to describe a video's output is cached
+// before describing it is tried again.
+const failedTTL = time.Minute
+
+// ErrNotPackaged is returned for videos whose output has not been described
+// yet. Describing it is started in the background.
+var ErrNotPackaged = errors.New("video output is not packaged yet")
+
+// Packager describes the encoder's output and generates manifests from it.
+type Packager struct {
+	store     storage.Storage
+	sprites   SpriteConfig
+	localRoot string
+
+	mu    sync.Mutex
+	cache map[string]cachedMetadata
+	// loads makes concurrent requests for the same video share one load,
+	// and describes share one background describe.
+	loads singleflight.Group
+}
+
+// cachedMetadata is the metadata of a video, or why it has none.
+type cachedMetadata struct {
+	meta    Metadata
+	err     error
+	expires time.Time
+}
+
+// Default is set up in main.
+var Default *Packager
+
+// KeyIDs returns the hex CENC key IDs of a video. It is set up in main when
+// content keys are configured and describes encrypted output packaged
+// without its profile.
+var KeyIDs func(ctx context.Context, videoID string) ([]string, error)
+
+func New(store storage.Storage) *Packager {
+	return &Packager{store: store, cache: make(map[string]cachedMetadata)}
+}
+
+// Package describes the output of a finished transcode and stores the
+// result next to it. profile is the profile the video was encoded with, or
+// nil to describe whatever the encoder left behind.
+func (p *Packager) Package(ctx context.Context, videoID string, profile *pbt.EncodingProfile) (Metadata, error) {
+	m, err := Describe(ctx, p.store, videoID, profile)
+	if err != nil {
+		return Metadata{}, err
+	}
+	if err := Save(ctx, p.store, m); err != nil {
+		return Metadata{}, err
+	}
+	p.remember(m)
+	return m, nil
+}
+
+// Metadata returns the stored metadata of a video. Output encoded before
+// the packager existed, or whose packaging failed, is described in the
+// background; until that is done ErrNotPackaged is returned.
+func (p *Packager) Metadata(ctx context.Context, videoID string) (Metadata, error) {
+	p.mu.Lock()
+	c, ok := p.cache[videoID]
+	p.mu.Unlock()
+	if ok && time.Now().Before(c.expires) {
+		return c.meta, c.err
+	}
+
+	// The load is shared, so one caller giving up must not fail the others.
+	ctx = context.WithoutCancel(ctx)
+	v, err, _ := p.loads.Do(videoID, func() (any, error) {
+		m, err := Load(ctx, p.store, videoID)
+		if errors.Is(err, storage.ErrNotFound) {
+			p.describe(videoID)
+			return Metadata{}, ErrNotPackaged
+		}
+		if err != nil {
+			return Metadata{}, err
+		}
+		p.remember(m)
+		return m, nil
+	})
+	if err != nil {
+		return Metadata{}, err
+	}
+	return v.(Metadata), nil
+}
+
+// describe packages the output of a video in the background. Callers are
+// told it is not packaged until it is, and for a while after it failed.
+func (p *Packager) describe(videoID string) {
+	p.rememberErr(videoID, ErrNotPackaged, metadataTTL)
+	go p.loads.Do("describe/"+videoID, func() (any, error) {
+		if _, err := p.Package(context.Background(), videoID, nil); err != nil {
+			log.Printf("Describing %s: %v", videoID, err)
+			p.rememberErr(videoID, ErrNotPackaged, failedTTL)
+		}
+		return nil, nil
+	})
+}
+
+func (p *Packager) remember(m Metadata) {
+	p.put(m.VideoID, cachedMetadata{meta: m}, metadataTTL)
+}
+
+func (p *Packager) rememberErr(videoID string, err error, ttl time.Duration) {
+	p.put(videoID, cachedMetadata{err: err}, ttl)
+}
+
+func (p *Packager) put(videoID string, c cachedMetadata, ttl time.Duration) {
+	now := time.Now()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.cache) > 4096 {
+		for id, c := range p.cache {
+			if now.After(c.expires) {
+				delete(p.cache, id)
+			}
+		}
+	}
+	c.expires = now.Add(ttl)
+	p.cache[videoID] = c
+}
+
+type source struct {
+	name      string
+	playlist  string
+	width     uint32
+	height    uint32
+	frameRate float64
+	codecs    string
+}
+
+// Describe builds the metadata of a video from its HLS output. Without a
+// profile the renditions are taken from the master playlist.
+func Describe(ctx context.Context, store storage.Storage, videoID string, profile *pbt.EncodingProfile) (Metadata, error) {
+	var sources []source
+	var err error
+	if profile != nil && len(profile.Renditions) > 0 {
+		sources = profileSources(ctx, store, videoID, profile)
+	} else {
+		sources, err = masterSources(ctx, store, videoID)
+		if err != nil {
+			return Metadata{}, err
+		}
+	}
+	if len(sources) == 0 {
+		return Metadata{}, fmt.Errorf("describe %s: %w", videoID, errNoRenditions)
+	}
+
+	m := Metadata{VideoID: videoID, Format: FormatTS, CreatedAt: time.Now().UTC()}
+	var method string
+	var playlistKIDs []string
+	for _, src := range sources {
+		r, mp, err := describeRendition(ctx, store, videoID, src)
+		if err != nil {
+			return Metadata{}, fmt.Errorf("describe %s/%s: %w", videoID, src.name, err)
+		}
+		if r.Init != "" {
+			m.Format = FormatFMP4
+		}
+		if mp.Method != "" {
+			method = mp.Method
+		}
+		for _, kid := range mp.KeyIDs {
+			if !slices.Contains(playlistKIDs, kid) {
+				playlistKIDs = append(playlistKIDs, kid)
+			}
+		}
+		m.DurationSeconds = math.Max(m.DurationSeconds, r.Duration())
+		m.Renditions = append(m.Renditions, r)
+	}
+
+	if method == "" {
+		return m, nil
+	}
+	if method != "AES-128" && m.Format == FormatFMP4 {
+		if m.KeyIDs, err = keyIDs(ctx, videoID, profile, playlistKIDs); err != nil {
+			return Metadata{}, fmt.Errorf("describe %s: key IDs: %w", videoID, err)
+		}
+	}
+	// Without key IDs only HLS players, which read the keys from the
+	// playlists, can play the segments.
+	m.Encrypted = len(m.KeyIDs) == 0
+	return m, nil
+}
+
+// keyIDs finds the key IDs of CENC-encrypted output: from the profile it was
+// encoded with, the KEYID attributes of its playlists or, for output described
+// without a profile, the video's content keys.
+func keyIDs(ctx context.Context, videoID string, profile *pbt.EncodingProfile, playlist []string) ([]string, error) {
+	if profile != nil && profile.Encryption != nil {
+		var kids []string
+		for _, k := range profile.Encryption.Keys {
+			if len(k.Kid) > 0 {
+				kids = append(kids, hex.EncodeToString(k.Kid))
+			}
+		}
+		if len(kids) > 0 {
+			return kids, nil
+		}
+	}
+	if len(playlist) > 0 || KeyIDs == nil {
+		return playlist, nil
+	}
+	return KeyIDs(ctx, videoID)
+}
+
+func profileSources(ctx context.Context, store storage.Storage, videoID string, profile *pbt.EncodingProfile) []source {
+	audio := ""
+	if profile.Audio != nil {
+		audio = AudioCodecs(profile.Audio.Codec)
+	}
+	var sources []source
+	for _, spec := range profile.Renditions {
+		playlist, ok := findPlaylist(ctx, store, videoID, spec.Name)
+		if !ok {
+			log.Printf("Packaging %s: no playlist for rendition %s", videoID, spec.Name)
+			continue
+		}
+		sources = append(sources, source{
+			name:      spec.Name,
+			playlist:  playlist,
+			width:     spec.Width,
+			height:    spec.Height,
+			frameRate: float64(spec.FrameRate),
+			codecs:    Codecs(VideoCodecs(profile.VideoCodec, spec.Height, float64(spec.FrameRate)), audio),
+		})
+	}
+	return sources
+}
+
+func masterSources(ctx context.Context, store storage.Storage, videoID string) ([]source, error) {
+	data, err := readAll(ctx, store, Dir(videoID)+"master.m3u8")
+	if err != nil {
+		return nil, fmt.Errorf("describe %s: %w", videoID, err)
+	}
+	var sources []source
+	for _, v := range ParseMasterPlaylist(data) {
+		name := VariantName(v.URI)
+		codecs := v.Codecs
+		if codecs == "" {
+			codecs = Codecs(VideoCodecs("", v.Height, v.FrameRate), AudioCodecs("aac"))
+		}
+		sources = append(sources, source{
+			name:      name,
+			playlist:  v.URI,
+			width:     v.Width,
+			height:    v.Height,
+			frameRate: v.FrameRate,
+			codecs:    codecs,
+		})
+	}
+	return sources, nil
+}
+
+// findPlaylist looks for the media playlist of a rendition in the layouts
+// the encoder has used, returning its path relative to the video directory.
+func findPlaylist(ctx context.Context, store storage.Storage, videoID, name string) (string, bool) {
+	for _, candidate := range []string{name + "/" + name + ".m3u8", name + "/index.m3u8", name + ".m3u8"} {
+		obj, err := store.Open(ctx, Dir(videoID)+candidate)
+		if err == nil {
+			obj.Close()
+			return candidate, true
+		}
+	}
+	return "", false
+}
+
+func describeRendition(ctx context.Context, store storage.Storage, videoID string, src source) (Rendition, MediaPlaylist, error) {
+	data, err := readAll(ctx, store, Dir(videoID)+src.playlist)
+	if err != nil {
+		return Rendition{}, MediaPlaylist{}, err
+	}
+	mp := ParseMediaPlaylist(data)
+	if len(mp.Segments) == 0 {
+		return Rendition{}, mp, errors.New("playlist has no segments")
+	}
+
+	base := path.Dir(src.playlist)
+	r := Rendition{
+		Name:      src.name,
+		Width:     src.width,
+		Height:    src.height,
+		FrameRate: src.frameRate,
+		Codecs:    src.codecs,
+		Playlist:  src.playlist,
+	}
+	if mp.Init != "" {
+		r.Init = resolve(base, mp.Init)
+	}
+	// Encrypted segments cannot be parsed, and byte ranges into them could
+	// not be decrypted anyway.
+	scan := r.Init == "" && mp.Method == ""
+	var total int64
+	for _, seg := range mp.Segments {
+		seg.URI = resolve(base, seg.URI)
+		if scan && !strings.Contains(seg.URI, "://") {
+			if data, err := readAll(ctx, store, Dir(videoID)+seg.URI); err == nil {
+				seg.Size = int64(len(data))
+				seg.Keyframes = ScanKeyframes(data)
+			}
+		} else if obj, err := store.Open(ctx, Dir(videoID)+seg.URI); err == nil {
+			seg.Size = obj.Size()
+			obj.Close()
+		}
+		if seg.Duration > 0 {
+			r.Bandwidth = max(r.Bandwidth, int64(float64(seg.Size*8)/seg.Duration))
+		}
+		total += seg.Size
+		r.Segments = append(r.Segments, seg)
+	}
+	if d := r.Duration(); d > 0 {
+		r.AverageBandwidth = int64(float64(total*8) / d)
+	}
+	// A progressive copy would bypass the encryption of the segments.
+	if mp.Method == "" {
+		r.MP4, r.MP4Size = findMP4(ctx, store, videoID, src)
+	}
+	return r, mp, nil
+}
+
+// findMP4 looks for the progressive MP4 the encoder writes next to a
+// rendition's playlist.
+func findMP4(ctx context.Context, store storage.Storage, videoID string, src source) (string, int64) {
+	name := path.Join(path.Dir(src.playlist), src.name+".mp4")
+	obj, err := store.Open(ctx, Dir(videoID)+name)
+	if err != nil {
+		return "", 0
+	}
+	defer obj.Close()
+	return name, obj.Size()
+}
+
+// resolve makes a URI from a playlist relative to the video directory.
+// Absolute URIs are kept.
+func resolve(base, uri string) string {
+	if strings.Contains(uri, "://") || strings.HasPrefix(uri, "/") {
+		return uri
+	}
+	return path.Join(base, uri)
+}
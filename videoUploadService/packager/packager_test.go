@@ -0,0 +1,232 @@
+package packager
+
+import (
+	"VideoUploadService/storage"
+	pbt "VideoUploadService/transcoding"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// writeOutput lays out encoder output for video v under root.
+func writeOutput(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(root, Dir("v"), name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestDescribeEncryption(t *testing.T) {
+	const master = "#EXTM3U\n#EXT-X-STREAM-INF:BANDWIDTH=800000,RESOLUTION=640x360\n360p/360p.m3u8\n"
+	const kid = "0123456789abcdef0123456789abcdef"
+	cenc := func(keyAttrs string) map[string]string {
+		return map[string]string{
+			"master.m3u8": master,
+			"360p/360p.m3u8": "#EXTM3U\n#EXT-X-MAP:URI=\"init.mp4\"\n" +
+				"#EXT-X-KEY:METHOD=SAMPLE-AES-CTR,KEYFORMAT=\"org.w3.clearkey\"" + keyAttrs + ",URI=\"data:\"\n" +
+				"#EXTINF:4,\nseg0.m4s\n#EXT-X-ENDLIST\n",
+			"360p/init.mp4": "init",
+			"360p/seg0.m4s": "segment",
+		}
+	}
+	storedKIDs := func(ctx context.Context, videoID string) ([]string, error) {
+		return []string{"ffffffffffffffffffffffffffffffff"}, nil
+	}
+
+	tests := []struct {
+		name          string
+		files         map[string]string
+		profile       *pbt.EncodingProfile
+		keyIDs        func(ctx context.Context, videoID string) ([]string, error)
+		wantFormat    string
+		wantEncrypted bool
+		wantKIDs      []string
+		wantErr       bool
+	}{
+		{
+			name: "clear mpeg-ts",
+			files: map[string]string{
+				"master.m3u8":    master,
+				"360p/360p.m3u8": "#EXTM3U\n#EXTINF:4,\nseg0.ts\n#EXT-X-ENDLIST\n",
+				"360p/seg0.ts":   "",
+			},
+			wantFormat: FormatTS,
+		},
+		{
+			name: "hls aes-128",
+			files: map[string]string{
+				"master.m3u8":    master,
+				"360p/360p.m3u8": "#EXTM3U\n#EXT-X-KEY:METHOD=AES-128,URI=\"key?i=0\"\n#EXTINF:4,\nseg0.ts\n#EXT-X-ENDLIST\n",
+				"360p/seg0.ts":   "",
+			},
+			keyIDs:        storedKIDs,
+			wantFormat:    FormatTS,
+			wantEncrypted: true,
+		},
+		{
+			name:       "cenc with playlist key ids",
+			files:      cenc(",KEYID=0x" + kid),
+			keyIDs:     storedKIDs,
+			wantFormat: FormatFMP4,
+			wantKIDs:   []string{kid},
+		},
+		{
+			name:       "cenc with stored key ids",
+			files:      cenc(""),
+			keyIDs:     storedKIDs,
+			wantFormat: FormatFMP4,
+			wantKIDs:   []string{"ffffffffffffffffffffffffffffffff"},
+		},
+		{
+			name:       "cenc with profile key ids",
+			files:      cenc(""),
+			profile:    &pbt.EncodingProfile{Encryption: &pbt.HlsEncryption{Keys: []*pbt.ContentKey{{Kid: []byte{0x01, 0x23, 0x45, 0x67, 0x89, 0xab, 0xcd, 0xef, 0x01, 0x23, 0x45, 0x67, 0x89, 0xab, 0xcd, 0xef}}}}},
+			keyIDs:     storedKIDs,
+			wantFormat: FormatFMP4,
+			wantKIDs:   []string{kid},
+		},
+		{
+			name:          "cenc without known key ids",
+			files:         cenc(""),
+			wantFormat:    FormatFMP4,
+			wantEncrypted: true,
+		},
+		{
+			name:  "key ids unavailable",
+			files: cenc(""),
+			keyIDs: func(ctx context.Context, videoID string) ([]string, error) {
+				return nil, errors.New("database is down")
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir() + "/"
+			writeOutput(t, root, tt.files)
+			defer func(orig func(context.Context, string) ([]string, error)) { KeyIDs = orig }(KeyIDs)
+			KeyIDs = tt.keyIDs
+
+			m, err := Describe(context.Background(), storage.NewLocal(root), "v", tt.profile)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if m.Format != tt.wantFormat || m.Encrypted != tt.wantEncrypted || !slices.Equal(m.KeyIDs, tt.wantKIDs) {
+				t.Errorf("format %s, encrypted %v, key IDs %v; want %s, %v, %v",
+					m.Format, m.Encrypted, m.KeyIDs, tt.wantFormat, tt.wantEncrypted, tt.wantKIDs)
+			}
+			_, dashErr := DASH(m, DASHOptions{})
+			if dashErr != nil != tt.wantEncrypted {
+				t.Errorf("DASH error = %v", dashErr)
+			}
+		})
+	}
+}
+
+// countingStore counts the reads of a video's stored metadata.
+type countingStore struct {
+	storage.Storage
+	mu    sync.Mutex
+	loads int
+}
+
+func (s *countingStore) Open(ctx context.Context, key string) (storage.Object, error) {
+	if key == Dir("v")+MetadataFile {
+		s.mu.Lock()
+		s.loads++
+		s.mu.Unlock()
+	}
+	return s.Storage.Open(ctx, key)
+}
+
+func TestMetadataDescribesInBackground(t *testing.T) {
+	root := t.TempDir()
+	writeOutput(t, root, map[string]string{
+		"master.m3u8":    "#EXTM3U\n#EXT-X-STREAM-INF:BANDWIDTH=800000,RESOLUTION=640x360\n360p/360p.m3u8\n",
+		"360p/360p.m3u8": "#EXTM3U\n#EXTINF:4,\nseg0.ts\n#EXT-X-ENDLIST\n",
+		"360p/seg0.ts":   "",
+	})
+	store := &countingStore{Storage: storage.NewLocal(root)}
+	p := New(store)
+	ctx := context.Background()
+
+	// Output without stored metadata is not described on the request path.
+	if _, err := p.Metadata(ctx, "v"); !errors.Is(err, ErrNotPackaged) {
+		t.Fatalf("err = %v, want ErrNotPackaged", err)
+	}
+	// Until the background describe is done, requests are answered from the
+	// cache.
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := p.Metadata(ctx, "v"); err != nil && !errors.Is(err, ErrNotPackaged) {
+				t.Errorf("err = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		m, err := p.Metadata(ctx, "v")
+		if err == nil {
+			if len(m.Renditions) != 1 || m.Renditions[0].Name != "360p" {
+				t.Errorf("renditions %+v", m.Renditions)
+			}
+			break
+		}
+		if !errors.Is(err, ErrNotPackaged) || time.Now().After(deadline) {
+			t.Fatalf("err = %v after describing in the background", err)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	// One load found nothing; the background describe cached what it saved.
+	store.mu.Lock()
+	loads := store.loads
+	store.mu.Unlock()
+	if loads != 1 {
+		t.Errorf("metadata loaded %d times, want 1", loads)
+	}
+	if _, err := Load(ctx, store, "v"); err != nil {
+		t.Errorf("described metadata was not saved: %v", err)
+	}
+}
+
+func TestMetadataCachesFailures(t *testing.T) {
+	store := &countingStore{Storage: storage.NewLocal(t.TempDir())}
+	p := New(store)
+	for i := 0; i < 3; i++ {
+		if _, err := p.Metadata(context.Background(), "v"); !errors.Is(err, ErrNotPackaged) {
+			t.Fatalf("err = %v, want ErrNotPackaged", err)
+		}
+	}
+	// Let the background describe fail, then ask again.
+	time.Sleep(50 * time.Millisecond)
+	if _, err := p.Metadata(context.Background(), "v"); !errors.Is(err, ErrNotPackaged) {
+		t.Fatalf("err = %v, want ErrNotPackaged", err)
+	}
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if store.loads != 1 {
+		t.Errorf("metadata of output that cannot be described loaded %d times, want 1", store.loads)
+	}
+}
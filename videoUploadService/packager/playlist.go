@@ -0,0 +1,145 @@
+package packager
+
+import (
+	"bufio"
+	"bytes"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// MediaPlaylist is the part of an HLS media playlist the packager needs.
+type MediaPlaylist struct {
+	Segments []Segment
+	// Init is the URI of the EXT-X-MAP initialization segment of fMP4 output.
+	Init string
+	// Method is the EXT-X-KEY method, empty for clear segments.
+	Method string
+	// KeyIDs are the hex KEYID attributes of the EXT-X-KEY tags, in order of
+	// first use.
+	KeyIDs []string
+}
+
+// Variant is one EXT-X-STREAM-INF entry of a master playlist.
+type Variant struct {
+	URI              string
+	Bandwidth        int64
+	AverageBandwidth int64
+	Width            uint32
+	Height           uint32
+	FrameRate        float64
+	Codecs           string
+}
+
+// ParseMediaPlaylist reads the segments of a media playlist. Segment URIs
+// are returned as written.
+func ParseMediaPlaylist(data []byte) MediaPlaylist {
+	var p MediaPlaylist
+	var duration float64
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		tag, value, _ := strings.Cut(line, ":")
+		switch {
+		case line == "":
+		case tag == "#EXTINF":
+			d, _, _ := strings.Cut(value, ",")
+			duration, _ = strconv.ParseFloat(d, 64)
+		case tag == "#EXT-X-MAP":
+			p.Init = parseAttributes(value)["URI"]
+		case tag == "#EXT-X-KEY":
+			attrs := parseAttributes(value)
+			if m := attrs["METHOD"]; m != "NONE" {
+				p.Method = m
+			}
+			kid := strings.ToLower(strings.TrimPrefix(strings.TrimPrefix(attrs["KEYID"], "0x"), "0X"))
+			if kid != "" && !slices.Contains(p.KeyIDs, kid) {
+				p.KeyIDs = append(p.KeyIDs, kid)
+			}
+		case strings.HasPrefix(line, "#"):
+		default:
+			p.Segments = append(p.Segments, Segment{URI: line, Duration: duration})
+			duration = 0
+		}
+	}
+	return p
+}
+
+// ParseMasterPlaylist reads the variants of a master playlist. Malformed
+// BANDWIDTH values such as "800000K" are read up to the first non-digit.
+func ParseMasterPlaylist(data []byte) []Variant {
+	var variants []Variant
+	var next *Variant
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		tag, value, _ := strings.Cut(line, ":")
+		switch {
+		case line == "":
+		case tag == "#EXT-X-STREAM-INF":
+			attrs := parseAttributes(value)
+			v := Variant{
+				Bandwidth:        leadingInt(attrs["BANDWIDTH"]),
+				AverageBandwidth: leadingInt(attrs["AVERAGE-BANDWIDTH"]),
+				Codecs:           attrs["CODECS"],
+			}
+			v.FrameRate, _ = strconv.ParseFloat(attrs["FRAME-RATE"], 64)
+			if w, h, ok := strings.Cut(attrs["RESOLUTION"], "x"); ok {
+				v.Width, v.Height = uint32(leadingInt(w)), uint32(leadingInt(h))
+			}
+			next = &v
+		case strings.HasPrefix(line, "#"):
+		case next != nil:
+			next.URI = line
+			variants = append(variants, *next)
+			next = nil
+		}
+	}
+	return variants
+}
+
+// VariantName is the rendition name of a variant URI of a master playlist:
+// its directory, or the playlist name for playlists next to the master.
+func VariantName(uri string) string {
+	if name := path.Base(path.Dir(uri)); name != "." {
+		return name
+	}
+	return strings.TrimSuffix(path.Base(uri), ".m3u8")
+}
+
+// parseAttributes parses an HLS attribute list such as
+// `METHOD=AES-128,URI="key?a=1,b=2"`. Quotes are removed from values.
+func parseAttributes(s string) map[string]string {
+	attrs := make(map[string]string)
+	for s != "" {
+		name, rest, ok := strings.Cut(s, "=")
+		if !ok {
+			break
+		}
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.IndexByte(rest[1:], '"')
+			if end < 0 {
+				value, rest = rest[1:], ""
+			} else {
+				value, rest = rest[1:end+1], rest[end+2:]
+			}
+			rest = strings.TrimPrefix(rest, ",")
+		} else {
+			value, rest, _ = strings.Cut(rest, ",")
+		}
+		attrs[strings.TrimSpace(name)] = value
+		s = rest
+	}
+	return attrs
+}
+
+func leadingInt(s string) int64 {
+	end := 0
+	for end < len(s) && s[end] >= '0' && s[end] <= '9' {
+		end++
+	}
+	n, _ := strconv.ParseInt(s[:end], 10, 64)
+	return n
+}
@@ -0,0 +1,137 @@
+package packager
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseMediaPlaylist(t *testing.T) {
+	tests := []struct {
+		name     string
+		playlist string
+		want     MediaPlaylist
+	}{
+		{
+			name: "mpeg-ts",
+			playlist: `#EXTM3U
+#EXT-X-VERSION:3
+#EXT-X-TARGETDURATION:4
+#EXTINF:4.000,
+seg0.ts
+#EXTINF:2.5,title
+seg1.ts
+#EXT-X-ENDLIST
+`,
+			want: MediaPlaylist{Segments: []Segment{{URI: "seg0.ts", Duration: 4}, {URI: "seg1.ts", Duration: 2.5}}},
+		},
+		{
+			name: "fmp4",
+			playlist: `#EXTM3U
+#EXT-X-VERSION:7
+#EXT-X-MAP:URI="init.mp4"
+#EXTINF:6,
+seg0.m4s
+#EXTINF:6,
+seg1.m4s
+`,
+			want: MediaPlaylist{Init: "init.mp4", Segments: []Segment{{URI: "seg0.m4s", Duration: 6}, {URI: "seg1.m4s", Duration: 6}}},
+		},
+		{
+			name: "aes-128 with rotation",
+			playlist: `#EXTM3U
+#EXT-X-KEY:METHOD=AES-128,URI="key?i=0,t=x",IV=0x01
+#EXTINF:4,
+seg0.ts
+#EXT-X-KEY:METHOD=AES-128,URI="key?i=1,t=x",IV=0x02
+#EXTINF:4,
+seg1.ts
+`,
+			want: MediaPlaylist{Method: "AES-128", Segments: []Segment{{URI: "seg0.ts", Duration: 4}, {URI: "seg1.ts", Duration: 4}}},
+		},
+		{
+			name: "cenc",
+			playlist: `#EXTM3U
+#EXT-X-MAP:URI="init.mp4"
+#EXT-X-KEY:METHOD=SAMPLE-AES-CTR,KEYFORMAT="org.w3.clearkey",KEYID=0x0123456789ABCDEF0123456789ABCDEF,URI="data:"
+#EXTINF:6,
+seg0.m4s
+#EXT-X-KEY:METHOD=SAMPLE-AES-CTR,KEYFORMAT="org.w3.clearkey",KEYID=0x0123456789abcdef0123456789abcdef,URI="data:"
+#EXTINF:6,
+seg1.m4s
+#EXT-X-KEY:METHOD=SAMPLE-AES-CTR,KEYFORMAT="org.w3.clearkey",KEYID=0xfedcba9876543210fedcba9876543210,URI="data:"
+#EXTINF:6,
+seg2.m4s
+`,
+			want: MediaPlaylist{
+				Init:     "init.mp4",
+				Method:   "SAMPLE-AES-CTR",
+				KeyIDs:   []string{"0123456789abcdef0123456789abcdef", "fedcba9876543210fedcba9876543210"},
+				Segments: []Segment{{URI: "seg0.m4s", Duration: 6}, {URI: "seg1.m4s", Duration: 6}, {URI: "seg2.m4s", Duration: 6}},
+			},
+		},
+		{
+			name: "clear after METHOD=NONE",
+			playlist: `#EXTM3U
+#EXT-X-KEY:METHOD=NONE
+#EXTINF:4,
+seg0.ts
+`,
+			want: MediaPlaylist{Segments: []Segment{{URI: "seg0.ts", Duration: 4}}},
+		},
+		{name: "empty", playlist: "", want: MediaPlaylist{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseMediaPlaylist([]byte(tt.playlist)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseMasterPlaylist(t *testing.T) {
+	tests := []struct {
+		name     string
+		playlist string
+		want     []Variant
+	}{
+		{
+			name: "variants",
+			playlist: `#EXTM3U
+#EXT-X-STREAM-INF:BANDWIDTH=800000,AVERAGE-BANDWIDTH=600000,RESOLUTION=640x360,FRAME-RATE=29.970,CODECS="avc1.4d401e,mp4a.40.2"
+360p/360p.m3u8
+
+#EXT-X-STREAM-INF:BANDWIDTH=2800000,RESOLUTION=1280x720
+720p/720p.m3u8
+`,
+			want: []Variant{
+				{URI: "360p/360p.m3u8", Bandwidth: 800000, AverageBandwidth: 600000, Width: 640, Height: 360, FrameRate: 29.97, Codecs: "avc1.4d401e,mp4a.40.2"},
+				{URI: "720p/720p.m3u8", Bandwidth: 2800000, Width: 1280, Height: 720},
+			},
+		},
+		{
+			name: "malformed bandwidth",
+			playlist: `#EXTM3U
+#EXT-X-STREAM-INF:BANDWIDTH=800000K,RESOLUTION=640x360p
+360p.m3u8
+`,
+			want: []Variant{{URI: "360p.m3u8", Bandwidth: 800000, Width: 640, Height: 360}},
+		},
+		{
+			name: "uri without stream-inf",
+			playlist: `#EXTM3U
+#EXT-X-INDEPENDENT-SEGMENTS
+stray.m3u8
+#EXT-X-I-FRAME-STREAM-INF:BANDWIDTH=100000,URI="iframes.m3u8"
+`,
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseMasterPlaylist([]byte(tt.playlist)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,18 @@
+package packager
+
+// Progressive picks the rendition served as a progressive MP4: the best one
+// passing the filter, or the lowest when none does. It reports false when
+// the encoder wrote no MP4 files.
+func Progressive(m Metadata, f Filter) (Rendition, bool) {
+	var withMP4 []Rendition
+	for _, r := range m.Renditions {
+		if r.MP4 != "" {
+			withMP4 = append(withMP4, r)
+		}
+	}
+	selected := Select(withMP4, f)
+	if len(selected) == 0 {
+		return Rendition{}, false
+	}
+	return selected[len(selected)-1], true
+}
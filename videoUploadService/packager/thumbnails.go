@@ -0,0 +1,157 @@
+package packager
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+)
+
+// ThumbnailTrackName is the WebVTT track served next to the master playlist.
+const ThumbnailTrackName = "thumbnails.vtt"
+
+// SpriteConfig controls the trick-play thumbnails. Thumbnails are taken
+// every Interval seconds and tiled Columns by Rows into each sprite sheet.
+type SpriteConfig struct {
+	Interval float64
+	Width    uint32
+	Columns  int
+	Rows     int
+}
+
+var DefaultSprites = SpriteConfig{Interval: 5, Width: 160, Columns: 10, Rows: 10}
+
+// Thumbnails describes the sprite sheets of a video.
+type Thumbnails struct {
+	Interval float64  `json:"interval"`
+	Width    uint32   `json:"width"`
+	Height   uint32   `json:"height"`
+	Columns  int      `json:"columns"`
+	Rows     int      `json:"rows"`
+	Sheets   []string `json:"sheets"`
+}
+
+// SetSprites enables sprite sheet generation with ffmpeg. localRoot is the
+// directory on disk holding the storage's objects, from which ffmpeg reads.
+func (p *Packager) SetSprites(cfg SpriteConfig, localRoot string) {
+	p.sprites, p.localRoot = cfg, localRoot
+}
+
+// GenerateSprites renders the sprite sheets of a packaged video from its
+// lowest rendition and records them in the metadata. It is a no-op unless
+// SetSprites was called.
+func (p *Packager) GenerateSprites(ctx context.Context, videoID string) error {
+	if p.localRoot == "" {
+		return nil
+	}
+	m, err := p.Metadata(ctx, videoID)
+	if err != nil {
+		return err
+	}
+	if m.Encrypted {
+		// ffmpeg cannot fetch keys from the authenticated key endpoint.
+		return errors.New("sprites of encrypted videos are not supported")
+	}
+	lowest := Select(m.Renditions, Filter{})
+	if len(lowest) == 0 {
+		return errNoRenditions
+	}
+	src := lowest[0]
+	cfg := p.sprites
+	height := cfg.Width * 9 / 16
+	if src.Width > 0 {
+		height = uint32(math.Round(float64(cfg.Width) * float64(src.Height) / float64(src.Width)))
+	}
+	height &^= 1
+
+	tmp, err := os.MkdirTemp("", "sprites-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+	input := filepath.Join(p.localRoot, filepath.FromSlash(Dir(videoID)+src.Playlist))
+	filter := fmt.Sprintf("fps=1/%g,scale=%d:%d,tile=%dx%d", cfg.Interval, cfg.Width, height, cfg.Columns, cfg.Rows)
+	cmd := exec.CommandContext(ctx, "ffmpeg", "-v", "error", "-i", input, "-vf", filter, "-q:v", "5",
+		filepath.Join(tmp, "sprite_%03d.jpg"))
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("ffmpeg: %v: %s", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+
+	files, err := filepath.Glob(filepath.Join(tmp, "sprite_*.jpg"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(files)
+	thumbs := &Thumbnails{Interval: cfg.Interval, Width: cfg.Width, Height: height, Columns: cfg.Columns, Rows: cfg.Rows}
+	for _, f := range files {
+		name := "thumbs/" + filepath.Base(f)
+		if err := p.copyIn(ctx, f, Dir(videoID)+name); err != nil {
+			return err
+		}
+		thumbs.Sheets = append(thumbs.Sheets, name)
+	}
+
+	m.Thumbnails = thumbs
+	if err := Save(ctx, p.store, m); err != nil {
+		return err
+	}
+	p.remember(m)
+	return nil
+}
+
+func (p *Packager) copyIn(ctx context.Context, file, key string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w, err := p.store.Create(ctx, key)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(w, f); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// ThumbnailTrack renders the WebVTT track pointing each time range at its
+// tile in a sprite sheet. Sheet URIs are relative to the video directory.
+// It reports false for videos without thumbnails.
+func ThumbnailTrack(m Metadata, mapURI func(uri string) string) ([]byte, bool) {
+	t := m.Thumbnails
+	if t == nil || len(t.Sheets) == 0 || t.Interval <= 0 {
+		return nil, false
+	}
+	perSheet := t.Columns * t.Rows
+	var b bytes.Buffer
+	b.WriteString("WEBVTT\n")
+	for i := 0; float64(i)*t.Interval < m.DurationSeconds && i/perSheet < len(t.Sheets); i++ {
+		start := float64(i) * t.Interval
+		end := math.Min(start+t.Interval, m.DurationSeconds)
+		tile := i % perSheet
+		uri := path.Clean(t.Sheets[i/perSheet])
+		if mapURI != nil {
+			uri = mapURI(uri)
+		}
+		fmt.Fprintf(&b, "\n%s --> %s\n%s#xywh=%d,%d,%d,%d\n", vttTime(start), vttTime(end), uri,
+			uint32(tile%t.Columns)*t.Width, uint32(tile/t.Columns)*t.Height, t.Width, t.Height)
+	}
+	return b.Bytes(), true
+}
+
+// vttTime formats seconds as a WebVTT timestamp, e.g. "00:01:05.250".
+func vttTime(seconds float64) string {
+	ms := int64(math.Round(seconds * 1000))
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
@@ -0,0 +1,93 @@
+package packager
+
+import "testing"
+
+func TestThumbnailTrack(t *testing.T) {
+	sheets := &Thumbnails{Interval: 5, Width: 160, Height: 90, Columns: 2, Rows: 2,
+		Sheets: []string{"thumbs/sprite_001.jpg", "thumbs/./sprite_002.jpg"}}
+	oneSheet := *sheets
+	oneSheet.Sheets = sheets.Sheets[:1]
+
+	tests := []struct {
+		name   string
+		m      Metadata
+		mapURI func(string) string
+		want   string
+	}{
+		{
+			name: "tiles across sheets, last cue cut at the end",
+			m:    Metadata{DurationSeconds: 23, Thumbnails: sheets},
+			want: `WEBVTT
+
+00:00:00.000 --> 00:00:05.000
+thumbs/sprite_001.jpg#xywh=0,0,160,90
+
+00:00:05.000 --> 00:00:10.000
+thumbs/sprite_001.jpg#xywh=160,0,160,90
+
+00:00:10.000 --> 00:00:15.000
+thumbs/sprite_001.jpg#xywh=0,90,160,90
+
+00:00:15.000 --> 00:00:20.000
+thumbs/sprite_001.jpg#xywh=160,90,160,90
+
+00:00:20.000 --> 00:00:23.000
+thumbs/sprite_002.jpg#xywh=0,0,160,90
+`,
+		},
+		{
+			name:   "no cues past the last sheet",
+			m:      Metadata{DurationSeconds: 30, Thumbnails: &oneSheet},
+			mapURI: func(uri string) string { return uri + "?token=t" },
+			want: `WEBVTT
+
+00:00:00.000 --> 00:00:05.000
+thumbs/sprite_001.jpg?token=t#xywh=0,0,160,90
+
+00:00:05.000 --> 00:00:10.000
+thumbs/sprite_001.jpg?token=t#xywh=160,0,160,90
+
+00:00:10.000 --> 00:00:15.000
+thumbs/sprite_001.jpg?token=t#xywh=0,90,160,90
+
+00:00:15.000 --> 00:00:20.000
+thumbs/sprite_001.jpg?token=t#xywh=160,90,160,90
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ThumbnailTrack(tt.m, tt.mapURI)
+			if !ok || string(got) != tt.want {
+				t.Errorf("ThumbnailTrack = %v,\n%s\nwant\n%s", ok, got, tt.want)
+			}
+		})
+	}
+
+	for _, m := range []Metadata{
+		{DurationSeconds: 10},
+		{DurationSeconds: 10, Thumbnails: &Thumbnails{Interval: 5}},
+		{DurationSeconds: 10, Thumbnails: &Thumbnails{Sheets: []string{"thumbs/sprite_001.jpg"}}},
+	} {
+		if _, ok := ThumbnailTrack(m, nil); ok {
+			t.Errorf("ThumbnailTrack(%+v) reported a track", m.Thumbnails)
+		}
+	}
+}
+
+func TestVTTTime(t *testing.T) {
+	tests := []struct {
+		seconds float64
+		want    string
+	}{
+		{0, "00:00:00.000"},
+		{65.25, "00:01:05.250"},
+		{3725.0004, "01:02:05.000"},
+		{59.9996, "00:01:00.000"},
+	}
+	for _, tt := range tests {
+		if got := vttTime(tt.seconds); got != tt.want {
+			t.Errorf("vttTime(%g) = %q, want %q", tt.seconds, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package packager
+
+import "bytes"
+
+const tsPacketSize = 188
+
+// MPEG-TS stream types of video codecs.
+const (
+	streamTypeMPEG2 = 0x02
+	streamTypeH264  = 0x1b
+	streamTypeHEVC  = 0x24
+)
+
+// Keyframe is the byte range of a keyframe's PES packet within a segment.
+type Keyframe struct {
+	Offset int64 `json:"offset"`
+	Size   int64 `json:"size"`
+	// Time is the presentation time relative to the first video frame of
+	// the segment, in seconds.
+	Time float64 `json:"time"`
+}
+
+// ScanKeyframes finds the keyframes of the video stream of an MPEG-TS
+// segment. A keyframe's range runs from the first TS packet of its PES
+// packet to the start of the next video PES packet, so it may include
+// interleaved audio packets, which players skip.
+func ScanKeyframes(data []byte) []Keyframe {
+	pmtPID, videoPID, videoType := -1, -1, 0
+	firstPTS := int64(-1)
+	var frames []Keyframe
+	open := -1
+	end := len(data) - len(data)%tsPacketSize
+
+	for off := 0; off < end; off += tsPacketSize {
+		pkt := data[off : off+tsPacketSize]
+		if pkt[0] != 0x47 {
+			continue
+		}
+		start := pkt[1]&0x40 != 0
+		pid := int(pkt[1]&0x1f)<<8 | int(pkt[2])
+		control := pkt[3] >> 4 & 3
+		payload := pkt[4:]
+		randomAccess := false
+		if control&2 != 0 {
+			n := int(payload[0])
+			if 1+n > len(payload) {
+				continue
+			}
+			randomAccess = n > 0 && payload[1]&0x40 != 0
+			payload = payload[1+n:]
+		}
+		if control&1 == 0 || !start {
+			continue
+		}
+
+		switch pid {
+		case 0:
+			pmtPID = parsePAT(payload)
+		case pmtPID:
+			videoPID, videoType = parsePMT(payload)
+		case videoPID:
+			if open >= 0 {
+				frames[open].Size = int64(off) - frames[open].Offset
+				open = -1
+			}
+			pts, ok := parsePTS(payload)
+			if ok && firstPTS < 0 {
+				firstPTS = pts
+			}
+			if !randomAccess && !(videoType == streamTypeH264 && hasIDR(payload)) {
+				continue
+			}
+			k := Keyframe{Offset: int64(off)}
+			if ok {
+				d := pts - firstPTS
+				if d < 0 {
+					d += 1 << 33 // PTS wrapped around
+				}
+				k.Time = float64(d) / 90000
+			}
+			frames = append(frames, k)
+			open = len(frames) - 1
+		}
+	}
+	if open >= 0 {
+		frames[open].Size = int64(end) - frames[open].Offset
+	}
+	return frames
+}
+
+// section returns the PSI section that starts in a payload.
+func section(payload []byte) []byte {
+	if len(payload) == 0 || 1+int(payload[0]) >= len(payload) {
+		return nil
+	}
+	s := payload[1+int(payload[0]):]
+	if len(s) < 3 {
+		return nil
+	}
+	n := 3 + (int(s[1]&0x0f)<<8 | int(s[2]))
+	return s[:min(n, len(s))]
+}
+
+// parsePAT returns the PMT PID of the first program.
+func parsePAT(payload []byte) int {
+	s := section(payload)
+	// Header of 8 bytes, 4 bytes per program, CRC of 4 bytes.
+	for i := 8; i+4 <= len(s)-4; i += 4 {
+		if program := int(s[i])<<8 | int(s[i+1]); program != 0 {
+			return int(s[i+2]&0x1f)<<8 | int(s[i+3])
+		}
+	}
+	return -1
+}
+
+// parsePMT returns the PID and stream type of the first video stream.
+func parsePMT(payload []byte) (int, int) {
+	s := section(payload)
+	if len(s) < 12 {
+		return -1, 0
+	}
+	i := 12 + (int(s[10]&0x0f)<<8 | int(s[11]))
+	for i+5 <= len(s)-4 {
+		typ := int(s[i])
+		pid := int(s[i+1]&0x1f)<<8 | int(s[i+2])
+		switch typ {
+		case streamTypeH264, streamTypeHEVC, streamTypeMPEG2:
+			return pid, typ
+		}
+		i += 5 + (int(s[i+3]&0x0f)<<8 | int(s[i+4]))
+	}
+	return -1, 0
+}
+
+// parsePTS reads the presentation timestamp of a PES packet header.
+func parsePTS(pes []byte) (int64, bool) {
+	if len(pes) < 14 || pes[0] != 0 || pes[1] != 0 || pes[2] != 1 || pes[7]&0x80 == 0 {
+		return 0, false
+	}
+	p := pes[9:14]
+	pts := int64(p[0]>>1&0x07)<<30 | int64(p[1])<<22 | int64(p[2]>>1)<<15 | int64(p[3])<<7 | int64(p[4]>>1)
+	return pts, true
+}
+
+// hasIDR reports whether the start of an H.264 PES packet holds an IDR
+// slice.
+func hasIDR(pes []byte) bool {
+	if len(pes) < 9 {
+		return false
+	}
+	es := pes[min(9+int(pes[8]), len(pes)):]
+	for {
+		i := bytes.Index(es, []byte{0, 0, 1})
+		if i < 0 || i+3 >= len(es) {
+			return false
+		}
+		if es[i+3]&0x1f == 5 {
+			return true
+		}
+		es = es[i+3:]
+	}
+}
@@ -0,0 +1,131 @@
+package packager
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+// tsPackets splits a PSI section or PES packet into TS packets of pid,
+// stuffing the last one. The first packet is flagged for random access if
+// asked.
+func tsPackets(pid int, randomAccess bool, payload []byte) []byte {
+	var out []byte
+	for first := true; first || len(payload) > 0; first = false {
+		header := []byte{0x47, byte(pid>>8) & 0x1f, byte(pid), 0x10}
+		var af []byte
+		if first {
+			header[1] |= 0x40
+			if randomAccess {
+				af = []byte{0x40}
+			}
+		}
+		space := 184
+		if af != nil {
+			space -= 1 + len(af)
+		}
+		if n := space - len(payload); n > 0 {
+			if af == nil {
+				af = []byte{}
+				n--
+				if n > 0 {
+					af = append(af, 0)
+					n--
+				}
+			}
+			af = append(af, bytes.Repeat([]byte{0xff}, n)...)
+			space = len(payload)
+		}
+		if af != nil {
+			header[3] |= 0x20
+			header = append(append(header, byte(len(af))), af...)
+		}
+		out = append(append(out, header...), payload[:space]...)
+		payload = payload[space:]
+	}
+	return out
+}
+
+// ptsPES is a PES packet of the video stream with a PTS and es.
+func ptsPES(pts int64, es []byte) []byte {
+	pes := []byte{0, 0, 1, 0xe0, 0, 0, 0x80, 0x80, 5,
+		byte(0x21 | pts>>29&0x0e), byte(pts >> 22), byte(pts>>14 | 1), byte(pts >> 7), byte(pts<<1 | 1)}
+	return append(pes, es...)
+}
+
+var (
+	// A PAT pointing program 1 at PID 0x1000, and its PMT listing an AAC
+	// stream ahead of the H.264 one on PID 0x100. CRCs are not checked.
+	testPAT = append([]byte{0, 0x00, 0xb0, 0x0d, 0, 1, 0xc1, 0, 0, 0, 1, 0xf0, 0x00}, 0, 0, 0, 0)
+	testPMT = append([]byte{0, 0x02, 0xb0, 0x17, 0, 1, 0xc1, 0, 0, 0xe1, 0x00, 0xf0, 0x00,
+		0x0f, 0xe1, 0x01, 0xf0, 0x00,
+		0x1b, 0xe1, 0x00, 0xf0, 0x00}, 0, 0, 0, 0)
+
+	idrFrame   = append([]byte{0, 0, 0, 1, 0x09, 0xf0, 0, 0, 1, 0x65}, bytes.Repeat([]byte{0xaa}, 300)...)
+	interFrame = []byte{0, 0, 0, 1, 0x09, 0xf0, 0, 0, 1, 0x41, 0xbb}
+	audioPES   = []byte{0, 0, 1, 0xc0, 0, 3, 0x80, 0, 0}
+)
+
+func TestScanKeyframes(t *testing.T) {
+	const p = tsPacketSize
+	tests := []struct {
+		name     string
+		segments [][]byte
+		want     []Keyframe
+	}{
+		{
+			name: "random access flag and IDR slices",
+			segments: [][]byte{
+				tsPackets(0, false, testPAT),
+				tsPackets(0x1000, false, testPMT),
+				tsPackets(0x100, true, ptsPES(9000, idrFrame)), // two packets
+				tsPackets(0x101, false, audioPES),
+				tsPackets(0x100, false, ptsPES(12000, interFrame)),
+				// Keyframe found by its IDR slice without the flag.
+				tsPackets(0x100, false, ptsPES(15000, idrFrame)),
+				tsPackets(0x101, false, audioPES),
+				tsPackets(0x100, false, ptsPES(18000, interFrame)),
+			},
+			want: []Keyframe{
+				{Offset: 2 * p, Size: 3 * p, Time: 0},
+				{Offset: 6 * p, Size: 3 * p, Time: 6000.0 / 90000},
+			},
+		},
+		{
+			name: "last keyframe runs to the last whole packet",
+			segments: [][]byte{
+				tsPackets(0, false, testPAT),
+				tsPackets(0x1000, false, testPMT),
+				tsPackets(0x100, false, ptsPES(0, interFrame)),
+				tsPackets(0x100, true, ptsPES(3000, interFrame)),
+				tsPackets(0x101, false, audioPES),
+				make([]byte, 100),
+			},
+			want: []Keyframe{{Offset: 3 * p, Size: 2 * p, Time: 3000.0 / 90000}},
+		},
+		{
+			name: "PTS wrapping around",
+			segments: [][]byte{
+				tsPackets(0, false, testPAT),
+				tsPackets(0x1000, false, testPMT),
+				tsPackets(0x100, false, ptsPES(1<<33-4500, interFrame)),
+				tsPackets(0x100, true, ptsPES(4500, interFrame)),
+			},
+			want: []Keyframe{{Offset: 3 * p, Size: p, Time: 0.1}},
+		},
+		{
+			name: "without PAT",
+			segments: [][]byte{
+				tsPackets(0x100, true, ptsPES(0, idrFrame)),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ScanKeyframes(bytes.Join(tt.segments, nil))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ScanKeyframes = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package playback
+
+import (
+	"VideoUploadService/packager"
+	"errors"
+	"log"
+	"net/url"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// serveDASH generates the MPD of a video from its rendition metadata. It is
+// authorized and filtered like HLS, and segment URLs carry the same token.
+func (s *Server) serveDASH(c *fiber.Ctx) error {
+	if s.packager == nil {
+		return c.Status(fiber.StatusNotFound).SendString("Not found")
+	}
+	video, err := s.readyVideo(c.Context(), c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).SendString("Video not available")
+	}
+	token := c.Query("token")
+	claims, err := s.authorize(c, video, token)
+	if err != nil {
+		return c.Status(fiber.StatusForbidden).SendString(err.Error())
+	}
+
+	meta, err := s.packager.Metadata(c.Context(), video.ID)
+	if err != nil {
+		log.Printf("Loading rendition metadata of %s: %v", video.ID, err)
+		return c.Status(fiber.StatusNotFound).SendString("Not found")
+	}
+	meta.Renditions = packager.Select(meta.Renditions, s.filter(c, video, claims))
+	opt := packager.DASHOptions{LicenseURL: s.baseURL + "/drm/clearkey/" + video.ID + "/license"}
+	cache := PlaylistCacheControl
+	if token != "" {
+		opt.MapURI = func(uri string) string { return withQuery(uri, "token", token) }
+		opt.LicenseURL += "?token=" + url.QueryEscape(token)
+		cache = SignedPlaylistCacheControl
+	}
+	body, err := packager.DASH(meta, opt)
+	if errors.Is(err, packager.ErrDASHUnsupported) {
+		return c.Status(fiber.StatusNotFound).SendString(err.Error())
+	}
+	if err != nil {
+		return err
+	}
+	return sendBody(c, "manifest.mpd", body, cache)
+}
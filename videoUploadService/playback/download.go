@@ -0,0 +1,236 @@
+package playback
+
+import (
+	"VideoUploadService/catalog"
+	"VideoUploadService/packager"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var (
+	ErrDownloadDisabled = errors.New("the creator has not allowed downloads of this video")
+	ErrNotOwner         = errors.New("only the creator may download the original upload")
+)
+
+// OriginalCacheControl keeps creators' original uploads out of shared caches.
+const OriginalCacheControl = "private, no-cache"
+
+// OriginalKey is where the raw upload of a video is kept in storage.
+func OriginalKey(videoID string) string {
+	return videoID
+}
+
+// ownedBy reports whether the token was issued to the video's creator.
+func ownedBy(video catalog.Video, claims Claims) bool {
+	return claims.Viewer != "" && claims.Viewer == video.Owner
+}
+
+// mayDownload reports whether the viewer may save the video as a file.
+func mayDownload(video catalog.Video, claims Claims) bool {
+	return video.AllowDownload || ownedBy(video, claims)
+}
+
+// serveProgressive serves the MP4 file of a rendition with range support,
+// for players that cannot play HLS. With ?download=true it is sent as an
+// attachment, which needs the creator's permission.
+func (s *Server) serveProgressive(c *fiber.Ctx) error {
+	if s.packager == nil {
+		return c.Status(fiber.StatusNotFound).SendString("Not found")
+	}
+	video, err := s.readyVideo(c.Context(), c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).SendString("Video not available")
+	}
+	claims, err := s.authorize(c, video, c.Query("token"))
+	if err != nil {
+		return c.Status(fiber.StatusForbidden).SendString(err.Error())
+	}
+	attachment := c.QueryBool("download")
+	if attachment && !mayDownload(video, claims) {
+		return c.Status(fiber.StatusForbidden).SendString(ErrDownloadDisabled.Error())
+	}
+	meta, err := s.packager.Metadata(c.Context(), video.ID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).SendString("Not found")
+	}
+	r, ok := meta.Rendition(c.Params("rendition"))
+	if !ok || r.MP4 == "" {
+		return c.Status(fiber.StatusNotFound).SendString("Not found")
+	}
+	if !s.entitledTo(video, claims, meta, r) {
+		return c.Status(fiber.StatusForbidden).SendString(ErrNotEntitled.Error())
+	}
+
+	key := packager.Dir(video.ID) + r.MP4
+	obj, err := s.store.Open(c.Context(), key)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).SendString("Not found")
+	}
+	disposition := "inline"
+	if attachment {
+		disposition = "attachment"
+	}
+	c.Set(fiber.HeaderContentDisposition, contentDisposition(disposition, fileName(video, r.Name, ".mp4")))
+	return serveObject(c, key, obj)
+}
+
+// serveOriginal sends the creator their raw upload. It always needs an
+// original-scoped token issued to the creator.
+func (s *Server) serveOriginal(c *fiber.Ctx) error {
+	video, err := s.catalog.Get(c.Context(), c.Params("id"))
+	if err != nil || video.State == catalog.StateDeleted || video.State == catalog.StateUploading {
+		return c.Status(fiber.StatusNotFound).SendString("Video not available")
+	}
+	token := c.Query("token")
+	if token == "" {
+		return c.Status(fiber.StatusUnauthorized).SendString(ErrInvalidToken.Error())
+	}
+	claims, err := s.authorizeScope(c, video, token, ScopeOriginal)
+	if err != nil {
+		return c.Status(fiber.StatusForbidden).SendString(err.Error())
+	}
+	if !ownedBy(video, claims) {
+		return c.Status(fiber.StatusForbidden).SendString(ErrNotOwner.Error())
+	}
+
+	obj, err := s.store.Open(c.Context(), OriginalKey(video.ID))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).SendString("Not found")
+	}
+	head := make([]byte, 16)
+	n, _ := io.ReadFull(obj, head)
+	if _, err := obj.Seek(0, io.SeekStart); err != nil {
+		obj.Close()
+		return err
+	}
+	name := fileName(video, "", containerExt(head[:n]))
+	c.Set(fiber.HeaderContentDisposition, contentDisposition("attachment", name))
+	return serveObjectWith(c, name, obj, OriginalCacheControl)
+}
+
+// containerExt guesses the file extension of an upload from its first
+// bytes. Uploads are stored without their original file name.
+func containerExt(head []byte) string {
+	switch {
+	case len(head) >= 12 && string(head[4:8]) == "ftyp" && string(head[8:12]) == "qt  ":
+		return ".mov"
+	case len(head) >= 8 && string(head[4:8]) == "ftyp":
+		return ".mp4"
+	case len(head) >= 4 && string(head[:4]) == "\x1a\x45\xdf\xa3":
+		return ".mkv"
+	case len(head) >= 12 && string(head[:4]) == "RIFF" && string(head[8:12]) == "AVI ":
+		return ".avi"
+	case len(head) >= 3 && string(head[:3]) == "FLV":
+		return ".flv"
+	default:
+		return ""
+	}
+}
+
+// fileName builds a download file name from the video title, e.g.
+// "Holiday (720p).mp4".
+func fileName(video catalog.Video, variant, ext string) string {
+	base := strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || unicode.IsControl(r) {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(video.Title))
+	if base == "" {
+		base = video.ID
+	}
+	if variant != "" {
+		base += " (" + variant + ")"
+	}
+	return base + ext
+}
+
+// contentDisposition builds the header with an ASCII fallback name and the
+// RFC 6266 UTF-8 name.
+func contentDisposition(disposition, name string) string {
+	fallback := strings.Map(func(r rune) rune {
+		if r < 0x20 || r > 0x7e || r == '"' || r == '\\' {
+			return '_'
+		}
+		return r
+	}, name)
+	var encoded strings.Builder
+	for _, b := range []byte(name) {
+		if 'a' <= b && b <= 'z' || 'A' <= b && b <= 'Z' || '0' <= b && b <= '9' || strings.IndexByte("!#$&+-.^_`|~", b) >= 0 {
+			encoded.WriteByte(b)
+		} else {
+			fmt.Fprintf(&encoded, "%%%02X", b)
+		}
+	}
+	return fmt.Sprintf(`%s; filename="%s"; filename*=UTF-8''%s`, disposition, fallback, encoded.String())
+}
+
+// Download is a rendition that can be saved as a file.
+type Download struct {
+	Name   string `json:"name"`
+	Width  uint32 `json:"width,omitempty"`
+	Height uint32 `json:"height,omitempty"`
+	Size   int64  `json:"size"`
+	URL    string `json:"url"`
+}
+
+// Downloads lists signed download URLs of a video.
+type Downloads struct {
+	Renditions []Download `json:"renditions"`
+	// Original is only offered to the creator.
+	Original  string    `json:"original,omitempty"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Downloads returns signed URLs for saving a video. Viewers get the
+// renditions of their tier if the creator allows downloads; creators always
+// get every rendition and their original upload.
+func (s *Server) Downloads(ctx context.Context, viewer catalog.Viewer, tier, videoID, ip string) (Downloads, error) {
+	video, err := s.catalog.View(ctx, viewer, videoID)
+	if err != nil {
+		return Downloads{}, err
+	}
+	if s.signer == nil {
+		return Downloads{}, ErrSigningDisabled
+	}
+	claims := Claims{VideoID: video.ID, Expires: ExpiresIn(s.tokenTTL), Viewer: viewer.ID, IP: ip, Tier: tier}
+	if !mayDownload(video, claims) {
+		return Downloads{}, ErrDownloadDisabled
+	}
+	query := "?token=" + url.QueryEscape(s.signer.Sign(claims))
+	base := s.baseURL + "/download/" + video.ID + "/"
+
+	res := Downloads{Renditions: []Download{}, ExpiresAt: time.Unix(claims.Expires, 0).UTC()}
+	if video.State == catalog.StateReady && s.packager != nil {
+		if meta, err := s.packager.Metadata(ctx, video.ID); err == nil {
+			for _, r := range packager.Select(meta.Renditions, packager.Filter{}) {
+				if r.MP4 == "" || !s.entitledTo(video, claims, meta, r) {
+					continue
+				}
+				res.Renditions = append(res.Renditions, Download{
+					Name:   r.Name,
+					Width:  r.Width,
+					Height: r.Height,
+					Size:   r.MP4Size,
+					URL:    base + r.Name + ".mp4" + query + "&download=true",
+				})
+			}
+		}
+	}
+	if ownedBy(video, claims) && video.State != catalog.StateUploading {
+		// The raw upload gets a token of its own that expires soon, so a
+		// leaked playback URL does not expose it.
+		original := claims
+		original.Expires, original.Scope = ExpiresIn(min(s.tokenTTL, OriginalTokenTTL)), ScopeOriginal
+		res.Original = base + "original?token=" + url.QueryEscape(s.signer.Sign(original))
+	}
+	return res, nil
+}
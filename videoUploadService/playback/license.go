@@ -0,0 +1,113 @@
+package playback
+
+import (
+	"VideoUploadService/catalog"
+	"VideoUploadService/clearkey"
+	"encoding/json"
+	"log"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetRecorder captures license requests for local replay.
+func (s *Server) SetRecorder(r *clearkey.Recorder) {
+	s.recorder = r
+}
+
+// serveLicense answers W3C ClearKey license requests for CENC-packaged
+// videos. The player sends its playback token either as the "token" query
+// parameter or as a bearer token, and only gets the keys of a video the
+// token's viewer may watch.
+func (s *Server) serveLicense(c *fiber.Ctx) error {
+	id := c.Params("id")
+	token := c.Query("token")
+	if bearer, ok := strings.CutPrefix(c.Get(fiber.HeaderAuthorization), "Bearer "); ok {
+		token = bearer
+	}
+	entry := clearkey.Entry{VideoID: id, IP: c.IP(), At: s.now()}
+	status, body := s.license(c, id, token, &entry)
+
+	clearkey.Default.Record(c.Context(), entry)
+	rec := clearkey.Recording{
+		VideoID:    id,
+		Token:      token,
+		Request:    json.RawMessage(append([]byte(nil), c.Body()...)),
+		Status:     status,
+		RecordedAt: entry.At,
+	}
+	if !json.Valid(rec.Request) {
+		// Keep malformed requests as a JSON string; replaying it is still
+		// rejected the same way.
+		rec.Request, _ = json.Marshal(string(rec.Request))
+	}
+	if status == fiber.StatusOK {
+		rec.Response = body
+	}
+	if err := s.recorder.Save(rec); err != nil {
+		log.Printf("Recording license request for %s: %v", id, err)
+	}
+
+	c.Set(fiber.HeaderCacheControl, KeyCacheControl)
+	if status != fiber.StatusOK {
+		return c.Status(status).SendString(entry.Reason)
+	}
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	return c.Send(body)
+}
+
+// license decides a license request, filling in the audit entry, and returns
+// the status and JSON body to send.
+func (s *Server) license(c *fiber.Ctx, id, token string, entry *clearkey.Entry) (int, []byte) {
+	deny := func(status int, reason string) (int, []byte) {
+		entry.Decision, entry.Reason = clearkey.Denied, reason
+		return status, nil
+	}
+	if s.keys == nil {
+		return deny(fiber.StatusNotFound, "content keys are not configured")
+	}
+
+	req, kids, err := clearkey.ParseRequest(c.Body())
+	if err != nil {
+		return deny(fiber.StatusBadRequest, err.Error())
+	}
+	entry.KeyIDs = req.KeyIDs
+
+	if token == "" || s.signer == nil {
+		return deny(fiber.StatusUnauthorized, ErrInvalidToken.Error())
+	}
+	claims, err := s.signer.Verify(token, id, "", c.IP(), s.now())
+	if err != nil {
+		return deny(fiber.StatusForbidden, err.Error())
+	}
+	entry.Viewer = claims.Viewer
+
+	// The token may outlive the viewer's access, e.g. when the video was made
+	// private since, so check the entitlement again.
+	video, err := s.catalog.View(c.Context(), catalog.Viewer{ID: claims.Viewer}, id)
+	if err != nil || video.State != catalog.StateReady {
+		return deny(fiber.StatusForbidden, "viewer is not entitled to this video")
+	}
+
+	keys, err := s.keys.List(c.Context(), id)
+	if err != nil {
+		log.Printf("Loading content keys of %s: %v", id, err)
+		return deny(fiber.StatusInternalServerError, "content keys unavailable")
+	}
+	byKID := make(map[string][]byte, len(keys))
+	for _, k := range keys {
+		byKID[string(k.KID())] = k.Key
+	}
+	res := clearkey.NewResponse(req, kids, byKID)
+	entry.Granted = len(res.Keys)
+	if entry.Granted == 0 {
+		return deny(fiber.StatusNotFound, "no requested key belongs to this video")
+	}
+
+	body, err := json.Marshal(res)
+	if err != nil {
+		return deny(fiber.StatusInternalServerError, err.Error())
+	}
+	entry.Decision = clearkey.Granted
+	return fiber.StatusOK, body
+}
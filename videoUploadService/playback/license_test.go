@@ -0,0 +1,128 @@
+package playback
+
+import (
+	"VideoUploadService/catalog"
+	"VideoUploadService/clearkey"
+	"VideoUploadService/contentkey"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// The recordings in testdata/clearkey were made against this setup: tokens
+// are signed with licenseSigningKey and checked at licenseClock.
+var (
+	licenseSigningKey = []byte("clearkey replay signing key")
+	licenseClock      = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+)
+
+// newLicenseServer serves licenses for two videos of alice: "pub", public
+// with two keys, and "priv", private with one.
+func newLicenseServer(t *testing.T) (*Server, *contentkey.Service) {
+	t.Helper()
+	store, err := catalog.Open("sqlite", "file::memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	ctx := context.Background()
+	for id, visibility := range map[string]catalog.Visibility{"pub": catalog.VisibilityPublic, "priv": catalog.VisibilityPrivate} {
+		v := catalog.Video{ID: id, Owner: "alice", State: catalog.StateReady, CreatedAt: licenseClock, UpdatedAt: licenseClock,
+			Metadata: catalog.Metadata{Visibility: visibility}}
+		if err := store.Create(ctx, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	keys, err := contentkey.Open(store.DB(), bytes.Repeat([]byte{1}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys.SetPolicy(1, []string{"*"})
+	if _, err := keys.Prepare(ctx, "pub", 8, 4); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := keys.Prepare(ctx, "priv", 4, 4); err != nil {
+		t.Fatal(err)
+	}
+
+	s := New(nil, catalog.New(store), "")
+	s.SetSigner(NewSigner(licenseSigningKey), time.Hour, "")
+	s.SetKeys(keys)
+	s.now = func() time.Time { return licenseClock }
+	return s, keys
+}
+
+func TestLicenseReplay(t *testing.T) {
+	recs, err := clearkey.LoadRecordings("testdata/clearkey")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recs) == 0 {
+		t.Fatal("no recordings in testdata/clearkey")
+	}
+	s, keys := newLicenseServer(t)
+	app := fiber.New()
+	app.Post("/drm/clearkey/:id/license", s.serveLicense)
+
+	for i, rec := range recs {
+		t.Run(fmt.Sprintf("%02d-%s", i+1, rec.VideoID), func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/drm/clearkey/"+rec.VideoID+"/license", bytes.NewReader(rec.Request))
+			req.Header.Set("Content-Type", "application/json")
+			if rec.Token != "" {
+				req.Header.Set("Authorization", "Bearer "+rec.Token)
+			}
+			res, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, _ := io.ReadAll(res.Body)
+			if res.StatusCode != rec.Status {
+				t.Fatalf("status = %d (%s), recorded %d", res.StatusCode, body, rec.Status)
+			}
+			if rec.Status != fiber.StatusOK {
+				return
+			}
+
+			// Keys are generated per run, so only the granted key IDs can
+			// match the recording; the keys must match the stored ones.
+			var got, want clearkey.Response
+			if err := json.Unmarshal(body, &got); err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal(rec.Response, &want); err != nil {
+				t.Fatal(err)
+			}
+			if got.Type != want.Type || !slices.Equal(kidsOf(got), kidsOf(want)) {
+				t.Fatalf("license = %s %v, recorded %s %v", got.Type, kidsOf(got), want.Type, kidsOf(want))
+			}
+			stored, err := keys.List(context.Background(), rec.VideoID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, jwk := range got.Keys {
+				k := slices.IndexFunc(stored, func(k contentkey.Key) bool { return clearkey.Encode(k.KID()) == jwk.Kid })
+				if k < 0 || jwk.K != clearkey.Encode(stored[k].Key) || jwk.Kty != "oct" {
+					t.Errorf("key %s does not match the stored key", jwk.Kid)
+				}
+			}
+		})
+	}
+}
+
+func kidsOf(res clearkey.Response) []string {
+	var kids []string
+	for _, k := range res.Keys {
+		kids = append(kids, k.Kid)
+	}
+	slices.Sort(kids)
+	return kids
+}
@@ -0,0 +1,94 @@
+package playback
+
+import (
+	"VideoUploadService/livehls"
+	"errors"
+	"math"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetLive enables playback of live broadcasts. Live channels are public;
+// they need no playback token.
+func (s *Server) SetLive(p *livehls.Packager) {
+	s.live = p
+}
+
+// serveLivePlaylist serves the playlist of a channel. The start query
+// parameter sets where players begin, in seconds from the start of the DVR
+// window or, if negative, back from the live edge. The Low-Latency HLS
+// parameters _HLS_msn, _HLS_part and _HLS_skip request blocking reloads
+// and delta updates.
+func (s *Server) serveLivePlaylist(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderCacheControl, LivePlaylistCacheControl)
+	if s.live == nil {
+		return c.Status(fiber.StatusNotFound).SendString("Not found")
+	}
+	var req livehls.PlaylistRequest
+	if v := c.Query("start"); v != "" {
+		start, err := strconv.ParseFloat(v, 64)
+		if err != nil || math.IsNaN(start) || math.IsInf(start, 0) {
+			return c.Status(fiber.StatusBadRequest).SendString("Invalid start")
+		}
+		req.Start = &start
+	}
+	var err error
+	if req.MSN, err = queryInt(c, "_HLS_msn"); err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString("Invalid _HLS_msn")
+	}
+	if req.Part, err = queryInt(c, "_HLS_part"); err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString("Invalid _HLS_part")
+	}
+	switch c.Query("_HLS_skip") {
+	case "":
+	case "YES", "v2":
+		req.Skip = true
+	default:
+		return c.Status(fiber.StatusBadRequest).SendString("Invalid _HLS_skip")
+	}
+	body, err := s.live.Playlist(c.Context(), c.Params("channel"), req)
+	switch {
+	case errors.Is(err, livehls.ErrNotLive), errors.Is(err, livehls.ErrStarting):
+		return c.Status(fiber.StatusNotFound).SendString(err.Error())
+	case errors.Is(err, livehls.ErrBadRequest):
+		return c.Status(fiber.StatusBadRequest).SendString(err.Error())
+	case errors.Is(err, livehls.ErrTimeout):
+		return c.Status(fiber.StatusServiceUnavailable).SendString(err.Error())
+	case err != nil:
+		return err
+	}
+	return sendBody(c, "index.m3u8", body, LivePlaylistCacheControl)
+}
+
+// queryInt parses an optional integer query parameter.
+func queryInt(c *fiber.Ctx, name string) (*int, error) {
+	v := c.Query(name)
+	if v == "" {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+// serveLiveSegment serves a media segment, part or initialization segment
+// of a live broadcast. Segment names are unique per broadcast, so they are cached
+// like any other segment; misses are not, as they may appear shortly.
+func (s *Server) serveLiveSegment(c *fiber.Ctx) error {
+	if s.live == nil {
+		return c.Status(fiber.StatusNotFound).SendString("Not found")
+	}
+	name := c.Params("name")
+	data, err := s.live.Segment(c.Context(), c.Params("channel"), c.Params("broadcast"), name)
+	if errors.Is(err, livehls.ErrNotLive) || errors.Is(err, livehls.ErrNotFound) {
+		c.Set(fiber.HeaderCacheControl, LivePlaylistCacheControl)
+		return c.Status(fiber.StatusNotFound).SendString("Not found")
+	}
+	if err != nil {
+		return err
+	}
+	return sendBody(c, name, data, SegmentCacheControl)
+}
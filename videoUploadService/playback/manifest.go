@@ -0,0 +1,44 @@
+package playback
+
+import (
+	"bytes"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var uriAttribute = regexp.MustCompile(`URI="([^"]*)"`)
+
+// RewriteURIs rewrites every URI in an HLS playlist: the URI lines of
+// variants and segments as well as URI attributes of tags such as
+// EXT-X-KEY, EXT-X-MAP and EXT-X-MEDIA.
+func RewriteURIs(playlist []byte, rewrite func(uri string) string) []byte {
+	lines := bytes.Split(playlist, []byte("\n"))
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(string(line))
+		switch {
+		case trimmed == "":
+		case strings.HasPrefix(trimmed, "#"):
+			lines[i] = uriAttribute.ReplaceAllFunc(line, func(m []byte) []byte {
+				uri := uriAttribute.FindSubmatch(m)[1]
+				return []byte(`URI="` + rewrite(string(uri)) + `"`)
+			})
+		default:
+			lines[i] = []byte(rewrite(trimmed))
+		}
+	}
+	return bytes.Join(lines, []byte("\n"))
+}
+
+// withQuery adds a query parameter to a relative URI. Absolute URIs point
+// elsewhere and are returned unchanged.
+func withQuery(uri, key, value string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.IsAbs() || u.Host != "" {
+		return uri
+	}
+	q := u.Query()
+	q.Set(key, value)
+	u.RawQuery = q.Encode()
+	return u.String()
+}
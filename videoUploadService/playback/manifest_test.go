@@ -0,0 +1,79 @@
+package playback
+
+import "testing"
+
+func TestWithQuery(t *testing.T) {
+	tests := []struct {
+		uri, value, want string
+	}{
+		{"360p/360p.m3u8", "t", "360p/360p.m3u8?token=t"},
+		{"/hls/v1/keys/0.key", "t", "/hls/v1/keys/0.key?token=t"},
+		{"seg_001.ts?v=2", "t", "seg_001.ts?token=t&v=2"},
+		{"seg_001.ts?token=old", "t", "seg_001.ts?token=t"},
+		{"seg_001.ts", "a b+c/=", "seg_001.ts?token=a+b%2Bc%2F%3D"},
+		{"https://cdn.example.com/seg_001.ts", "t", "https://cdn.example.com/seg_001.ts"},
+		{"//cdn.example.com/seg_001.ts", "t", "//cdn.example.com/seg_001.ts"},
+		{"skd://key-1", "t", "skd://key-1"},
+		{"seg%zz.ts", "t", "seg%zz.ts"},
+	}
+	for _, tt := range tests {
+		if got := withQuery(tt.uri, "token", tt.value); got != tt.want {
+			t.Errorf("withQuery(%q, %q) = %q, want %q", tt.uri, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestRewriteURIs(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{
+			name: "master playlist",
+			in: `#EXTM3U
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="aac",NAME="English",URI="audio/en.m3u8"
+#EXT-X-STREAM-INF:BANDWIDTH=1400000,RESOLUTION=854x480,CODECS="avc1.4d401e,mp4a.40.2"
+480p/480p.m3u8
+#EXT-X-I-FRAME-STREAM-INF:BANDWIDTH=140000,RESOLUTION=854x480,URI="480p/iframes.m3u8"
+`,
+			want: `#EXTM3U
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="aac",NAME="English",URI="audio/en.m3u8?token=t"
+#EXT-X-STREAM-INF:BANDWIDTH=1400000,RESOLUTION=854x480,CODECS="avc1.4d401e,mp4a.40.2"
+480p/480p.m3u8?token=t
+#EXT-X-I-FRAME-STREAM-INF:BANDWIDTH=140000,RESOLUTION=854x480,URI="480p/iframes.m3u8?token=t"
+`,
+		},
+		{
+			name: "media playlist",
+			in: `#EXTM3U
+#EXT-X-MAP:URI="init.mp4"
+#EXT-X-KEY:METHOD=AES-128,URI="/hls/v1/keys/0.key",IV=0x00000000000000000000000000000001
+
+#EXTINF:4.000,
+seg_000.m4s
+#EXT-X-BYTERANGE:1000@0
+  seg_001.m4s?part=1  
+#EXTINF:4.000,
+https://cdn.example.com/seg_002.m4s
+#EXT-X-ENDLIST`,
+			want: `#EXTM3U
+#EXT-X-MAP:URI="init.mp4?token=t"
+#EXT-X-KEY:METHOD=AES-128,URI="/hls/v1/keys/0.key?token=t",IV=0x00000000000000000000000000000001
+
+#EXTINF:4.000,
+seg_000.m4s?token=t
+#EXT-X-BYTERANGE:1000@0
+seg_001.m4s?part=1&token=t
+#EXTINF:4.000,
+https://cdn.example.com/seg_002.m4s
+#EXT-X-ENDLIST`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := RewriteURIs([]byte(tt.in), func(uri string) string { return withQuery(uri, "token", "t") })
+			if string(got) != tt.want {
+				t.Errorf("RewriteURIs\n got %q\nwant %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,213 @@
+package playback
+
+import (
+	"VideoUploadService/catalog"
+	"VideoUploadService/packager"
+	"VideoUploadService/storage"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var ErrNotEntitled = errors.New("rendition not included in the viewer's plan")
+
+var (
+	errNoPackager = errors.New("rendition metadata is not configured")
+	errNoVariants = errors.New("master playlist has no variants")
+)
+
+// DefaultTierMaxHeight limits free and anonymous viewers to 720p. Zero means
+// no limit.
+var DefaultTierMaxHeight = map[string]uint32{
+	"free":    720,
+	"premium": 0,
+}
+
+// SetTierLimits sets the tallest rendition each viewer tier may watch. Tiers
+// without an entry, including anonymous viewers, get the "free" limit.
+func (s *Server) SetTierLimits(maxHeight map[string]uint32) {
+	s.tierMaxHeight = maxHeight
+}
+
+// tierLimit returns the entitlement filter of the viewer. Owners always get
+// every rendition of their videos.
+func (s *Server) tierLimit(video catalog.Video, claims Claims) packager.Filter {
+	if ownedBy(video, claims) {
+		return packager.Filter{}
+	}
+	limits := s.tierMaxHeight
+	if limits == nil {
+		limits = DefaultTierMaxHeight
+	}
+	maxHeight, ok := limits[claims.Tier]
+	if !ok {
+		maxHeight = limits["free"]
+	}
+	return packager.Filter{MaxHeight: maxHeight}
+}
+
+// filter combines the viewer's entitlement with the device hints of the
+// request. Hints can only narrow the selection.
+func (s *Server) filter(c *fiber.Ctx, video catalog.Video, claims Claims) packager.Filter {
+	f := s.tierLimit(video, claims)
+	if h := uint32(c.QueryInt("max_height")); h > 0 && (f.MaxHeight == 0 || h < f.MaxHeight) {
+		f.MaxHeight = h
+	}
+	if w := uint32(c.QueryInt("max_width")); w > 0 {
+		f.MaxWidth = w
+	}
+	if b := int64(c.QueryInt("max_bandwidth")); b > 0 {
+		f.MaxBandwidth = b
+	}
+	return f
+}
+
+// entitled checks that a media request does not reach into a rendition the
+// viewer's tier excludes. Objects outside rendition directories are not
+// restricted. Renditions are looked up in the stored master playlist of
+// videos without metadata; if neither can be read, only viewers without a
+// tier limit get through.
+func (s *Server) entitled(c *fiber.Ctx, video catalog.Video, claims Claims, key string) bool {
+	name, _, ok := strings.Cut(strings.TrimPrefix(key, packager.Dir(video.ID)), "/")
+	if !ok {
+		return true
+	}
+	meta, err := s.metadata(c.Context(), video.ID)
+	if err != nil {
+		meta, _, err = s.storedRenditions(c.Context(), video.ID)
+	}
+	if err != nil {
+		return s.tierLimit(video, claims) == packager.Filter{}
+	}
+	r, ok := meta.Rendition(name)
+	return !ok || s.entitledTo(video, claims, meta, r)
+}
+
+// metadata returns the rendition metadata of a video.
+func (s *Server) metadata(ctx context.Context, videoID string) (packager.Metadata, error) {
+	if s.packager == nil {
+		return packager.Metadata{}, errNoPackager
+	}
+	return s.packager.Metadata(ctx, videoID)
+}
+
+// storedRenditions describes the renditions listed in the stored master
+// playlist of a video, returning the playlist as well.
+func (s *Server) storedRenditions(ctx context.Context, videoID string) (packager.Metadata, []byte, error) {
+	obj, err := s.store.Open(ctx, packager.Dir(videoID)+"master.m3u8")
+	if err != nil {
+		return packager.Metadata{}, nil, err
+	}
+	data, err := io.ReadAll(obj)
+	obj.Close()
+	if err != nil {
+		return packager.Metadata{}, nil, err
+	}
+	meta := packager.Metadata{VideoID: videoID}
+	for _, v := range packager.ParseMasterPlaylist(data) {
+		meta.Renditions = append(meta.Renditions, packager.Rendition{
+			Name:             packager.VariantName(v.URI),
+			Width:            v.Width,
+			Height:           v.Height,
+			FrameRate:        v.FrameRate,
+			Bandwidth:        v.Bandwidth,
+			AverageBandwidth: v.AverageBandwidth,
+			Codecs:           v.Codecs,
+			Playlist:         v.URI,
+		})
+	}
+	if len(meta.Renditions) == 0 {
+		return packager.Metadata{}, nil, errNoVariants
+	}
+	return meta, data, nil
+}
+
+// entitledTo reports whether the viewer's tier includes the rendition. The
+// lowest rendition is always included.
+func (s *Server) entitledTo(video catalog.Video, claims Claims, meta packager.Metadata, r packager.Rendition) bool {
+	limit := s.tierLimit(video, claims)
+	if limit.Allows(r) {
+		return true
+	}
+	lowest := packager.Select(meta.Renditions, limit)
+	return len(lowest) > 0 && lowest[0].Name == r.Name
+}
+
+// serveMaster generates the master playlist for the viewer from rendition
+// metadata. For videos that cannot be described the stored playlist is served
+// without the variants the viewer is not entitled to.
+func (s *Server) serveMaster(c *fiber.Ctx) error {
+	video, err := s.readyVideo(c.Context(), c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).SendString("Video not available")
+	}
+	token := c.Query("token")
+	claims, err := s.authorize(c, video, token)
+	if err != nil {
+		return c.Status(fiber.StatusForbidden).SendString(err.Error())
+	}
+
+	var mapURI func(string) string
+	cache := PlaylistCacheControl
+	if token != "" {
+		mapURI = func(uri string) string { return withQuery(uri, "token", token) }
+		cache = SignedPlaylistCacheControl
+	}
+	f := s.filter(c, video, claims)
+	if meta, err := s.metadata(c.Context(), video.ID); err == nil {
+		return sendBody(c, path.Base(c.Path()), packager.Master(meta, f, mapURI), cache)
+	}
+
+	meta, stored, err := s.storedRenditions(c.Context(), video.ID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return c.Status(fiber.StatusNotFound).SendString("Not found")
+	}
+	if err != nil {
+		if s.tierLimit(video, claims) != (packager.Filter{}) {
+			return c.Status(fiber.StatusForbidden).SendString(ErrNotEntitled.Error())
+		}
+		return s.serveStored(c, "master.m3u8")
+	}
+	keep := make(map[string]bool)
+	for _, r := range packager.Select(meta.Renditions, f) {
+		keep[r.Name] = true
+	}
+	body := filterMaster(stored, keep)
+	if mapURI != nil {
+		body = RewriteURIs(body, mapURI)
+	}
+	return sendBody(c, path.Base(c.Path()), body, cache)
+}
+
+// filterMaster removes the variants and I-frame playlists of renditions not
+// in keep from a master playlist. Other lines are kept as they are.
+func filterMaster(playlist []byte, keep map[string]bool) []byte {
+	var out [][]byte
+	var streamInf []byte
+	for _, line := range bytes.Split(playlist, []byte("\n")) {
+		trimmed := strings.TrimSpace(string(line))
+		tag, value, _ := strings.Cut(trimmed, ":")
+		switch {
+		case tag == "#EXT-X-STREAM-INF":
+			streamInf = line
+			continue
+		case tag == "#EXT-X-I-FRAME-STREAM-INF":
+			if m := uriAttribute.FindStringSubmatch(value); m != nil && !keep[packager.VariantName(m[1])] {
+				continue
+			}
+		case streamInf != nil && trimmed != "" && !strings.HasPrefix(trimmed, "#"):
+			if keep[packager.VariantName(trimmed)] {
+				out = append(out, streamInf, line)
+			}
+			streamInf = nil
+			continue
+		}
+		out = append(out, line)
+	}
+	return bytes.Join(out, []byte("\n"))
+}
@@ -0,0 +1,122 @@
+package playback
+
+import (
+	"VideoUploadService/catalog"
+	"VideoUploadService/storage"
+	"context"
+	"io"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const storedMaster = `#EXTM3U
+#EXT-X-VERSION:3
+#EXT-X-STREAM-INF:BANDWIDTH=800000,RESOLUTION=640x360
+360p/360p.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=2800000,RESOLUTION=1280x720
+720p/720p.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=5000000,RESOLUTION=1920x1080
+1080p/1080p.m3u8
+#EXT-X-I-FRAME-STREAM-INF:BANDWIDTH=100000,URI="1080p/iframes.m3u8"
+`
+
+// newMediaServer serves the public video "v" of alice from files, without a
+// packager, so manifests fall back to the stored master playlist.
+func newMediaServer(t *testing.T, files map[string]string) *fiber.App {
+	t.Helper()
+	store, err := catalog.Open("sqlite", "file::memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	now := time.Now().UTC()
+	v := catalog.Video{ID: "v", Owner: "alice", State: catalog.StateReady, CreatedAt: now, UpdatedAt: now,
+		Metadata: catalog.Metadata{Visibility: catalog.VisibilityPublic}}
+	if err := store.Create(context.Background(), v); err != nil {
+		t.Fatal(err)
+	}
+
+	root := t.TempDir() + "/"
+	for name, content := range files {
+		p := filepath.Join(root, "encoded/v", name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s := New(storage.NewLocal(root), catalog.New(store), "")
+	app := fiber.New()
+	app.Get("/hls/:id/master.m3u8", s.serveMaster)
+	app.Get("/hls/:id/*", s.serveMedia)
+	return app
+}
+
+func TestStoredMasterEntitlement(t *testing.T) {
+	files := map[string]string{
+		"master.m3u8":     storedMaster,
+		"360p/seg0.ts":    "360",
+		"1080p/seg0.ts":   "1080",
+		"unlisted/seg.ts": "x",
+	}
+	tests := []struct {
+		name       string
+		files      map[string]string
+		path       string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "master without excluded variants",
+			files:      files,
+			path:       "/hls/v/master.m3u8",
+			wantStatus: fiber.StatusOK,
+			wantBody: `#EXTM3U
+#EXT-X-VERSION:3
+#EXT-X-STREAM-INF:BANDWIDTH=800000,RESOLUTION=640x360
+360p/360p.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=2800000,RESOLUTION=1280x720
+720p/720p.m3u8
+`,
+		},
+		{
+			name:       "device hint narrows the master",
+			files:      files,
+			path:       "/hls/v/master.m3u8?max_height=360",
+			wantStatus: fiber.StatusOK,
+			wantBody: `#EXTM3U
+#EXT-X-VERSION:3
+#EXT-X-STREAM-INF:BANDWIDTH=800000,RESOLUTION=640x360
+360p/360p.m3u8
+`,
+		},
+		{name: "entitled segment", files: files, path: "/hls/v/360p/seg0.ts", wantStatus: fiber.StatusOK, wantBody: "360"},
+		{name: "segment above the tier limit", files: files, path: "/hls/v/1080p/seg0.ts", wantStatus: fiber.StatusForbidden},
+		{name: "directory not in the master", files: files, path: "/hls/v/unlisted/seg.ts", wantStatus: fiber.StatusOK, wantBody: "x"},
+		{name: "no master", files: map[string]string{"1080p/seg0.ts": "1080"}, path: "/hls/v/1080p/seg0.ts", wantStatus: fiber.StatusForbidden},
+		{name: "master without variants", files: map[string]string{"master.m3u8": "#EXTM3U\n"}, path: "/hls/v/master.m3u8", wantStatus: fiber.StatusForbidden},
+		{name: "missing master", files: map[string]string{}, path: "/hls/v/master.m3u8", wantStatus: fiber.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newMediaServer(t, tt.files)
+			res, err := app.Test(httptest.NewRequest("GET", tt.path, nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, _ := io.ReadAll(res.Body)
+			if res.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d (%s), want %d", res.StatusCode, body, tt.wantStatus)
+			}
+			if tt.wantBody != "" && string(body) != tt.wantBody {
+				t.Errorf("body = %q, want %q", body, tt.wantBody)
+			}
+		})
+	}
+}
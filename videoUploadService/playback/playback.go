@@ -0,0 +1,339 @@
+package playback
+
+import (
+	"VideoUploadService/catalog"
+	"VideoUploadService/clearkey"
+	"VideoUploadService/contentkey"
+	"VideoUploadService/livehls"
+	"VideoUploadService/packager"
+	"VideoUploadService/storage"
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// readyTTL is how long a video's readiness is cached, so that segment
+// requests don't each hit the catalog.
+const readyTTL = 10 * time.Second
+
+var ErrSigningDisabled = errors.New("playback signing is not configured")
+
+// Server serves encoded videos from storage. Encoded output lives below
+// "encoded/<uuid>/".
+//
+// When a Signer is configured every request needs a valid playback token in
+// the "token" query parameter. Without one, only public and unlisted videos
+// are served.
+type Server struct {
+	store         storage.Storage
+	catalog       *catalog.Catalog
+	allowedOrigin string
+	signer        *Signer
+	tokenTTL      time.Duration
+	baseURL       string
+	keys          *contentkey.Service
+	recorder      *clearkey.Recorder
+	packager      *packager.Packager
+	live          *livehls.Packager
+	tierMaxHeight map[string]uint32
+	// now is the clock tokens are checked against.
+	now func() time.Time
+
+	mu    sync.Mutex
+	ready map[string]readyEntry
+}
+
+type readyEntry struct {
+	video   catalog.Video
+	expires time.Time
+}
+
+// Default is set up in main.
+var Default *Server
+
+func New(store storage.Storage, cat *catalog.Catalog, allowedOrigin string) *Server {
+	if allowedOrigin == "" {
+		allowedOrigin = "*"
+	}
+	return &Server{
+		store:         store,
+		catalog:       cat,
+		allowedOrigin: allowedOrigin,
+		now:           time.Now,
+		ready:         make(map[string]readyEntry),
+	}
+}
+
+// DefaultTokenTTL is how long issued playback tokens are valid.
+const DefaultTokenTTL = 6 * time.Hour
+
+// OriginalTokenTTL is how long a link to a creator's original upload is
+// valid. It only needs to last until the download starts.
+const OriginalTokenTTL = 5 * time.Minute
+
+// SetSigner enables signed playback URLs. Tokens are issued for ttl and
+// playback URLs are built relative to baseURL, e.g. "https://cdn.example".
+func (s *Server) SetSigner(signer *Signer, ttl time.Duration, baseURL string) {
+	if ttl <= 0 {
+		ttl = DefaultTokenTTL
+	}
+	s.signer, s.tokenTTL, s.baseURL = signer, ttl, strings.TrimSuffix(baseURL, "/")
+}
+
+// SetKeys enables the key endpoint for AES-128 encrypted videos.
+func (s *Server) SetKeys(keys *contentkey.Service) {
+	s.keys = keys
+}
+
+// SetPackager enables manifests generated from rendition metadata.
+func (s *Server) SetPackager(p *packager.Packager) {
+	s.packager = p
+}
+
+func SetupRoutes(app *fiber.App) {
+	hls := app.Group("/hls", Default.cors)
+	hls.Get("/live/:channel/index.m3u8", Default.serveLivePlaylist)
+	hls.Get("/live/:channel/:broadcast/:name", Default.serveLiveSegment)
+	hls.Get("/:id/keys/:index.key", Default.serveKey)
+	hls.Get("/:id/master.m3u8", Default.serveMaster)
+	hls.Get("/:id/"+packager.ThumbnailTrackName, Default.serveThumbnails)
+	hls.Get("/:id/:rendition/"+packager.IFramePlaylistName, Default.serveIFrames)
+	// Get also answers HEAD requests.
+	hls.Get("/:id/*", Default.serveMedia)
+
+	dash := app.Group("/dash", Default.cors)
+	dash.Get("/:id/manifest.mpd", Default.serveDASH)
+	dash.Get("/:id/*", Default.serveMedia)
+
+	drm := app.Group("/drm", Default.cors)
+	drm.Post("/clearkey/:id/license", Default.serveLicense)
+
+	download := app.Group("/download", Default.cors)
+	download.Get("/:id/original", Default.serveOriginal)
+	download.Get("/:id/:rendition.mp4", Default.serveProgressive)
+}
+
+// cors allows browser players on other origins to fetch manifests and
+// segments, including ranged requests, and to request licenses.
+func (s *Server) cors(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderAccessControlAllowOrigin, s.allowedOrigin)
+	c.Set(fiber.HeaderAccessControlAllowMethods, "GET, HEAD, POST, OPTIONS")
+	c.Set(fiber.HeaderAccessControlAllowHeaders, "Range, If-None-Match, If-Range, Authorization, Content-Type")
+	c.Set(fiber.HeaderAccessControlExposeHeaders, "Content-Length, Content-Range, Content-Disposition, ETag")
+	if s.allowedOrigin != "*" {
+		c.Vary(fiber.HeaderOrigin)
+	}
+	if c.Method() == fiber.MethodOptions {
+		c.Set(fiber.HeaderAccessControlMaxAge, "86400")
+		return c.SendStatus(fiber.StatusNoContent)
+	}
+	return c.Next()
+}
+
+// mediaExtensions are the kinds of objects players fetch from a video's
+// output directory: playlists, segments and thumbnail sheets.
+var mediaExtensions = map[string]bool{
+	".m3u8": true,
+	".mpd":  true,
+	".ts":   true,
+	".m4s":  true,
+	".aac":  true,
+	".vtt":  true,
+	".jpg":  true,
+}
+
+// isMedia reports whether a player may fetch name through the media routes.
+// Of MP4 files only fMP4 initialization segments are; progressive MP4s are
+// served by the download routes, which check download permission.
+func isMedia(name string) bool {
+	ext := path.Ext(name)
+	if ext == ".mp4" {
+		return strings.HasPrefix(path.Base(name), "init")
+	}
+	return mediaExtensions[ext]
+}
+
+// serveMedia serves stored playlists and segments of a video.
+func (s *Server) serveMedia(c *fiber.Ctx) error {
+	name := c.Params("*")
+	if !isMedia(name) {
+		return c.Status(fiber.StatusNotFound).SendString("Not found")
+	}
+	return s.serveStored(c, name)
+}
+
+// serveStored serves the object name below the video's output directory.
+func (s *Server) serveStored(c *fiber.Ctx, name string) error {
+	id := c.Params("id")
+	video, err := s.readyVideo(c.Context(), id)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).SendString("Video not available")
+	}
+	token := c.Query("token")
+	claims, err := s.authorize(c, video, token)
+	if err != nil {
+		return c.Status(fiber.StatusForbidden).SendString(err.Error())
+	}
+
+	key, err := storage.CleanKey("encoded/" + id + "/" + name)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString("Invalid path")
+	}
+	if !s.entitled(c, video, claims, key) {
+		return c.Status(fiber.StatusForbidden).SendString(ErrNotEntitled.Error())
+	}
+	obj, err := s.store.Open(c.Context(), key)
+	if errors.Is(err, storage.ErrNotFound) {
+		return c.Status(fiber.StatusNotFound).SendString("Not found")
+	}
+	if err != nil {
+		return err
+	}
+	if token != "" && path.Ext(key) == ".m3u8" {
+		return s.sendSignedPlaylist(c, key, obj, token)
+	}
+	return serveObject(c, key, obj)
+}
+
+// serveKey hands out content keys. Keys always require a playback token, so
+// segments copied from a CDN cannot be decrypted without one.
+func (s *Server) serveKey(c *fiber.Ctx) error {
+	if s.keys == nil {
+		return c.Status(fiber.StatusNotFound).SendString("Not found")
+	}
+	video, err := s.readyVideo(c.Context(), c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).SendString("Video not available")
+	}
+	token := c.Query("token")
+	if token == "" {
+		return c.Status(fiber.StatusUnauthorized).SendString(ErrInvalidToken.Error())
+	}
+	if _, err := s.authorize(c, video, token); err != nil {
+		return c.Status(fiber.StatusForbidden).SendString(err.Error())
+	}
+	index, err := strconv.ParseUint(c.Params("index"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).SendString("Not found")
+	}
+	key, err := s.keys.Get(c.Context(), video.ID, uint32(index))
+	if errors.Is(err, contentkey.ErrNotFound) {
+		return c.Status(fiber.StatusNotFound).SendString("Not found")
+	}
+	if err != nil {
+		return err
+	}
+	c.Set(fiber.HeaderContentType, "application/octet-stream")
+	c.Set(fiber.HeaderCacheControl, KeyCacheControl)
+	return c.Send(key.Key)
+}
+
+// authorize checks that the request may fetch media of the video.
+// Requests without a token get empty claims.
+func (s *Server) authorize(c *fiber.Ctx, video catalog.Video, token string) (Claims, error) {
+	return s.authorizeScope(c, video, token, "")
+}
+
+func (s *Server) authorizeScope(c *fiber.Ctx, video catalog.Video, token, scope string) (Claims, error) {
+	if token == "" {
+		if s.signer != nil || video.Visibility == catalog.VisibilityPrivate {
+			return Claims{}, ErrInvalidToken
+		}
+		return Claims{}, nil
+	}
+	if s.signer == nil {
+		return Claims{}, ErrInvalidToken
+	}
+	return s.signer.Verify(token, video.ID, scope, c.IP(), s.now())
+}
+
+// sendSignedPlaylist rewrites the playlist so that every variant, segment
+// and key request carries the same token.
+func (s *Server) sendSignedPlaylist(c *fiber.Ctx, key string, obj storage.Object, token string) error {
+	body, err := io.ReadAll(obj)
+	obj.Close()
+	if err != nil {
+		return err
+	}
+	body = RewriteURIs(body, func(uri string) string {
+		return withQuery(uri, "token", token)
+	})
+	return sendBody(c, key, body, SignedPlaylistCacheControl)
+}
+
+// Grant is a set of signed playback URLs. MP4 is the progressive fallback,
+// empty when the video has no MP4 renditions.
+type Grant struct {
+	HLS    string
+	DASH   string
+	MP4    string
+	Claims Claims
+}
+
+// Issue returns signed playback URLs for a video the viewer may watch. The
+// viewer's tier is baked into the token and decides the renditions served.
+func (s *Server) Issue(ctx context.Context, viewer catalog.Viewer, tier, videoID, ip string) (Grant, error) {
+	video, err := s.catalog.View(ctx, viewer, videoID)
+	if err != nil {
+		return Grant{}, err
+	}
+	if video.State != catalog.StateReady {
+		return Grant{}, catalog.ErrNotFound
+	}
+	if s.signer == nil {
+		return Grant{}, ErrSigningDisabled
+	}
+	claims := Claims{VideoID: video.ID, Expires: ExpiresIn(s.tokenTTL), Viewer: viewer.ID, IP: ip, Tier: tier}
+	query := "?token=" + url.QueryEscape(s.signer.Sign(claims))
+	grant := Grant{
+		HLS:    s.baseURL + "/hls/" + video.ID + "/master.m3u8" + query,
+		DASH:   s.baseURL + "/dash/" + video.ID + "/manifest.mpd" + query,
+		Claims: claims,
+	}
+	if s.packager != nil {
+		if meta, err := s.packager.Metadata(ctx, video.ID); err == nil {
+			if r, ok := packager.Progressive(meta, s.tierLimit(video, claims)); ok {
+				grant.MP4 = s.baseURL + "/download/" + video.ID + "/" + r.Name + ".mp4" + query
+			}
+		}
+	}
+	return grant, nil
+}
+
+// readyVideo returns the catalog entry of a video that can be played.
+func (s *Server) readyVideo(ctx context.Context, id string) (catalog.Video, error) {
+	now := time.Now()
+	s.mu.Lock()
+	entry, ok := s.ready[id]
+	s.mu.Unlock()
+	if ok && now.Before(entry.expires) {
+		return entry.video, nil
+	}
+
+	v, err := s.catalog.Get(ctx, id)
+	if err != nil {
+		return catalog.Video{}, err
+	}
+	if v.State != catalog.StateReady {
+		return catalog.Video{}, catalog.ErrNotFound
+	}
+	s.mu.Lock()
+	if len(s.ready) > 4096 {
+		for k, e := range s.ready {
+			if now.After(e.expires) {
+				delete(s.ready, k)
+			}
+		}
+	}
+	s.ready[id] = readyEntry{video: v, expires: now.Add(readyTTL)}
+	s.mu.Unlock()
+	return v, nil
+}
@@ -0,0 +1,47 @@
+package playback
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestServeMediaOnlyServesPlayerObjects(t *testing.T) {
+	app := newMediaServer(t, map[string]string{
+		"master.m3u8":           storedMaster,
+		"renditions.json":       "{}",
+		"original.mov":          "source",
+		"360p/360p.m3u8":        "#EXTM3U\n",
+		"360p/360p_000.ts":      "ts",
+		"360p/init.mp4":         "init",
+		"360p/seg0.m4s":         "m4s",
+		"360p/360p.mp4":         "progressive",
+		"thumbs/sprite_000.jpg": "jpg",
+	})
+	tests := []struct {
+		path string
+		want int
+	}{
+		{"/hls/v/360p/360p.m3u8", fiber.StatusOK},
+		{"/hls/v/360p/360p_000.ts", fiber.StatusOK},
+		{"/hls/v/360p/init.mp4", fiber.StatusOK},
+		{"/hls/v/360p/seg0.m4s", fiber.StatusOK},
+		{"/hls/v/thumbs/sprite_000.jpg", fiber.StatusOK},
+		{"/hls/v/360p/360p.mp4", fiber.StatusNotFound},
+		{"/hls/v/renditions.json", fiber.StatusNotFound},
+		{"/hls/v/original.mov", fiber.StatusNotFound},
+		{"/hls/v/360p/missing.ts", fiber.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			res, err := app.Test(httptest.NewRequest("GET", tt.path, nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.StatusCode != tt.want {
+				t.Errorf("status = %d, want %d", res.StatusCode, tt.want)
+			}
+		})
+	}
+}
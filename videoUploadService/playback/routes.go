@@ -0,0 +1,77 @@
+package playback
+
+import (
+	"VideoUploadService/catalog"
+	"VideoUploadService/identity"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetupAPIRoutes registers the endpoints that hand out playback URLs on the
+// main API app, where callers are authenticated.
+func SetupAPIRoutes(app *fiber.App) {
+	app.Get("/videos/:id/playback", issueHandler)
+	app.Get("/videos/:id/downloads", downloadsHandler)
+}
+
+// issueHandler returns signed HLS and DASH manifest URLs, plus a progressive
+// MP4 URL for players without adaptive streaming. With ?bind_ip=true the
+// token only works from the caller's IP address. max_width, max_height and
+// max_bandwidth describe the device and limit the renditions offered.
+func issueHandler(c *fiber.Ctx) error {
+	viewer := catalog.Viewer{ID: identity.FromFiber(c), Admin: identity.IsAdminFiber(c)}
+	ip := ""
+	if c.QueryBool("bind_ip") {
+		ip = c.IP()
+	}
+	grant, err := Default.Issue(c.Context(), viewer, identity.TierFromFiber(c), c.Params("id"), ip)
+	switch {
+	case errors.Is(err, catalog.ErrNotFound):
+		return c.Status(404).SendString("Video not available")
+	case errors.Is(err, ErrSigningDisabled):
+		return c.Status(503).SendString(err.Error())
+	case err != nil:
+		return c.Status(500).SendString(err.Error())
+	}
+	// Pass device hints through to the manifests.
+	hints := ""
+	for _, name := range []string{"max_width", "max_height", "max_bandwidth"} {
+		if v := c.QueryInt(name); v > 0 {
+			hints += "&" + name + "=" + strconv.Itoa(v)
+		}
+	}
+	res := fiber.Map{
+		"url":        grant.HLS + hints,
+		"dash_url":   grant.DASH + hints,
+		"expires_at": time.Unix(grant.Claims.Expires, 0).UTC(),
+	}
+	if grant.MP4 != "" {
+		res["mp4_url"] = grant.MP4
+	}
+	return c.JSON(res)
+}
+
+// downloadsHandler returns signed download URLs of the renditions and, for
+// the creator, of the original upload.
+func downloadsHandler(c *fiber.Ctx) error {
+	viewer := catalog.Viewer{ID: identity.FromFiber(c), Admin: identity.IsAdminFiber(c)}
+	ip := ""
+	if c.QueryBool("bind_ip") {
+		ip = c.IP()
+	}
+	downloads, err := Default.Downloads(c.Context(), viewer, identity.TierFromFiber(c), c.Params("id"), ip)
+	switch {
+	case errors.Is(err, catalog.ErrNotFound):
+		return c.Status(404).SendString("Video not available")
+	case errors.Is(err, ErrDownloadDisabled):
+		return c.Status(403).SendString(err.Error())
+	case errors.Is(err, ErrSigningDisabled):
+		return c.Status(503).SendString(err.Error())
+	case err != nil:
+		return c.Status(500).SendString(err.Error())
+	}
+	return c.JSON(downloads)
+}
@@ -0,0 +1,188 @@
+package playback
+
+import (
+	"VideoUploadService/storage"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ContentTypes maps file extensions to the MIME types players expect.
+var ContentTypes = map[string]string{
+	".m3u8": "application/vnd.apple.mpegurl",
+	".ts":   "video/mp2t",
+	".m4s":  "video/iso.segment",
+	".mp4":  "video/mp4",
+	".mov":  "video/quicktime",
+	".mkv":  "video/x-matroska",
+	".avi":  "video/x-msvideo",
+	".flv":  "video/x-flv",
+	".aac":  "audio/aac",
+	".vtt":  "text/vtt",
+	".jpg":  "image/jpeg",
+	".mpd":  "application/dash+xml",
+	".key":  "application/octet-stream",
+}
+
+// Cache policies. Playlists may still change (live streams) so they are only
+// cached briefly; segments never change once written.
+const (
+	PlaylistCacheControl = "public, max-age=2"
+	SegmentCacheControl  = "public, max-age=31536000, immutable"
+	// Playlists rewritten with a viewer's token must not be shared by caches.
+	SignedPlaylistCacheControl = "private, max-age=2"
+	// Live playlists change with every segment; players and caches have to
+	// revalidate them on each request.
+	LivePlaylistCacheControl = "no-cache"
+	// Content keys must never end up in a shared cache.
+	KeyCacheControl = "private, no-store"
+)
+
+func contentType(name string) string {
+	if t, ok := ContentTypes[path.Ext(name)]; ok {
+		return t
+	}
+	return "application/octet-stream"
+}
+
+func cacheControl(name string) string {
+	switch path.Ext(name) {
+	case ".m3u8", ".mpd":
+		return PlaylistCacheControl
+	default:
+		return SegmentCacheControl
+	}
+}
+
+// etag derives a strong validator from the object's size and modification
+// time.
+func etag(obj storage.Object) string {
+	return fmt.Sprintf(`"%x-%x"`, obj.Size(), obj.ModTime().UnixNano())
+}
+
+// serveObject writes a stored object honouring conditional and single range
+// requests.
+func serveObject(c *fiber.Ctx, name string, obj storage.Object) error {
+	return serveObjectWith(c, name, obj, cacheControl(name))
+}
+
+// serveObjectWith is serveObject with a given cache policy.
+func serveObjectWith(c *fiber.Ctx, name string, obj storage.Object, cache string) error {
+	tag := etag(obj)
+	c.Set(fiber.HeaderContentType, contentType(name))
+	c.Set(fiber.HeaderCacheControl, cache)
+	c.Set(fiber.HeaderETag, tag)
+	c.Set(fiber.HeaderLastModified, obj.ModTime().UTC().Format(time.RFC1123))
+	c.Set(fiber.HeaderAcceptRanges, "bytes")
+
+	if match := c.Get(fiber.HeaderIfNoneMatch); match != "" && etagMatches(match, tag) {
+		obj.Close()
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	size := obj.Size()
+	start, end := int64(0), size-1
+	status := fiber.StatusOK
+	if header := c.Get(fiber.HeaderRange); header != "" && (c.Get(fiber.HeaderIfRange) == "" || c.Get(fiber.HeaderIfRange) == tag) {
+		var ok bool
+		start, end, ok = parseRange(header, size)
+		if !ok {
+			obj.Close()
+			c.Set(fiber.HeaderContentRange, fmt.Sprintf("bytes */%d", size))
+			return c.SendStatus(fiber.StatusRequestedRangeNotSatisfiable)
+		}
+		status = fiber.StatusPartialContent
+		c.Set(fiber.HeaderContentRange, fmt.Sprintf("bytes %d-%d/%d", start, end, size))
+	}
+
+	if c.Method() == fiber.MethodHead {
+		obj.Close()
+		// SendStatus would set the status text as body and its length.
+		c.Status(status)
+		c.Response().SkipBody = true
+		c.Response().Header.SetContentLength(int(end - start + 1))
+		return nil
+	}
+	if _, err := obj.Seek(start, io.SeekStart); err != nil {
+		obj.Close()
+		return err
+	}
+	c.Status(status)
+	// fasthttp closes the reader once the body has been sent.
+	return c.SendStream(readCloser{io.LimitReader(obj, end-start+1), obj}, int(end-start+1))
+}
+
+// sendBody writes generated content such as rewritten playlists, using a
+// hash of the body as ETag.
+func sendBody(c *fiber.Ctx, name string, body []byte, cache string) error {
+	sum := sha256.Sum256(body)
+	tag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	c.Set(fiber.HeaderContentType, contentType(name))
+	c.Set(fiber.HeaderCacheControl, cache)
+	c.Set(fiber.HeaderETag, tag)
+	if match := c.Get(fiber.HeaderIfNoneMatch); match != "" && etagMatches(match, tag) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+	return c.Send(body)
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+func etagMatches(header, tag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// parseRange parses a single "bytes=" range. Multiple ranges are not
+// supported; players only ever ask for one.
+func parseRange(header string, size int64) (int64, int64, bool) {
+	spec, ok := strings.CutPrefix(header, "bytes=")
+	if !ok || strings.Contains(spec, ",") || size == 0 {
+		return 0, 0, false
+	}
+	first, last, ok := strings.Cut(strings.TrimSpace(spec), "-")
+	if !ok {
+		return 0, 0, false
+	}
+	if first == "" {
+		// Suffix range: the last N bytes.
+		n, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || n <= 0 {
+			return 0, 0, false
+		}
+		if n > size {
+			n = size
+		}
+		return size - n, size - 1, true
+	}
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil || start < 0 || start >= size {
+		return 0, 0, false
+	}
+	end := size - 1
+	if last != "" {
+		end, err = strconv.ParseInt(last, 10, 64)
+		if err != nil || end < start {
+			return 0, 0, false
+		}
+		if end >= size {
+			end = size - 1
+		}
+	}
+	return start, end, true
+}
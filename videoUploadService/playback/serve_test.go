@@ -0,0 +1,134 @@
+package playback
+
+import (
+	"bytes"
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestParseRange(t *testing.T) {
+	tests := []struct {
+		header     string
+		size       int64
+		start, end int64
+		ok         bool
+	}{
+		{"bytes=0-99", 1000, 0, 99, true},
+		{"bytes=100-", 1000, 100, 999, true},
+		{"bytes=-100", 1000, 900, 999, true},
+		{"bytes=-2000", 1000, 0, 999, true},
+		{"bytes=900-2000", 1000, 900, 999, true},
+		{"bytes=999-999", 1000, 999, 999, true},
+		{"bytes= 10-19", 1000, 10, 19, true},
+		{"bytes=1000-", 1000, 0, 0, false},
+		{"bytes=100-99", 1000, 0, 0, false},
+		{"bytes=-0", 1000, 0, 0, false},
+		{"bytes=-", 1000, 0, 0, false},
+		{"bytes=0-1,5-6", 1000, 0, 0, false},
+		{"bytes=a-b", 1000, 0, 0, false},
+		{"bytes=10", 1000, 0, 0, false},
+		{"items=0-1", 1000, 0, 0, false},
+		{"bytes=0-", 0, 0, 0, false},
+	}
+	for _, tt := range tests {
+		start, end, ok := parseRange(tt.header, tt.size)
+		if ok != tt.ok || ok && (start != tt.start || end != tt.end) {
+			t.Errorf("parseRange(%q, %d) = %d, %d, %v; want %d, %d, %v",
+				tt.header, tt.size, start, end, ok, tt.start, tt.end, tt.ok)
+		}
+	}
+}
+
+func TestEtagMatches(t *testing.T) {
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{`"abc"`, true},
+		{`W/"abc"`, true},
+		{`"xyz", "abc"`, true},
+		{`*`, true},
+		{`"xyz"`, false},
+		{`abc`, false},
+		{`"abc-gzip"`, false},
+	}
+	for _, tt := range tests {
+		if got := etagMatches(tt.header, `"abc"`); got != tt.want {
+			t.Errorf("etagMatches(%s) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
+
+type memObject struct {
+	*bytes.Reader
+	modTime time.Time
+}
+
+func (o memObject) Size() int64        { return o.Reader.Size() }
+func (o memObject) ModTime() time.Time { return o.modTime }
+func (o memObject) Close() error       { return nil }
+
+func TestServeObject(t *testing.T) {
+	data := []byte("0123456789")
+	obj := func() memObject {
+		return memObject{bytes.NewReader(data), time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	}
+	tag := etag(obj())
+	app := fiber.New()
+	app.Get("/seg.ts", func(c *fiber.Ctx) error {
+		return serveObject(c, "seg.ts", obj())
+	})
+
+	tests := []struct {
+		name         string
+		method       string
+		headers      map[string]string
+		status       int
+		contentRange string
+		body         string
+	}{
+		{"whole object", "GET", nil, 200, "", "0123456789"},
+		{"range", "GET", map[string]string{"Range": "bytes=2-5"}, 206, "bytes 2-5/10", "2345"},
+		{"open range", "GET", map[string]string{"Range": "bytes=7-"}, 206, "bytes 7-9/10", "789"},
+		{"suffix range", "GET", map[string]string{"Range": "bytes=-3"}, 206, "bytes 7-9/10", "789"},
+		{"range past the end", "GET", map[string]string{"Range": "bytes=8-100"}, 206, "bytes 8-9/10", "89"},
+		{"unsatisfiable", "GET", map[string]string{"Range": "bytes=10-"}, 416, "bytes */10", ""},
+		{"head of a range", "HEAD", map[string]string{"Range": "bytes=2-5"}, 206, "bytes 2-5/10", ""},
+		{"not modified", "GET", map[string]string{"If-None-Match": tag}, 304, "", ""},
+		{"modified", "GET", map[string]string{"If-None-Match": `"other"`}, 200, "", "0123456789"},
+		{"if-range matches", "GET", map[string]string{"Range": "bytes=0-1", "If-Range": tag}, 206, "bytes 0-1/10", "01"},
+		{"if-range mismatch", "GET", map[string]string{"Range": "bytes=0-1", "If-Range": `"stale"`}, 200, "", "0123456789"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/seg.ts", nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			res, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, _ := io.ReadAll(res.Body)
+			if res.StatusCode != tt.status {
+				t.Fatalf("status = %d, want %d", res.StatusCode, tt.status)
+			}
+			if got := res.Header.Get("Content-Range"); got != tt.contentRange {
+				t.Errorf("Content-Range = %q, want %q", got, tt.contentRange)
+			}
+			if tt.status < 300 && string(body) != tt.body {
+				t.Errorf("body = %q, want %q", body, tt.body)
+			}
+			if got := res.Header.Get("ETag"); got != tag {
+				t.Errorf("ETag = %s, want %s", got, tag)
+			}
+			if tt.method == "HEAD" && res.Header.Get("Content-Length") != "4" {
+				t.Errorf("Content-Length = %s, want 4", res.Header.Get("Content-Length"))
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package playback
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestVerify(t *testing.T) {
+	signer := NewSigner([]byte("test key"))
+	now := time.Unix(1_700_000_000, 0)
+	claims := Claims{VideoID: "v1", Expires: now.Add(time.Hour).Unix(), Viewer: "alice", IP: "10.0.0.1"}
+	playback := signer.Sign(claims)
+	original := claims
+	original.Scope = ScopeOriginal
+	originalToken := signer.Sign(original)
+
+	tests := []struct {
+		name, token, video, scope, ip string
+		now                           time.Time
+		want                          error
+	}{
+		{"valid", playback, "v1", "", "10.0.0.1", now, nil},
+		{"other video", playback, "v2", "", "10.0.0.1", now, ErrTokenScope},
+		{"other ip", playback, "v1", "", "10.0.0.2", now, ErrTokenScope},
+		{"expired", playback, "v1", "", "10.0.0.1", now.Add(time.Hour), ErrTokenExpired},
+		{"playback token for the original", playback, "v1", ScopeOriginal, "10.0.0.1", now, ErrTokenScope},
+		{"original token", originalToken, "v1", ScopeOriginal, "10.0.0.1", now, nil},
+		{"original token for media", originalToken, "v1", "", "10.0.0.1", now, ErrTokenScope},
+		{"tampered", playback[:len(playback)-2] + "AA", "v1", "", "10.0.0.1", now, ErrInvalidToken},
+		{"other key", NewSigner([]byte("other")).Sign(claims), "v1", "", "10.0.0.1", now, ErrInvalidToken},
+		{"malformed", "not-a-token", "v1", "", "10.0.0.1", now, ErrInvalidToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := signer.Verify(tt.token, tt.video, tt.scope, tt.ip, tt.now)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("Verify = %v, want %v", err, tt.want)
+			}
+			if err == nil && got.Viewer != "alice" {
+				t.Errorf("Viewer = %q, want alice", got.Viewer)
+			}
+		})
+	}
+}
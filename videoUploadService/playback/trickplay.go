@@ -0,0 +1,101 @@
+package playback
+
+import (
+	"VideoUploadService/catalog"
+	"VideoUploadService/packager"
+	"context"
+	"path"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// TrickPlay lists the I-frame playlists and thumbnail track of a video for
+// the catalog API. The URLs need a playback token like any other media.
+func (s *Server) TrickPlay(ctx context.Context, videoID string) *catalog.TrickPlay {
+	if s.packager == nil {
+		return nil
+	}
+	meta, err := s.packager.Metadata(ctx, videoID)
+	if err != nil {
+		return nil
+	}
+	base := s.baseURL + "/hls/" + videoID + "/"
+	tp := &catalog.TrickPlay{}
+	if meta.Thumbnails != nil {
+		tp.Thumbnails = base + packager.ThumbnailTrackName
+	}
+	for _, r := range meta.Renditions {
+		if r.HasIFrames() {
+			if tp.IFramePlaylists == nil {
+				tp.IFramePlaylists = make(map[string]string)
+			}
+			tp.IFramePlaylists[r.Name] = base + r.IFramePlaylistURI()
+		}
+	}
+	if tp.Thumbnails == "" && tp.IFramePlaylists == nil {
+		return nil
+	}
+	return tp
+}
+
+// serveIFrames generates the I-frame playlist of a rendition.
+func (s *Server) serveIFrames(c *fiber.Ctx) error {
+	return s.serveGenerated(c, func(video catalog.Video, claims Claims, meta packager.Metadata, mapURI func(string) string) ([]byte, int) {
+		dir := c.Params("rendition")
+		for _, r := range meta.Renditions {
+			if path.Dir(r.Playlist) != dir || !r.HasIFrames() {
+				continue
+			}
+			if !s.entitledTo(video, claims, meta, r) {
+				return nil, fiber.StatusForbidden
+			}
+			return packager.IFramePlaylist(r, mapURI), fiber.StatusOK
+		}
+		return nil, fiber.StatusNotFound
+	})
+}
+
+// serveThumbnails generates the WebVTT thumbnail track.
+func (s *Server) serveThumbnails(c *fiber.Ctx) error {
+	return s.serveGenerated(c, func(_ catalog.Video, _ Claims, meta packager.Metadata, mapURI func(string) string) ([]byte, int) {
+		body, ok := packager.ThumbnailTrack(meta, mapURI)
+		if !ok {
+			return nil, fiber.StatusNotFound
+		}
+		return body, fiber.StatusOK
+	})
+}
+
+// serveGenerated authorizes a request for a manifest generated from
+// rendition metadata and sends what render produces. URIs in the manifest
+// carry the request's token.
+func (s *Server) serveGenerated(c *fiber.Ctx, render func(catalog.Video, Claims, packager.Metadata, func(string) string) ([]byte, int)) error {
+	if s.packager == nil {
+		return c.Status(fiber.StatusNotFound).SendString("Not found")
+	}
+	video, err := s.readyVideo(c.Context(), c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).SendString("Video not available")
+	}
+	token := c.Query("token")
+	claims, err := s.authorize(c, video, token)
+	if err != nil {
+		return c.Status(fiber.StatusForbidden).SendString(err.Error())
+	}
+	meta, err := s.packager.Metadata(c.Context(), video.ID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).SendString("Not found")
+	}
+
+	mapURI := func(uri string) string { return uri }
+	cache := PlaylistCacheControl
+	if token != "" {
+		mapURI = func(uri string) string { return withQuery(uri, "token", token) }
+		cache = SignedPlaylistCacheControl
+	}
+	body, status := render(video, claims, meta, mapURI)
+	if status != fiber.StatusOK {
+		return c.SendStatus(status)
+	}
+	return sendBody(c, path.Base(c.Path()), body, cache)
+}
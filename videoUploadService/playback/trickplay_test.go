@@ -0,0 +1,133 @@
+package playback
+
+import (
+	"VideoUploadService/catalog"
+	"VideoUploadService/packager"
+	"VideoUploadService/storage"
+	"context"
+	"io"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// newTrickPlayServer serves the public video "v" of alice with signed URLs.
+// Its 360p and 1080p renditions have keyframes and its 720p rendition does
+// not; 23 seconds of thumbnails are tiled 2 by 2.
+func newTrickPlayServer(t *testing.T) (*Server, *Signer) {
+	t.Helper()
+	store, err := catalog.Open("sqlite", "file::memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	now := time.Now().UTC()
+	v := catalog.Video{ID: "v", Owner: "alice", State: catalog.StateReady, CreatedAt: now, UpdatedAt: now,
+		Metadata: catalog.Metadata{Visibility: catalog.VisibilityPublic}}
+	if err := store.Create(context.Background(), v); err != nil {
+		t.Fatal(err)
+	}
+
+	media := storage.NewLocal(t.TempDir() + "/")
+	keyframes := []packager.Keyframe{{Offset: 376, Size: 1000}}
+	m := packager.Metadata{
+		VideoID:         "v",
+		Format:          packager.FormatTS,
+		DurationSeconds: 23,
+		Renditions: []packager.Rendition{
+			{Name: "360p", Height: 360, Bandwidth: 800000, Playlist: "360p/360p.m3u8",
+				Segments: []packager.Segment{{URI: "360p/seg0.ts", Duration: 4, Keyframes: keyframes}}},
+			{Name: "720p", Height: 720, Bandwidth: 2800000, Playlist: "720p/720p.m3u8",
+				Segments: []packager.Segment{{URI: "720p/seg0.ts", Duration: 4}}},
+			{Name: "1080p", Height: 1080, Bandwidth: 5000000, Playlist: "1080p/1080p.m3u8",
+				Segments: []packager.Segment{{URI: "1080p/seg0.ts", Duration: 4, Keyframes: keyframes}}},
+		},
+		Thumbnails: &packager.Thumbnails{Interval: 5, Width: 160, Height: 90, Columns: 2, Rows: 2,
+			Sheets: []string{"thumbs/sprite_001.jpg", "thumbs/sprite_002.jpg"}},
+	}
+	if err := packager.Save(context.Background(), media, m); err != nil {
+		t.Fatal(err)
+	}
+
+	s := New(media, catalog.New(store), "")
+	s.SetPackager(packager.New(media))
+	signer := NewSigner([]byte("test key"))
+	s.SetSigner(signer, time.Hour, "https://media.example.com")
+	return s, signer
+}
+
+func TestTrickPlay(t *testing.T) {
+	s, _ := newTrickPlayServer(t)
+	got := s.TrickPlay(context.Background(), "v")
+	want := &catalog.TrickPlay{
+		Thumbnails: "https://media.example.com/hls/v/thumbnails.vtt",
+		IFramePlaylists: map[string]string{
+			"360p":  "https://media.example.com/hls/v/360p/iframes.m3u8",
+			"1080p": "https://media.example.com/hls/v/1080p/iframes.m3u8",
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("TrickPlay = %+v, want %+v", got, want)
+	}
+	if got := s.TrickPlay(context.Background(), "unpackaged"); got != nil {
+		t.Errorf("TrickPlay of a video without metadata = %+v", got)
+	}
+}
+
+func TestServeTrickPlay(t *testing.T) {
+	s, signer := newTrickPlayServer(t)
+	app := fiber.New()
+	app.Get("/hls/:id/"+packager.ThumbnailTrackName, s.serveThumbnails)
+	app.Get("/hls/:id/:rendition/"+packager.IFramePlaylistName, s.serveIFrames)
+	free := signer.Sign(Claims{VideoID: "v", Expires: ExpiresIn(time.Hour), Tier: "free"})
+	premium := signer.Sign(Claims{VideoID: "v", Expires: ExpiresIn(time.Hour), Tier: "premium"})
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+		// wantLines must all be in the body.
+		wantLines []string
+	}{
+		{
+			name:       "thumbnails carry the token",
+			path:       "/hls/v/thumbnails.vtt?token=" + free,
+			wantStatus: fiber.StatusOK,
+			wantLines: []string{
+				"00:00:15.000 --> 00:00:20.000\nthumbs/sprite_001.jpg?token=" + free + "#xywh=160,90,160,90\n",
+				"00:00:20.000 --> 00:00:23.000\nthumbs/sprite_002.jpg?token=" + free + "#xywh=0,0,160,90\n",
+			},
+		},
+		{name: "thumbnails without a token", path: "/hls/v/thumbnails.vtt", wantStatus: fiber.StatusForbidden},
+		{
+			name:       "entitled I-frames",
+			path:       "/hls/v/360p/iframes.m3u8?token=" + free,
+			wantStatus: fiber.StatusOK,
+			wantLines:  []string{"#EXT-X-BYTERANGE:1000@376\nseg0.ts?token=" + free + "\n"},
+		},
+		{name: "I-frames above the tier limit", path: "/hls/v/1080p/iframes.m3u8?token=" + free, wantStatus: fiber.StatusForbidden},
+		{name: "premium I-frames", path: "/hls/v/1080p/iframes.m3u8?token=" + premium, wantStatus: fiber.StatusOK},
+		{name: "rendition without keyframes", path: "/hls/v/720p/iframes.m3u8?token=" + free, wantStatus: fiber.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := app.Test(httptest.NewRequest("GET", tt.path, nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, _ := io.ReadAll(res.Body)
+			if res.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d (%s), want %d", res.StatusCode, body, tt.wantStatus)
+			}
+			for _, line := range tt.wantLines {
+				if !strings.Contains(string(body), line) {
+					t.Errorf("body\n%s\ndoes not contain\n%s", body, line)
+				}
+			}
+		})
+	}
+}
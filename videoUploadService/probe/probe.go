@@ -0,0 +1,107 @@
+package probe
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// Info describes the source video as reported by ffprobe.
+type Info struct {
+	Width           uint32  `json:"width"`
+	Height          uint32  `json:"height"`
+	FrameRate       float64 `json:"frame_rate"`
+	DurationSeconds float64 `json:"duration_seconds"`
+	BitrateKbps     uint32  `json:"bitrate_kbps"`
+	VideoCodec      string  `json:"video_codec"`
+	HasAudio        bool    `json:"has_audio"`
+}
+
+type ffprobeOutput struct {
+	Streams []struct {
+		CodecType    string `json:"codec_type"`
+		CodecName    string `json:"codec_name"`
+		Width        uint32 `json:"width"`
+		Height       uint32 `json:"height"`
+		AvgFrameRate string `json:"avg_frame_rate"`
+		BitRate      string `json:"bit_rate"`
+	} `json:"streams"`
+	Format struct {
+		Duration string `json:"duration"`
+		BitRate  string `json:"bit_rate"`
+	} `json:"format"`
+}
+
+// File runs ffprobe on the file at path.
+func File(ctx context.Context, path string) (Info, error) {
+	cmd := exec.CommandContext(ctx, "ffprobe", "-v", "quiet", "-print_format", "json",
+		"-show_streams", "-show_format", path)
+	out, err := cmd.Output()
+	if err != nil {
+		return Info{}, fmt.Errorf("ffprobe %s: %w", path, err)
+	}
+	return Parse(out)
+}
+
+// Parse reads the JSON output of ffprobe -show_streams -show_format.
+func Parse(data []byte) (Info, error) {
+	var out ffprobeOutput
+	if err := json.Unmarshal(data, &out); err != nil {
+		return Info{}, fmt.Errorf("parse ffprobe output: %w", err)
+	}
+
+	var info Info
+	foundVideo := false
+	for _, s := range out.Streams {
+		switch s.CodecType {
+		case "video":
+			if foundVideo {
+				continue
+			}
+			foundVideo = true
+			info.Width = s.Width
+			info.Height = s.Height
+			info.VideoCodec = s.CodecName
+			info.FrameRate = parseRate(s.AvgFrameRate)
+			info.BitrateKbps = parseKbps(s.BitRate)
+		case "audio":
+			info.HasAudio = true
+		}
+	}
+	if !foundVideo {
+		return Info{}, fmt.Errorf("no video stream")
+	}
+	if info.BitrateKbps == 0 {
+		info.BitrateKbps = parseKbps(out.Format.BitRate)
+	}
+	info.DurationSeconds, _ = strconv.ParseFloat(out.Format.Duration, 64)
+	return info, nil
+}
+
+// parseRate parses ffprobe rates such as "30000/1001".
+func parseRate(s string) float64 {
+	num, den, ok := strings.Cut(s, "/")
+	n, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return 0
+	}
+	if !ok {
+		return n
+	}
+	d, err := strconv.ParseFloat(den, 64)
+	if err != nil || d == 0 {
+		return 0
+	}
+	return n / d
+}
+
+func parseKbps(s string) uint32 {
+	bps, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return uint32(bps / 1000)
+}
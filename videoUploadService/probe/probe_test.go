@@ -0,0 +1,77 @@
+package probe
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		fixture string
+		want    Info
+	}{
+		{
+			// The stream's own bitrate wins over the container's, and the
+			// cover art is not the video.
+			fixture: "h264_aac.json",
+			want: Info{Width: 1920, Height: 1080, FrameRate: 30000.0 / 1001, DurationSeconds: 12.032,
+				BitrateKbps: 4823, VideoCodec: "h264", HasAudio: true},
+		},
+		{
+			// WebM streams carry no bitrate of their own.
+			fixture: "vp9_webm.json",
+			want:    Info{Width: 1080, Height: 1920, FrameRate: 60, DurationSeconds: 31.5, BitrateKbps: 7200, VideoCodec: "vp9"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", tt.fixture))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := Parse(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Parse\n got %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	audio, err := os.ReadFile(filepath.Join("testdata", "audio_only.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, data := range map[string][]byte{
+		"audio only": audio,
+		"truncated":  []byte(`{"streams": [{"codec_type": "video"`),
+		"empty":      nil,
+	} {
+		if info, err := Parse(data); err == nil {
+			t.Errorf("%s: Parse = %+v, want an error", name, info)
+		}
+	}
+}
+
+func TestParseRate(t *testing.T) {
+	tests := []struct {
+		in   string
+		want float64
+	}{
+		{"30000/1001", 30000.0 / 1001},
+		{"25/1", 25},
+		{"24", 24},
+		{"0/0", 0},
+		{"", 0},
+		{"abc/1", 0},
+	}
+	for _, tt := range tests {
+		if got := parseRate(tt.in); got != tt.want {
+			t.Errorf("parseRate(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package profile
+
+import (
+	"VideoUploadService/probe"
+	pbt "VideoUploadService/transcoding"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"sync"
+)
+
+// Rung is one rendition of an encoding ladder.
+type Rung struct {
+	Name           string `json:"name"`
+	Width          uint32 `json:"width"`
+	Height         uint32 `json:"height"`
+	BitrateKbps    uint32 `json:"bitrate_kbps"`
+	MaxBitrateKbps uint32 `json:"max_bitrate_kbps"`
+}
+
+type Audio struct {
+	Codec       string `json:"codec"`
+	BitrateKbps uint32 `json:"bitrate_kbps"`
+	SampleRate  uint32 `json:"sample_rate"`
+	Channels    uint32 `json:"channels"`
+}
+
+// Preset is a named encoding configuration.
+type Preset struct {
+	Name            string `json:"name"`
+	VideoCodec      string `json:"video_codec"`
+	SegmentDuration uint32 `json:"segment_duration_seconds"`
+	MaxFrameRate    uint32 `json:"max_frame_rate"`
+	Audio           Audio  `json:"audio"`
+	Ladder          []Rung `json:"ladder"`
+}
+
+var standardLadder = []Rung{
+	{Name: "360p", Width: 640, Height: 360, BitrateKbps: 800, MaxBitrateKbps: 1200},
+	{Name: "480p", Width: 854, Height: 480, BitrateKbps: 1400, MaxBitrateKbps: 2100},
+	{Name: "720p", Width: 1280, Height: 720, BitrateKbps: 2800, MaxBitrateKbps: 4200},
+	{Name: "1080p", Width: 1920, Height: 1080, BitrateKbps: 5000, MaxBitrateKbps: 7500},
+}
+
+var (
+	mu      sync.RWMutex
+	presets = map[string]Preset{
+		"standard": {
+			Name:            "standard",
+			VideoCodec:      "libx264",
+			SegmentDuration: 4,
+			MaxFrameRate:    30,
+			Audio:           Audio{Codec: "aac", BitrateKbps: 128, SampleRate: 44100, Channels: 2},
+			Ladder:          standardLadder,
+		},
+		"low": {
+			Name:            "low",
+			VideoCodec:      "libx264",
+			SegmentDuration: 6,
+			MaxFrameRate:    24,
+			Audio:           Audio{Codec: "aac", BitrateKbps: 64, SampleRate: 44100, Channels: 2},
+			Ladder:          standardLadder[:2],
+		},
+	}
+	defaultPreset = "standard"
+)
+
+// Load reads presets from a JSON file holding a list of presets. Presets with
+// the same name as a built-in one replace it.
+func Load(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var list []Preset
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("parse presets %s: %w", path, err)
+	}
+
+	for _, p := range list {
+		if p.Name == "" || len(p.Ladder) == 0 {
+			return fmt.Errorf("preset %q: name and ladder are required", p.Name)
+		}
+	}
+
+	// Presets are swapped in all at once, so a file with an invalid preset
+	// leaves the current ones untouched.
+	mu.Lock()
+	defer mu.Unlock()
+	next := make(map[string]Preset, len(presets)+len(list))
+	for name, p := range presets {
+		next[name] = p
+	}
+	for _, p := range list {
+		next[p.Name] = p
+	}
+	presets = next
+	return nil
+}
+
+// SetDefault selects the preset used when none is requested.
+func SetDefault(name string) error {
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := presets[name]; !ok {
+		return fmt.Errorf("unknown preset %q", name)
+	}
+	defaultPreset = name
+	return nil
+}
+
+// Get returns the named preset, or the default preset for an empty name.
+func Get(name string) (Preset, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	if name == "" {
+		name = defaultPreset
+	}
+	p, ok := presets[name]
+	return p, ok
+}
+
+// Build computes the encoding profile for a source. Rungs taller than the
+// source are dropped so that low resolution uploads are never upscaled; if
+// the source is smaller than every rung, a single rendition at the source
+// size is produced instead. Bitrates are capped at the source bitrate.
+func Build(p Preset, src probe.Info) *pbt.EncodingProfile {
+	ladder := append([]Rung(nil), p.Ladder...)
+	sort.Slice(ladder, func(i, j int) bool { return ladder[i].Height < ladder[j].Height })
+
+	var rungs []Rung
+	for _, r := range ladder {
+		if src.Height == 0 || r.Height <= src.Height {
+			rungs = append(rungs, r)
+		}
+	}
+	if len(rungs) == 0 {
+		r := ladder[0]
+		r.Name = fmt.Sprintf("%dp", src.Height)
+		r.Width = evenWidth(src.Width, src.Height, src.Height)
+		r.Height = src.Height &^ 1
+		rungs = append(rungs, r)
+	}
+
+	frameRate := p.MaxFrameRate
+	if src.FrameRate > 0 && (frameRate == 0 || uint32(math.Round(src.FrameRate)) < frameRate) {
+		frameRate = uint32(math.Round(src.FrameRate))
+	}
+
+	profile := &pbt.EncodingProfile{
+		Name:                   p.Name,
+		VideoCodec:             p.VideoCodec,
+		SegmentDurationSeconds: p.SegmentDuration,
+	}
+	if src.HasAudio || src.Height == 0 {
+		profile.Audio = &pbt.AudioSettings{
+			Codec:       p.Audio.Codec,
+			BitrateKbps: p.Audio.BitrateKbps,
+			SampleRate:  p.Audio.SampleRate,
+			Channels:    p.Audio.Channels,
+		}
+	}
+	for _, r := range rungs {
+		bitrate, maxBitrate := r.BitrateKbps, r.MaxBitrateKbps
+		if src.BitrateKbps > 0 && bitrate > src.BitrateKbps {
+			bitrate = src.BitrateKbps
+			maxBitrate = src.BitrateKbps * 3 / 2
+		}
+		width := r.Width
+		if src.Width > 0 && src.Height > 0 {
+			width = evenWidth(src.Width, src.Height, r.Height)
+		}
+		profile.Renditions = append(profile.Renditions, &pbt.RenditionSpec{
+			Name:             r.Name,
+			Width:            width,
+			Height:           r.Height,
+			VideoBitrateKbps: bitrate,
+			MaxBitrateKbps:   maxBitrate,
+			FrameRate:        frameRate,
+		})
+	}
+	return profile
+}
+
+// evenWidth scales the source width to the target height, keeping the aspect
+// ratio and rounding to an even number as required by yuv420p.
+func evenWidth(srcWidth, srcHeight, height uint32) uint32 {
+	if srcHeight == 0 {
+		return 0
+	}
+	w := uint32(math.Round(float64(srcWidth) * float64(height) / float64(srcHeight)))
+	return w &^ 1
+}
@@ -0,0 +1,158 @@
+package profile
+
+import (
+	"VideoUploadService/probe"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func writePresets(t *testing.T, data string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "presets.json")
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	saved := presets
+	defer func() { presets = saved }()
+
+	// The first preset is valid, the second is not: neither may be applied.
+	path := writePresets(t, `[
+		{"name": "standard", "video_codec": "libx265", "ladder": [{"name": "720p", "width": 1280, "height": 720}]},
+		{"name": "broken"}
+	]`)
+	if err := Load(path); err == nil {
+		t.Fatal("Load accepted a preset without a ladder")
+	}
+	if p, _ := Get("standard"); p.VideoCodec != "libx264" {
+		t.Errorf("standard preset changed to %q by a rejected file", p.VideoCodec)
+	}
+
+	path = writePresets(t, `[
+		{"name": "standard", "video_codec": "libx265", "ladder": [{"name": "720p", "width": 1280, "height": 720}]},
+		{"name": "mobile", "video_codec": "libx264", "ladder": [{"name": "360p", "width": 640, "height": 360}]}
+	]`)
+	if err := Load(path); err != nil {
+		t.Fatal(err)
+	}
+	if p, _ := Get(""); p.VideoCodec != "libx265" {
+		t.Errorf("default preset codec = %q, want the loaded libx265", p.VideoCodec)
+	}
+	if _, ok := Get("mobile"); !ok {
+		t.Error("loaded preset mobile is missing")
+	}
+	if _, ok := Get("low"); !ok {
+		t.Error("built-in preset low was dropped")
+	}
+	if saved["standard"].VideoCodec != "libx264" {
+		t.Error("Load modified the previous presets in place")
+	}
+}
+
+func TestBuild(t *testing.T) {
+	preset := Preset{
+		Name:            "test",
+		VideoCodec:      "libx264",
+		SegmentDuration: 4,
+		MaxFrameRate:    30,
+		Audio:           Audio{Codec: "aac", BitrateKbps: 128, SampleRate: 48000, Channels: 2},
+		// Out of order: Build sorts the ladder by height.
+		Ladder: []Rung{standardLadder[2], standardLadder[0], standardLadder[3], standardLadder[1]},
+	}
+	type rendition struct {
+		name                string
+		width, height       uint32
+		bitrate, maxBitrate uint32
+	}
+	tests := []struct {
+		name       string
+		src        probe.Info
+		renditions []rendition
+		frameRate  uint32
+		audio      bool
+	}{
+		{
+			// Widths are rounded down to even numbers: 853 becomes 852.
+			name: "720p landscape",
+			src:  probe.Info{Width: 1280, Height: 720, FrameRate: 30000.0 / 1001, BitrateKbps: 6000, HasAudio: true},
+			renditions: []rendition{
+				{"360p", 640, 360, 800, 1200},
+				{"480p", 852, 480, 1400, 2100},
+				{"720p", 1280, 720, 2800, 4200},
+			},
+			frameRate: 30,
+			audio:     true,
+		},
+		{
+			name: "low bitrate source caps the rungs",
+			src:  probe.Info{Width: 1280, Height: 720, FrameRate: 24, BitrateKbps: 1000, HasAudio: true},
+			renditions: []rendition{
+				{"360p", 640, 360, 800, 1200},
+				{"480p", 852, 480, 1000, 1500},
+				{"720p", 1280, 720, 1000, 1500},
+			},
+			frameRate: 24,
+			audio:     true,
+		},
+		{
+			name: "portrait keeps its aspect ratio",
+			src:  probe.Info{Width: 1080, Height: 1920, FrameRate: 60, BitrateKbps: 12000},
+			renditions: []rendition{
+				{"360p", 202, 360, 800, 1200},
+				{"480p", 270, 480, 1400, 2100},
+				{"720p", 404, 720, 2800, 4200},
+				{"1080p", 608, 1080, 5000, 7500},
+			},
+			frameRate: 30,
+		},
+		{
+			name: "smaller than every rung",
+			src:  probe.Info{Width: 321, Height: 181, FrameRate: 15, HasAudio: true},
+			renditions: []rendition{
+				{"181p", 318, 180, 800, 1200},
+			},
+			frameRate: 15,
+			audio:     true,
+		},
+		{
+			name: "unknown source gets the full ladder",
+			src:  probe.Info{},
+			renditions: []rendition{
+				{"360p", 640, 360, 800, 1200},
+				{"480p", 854, 480, 1400, 2100},
+				{"720p", 1280, 720, 2800, 4200},
+				{"1080p", 1920, 1080, 5000, 7500},
+			},
+			frameRate: 30,
+			audio:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := Build(preset, tt.src)
+			if p.Name != "test" || p.VideoCodec != "libx264" || p.SegmentDurationSeconds != 4 {
+				t.Errorf("profile %s/%s/%ds, want the preset's", p.Name, p.VideoCodec, p.SegmentDurationSeconds)
+			}
+			if (p.Audio != nil) != tt.audio {
+				t.Errorf("audio = %v, want %v", p.Audio, tt.audio)
+			} else if p.Audio != nil && (p.Audio.Codec != "aac" || p.Audio.BitrateKbps != 128 || p.Audio.SampleRate != 48000 || p.Audio.Channels != 2) {
+				t.Errorf("audio = %v, want the preset's", p.Audio)
+			}
+			var got []rendition
+			for _, r := range p.Renditions {
+				got = append(got, rendition{r.Name, r.Width, r.Height, r.VideoBitrateKbps, r.MaxBitrateKbps})
+				if r.FrameRate != tt.frameRate {
+					t.Errorf("%s frame rate = %d, want %d", r.Name, r.FrameRate, tt.frameRate)
+				}
+			}
+			if !slices.Equal(got, tt.renditions) {
+				t.Errorf("renditions\n got %v\nwant %v", got, tt.renditions)
+			}
+		})
+	}
+}
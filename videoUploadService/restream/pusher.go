@@ -0,0 +1,296 @@
+package restream
+
+import (
+	"VideoUploadService/flv"
+	"VideoUploadService/live"
+	"VideoUploadService/rtmp"
+	"context"
+	"errors"
+	"log"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+)
+
+// States of a destination.
+const (
+	// StateIdle is an enabled destination of a channel that is not live.
+	StateIdle       = "idle"
+	StateDisabled   = "disabled"
+	StateConnecting = "connecting"
+	StateLive       = "live"
+	// StateRetrying is a destination waiting to reconnect after a failure.
+	StateRetrying = "retrying"
+)
+
+const (
+	// connectTimeout bounds connecting and setting up a publish.
+	connectTimeout = 10 * time.Second
+	// packetBuffer is how many packets may queue up for a destination
+	// before it is skipped ahead to the next keyframe.
+	packetBuffer = 2048
+)
+
+// Status is how the push to a destination is doing.
+type Status struct {
+	State string     `json:"state"`
+	Since *time.Time `json:"since,omitempty"`
+	// Failures counts the failed attempts since the last stable connection.
+	Failures  int        `json:"failures,omitempty"`
+	LastError string     `json:"last_error,omitempty"`
+	NextRetry *time.Time `json:"next_retry,omitempty"`
+	// BytesSent counts the media sent during this broadcast.
+	BytesSent int64 `json:"bytes_sent"`
+}
+
+// Backoff is how long a destination waits before reconnecting after a
+// failure, doubling from Initial up to Max.
+type Backoff struct {
+	Initial time.Duration
+	Max     time.Duration
+	// Reset is how long a connection must last for the next failure to
+	// wait Initial again.
+	Reset time.Duration
+}
+
+var DefaultBackoff = Backoff{Initial: time.Second, Max: 30 * time.Second, Reset: time.Minute}
+
+// delay returns the wait after the given number of failures in a row.
+func (b Backoff) delay(failures int) time.Duration {
+	d := b.Initial
+	for i := 1; i < failures && d < b.Max; i++ {
+		d *= 2
+	}
+	d = min(d, b.Max)
+	// Jitter keeps destinations that failed together from retrying in
+	// lockstep.
+	return d - time.Duration(rand.Int63n(int64(d)/5+1))
+}
+
+// pusher pushes one broadcast to one destination until the broadcast ends
+// or it is stopped.
+type pusher struct {
+	dest    Destination
+	stream  *live.Stream
+	backoff Backoff
+	dialer  *net.Dialer
+	ctx     context.Context
+	stop    context.CancelFunc
+
+	mu     sync.Mutex
+	status Status
+}
+
+func newPusher(d Destination, s *live.Stream, backoff Backoff, dialer *net.Dialer) *pusher {
+	ctx, cancel := context.WithCancel(context.Background())
+	now := time.Now().UTC()
+	return &pusher{
+		dest:    d,
+		stream:  s,
+		backoff: backoff,
+		dialer:  dialer,
+		ctx:     ctx,
+		stop:    cancel,
+		status:  Status{State: StateConnecting, Since: &now},
+	}
+}
+
+func (p *pusher) snapshot() Status {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.status
+}
+
+func (p *pusher) setState(state string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := time.Now().UTC()
+	p.status.State, p.status.Since, p.status.NextRetry = state, &now, nil
+}
+
+// fail records a failed attempt and returns how long to wait before the
+// next. Failures after a stable connection start the backoff over.
+func (p *pusher) fail(err error, stable bool) time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if stable {
+		p.status.Failures = 0
+	}
+	p.status.Failures++
+	wait := p.backoff.delay(p.status.Failures)
+	now := time.Now().UTC()
+	next := now.Add(wait)
+	p.status.State, p.status.Since, p.status.NextRetry = StateRetrying, &now, &next
+	p.status.LastError = err.Error()
+	return wait
+}
+
+// run connects and pushes, reconnecting with backoff after failures.
+func (p *pusher) run() {
+	s := p.stream
+	// Connecting gives up when the broadcast ends; forwarding plays out the
+	// packets still queued, as the subscription ends after them.
+	connecting, stopConnecting := context.WithCancel(p.ctx)
+	go func() {
+		select {
+		case <-s.Done():
+			stopConnecting()
+		case <-connecting.Done():
+		}
+	}()
+	defer stopConnecting()
+	defer p.stop()
+
+	log.Printf("Live %s: restreaming to %s", s.ID, p.dest.label())
+	for {
+		p.setState(StateConnecting)
+		up, err := p.push(connecting)
+		if p.ctx.Err() != nil || ended(s) {
+			log.Printf("Live %s: stopped restreaming to %s", s.ID, p.dest.label())
+			return
+		}
+		if err == nil {
+			err = errors.New("destination closed the connection")
+		}
+		wait := p.fail(err, p.backoff.Reset > 0 && up >= p.backoff.Reset)
+		log.Printf("Live %s: restreaming to %s failed, retrying in %s: %v", s.ID, p.dest.label(), wait.Round(time.Millisecond), err)
+		select {
+		case <-time.After(wait):
+		case <-connecting.Done():
+			return
+		}
+	}
+}
+
+func ended(s *live.Stream) bool {
+	select {
+	case <-s.Done():
+		return true
+	default:
+		return false
+	}
+}
+
+// push publishes to the destination, unless connecting is done first, and
+// forwards packets until the broadcast ends, the connection fails or the
+// pusher is stopped. It returns how long the connection was up.
+func (p *pusher) push(connecting context.Context) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(connecting, connectTimeout)
+	cl, err := rtmp.PublishWith(ctx, p.dialer, p.dest.rawURL)
+	cancel()
+	if err != nil {
+		return 0, err
+	}
+	defer cl.Close()
+	// Subscribe before going live, so that no packet published once the
+	// destination shows as live is missed.
+	sub := p.stream.Subscribe(packetBuffer)
+	p.setState(StateLive)
+	connected := time.Now()
+	err = p.forward(cl, sub)
+	return time.Since(connected), err
+}
+
+// forward sends the broadcast to cl from the next keyframe on, with
+// timestamps starting at zero. A destination too slow for the broadcast
+// skips ahead to the following keyframe rather than disconnecting.
+func (p *pusher) forward(cl *rtmp.Client, sub *live.Subscription) error {
+	s := p.stream
+	defer func() { sub.Close() }()
+
+	var (
+		started bool
+		base    time.Duration
+		sent    *live.Codecs
+	)
+	for {
+		select {
+		case pkt, ok := <-sub.Packets():
+			if !ok {
+				if !errors.Is(sub.Err(), live.ErrSlowSubscriber) {
+					return nil
+				}
+				log.Printf("Live %s: %s fell behind, skipping to the next keyframe", s.ID, p.dest.label())
+				sub, started = s.Subscribe(packetBuffer), false
+				continue
+			}
+			if !started {
+				// Audio-only broadcasts can start anywhere.
+				if pkt.Codecs.Video != nil && (pkt.Kind != live.Video || !pkt.Keyframe) {
+					continue
+				}
+				if sent == nil {
+					base = pkt.DTS
+				}
+				started = true
+			}
+			ts := uint32(max(pkt.DTS-base, 0) / time.Millisecond)
+			var tags []flv.Tag
+			if pkt.Codecs != sent {
+				tags = sequenceHeaders(ts, pkt.Codecs)
+				sent = pkt.Codecs
+			}
+			tags = append(tags, packetTag(ts, pkt))
+			for _, t := range tags {
+				if err := cl.WriteTag(t); err != nil {
+					return err
+				}
+				p.mu.Lock()
+				p.status.BytesSent += int64(len(t.Data))
+				p.mu.Unlock()
+			}
+		case <-cl.Done():
+			return cl.Err()
+		case <-p.ctx.Done():
+			return nil
+		}
+	}
+}
+
+// sequenceHeaders returns the tags that configure the decoders of c.
+func sequenceHeaders(ts uint32, c *live.Codecs) []flv.Tag {
+	var tags []flv.Tag
+	if c.Video != nil && len(c.AVCRecord) > 4 {
+		record := append([]byte{}, c.AVCRecord...)
+		// Packets carry NAL units with 4-byte lengths, whatever the
+		// publisher used.
+		record[4] |= 0x03
+		data := append([]byte{flv.FrameKey<<4 | flv.CodecAVC, flv.AVCSequenceHeader, 0, 0, 0}, record...)
+		tags = append(tags, flv.Tag{Type: flv.TagVideo, Timestamp: ts, Data: data})
+	}
+	if c.Audio != nil {
+		data := append([]byte{aacHeader, flv.AACSequenceHeader}, c.AACRecord...)
+		tags = append(tags, flv.Tag{Type: flv.TagAudio, Timestamp: ts, Data: data})
+	}
+	return tags
+}
+
+// aacHeader is the FLV audio header of AAC: 44kHz, 16-bit stereo, as the
+// format requires whatever the actual configuration.
+const aacHeader = flv.SoundAAC<<4 | 0x0f
+
+func packetTag(ts uint32, pkt live.Packet) flv.Tag {
+	if pkt.Kind == live.Audio {
+		data := append([]byte{aacHeader, flv.AACRaw}, pkt.Data...)
+		return flv.Tag{Type: flv.TagAudio, Timestamp: ts, Data: data}
+	}
+	frame := byte(flv.FrameInter)
+	if pkt.Keyframe {
+		frame = flv.FrameKey
+	}
+	cts := int32((pkt.PTS - pkt.DTS) / time.Millisecond)
+	data := make([]byte, 5, 5+len(pkt.Data))
+	data[0] = frame<<4 | flv.CodecAVC
+	data[1] = flv.AVCNALU
+	data[2], data[3], data[4] = byte(cts>>16), byte(cts>>8), byte(cts)
+	return flv.Tag{Type: flv.TagVideo, Timestamp: ts, Data: append(data, pkt.Data...)}
+}
+
+// label names a destination in logs without its stream key.
+func (d Destination) label() string {
+	if d.Name != "" {
+		return d.Name
+	}
+	return maskURL(d.rawURL)
+}
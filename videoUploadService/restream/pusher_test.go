@@ -0,0 +1,203 @@
+package restream
+
+import (
+	"VideoUploadService/flv"
+	"VideoUploadService/live"
+	"VideoUploadService/rtmp"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+// destination is an RTMP server standing in for another platform. It
+// rejects the first publishes it is told to and records the tags of the
+// others.
+type destination struct {
+	mu      sync.Mutex
+	reject  int
+	streams []string
+	tags    []flv.Tag
+	closed  chan struct{}
+}
+
+func (d *destination) Publish(ctx context.Context, req rtmp.PublishRequest) (rtmp.Publisher, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.reject > 0 {
+		d.reject--
+		return nil, errors.New("not yet")
+	}
+	d.streams = append(d.streams, req.App+"/"+req.Name)
+	return d, nil
+}
+
+func (d *destination) WriteTag(t flv.Tag) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.tags = append(d.tags, t)
+	return nil
+}
+
+func (d *destination) Close(err error) {
+	close(d.closed)
+}
+
+// serve serves h on a loopback listener and returns its address.
+func serve(t *testing.T, h rtmp.Handler) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &rtmp.Server{Handler: h, Timeout: 5 * time.Second}
+	go srv.Serve(l)
+	t.Cleanup(func() { srv.Close() })
+	return l.Addr().String()
+}
+
+// waitState polls the status of a destination until it is in state.
+func waitState(t *testing.T, s *Service, id, state string) Status {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		d, err := s.Get(context.Background(), Caller{Admin: true}, "ch", id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d.Status.State == state {
+			return d.Status
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("destination is %+v, want %s", d.Status, state)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// ../live/testdata/publish.flv holds H.264 at 10 fps with a keyframe every 5
+// frames and AAC, both starting at 1000ms. A frame precedes the first
+// sequence header, and a new H.264 sequence header precedes frame 5.
+func TestPushReconnectsAndForwards(t *testing.T) {
+	hub := live.NewHub()
+	orig := live.Authenticate
+	live.Authenticate = func(ctx context.Context, key string) (live.Channel, error) {
+		return live.Channel{ID: "ch", Owner: "ch"}, nil
+	}
+	t.Cleanup(func() { live.Authenticate = orig })
+	ingest := serve(t, live.Ingest{Hub: hub})
+	dest := &destination{reject: 2, closed: make(chan struct{})}
+	destAddr := serve(t, dest)
+
+	s := openTestService(t)
+	s.hub = hub
+	s.SetAllowInternal(true)
+	s.SetBackoff(Backoff{Initial: 20 * time.Millisecond, Max: 40 * time.Millisecond, Reset: time.Minute})
+	hub.OnStart(s.Start)
+	d, err := s.Create(context.Background(), Caller{ID: "ch"}, "ch", Options{URL: "rtmp://" + destAddr + "/app/dest-key"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Status.State != StateIdle {
+		t.Fatalf("destination of an offline channel is %s, want %s", d.Status.State, StateIdle)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	cl, err := rtmp.Publish(ctx, "rtmp://"+ingest+"/"+live.App+"/secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cl.Close()
+
+	// The destination rejects two publishes: the push retries with backoff
+	// and counts the failures until it is connected.
+	retrying := waitState(t, s, d.ID, StateRetrying)
+	if retrying.Failures < 1 || retrying.LastError == "" || retrying.NextRetry == nil {
+		t.Errorf("retrying status %+v", retrying)
+	}
+	connected := waitState(t, s, d.ID, StateLive)
+	if connected.Failures != 2 || connected.NextRetry != nil {
+		t.Errorf("live status %+v, want 2 failures and no retry", connected)
+	}
+
+	f, err := os.Open("../live/testdata/publish.flv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	r, err := flv.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for {
+		tag, err := r.ReadTag()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := cl.WriteTag(tag); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cl.Close()
+
+	select {
+	case <-dest.closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("push did not end with the broadcast")
+	}
+	waitState(t, s, d.ID, StateIdle)
+
+	dest.mu.Lock()
+	defer dest.mu.Unlock()
+	if len(dest.streams) != 1 || dest.streams[0] != "app/dest-key" {
+		t.Errorf("destination got publishes %q, want [app/dest-key]", dest.streams)
+	}
+	var videoHeaders, audioHeaders, frames, audio int
+	for i, tag := range dest.tags {
+		switch {
+		case tag.Type == flv.TagVideo && tag.Data[1] == flv.AVCSequenceHeader:
+			videoHeaders++
+		case tag.Type == flv.TagAudio && tag.Data[1] == flv.AACSequenceHeader:
+			audioHeaders++
+		case tag.Type == flv.TagVideo:
+			if frames == 0 && (tag.Data[0]>>4 != flv.FrameKey || tag.Timestamp != 0) {
+				t.Errorf("first frame, tag %d, is not a keyframe at 0ms: %+v", i, tag)
+			}
+			frames++
+		case tag.Type == flv.TagAudio:
+			audio++
+		}
+	}
+	if videoHeaders != 2 || audioHeaders != 2 || frames != 10 || audio == 0 {
+		t.Errorf("forwarded %d video and %d audio sequence headers, %d frames and %d audio packets; want 2, 2, 10 and some",
+			videoHeaders, audioHeaders, frames, audio)
+	}
+}
+
+func TestBackoffDelay(t *testing.T) {
+	b := Backoff{Initial: time.Second, Max: 30 * time.Second}
+	for _, tt := range []struct {
+		failures int
+		want     time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{5, 16 * time.Second},
+		{6, 30 * time.Second},
+		{20, 30 * time.Second},
+	} {
+		// Jitter takes up to a fifth off.
+		if got := b.delay(tt.failures); got > tt.want || got < tt.want*4/5 {
+			t.Errorf("delay after %d failures = %s, want %s less up to 20%%", tt.failures, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,440 @@
+// Package restream forwards live broadcasts to other RTMP services, so
+// creators can simulcast to several platforms from one ingest. Each
+// destination is pushed by its own connection that reconnects with backoff.
+package restream
+
+import (
+	"VideoUploadService/live"
+	"VideoUploadService/rtmp"
+	"VideoUploadService/schema"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaxDestinations bounds the destinations of a channel.
+const MaxDestinations = 10
+
+const maxNameLength = 100
+
+var (
+	ErrNotFound  = errors.New("destination not found")
+	ErrForbidden = errors.New("not allowed to manage the destinations of this channel")
+	ErrInvalid   = errors.New("invalid destination")
+	ErrTooMany   = errors.New("channel has too many destinations")
+
+	errInternalAddress = errors.New("destination resolves to a loopback, link-local or private address")
+)
+
+// Destination is an RTMP(S) endpoint a channel's broadcasts are pushed to.
+type Destination struct {
+	ID      string `json:"id"`
+	Channel string `json:"channel"`
+	Name    string `json:"name"`
+	// URL is shown with the stream name masked, as it usually is the
+	// other platform's stream key.
+	URL       string    `json:"url"`
+	Enabled   bool      `json:"enabled"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Status    Status    `json:"status"`
+
+	rawURL string
+}
+
+// Options are what a creator chooses when adding a destination.
+type Options struct {
+	Name    string `json:"name"`
+	URL     string `json:"url"`
+	Enabled *bool  `json:"enabled"`
+}
+
+// Patch changes a destination. Nil fields are left alone.
+type Patch struct {
+	Name    *string `json:"name"`
+	URL     *string `json:"url"`
+	Enabled *bool   `json:"enabled"`
+}
+
+// Service stores the destinations of channels and pushes their broadcasts
+// to them.
+type Service struct {
+	db      *sql.DB
+	hub     *live.Hub
+	backoff Backoff
+	// allowInternal lets destinations point into the server's own
+	// network.
+	allowInternal bool
+
+	mu      sync.Mutex
+	pushers map[string]*pusher
+}
+
+// Default is set up in main.
+var Default *Service
+
+// migrations create the restream_destinations table. Earlier versions
+// created it in the catalog migrations, hence IF NOT EXISTS.
+var migrations = []string{
+	`CREATE TABLE IF NOT EXISTS restream_destinations (
+		id TEXT PRIMARY KEY,
+		channel TEXT NOT NULL,
+		name TEXT NOT NULL DEFAULT '',
+		url TEXT NOT NULL,
+		enabled BOOLEAN NOT NULL,
+		created_at TIMESTAMP NOT NULL,
+		updated_at TIMESTAMP NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS restream_destinations_channel_idx ON restream_destinations (channel)`,
+}
+
+// Open stores destinations in the restream_destinations table of db,
+// migrating it first. Broadcasts started on hub are forwarded once Start is
+// registered with it.
+func Open(db *sql.DB, hub *live.Hub) (*Service, error) {
+	if err := schema.Migrate(context.Background(), db, "restream", migrations); err != nil {
+		return nil, err
+	}
+	return &Service{db: db, hub: hub, backoff: DefaultBackoff, pushers: make(map[string]*pusher)}, nil
+}
+
+// SetBackoff sets how destinations reconnect after failures. It applies to
+// pushes started afterwards.
+func (s *Service) SetBackoff(b Backoff) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.backoff = b
+}
+
+// SetAllowInternal lets destinations point at loopback, link-local and
+// private addresses, such as an RTMP server next to this one. They are
+// refused by default so that creators cannot make the server connect into
+// its own network. It applies to destinations saved and pushes started
+// afterwards.
+func (s *Service) SetAllowInternal(allow bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.allowInternal = allow
+}
+
+// Caller is who manages destinations.
+type Caller struct {
+	ID    string
+	Admin bool
+}
+
+// mayManage reports whether the caller may manage a channel's
+// destinations. A channel belongs to the user of the same ID.
+func (c Caller) mayManage(channel string) bool {
+	return c.Admin || (c.ID != "" && c.ID == channel)
+}
+
+// Create adds a destination. Enabled destinations of a live channel start
+// receiving the broadcast right away.
+func (s *Service) Create(ctx context.Context, caller Caller, channel string, o Options) (Destination, error) {
+	if !caller.mayManage(channel) {
+		return Destination{}, ErrForbidden
+	}
+	now := time.Now().UTC()
+	d := Destination{
+		ID:        uuid.NewString(),
+		Channel:   channel,
+		Name:      strings.TrimSpace(o.Name),
+		Enabled:   o.Enabled == nil || *o.Enabled,
+		CreatedAt: now,
+		UpdatedAt: now,
+		rawURL:    strings.TrimSpace(o.URL),
+	}
+	if err := s.validate(ctx, d.Name, d.rawURL); err != nil {
+		return Destination{}, err
+	}
+	var n int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM restream_destinations WHERE channel = $1`, channel).Scan(&n); err != nil {
+		return Destination{}, err
+	}
+	if n >= MaxDestinations {
+		return Destination{}, ErrTooMany
+	}
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO restream_destinations (id, channel, name, url, enabled, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		d.ID, d.Channel, d.Name, d.rawURL, d.Enabled, d.CreatedAt, d.UpdatedAt)
+	if err != nil {
+		return Destination{}, err
+	}
+	s.apply(d)
+	return s.describe(d), nil
+}
+
+// List returns the destinations of a channel with their status, oldest
+// first.
+func (s *Service) List(ctx context.Context, caller Caller, channel string) ([]Destination, error) {
+	if !caller.mayManage(channel) {
+		return nil, ErrForbidden
+	}
+	ds, err := s.destinations(ctx, channel)
+	if err != nil {
+		return nil, err
+	}
+	for i := range ds {
+		ds[i] = s.describe(ds[i])
+	}
+	return ds, nil
+}
+
+// Get returns one destination of a channel with its status.
+func (s *Service) Get(ctx context.Context, caller Caller, channel, id string) (Destination, error) {
+	if !caller.mayManage(channel) {
+		return Destination{}, ErrForbidden
+	}
+	d, err := s.get(ctx, channel, id)
+	if err != nil {
+		return Destination{}, err
+	}
+	return s.describe(d), nil
+}
+
+// Update changes a destination. A live push is restarted if its URL
+// changes and stopped if it is disabled.
+func (s *Service) Update(ctx context.Context, caller Caller, channel, id string, p Patch) (Destination, error) {
+	if !caller.mayManage(channel) {
+		return Destination{}, ErrForbidden
+	}
+	d, err := s.get(ctx, channel, id)
+	if err != nil {
+		return Destination{}, err
+	}
+	old := d
+	if p.Name != nil {
+		d.Name = strings.TrimSpace(*p.Name)
+	}
+	if p.URL != nil {
+		d.rawURL = strings.TrimSpace(*p.URL)
+	}
+	if p.Enabled != nil {
+		d.Enabled = *p.Enabled
+	}
+	if err := s.validate(ctx, d.Name, d.rawURL); err != nil {
+		return Destination{}, err
+	}
+	d.UpdatedAt = time.Now().UTC()
+	_, err = s.db.ExecContext(ctx,
+		`UPDATE restream_destinations SET name = $1, url = $2, enabled = $3, updated_at = $4 WHERE id = $5`,
+		d.Name, d.rawURL, d.Enabled, d.UpdatedAt, d.ID)
+	if err != nil {
+		return Destination{}, err
+	}
+	if d.rawURL != old.rawURL || d.Enabled != old.Enabled {
+		s.apply(d)
+	}
+	return s.describe(d), nil
+}
+
+// Delete removes a destination, stopping its push.
+func (s *Service) Delete(ctx context.Context, caller Caller, channel, id string) error {
+	if !caller.mayManage(channel) {
+		return ErrForbidden
+	}
+	d, err := s.get(ctx, channel, id)
+	if err != nil {
+		return err
+	}
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM restream_destinations WHERE id = $1`, d.ID); err != nil {
+		return err
+	}
+	d.Enabled = false
+	s.apply(d)
+	return nil
+}
+
+// Start pushes a broadcast to the enabled destinations of its channel. It
+// is meant to be registered with live.Hub.OnStart.
+func (s *Service) Start(stream *live.Stream) {
+	ds, err := s.destinations(context.Background(), stream.Channel)
+	if err != nil {
+		// The broadcast itself goes on; only the simulcast is lost.
+		log.Printf("Live %s: loading restream destinations: %v", stream.ID, err)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, d := range ds {
+		if d.Enabled {
+			s.startLocked(d, stream)
+		}
+	}
+}
+
+// apply brings the push to a changed destination in line with it.
+func (s *Service) apply(d Destination) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if p, ok := s.pushers[d.ID]; ok {
+		p.stop()
+		delete(s.pushers, d.ID)
+	}
+	if !d.Enabled || s.hub == nil {
+		return
+	}
+	if stream, ok := s.hub.Get(d.Channel); ok {
+		s.startLocked(d, stream)
+	}
+}
+
+func (s *Service) startLocked(d Destination, stream *live.Stream) {
+	if old, ok := s.pushers[d.ID]; ok {
+		old.stop()
+	}
+	p := newPusher(d, stream, s.backoff, s.dialerLocked())
+	s.pushers[d.ID] = p
+	go func() {
+		p.run()
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.pushers[d.ID] == p {
+			delete(s.pushers, d.ID)
+		}
+	}()
+}
+
+// describe masks the URL of a destination and fills in its status.
+func (s *Service) describe(d Destination) Destination {
+	d.URL = maskURL(d.rawURL)
+	s.mu.Lock()
+	p, ok := s.pushers[d.ID]
+	s.mu.Unlock()
+	switch {
+	case ok:
+		d.Status = p.snapshot()
+	case d.Enabled:
+		d.Status = Status{State: StateIdle}
+	default:
+		d.Status = Status{State: StateDisabled}
+	}
+	return d
+}
+
+const destinationColumns = `id, channel, name, url, enabled, created_at, updated_at`
+
+func (s *Service) destinations(ctx context.Context, channel string) ([]Destination, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT `+destinationColumns+` FROM restream_destinations WHERE channel = $1 ORDER BY created_at, id`, channel)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	ds := []Destination{}
+	for rows.Next() {
+		d, err := scanDestination(rows)
+		if err != nil {
+			return nil, err
+		}
+		ds = append(ds, d)
+	}
+	return ds, rows.Err()
+}
+
+func (s *Service) get(ctx context.Context, channel, id string) (Destination, error) {
+	d, err := scanDestination(s.db.QueryRowContext(ctx,
+		`SELECT `+destinationColumns+` FROM restream_destinations WHERE channel = $1 AND id = $2`, channel, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return Destination{}, ErrNotFound
+	}
+	return d, err
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanDestination(row scanner) (Destination, error) {
+	var d Destination
+	err := row.Scan(&d.ID, &d.Channel, &d.Name, &d.rawURL, &d.Enabled, &d.CreatedAt, &d.UpdatedAt)
+	if err != nil {
+		return Destination{}, err
+	}
+	d.CreatedAt, d.UpdatedAt = d.CreatedAt.UTC(), d.UpdatedAt.UTC()
+	return d, nil
+}
+
+// validate checks a destination's name and URL, whose host must not
+// resolve to an internal address unless they are allowed.
+func (s *Service) validate(ctx context.Context, name, rawURL string) error {
+	if len(name) > maxNameLength {
+		return fmt.Errorf("%w: name is longer than %d bytes", ErrInvalid, maxNameLength)
+	}
+	addr, _, _, err := rtmp.SplitURL(rawURL)
+	if err != nil {
+		return fmt.Errorf("%w: url must be rtmp:// or rtmps:// with an application and a stream name", ErrInvalid)
+	}
+	s.mu.Lock()
+	allow := s.allowInternal
+	s.mu.Unlock()
+	if allow {
+		return nil
+	}
+	host, _, _ := net.SplitHostPort(addr)
+	ips, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil || len(ips) == 0 {
+		return fmt.Errorf("%w: url host %s does not resolve", ErrInvalid, host)
+	}
+	for _, ip := range ips {
+		if internal(ip.IP) {
+			return fmt.Errorf("%w: url must not point at a loopback, link-local or private address", ErrInvalid)
+		}
+	}
+	return nil
+}
+
+// dialerLocked returns the dialer pushes connect with. Unless internal
+// addresses are allowed, it refuses them when connecting as well, as the
+// host may resolve differently than when the destination was saved.
+func (s *Service) dialerLocked() *net.Dialer {
+	if s.allowInternal {
+		return &net.Dialer{}
+	}
+	return &net.Dialer{Control: func(network, address string, _ syscall.RawConn) error {
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			return err
+		}
+		if ip := net.ParseIP(host); ip == nil || internal(ip) {
+			return errInternalAddress
+		}
+		return nil
+	}}
+}
+
+// internal reports whether ip is an address of the server's own host or
+// network.
+func internal(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast()
+}
+
+// maskURL hides all but the last characters of the stream name.
+func maskURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	_, app, name, err := rtmp.SplitURL(rawURL)
+	if err != nil {
+		return ""
+	}
+	hint := ""
+	if len(name) > 8 {
+		hint = name[len(name)-4:]
+	}
+	return u.Scheme + "://" + u.Host + "/" + app + "/****" + hint
+}
@@ -0,0 +1,135 @@
+package restream
+
+import (
+	"VideoUploadService/catalog"
+	"context"
+	"errors"
+	"net"
+	"testing"
+)
+
+func openTestService(t *testing.T) *Service {
+	t.Helper()
+	store, err := catalog.Open("sqlite", "file::memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	s, err := Open(store.DB(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestDestinationLifecycle(t *testing.T) {
+	s := openTestService(t)
+	ctx := context.Background()
+	alice := Caller{ID: "alice"}
+	const url = "rtmp://203.0.113.10/live/abcdefgh1234"
+
+	if _, err := s.Create(ctx, Caller{ID: "bob"}, "alice", Options{URL: url}); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("create for another channel: err = %v", err)
+	}
+	for _, bad := range []string{"", "http://203.0.113.10/live/key", "rtmp://203.0.113.10/key"} {
+		if _, err := s.Create(ctx, alice, "alice", Options{URL: bad}); !errors.Is(err, ErrInvalid) {
+			t.Errorf("create with url %q: err = %v, want ErrInvalid", bad, err)
+		}
+	}
+
+	d, err := s.Create(ctx, alice, "alice", Options{Name: " YouTube ", URL: url})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Name != "YouTube" || !d.Enabled || d.URL != "rtmp://203.0.113.10/live/****1234" || d.Status.State != StateIdle {
+		t.Fatalf("created %+v", d)
+	}
+
+	disabled := false
+	d, err = s.Update(ctx, alice, "alice", d.ID, Patch{Enabled: &disabled})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Enabled || d.Status.State != StateDisabled {
+		t.Errorf("updated %+v, want it disabled", d)
+	}
+	ds, err := s.List(ctx, alice, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ds) != 1 || ds[0].ID != d.ID || ds[0].Enabled {
+		t.Fatalf("listed %+v", ds)
+	}
+
+	for i := 1; i < MaxDestinations; i++ {
+		if _, err := s.Create(ctx, alice, "alice", Options{URL: url}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := s.Create(ctx, alice, "alice", Options{URL: url}); !errors.Is(err, ErrTooMany) {
+		t.Errorf("create beyond the limit: err = %v, want ErrTooMany", err)
+	}
+
+	if err := s.Delete(ctx, alice, "alice", d.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Get(ctx, alice, "alice", d.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("get deleted destination: err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestValidateRefusesInternalAddresses(t *testing.T) {
+	s := openTestService(t)
+	ctx := context.Background()
+	tests := []struct {
+		host     string
+		internal bool
+	}{
+		{"203.0.113.10", false},
+		{"[2001:db8::1]", false},
+		{"127.0.0.1", true},
+		{"localhost", true},
+		{"10.1.2.3", true},
+		{"172.16.0.1", true},
+		{"192.168.1.1:1935", true},
+		{"169.254.169.254", true},
+		{"0.0.0.0", true},
+		{"[::1]", true},
+		{"[fe80::1]", true},
+		{"[fd00::1]", true},
+		{"[::ffff:127.0.0.1]", true},
+	}
+	for _, tt := range tests {
+		err := s.validate(ctx, "", "rtmp://"+tt.host+"/live/key")
+		if refused := errors.Is(err, ErrInvalid); refused != tt.internal {
+			t.Errorf("%s: err = %v, want refused = %v", tt.host, err, tt.internal)
+		}
+	}
+
+	s.SetAllowInternal(true)
+	for _, tt := range tests {
+		if err := s.validate(ctx, "", "rtmp://"+tt.host+"/live/key"); err != nil {
+			t.Errorf("%s with internal addresses allowed: err = %v", tt.host, err)
+		}
+	}
+}
+
+func TestDialerRefusesInternalAddresses(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	s := openTestService(t)
+
+	// A destination saved while its host resolved elsewhere.
+	if _, err := s.dialerLocked().Dial("tcp", l.Addr().String()); !errors.Is(err, errInternalAddress) {
+		t.Errorf("dial loopback: err = %v, want errInternalAddress", err)
+	}
+	s.SetAllowInternal(true)
+	conn, err := s.dialerLocked().Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatalf("dial loopback with internal addresses allowed: %v", err)
+	}
+	conn.Close()
+}
@@ -0,0 +1,84 @@
+package restream
+
+import (
+	"VideoUploadService/identity"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func SetupRoutes(app *fiber.App) {
+	app.Get("/live/channels/:channel/destinations", listDestinationsHandler)
+	app.Post("/live/channels/:channel/destinations", createDestinationHandler)
+	app.Get("/live/channels/:channel/destinations/:id", getDestinationHandler)
+	app.Patch("/live/channels/:channel/destinations/:id", updateDestinationHandler)
+	app.Delete("/live/channels/:channel/destinations/:id", deleteDestinationHandler)
+}
+
+func callerFromFiber(c *fiber.Ctx) Caller {
+	return Caller{ID: identity.FromFiber(c), Admin: identity.IsAdminFiber(c)}
+}
+
+func listDestinationsHandler(c *fiber.Ctx) error {
+	ds, err := Default.List(c.Context(), callerFromFiber(c), c.Params("channel"))
+	if err != nil {
+		return respondErr(c, err)
+	}
+	return c.JSON(fiber.Map{"destinations": ds})
+}
+
+func createDestinationHandler(c *fiber.Ctx) error {
+	var o Options
+	if err := c.BodyParser(&o); err != nil {
+		return c.Status(400).SendString("Invalid request body")
+	}
+	d, err := Default.Create(c.Context(), callerFromFiber(c), c.Params("channel"), o)
+	if err != nil {
+		return respondErr(c, err)
+	}
+	return c.Status(201).JSON(d)
+}
+
+func getDestinationHandler(c *fiber.Ctx) error {
+	d, err := Default.Get(c.Context(), callerFromFiber(c), c.Params("channel"), c.Params("id"))
+	if err != nil {
+		return respondErr(c, err)
+	}
+	return c.JSON(d)
+}
+
+func updateDestinationHandler(c *fiber.Ctx) error {
+	var p Patch
+	if err := c.BodyParser(&p); err != nil {
+		return c.Status(400).SendString("Invalid request body")
+	}
+	d, err := Default.Update(c.Context(), callerFromFiber(c), c.Params("channel"), c.Params("id"), p)
+	if err != nil {
+		return respondErr(c, err)
+	}
+	return c.JSON(d)
+}
+
+func deleteDestinationHandler(c *fiber.Ctx) error {
+	if err := Default.Delete(c.Context(), callerFromFiber(c), c.Params("channel"), c.Params("id")); err != nil {
+		return respondErr(c, err)
+	}
+	return c.SendStatus(204)
+}
+
+func respondErr(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, ErrForbidden) && identity.FromFiber(c) == "":
+		return c.Status(401).SendString("Authentication required")
+	case errors.Is(err, ErrForbidden):
+		return c.Status(403).SendString(err.Error())
+	case errors.Is(err, ErrNotFound):
+		return c.Status(404).SendString(err.Error())
+	case errors.Is(err, ErrInvalid):
+		return c.Status(400).SendString(err.Error())
+	case errors.Is(err, ErrTooMany):
+		return c.Status(409).SendString(err.Error())
+	default:
+		return c.Status(500).SendString(err.Error())
+	}
+}
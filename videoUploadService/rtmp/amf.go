@@ -0,0 +1,248 @@
+package rtmp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// AMF0 type markers.
+const (
+	amfNumber      = 0x00
+	amfBoolean     = 0x01
+	amfString      = 0x02
+	amfObject      = 0x03
+	amfNull        = 0x05
+	amfUndefined   = 0x06
+	amfECMAArray   = 0x08
+	amfObjectEnd   = 0x09
+	amfStrictArray = 0x0a
+	amfDate        = 0x0b
+	amfLongString  = 0x0c
+)
+
+// Object is an AMF0 object or ECMA array.
+type Object map[string]any
+
+var errAMF = errors.New("rtmp: malformed AMF0 data")
+
+// maxAMFDepth bounds nesting so hostile input cannot exhaust the stack.
+const maxAMFDepth = 16
+
+// decodeAMF decodes all AMF0 values in data. Numbers decode as float64,
+// objects and ECMA arrays as Object, strict arrays as []any and null or
+// undefined as nil.
+func decodeAMF(data []byte) ([]any, error) {
+	d := amfDecoder{data: data}
+	var values []any
+	for len(d.data) > 0 {
+		v, err := d.value(0)
+		if err != nil {
+			return values, err
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+type amfDecoder struct {
+	data []byte
+}
+
+func (d *amfDecoder) take(n int) ([]byte, error) {
+	if n < 0 || len(d.data) < n {
+		return nil, errAMF
+	}
+	b := d.data[:n]
+	d.data = d.data[n:]
+	return b, nil
+}
+
+func (d *amfDecoder) value(depth int) (any, error) {
+	if depth > maxAMFDepth {
+		return nil, errAMF
+	}
+	marker, err := d.take(1)
+	if err != nil {
+		return nil, err
+	}
+	switch marker[0] {
+	case amfNumber:
+		b, err := d.take(8)
+		if err != nil {
+			return nil, err
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(b)), nil
+	case amfBoolean:
+		b, err := d.take(1)
+		if err != nil {
+			return nil, err
+		}
+		return b[0] != 0, nil
+	case amfString:
+		return d.string(2)
+	case amfLongString:
+		return d.string(4)
+	case amfObject:
+		return d.object(depth)
+	case amfECMAArray:
+		// The count is only a hint; the entries end with an object end marker.
+		if _, err := d.take(4); err != nil {
+			return nil, err
+		}
+		return d.object(depth)
+	case amfStrictArray:
+		b, err := d.take(4)
+		if err != nil {
+			return nil, err
+		}
+		n := binary.BigEndian.Uint32(b)
+		if int64(n) > int64(len(d.data)) {
+			return nil, errAMF
+		}
+		values := make([]any, 0, n)
+		for i := uint32(0); i < n; i++ {
+			v, err := d.value(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+		}
+		return values, nil
+	case amfDate:
+		b, err := d.take(10)
+		if err != nil {
+			return nil, err
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(b)), nil
+	case amfNull, amfUndefined:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("rtmp: unsupported AMF0 type 0x%02x", marker[0])
+	}
+}
+
+func (d *amfDecoder) string(lengthSize int) (string, error) {
+	b, err := d.take(lengthSize)
+	if err != nil {
+		return "", err
+	}
+	n := 0
+	for _, c := range b {
+		n = n<<8 | int(c)
+	}
+	s, err := d.take(n)
+	return string(s), err
+}
+
+func (d *amfDecoder) object(depth int) (Object, error) {
+	obj := Object{}
+	for {
+		key, err := d.string(2)
+		if err != nil {
+			return nil, err
+		}
+		if key == "" && len(d.data) > 0 && d.data[0] == amfObjectEnd {
+			d.data = d.data[1:]
+			return obj, nil
+		}
+		v, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		obj[key] = v
+	}
+}
+
+// encodeAMF encodes values as AMF0. It accepts float64, the integer types,
+// bool, string, Object, []any and nil.
+func encodeAMF(values ...any) []byte {
+	var b bytes.Buffer
+	for _, v := range values {
+		writeAMF(&b, v)
+	}
+	return b.Bytes()
+}
+
+func writeAMF(b *bytes.Buffer, v any) {
+	switch v := v.(type) {
+	case nil:
+		b.WriteByte(amfNull)
+	case bool:
+		b.WriteByte(amfBoolean)
+		if v {
+			b.WriteByte(1)
+		} else {
+			b.WriteByte(0)
+		}
+	case string:
+		if len(v) > math.MaxUint16 {
+			b.WriteByte(amfLongString)
+			binary.Write(b, binary.BigEndian, uint32(len(v)))
+		} else {
+			b.WriteByte(amfString)
+			binary.Write(b, binary.BigEndian, uint16(len(v)))
+		}
+		b.WriteString(v)
+	case Object:
+		b.WriteByte(amfObject)
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			binary.Write(b, binary.BigEndian, uint16(len(k)))
+			b.WriteString(k)
+			writeAMF(b, v[k])
+		}
+		b.Write([]byte{0, 0, amfObjectEnd})
+	case []any:
+		b.WriteByte(amfStrictArray)
+		binary.Write(b, binary.BigEndian, uint32(len(v)))
+		for _, e := range v {
+			writeAMF(b, e)
+		}
+	case float64:
+		b.WriteByte(amfNumber)
+		binary.Write(b, binary.BigEndian, math.Float64bits(v))
+	case int:
+		writeAMF(b, float64(v))
+	case int64:
+		writeAMF(b, float64(v))
+	case uint32:
+		writeAMF(b, float64(v))
+	default:
+		panic(fmt.Sprintf("rtmp: cannot encode %T as AMF0", v))
+	}
+}
+
+// str returns values[i] if it is a string.
+func str(values []any, i int) string {
+	if i < len(values) {
+		s, _ := values[i].(string)
+		return s
+	}
+	return ""
+}
+
+// num returns values[i] if it is a number.
+func num(values []any, i int) float64 {
+	if i < len(values) {
+		n, _ := values[i].(float64)
+		return n
+	}
+	return 0
+}
+
+// obj returns values[i] if it is an object.
+func obj(values []any, i int) Object {
+	if i < len(values) {
+		o, _ := values[i].(Object)
+		return o
+	}
+	return nil
+}
@@ -0,0 +1,259 @@
+package rtmp
+
+import (
+	"VideoUploadService/flv"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Client publishes one stream to an RTMP server.
+type Client struct {
+	c        *conn
+	name     string
+	streamID uint32
+
+	closeOnce sync.Once
+	done      chan struct{}
+	mu        sync.Mutex
+	err       error
+}
+
+// SplitURL splits a publish URL such as "rtmp://host/live/key" into the
+// server address, the application and the stream name. The application is
+// everything but the last path element.
+func SplitURL(rawURL string) (addr, app, name string, err error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", "", "", err
+	}
+	port := "1935"
+	switch u.Scheme {
+	case "rtmp":
+	case "rtmps":
+		port = "443"
+	default:
+		return "", "", "", fmt.Errorf("rtmp: unsupported scheme %q", u.Scheme)
+	}
+	addr = u.Host
+	if u.Port() == "" {
+		addr = net.JoinHostPort(u.Hostname(), port)
+	}
+	p := strings.Trim(u.Path, "/")
+	i := strings.LastIndex(p, "/")
+	if i <= 0 || i == len(p)-1 {
+		return "", "", "", errors.New("rtmp: URL needs an application and a stream name")
+	}
+	app, name = p[:i], p[i+1:]
+	if u.RawQuery != "" {
+		name += "?" + u.RawQuery
+	}
+	return addr, app, name, nil
+}
+
+// Publish connects to rawURL, an rtmp:// or rtmps:// URL ending in the
+// stream name, and starts publishing. ctx bounds the connection setup.
+func Publish(ctx context.Context, rawURL string) (*Client, error) {
+	return PublishWith(ctx, &net.Dialer{}, rawURL)
+}
+
+// PublishWith is Publish connecting through d, whose Control function may
+// refuse the addresses the host resolves to.
+func PublishWith(ctx context.Context, d *net.Dialer, rawURL string) (*Client, error) {
+	addr, app, name, err := SplitURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	u, _ := url.Parse(rawURL)
+	var nc net.Conn
+	if u.Scheme == "rtmps" {
+		td := tls.Dialer{NetDialer: d, Config: &tls.Config{ServerName: u.Hostname()}}
+		nc, err = td.DialContext(ctx, "tcp", addr)
+	} else {
+		nc, err = d.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	stop := context.AfterFunc(ctx, func() { nc.Close() })
+	defer stop()
+
+	cl := &Client{c: newConn(nc, DefaultTimeout), name: name, done: make(chan struct{})}
+	if err := cl.setup(u.Scheme+"://"+u.Host+"/"+app, app, name); err != nil {
+		nc.Close()
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, err
+	}
+	// Servers may stay silent for long stretches while we publish.
+	cl.c.readTimeout = 0
+	nc.SetReadDeadline(time.Time{})
+	go cl.drain()
+	return cl, nil
+}
+
+func (cl *Client) setup(tcURL, app, name string) error {
+	c := cl.c
+	if err := c.clientHandshake(); err != nil {
+		return err
+	}
+	if err := c.setChunkSize(outChunkSize); err != nil {
+		return err
+	}
+	err := c.writeCommand(0, "connect", 1.0, Object{
+		"app":      app,
+		"type":     "nonprivate",
+		"flashVer": "FMLE/3.0 (compatible; StreamTube)",
+		"tcUrl":    tcURL,
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := cl.await(1); err != nil {
+		return fmt.Errorf("connect: %w", err)
+	}
+	c.writeCommand(0, "releaseStream", 2.0, nil, name)
+	c.writeCommand(0, "FCPublish", 3.0, nil, name)
+	if err := c.writeCommand(0, "createStream", 4.0, nil); err != nil {
+		return err
+	}
+	values, err := cl.await(4)
+	if err != nil {
+		return fmt.Errorf("createStream: %w", err)
+	}
+	cl.streamID = uint32(num(values, 3))
+	if err := c.writeCommand(cl.streamID, "publish", 0.0, nil, name, "live"); err != nil {
+		return err
+	}
+	for {
+		values, err := cl.command()
+		if err != nil {
+			return err
+		}
+		if str(values, 0) != "onStatus" {
+			continue
+		}
+		info := obj(values, 3)
+		code, _ := info["code"].(string)
+		if code == "NetStream.Publish.Start" {
+			return nil
+		}
+		if level, _ := info["level"].(string); level == "error" {
+			description, _ := info["description"].(string)
+			return fmt.Errorf("publish: %s: %s", code, description)
+		}
+	}
+}
+
+// await waits for the _result of transaction txn.
+func (cl *Client) await(txn float64) ([]any, error) {
+	for {
+		values, err := cl.command()
+		if err != nil {
+			return nil, err
+		}
+		switch str(values, 0) {
+		case "_result":
+			if num(values, 1) == txn {
+				return values, nil
+			}
+		case "_error":
+			if num(values, 1) == txn {
+				description, _ := obj(values, 3)["description"].(string)
+				return nil, errors.New(description)
+			}
+		}
+	}
+}
+
+// command reads the next command message.
+func (cl *Client) command() ([]any, error) {
+	for {
+		m, err := cl.c.readMessage()
+		if err != nil {
+			return nil, err
+		}
+		if m.Type == typeCommandAMF0 || m.Type == typeCommandAMF3 {
+			payload := m.Payload
+			if m.Type == typeCommandAMF3 && len(payload) > 0 {
+				payload = payload[1:]
+			}
+			return decodeAMF(payload)
+		}
+	}
+}
+
+// drain reads what the server sends while publishing, so that pings and
+// acknowledgements are handled, until the connection fails.
+func (cl *Client) drain() {
+	for {
+		if _, err := cl.c.readMessage(); err != nil {
+			cl.fail(err)
+			return
+		}
+	}
+}
+
+func (cl *Client) fail(err error) {
+	cl.mu.Lock()
+	if cl.err == nil {
+		cl.err = err
+	}
+	cl.mu.Unlock()
+	cl.closeOnce.Do(func() {
+		close(cl.done)
+		cl.c.Close()
+	})
+}
+
+// Done is closed when the connection ends.
+func (cl *Client) Done() <-chan struct{} {
+	return cl.done
+}
+
+// Err returns why the connection ended.
+func (cl *Client) Err() error {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	return cl.err
+}
+
+// WriteTag sends an audio, video or script data tag.
+func (cl *Client) WriteTag(t flv.Tag) error {
+	m := Message{Type: t.Type, StreamID: cl.streamID, Timestamp: t.Timestamp, Payload: t.Data}
+	csid := uint32(csidVideo)
+	switch t.Type {
+	case flv.TagAudio:
+		csid = csidAudio
+	case flv.TagScript:
+		csid = csidData
+		m.Payload = append(encodeAMF("@setDataFrame"), t.Data...)
+	}
+	if err := cl.c.writeMessage(csid, m); err != nil {
+		cl.fail(err)
+		return err
+	}
+	return nil
+}
+
+// Close unpublishes the stream and disconnects.
+func (cl *Client) Close() error {
+	select {
+	case <-cl.done:
+		return nil
+	default:
+	}
+	cl.c.writeCommand(0, "FCUnpublish", 5.0, nil, cl.name)
+	cl.c.writeCommand(0, "deleteStream", 6.0, nil, float64(cl.streamID))
+	cl.fail(errClientClosed)
+	return nil
+}
+
+var errClientClosed = errors.New("rtmp: client closed")
@@ -0,0 +1,425 @@
+package rtmp
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Message types.
+const (
+	typeSetChunkSize     = 1
+	typeAbort            = 2
+	typeAck              = 3
+	typeUserControl      = 4
+	typeWindowAckSize    = 5
+	typeSetPeerBandwidth = 6
+	typeAudio            = 8
+	typeVideo            = 9
+	typeDataAMF3         = 15
+	typeCommandAMF3      = 17
+	typeDataAMF0         = 18
+	typeCommandAMF0      = 20
+)
+
+// User control events.
+const (
+	eventStreamBegin  = 0
+	eventStreamEOF    = 1
+	eventPingRequest  = 6
+	eventPingResponse = 7
+)
+
+// Chunk stream IDs of outgoing messages.
+const (
+	csidControl = 2
+	csidCommand = 3
+	csidAudio   = 4
+	csidData    = 5
+	csidVideo   = 6
+)
+
+const (
+	handshakeSize    = 1536
+	defaultChunkSize = 128
+	// outChunkSize is announced to peers right after the handshake.
+	outChunkSize  = 4096
+	maxChunkSize  = 1 << 24
+	windowAckSize = 2500000
+	// maxChunkStreams bounds the partial messages a peer can keep open.
+	maxChunkStreams = 64
+)
+
+var errProtocol = errors.New("rtmp: protocol error")
+
+// Message is an RTMP message. Timestamp is in milliseconds.
+type Message struct {
+	Type      uint8
+	StreamID  uint32
+	Timestamp uint32
+	Payload   []byte
+}
+
+// chunkStream is the header state of one incoming chunk stream.
+type chunkStream struct {
+	timestamp uint32
+	delta     uint32
+	length    uint32
+	typ       uint8
+	streamID  uint32
+	extended  bool
+	buf       []byte
+}
+
+// conn reads and writes RTMP messages over the chunk stream protocol.
+// Protocol control messages are answered inside readMessage.
+type conn struct {
+	nc net.Conn
+	r  *bufio.Reader
+	// readTimeout and writeTimeout bound each read and write; zero
+	// disables them.
+	readTimeout  time.Duration
+	writeTimeout time.Duration
+
+	inChunkSize uint32
+	streams     map[uint32]*chunkStream
+	read        uint64
+	acked       uint64
+	peerWindow  uint32
+
+	wmu          sync.Mutex
+	w            *bufio.Writer
+	outChunkSize uint32
+}
+
+func newConn(nc net.Conn, timeout time.Duration) *conn {
+	c := &conn{
+		nc:           nc,
+		readTimeout:  timeout,
+		writeTimeout: timeout,
+		inChunkSize:  defaultChunkSize,
+		streams:      make(map[uint32]*chunkStream),
+		w:            bufio.NewWriterSize(nc, 64<<10),
+		outChunkSize: defaultChunkSize,
+	}
+	c.r = bufio.NewReaderSize(countingReader{nc, &c.read}, 64<<10)
+	return c
+}
+
+type countingReader struct {
+	r io.Reader
+	n *uint64
+}
+
+func (c countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	*c.n += uint64(n)
+	return n, err
+}
+
+func (c *conn) readDeadline() {
+	if c.readTimeout > 0 {
+		c.nc.SetReadDeadline(time.Now().Add(c.readTimeout))
+	}
+}
+
+func (c *conn) writeDeadline() {
+	if c.writeTimeout > 0 {
+		c.nc.SetWriteDeadline(time.Now().Add(c.writeTimeout))
+	}
+}
+
+// serverHandshake performs the plain RTMP handshake: S1 carries random
+// bytes and S2 echoes C1. Publishers do not require the digest variant.
+func (c *conn) serverHandshake() error {
+	c.readDeadline()
+	c.writeDeadline()
+	c0c1 := make([]byte, 1+handshakeSize)
+	if _, err := io.ReadFull(c.r, c0c1); err != nil {
+		return err
+	}
+	if c0c1[0] != 3 {
+		return fmt.Errorf("rtmp: unsupported version %d", c0c1[0])
+	}
+	s0s1s2 := make([]byte, 1+2*handshakeSize)
+	s0s1s2[0] = 3
+	rand.Read(s0s1s2[9 : 1+handshakeSize])
+	copy(s0s1s2[1+handshakeSize:], c0c1[1:])
+	if _, err := c.w.Write(s0s1s2); err != nil {
+		return err
+	}
+	if err := c.w.Flush(); err != nil {
+		return err
+	}
+	_, err := io.ReadFull(c.r, make([]byte, handshakeSize))
+	return err
+}
+
+// clientHandshake is the client side of serverHandshake.
+func (c *conn) clientHandshake() error {
+	c.readDeadline()
+	c.writeDeadline()
+	c0c1 := make([]byte, 1+handshakeSize)
+	c0c1[0] = 3
+	rand.Read(c0c1[9:])
+	if _, err := c.w.Write(c0c1); err != nil {
+		return err
+	}
+	if err := c.w.Flush(); err != nil {
+		return err
+	}
+	s0s1s2 := make([]byte, 1+2*handshakeSize)
+	if _, err := io.ReadFull(c.r, s0s1s2); err != nil {
+		return err
+	}
+	if s0s1s2[0] != 3 {
+		return fmt.Errorf("rtmp: unsupported version %d", s0s1s2[0])
+	}
+	if _, err := c.w.Write(s0s1s2[1 : 1+handshakeSize]); err != nil {
+		return err
+	}
+	return c.w.Flush()
+}
+
+// readMessage returns the next message that is not a protocol control
+// message.
+func (c *conn) readMessage() (Message, error) {
+	for {
+		m, err := c.readChunks()
+		if err != nil {
+			return Message{}, err
+		}
+		handled, err := c.control(m)
+		if err != nil {
+			return Message{}, err
+		}
+		if c.peerWindow > 0 && c.read-c.acked >= uint64(c.peerWindow) {
+			c.acked = c.read
+			if err := c.writeControl(typeAck, be32(uint32(c.read))); err != nil {
+				return Message{}, err
+			}
+		}
+		if !handled {
+			return m, nil
+		}
+	}
+}
+
+// readChunks reads chunks until a message is complete.
+func (c *conn) readChunks() (Message, error) {
+	for {
+		c.readDeadline()
+		first, err := c.r.ReadByte()
+		if err != nil {
+			return Message{}, err
+		}
+		format := first >> 6
+		csid := uint32(first & 0x3f)
+		switch csid {
+		case 0:
+			b, err := c.r.ReadByte()
+			if err != nil {
+				return Message{}, err
+			}
+			csid = 64 + uint32(b)
+		case 1:
+			var b [2]byte
+			if _, err := io.ReadFull(c.r, b[:]); err != nil {
+				return Message{}, err
+			}
+			csid = 64 + uint32(b[0]) + uint32(b[1])<<8
+		}
+		cs := c.streams[csid]
+		if cs == nil {
+			if len(c.streams) >= maxChunkStreams {
+				return Message{}, fmt.Errorf("%w: too many chunk streams", errProtocol)
+			}
+			cs = &chunkStream{}
+			c.streams[csid] = cs
+		}
+
+		var header [11]byte
+		n := [4]int{11, 7, 3, 0}[format]
+		if _, err := io.ReadFull(c.r, header[:n]); err != nil {
+			return Message{}, err
+		}
+		if format < 3 {
+			ts := uint24(header[:3])
+			cs.extended = ts == 0xffffff
+			if format < 2 {
+				cs.length = uint24(header[3:6])
+				cs.typ = header[6]
+			}
+			if format == 0 {
+				cs.streamID = binary.LittleEndian.Uint32(header[7:11])
+			}
+			if cs.extended {
+				var ext [4]byte
+				if _, err := io.ReadFull(c.r, ext[:]); err != nil {
+					return Message{}, err
+				}
+				ts = binary.BigEndian.Uint32(ext[:])
+			}
+			if format == 0 {
+				cs.timestamp, cs.delta = ts, 0
+				cs.buf = nil
+			} else {
+				cs.delta = ts
+			}
+		} else if cs.extended {
+			// The extended timestamp is repeated on type 3 chunks.
+			if _, err := io.ReadFull(c.r, make([]byte, 4)); err != nil {
+				return Message{}, err
+			}
+		}
+		if cs.buf == nil {
+			// First chunk of a message.
+			if format != 0 {
+				cs.timestamp += cs.delta
+			}
+			// Grow as chunks arrive rather than trusting the announced
+			// length before any of it was sent.
+			cs.buf = make([]byte, 0, min(cs.length, 64<<10))
+		}
+
+		size := min(c.inChunkSize, cs.length-uint32(len(cs.buf)))
+		start := len(cs.buf)
+		cs.buf = slices.Grow(cs.buf, int(size))[:start+int(size)]
+		if _, err := io.ReadFull(c.r, cs.buf[start:]); err != nil {
+			return Message{}, err
+		}
+		if uint32(len(cs.buf)) == cs.length {
+			m := Message{Type: cs.typ, StreamID: cs.streamID, Timestamp: cs.timestamp, Payload: cs.buf}
+			cs.buf = nil
+			return m, nil
+		}
+	}
+}
+
+// control handles protocol control and user control messages.
+func (c *conn) control(m Message) (bool, error) {
+	switch m.Type {
+	case typeSetChunkSize:
+		if len(m.Payload) < 4 {
+			return true, errProtocol
+		}
+		size := binary.BigEndian.Uint32(m.Payload) & 0x7fffffff
+		if size == 0 || size > maxChunkSize {
+			return true, fmt.Errorf("%w: chunk size %d", errProtocol, size)
+		}
+		c.inChunkSize = size
+	case typeAbort:
+		if len(m.Payload) >= 4 {
+			if cs := c.streams[binary.BigEndian.Uint32(m.Payload)]; cs != nil {
+				cs.buf = nil
+			}
+		}
+	case typeWindowAckSize:
+		if len(m.Payload) >= 4 {
+			c.peerWindow = binary.BigEndian.Uint32(m.Payload)
+		}
+	case typeAck, typeSetPeerBandwidth:
+	case typeUserControl:
+		if len(m.Payload) >= 6 && binary.BigEndian.Uint16(m.Payload) == eventPingRequest {
+			reply := append([]byte{0, eventPingResponse}, m.Payload[2:6]...)
+			return true, c.writeControl(typeUserControl, reply)
+		}
+	default:
+		return false, nil
+	}
+	return true, nil
+}
+
+// writeMessage writes a message on a chunk stream. Every message starts
+// with a type 0 chunk, so no header state has to be tracked.
+func (c *conn) writeMessage(csid uint32, m Message) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	c.writeDeadline()
+	extended := m.Timestamp >= 0xffffff
+	var header [16]byte
+	header[0] = byte(csid)
+	ts := m.Timestamp
+	if extended {
+		ts = 0xffffff
+	}
+	putUint24(header[1:], ts)
+	putUint24(header[4:], uint32(len(m.Payload)))
+	header[7] = m.Type
+	binary.LittleEndian.PutUint32(header[8:], m.StreamID)
+	n := 12
+	if extended {
+		binary.BigEndian.PutUint32(header[12:], m.Timestamp)
+		n = 16
+	}
+	if _, err := c.w.Write(header[:n]); err != nil {
+		return err
+	}
+	payload := m.Payload
+	for {
+		size := min(len(payload), int(c.outChunkSize))
+		if _, err := c.w.Write(payload[:size]); err != nil {
+			return err
+		}
+		payload = payload[size:]
+		if len(payload) == 0 {
+			break
+		}
+		c.w.WriteByte(0xc0 | byte(csid))
+		if extended {
+			c.w.Write(header[12:16])
+		}
+	}
+	return c.w.Flush()
+}
+
+func (c *conn) writeControl(typ uint8, payload []byte) error {
+	return c.writeMessage(csidControl, Message{Type: typ, Payload: payload})
+}
+
+// setChunkSize announces and switches to a larger outgoing chunk size.
+func (c *conn) setChunkSize(size uint32) error {
+	if err := c.writeControl(typeSetChunkSize, be32(size)); err != nil {
+		return err
+	}
+	c.wmu.Lock()
+	c.outChunkSize = size
+	c.wmu.Unlock()
+	return nil
+}
+
+func (c *conn) writeCommand(streamID uint32, values ...any) error {
+	return c.writeMessage(csidCommand, Message{Type: typeCommandAMF0, StreamID: streamID, Payload: encodeAMF(values...)})
+}
+
+func (c *conn) userControl(event uint16, streamID uint32) error {
+	payload := make([]byte, 6)
+	binary.BigEndian.PutUint16(payload, event)
+	binary.BigEndian.PutUint32(payload[2:], streamID)
+	return c.writeControl(typeUserControl, payload)
+}
+
+func (c *conn) Close() error {
+	return c.nc.Close()
+}
+
+func be32(v uint32) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, v)
+	return b
+}
+
+func uint24(b []byte) uint32 {
+	return uint32(b[0])<<16 | uint32(b[1])<<8 | uint32(b[2])
+}
+
+func putUint24(b []byte, v uint32) {
+	b[0], b[1], b[2] = byte(v>>16), byte(v>>8), byte(v)
+}
@@ -0,0 +1,60 @@
+package rtmp
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitURL(t *testing.T) {
+	tests := []struct {
+		url             string
+		addr, app, name string
+		wantErr         bool
+	}{
+		{url: "rtmp://example.com/live/key", addr: "example.com:1935", app: "live", name: "key"},
+		{url: "rtmps://example.com/live/key", addr: "example.com:443", app: "live", name: "key"},
+		{url: "rtmp://127.0.0.1:1936/app/sub/key?token=t", addr: "127.0.0.1:1936", app: "app/sub", name: "key?token=t"},
+		{url: "rtmp://[::1]/live/key", addr: "[::1]:1935", app: "live", name: "key"},
+		{url: "rtmp://example.com/key", wantErr: true},
+		{url: "rtmp://example.com/live/", wantErr: true},
+		{url: "http://example.com/live/key", wantErr: true},
+	}
+	for _, tt := range tests {
+		addr, app, name, err := SplitURL(tt.url)
+		if (err != nil) != tt.wantErr || addr != tt.addr || app != tt.app || name != tt.name {
+			t.Errorf("SplitURL(%q) = %q, %q, %q, %v", tt.url, addr, app, name, err)
+		}
+	}
+}
+
+func TestAMFRoundTrip(t *testing.T) {
+	in := []any{"connect", 1.0, Object{"app": "live", "flashVer": "FMLE/3.0", "nested": Object{"ok": true}}, nil, []any{1.0, "two"}}
+	out, err := decodeAMF(encodeAMF(in...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(out, in) {
+		t.Errorf("got %#v, want %#v", out, in)
+	}
+	if _, err := decodeAMF([]byte{amfString, 0, 5, 'a'}); err == nil {
+		t.Error("truncated string was accepted")
+	}
+}
+
+func TestStripSetDataFrame(t *testing.T) {
+	meta := encodeAMF("onMetaData", Object{"width": 1280.0})
+	tests := []struct {
+		name    string
+		payload []byte
+		amf3    bool
+	}{
+		{"wrapped", append(encodeAMF("@setDataFrame"), meta...), false},
+		{"wrapped amf3", append(append([]byte{0}, encodeAMF("@setDataFrame")...), meta...), true},
+		{"bare", meta, false},
+	}
+	for _, tt := range tests {
+		if got := stripSetDataFrame(tt.payload, tt.amf3); !reflect.DeepEqual(got, meta) {
+			t.Errorf("%s: got %x, want %x", tt.name, got, meta)
+		}
+	}
+}
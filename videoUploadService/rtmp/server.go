@@ -0,0 +1,275 @@
+// Package rtmp implements the publishing side of RTMP: a server accepting
+// streams from encoders such as OBS, and a client pushing streams to other
+// servers.
+package rtmp
+
+import (
+	"VideoUploadService/flv"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultTimeout closes connections that send nothing for this long.
+const DefaultTimeout = 30 * time.Second
+
+var ErrServerClosed = errors.New("rtmp: server closed")
+
+// PublishRequest describes a client starting to publish.
+type PublishRequest struct {
+	// App is the application of the connection, e.g. "live".
+	App string
+	// Name is the stream name, which carries the stream key, and Query any
+	// parameters appended to it after "?".
+	Name       string
+	Query      string
+	RemoteAddr net.Addr
+}
+
+// Publisher receives the media of one publish session.
+type Publisher interface {
+	// WriteTag receives audio, video and script data tags in the order they
+	// arrive. An error ends the session and disconnects the client.
+	WriteTag(flv.Tag) error
+	// Close is called once when the session ends. err is nil when the
+	// client unpublished cleanly.
+	Close(err error)
+}
+
+// Handler decides whether a publish may start.
+type Handler interface {
+	// Publish returns the publisher receiving the stream, or an error to
+	// reject it. The error text is sent to the client.
+	Publish(ctx context.Context, req PublishRequest) (Publisher, error)
+}
+
+// Server accepts RTMP publishes.
+type Server struct {
+	Handler Handler
+	// Timeout closes connections idle for this long; zero means
+	// DefaultTimeout.
+	Timeout time.Duration
+
+	mu        sync.Mutex
+	listeners map[net.Listener]struct{}
+	conns     map[*conn]struct{}
+	closed    bool
+}
+
+// ListenAndServe listens on addr, e.g. ":1935", and serves connections.
+func (s *Server) ListenAndServe(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(l)
+}
+
+// Serve accepts connections on l until the server is closed.
+func (s *Server) Serve(l net.Listener) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		l.Close()
+		return ErrServerClosed
+	}
+	if s.listeners == nil {
+		s.listeners = make(map[net.Listener]struct{})
+		s.conns = make(map[*conn]struct{})
+	}
+	s.listeners[l] = struct{}{}
+	s.mu.Unlock()
+
+	for {
+		nc, err := l.Accept()
+		if err != nil {
+			s.mu.Lock()
+			closed := s.closed
+			delete(s.listeners, l)
+			s.mu.Unlock()
+			if closed {
+				return ErrServerClosed
+			}
+			return err
+		}
+		go s.serveConn(nc)
+	}
+}
+
+// Close stops the listeners and disconnects every client.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	for l := range s.listeners {
+		l.Close()
+	}
+	for c := range s.conns {
+		c.Close()
+	}
+	return nil
+}
+
+func (s *Server) serveConn(nc net.Conn) {
+	timeout := s.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	c := newConn(nc, timeout)
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		nc.Close()
+		return
+	}
+	s.conns[c] = struct{}{}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, c)
+		s.mu.Unlock()
+		c.Close()
+	}()
+
+	if err := c.serverHandshake(); err != nil {
+		log.Printf("RTMP handshake with %s: %v", nc.RemoteAddr(), err)
+		return
+	}
+	sess := &session{handler: s.Handler, c: c}
+	err := sess.run()
+	if sess.publisher != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		sess.publisher.Close(err)
+	}
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+		log.Printf("RTMP connection from %s: %v", nc.RemoteAddr(), err)
+	}
+}
+
+// session is the state of one client connection.
+type session struct {
+	handler Handler
+	c       *conn
+
+	app       string
+	streamID  uint32
+	publisher Publisher
+}
+
+func (s *session) run() error {
+	for {
+		m, err := s.c.readMessage()
+		if err != nil {
+			return err
+		}
+		switch m.Type {
+		case typeCommandAMF0, typeCommandAMF3:
+			payload := m.Payload
+			if m.Type == typeCommandAMF3 && len(payload) > 0 {
+				payload = payload[1:]
+			}
+			values, err := decodeAMF(payload)
+			if err != nil {
+				return err
+			}
+			if err := s.command(m.StreamID, values); err != nil {
+				return err
+			}
+		case typeAudio, typeVideo, typeDataAMF0, typeDataAMF3:
+			if s.publisher == nil || m.StreamID != s.streamID {
+				continue
+			}
+			tag := flv.Tag{Type: m.Type, Timestamp: m.Timestamp, Data: m.Payload}
+			if m.Type != typeAudio && m.Type != typeVideo {
+				tag.Type = flv.TagScript
+				tag.Data = stripSetDataFrame(m.Payload, m.Type == typeDataAMF3)
+			}
+			if err := s.publisher.WriteTag(tag); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *session) command(streamID uint32, values []any) error {
+	name, txn := str(values, 0), num(values, 1)
+	switch name {
+	case "connect":
+		app, _ := obj(values, 2)["app"].(string)
+		app, _, _ = strings.Cut(strings.Trim(app, "/"), "?")
+		s.app = app
+		if err := s.c.writeControl(typeWindowAckSize, be32(windowAckSize)); err != nil {
+			return err
+		}
+		// Dynamic limit type.
+		if err := s.c.writeControl(typeSetPeerBandwidth, append(be32(windowAckSize), 2)); err != nil {
+			return err
+		}
+		if err := s.c.setChunkSize(outChunkSize); err != nil {
+			return err
+		}
+		return s.c.writeCommand(0, "_result", txn,
+			Object{"fmsVer": "FMS/3,0,1,123", "capabilities": 31.0},
+			Object{"level": "status", "code": "NetConnection.Connect.Success", "description": "Connection succeeded.", "objectEncoding": 0.0})
+	case "createStream":
+		if s.streamID != 0 {
+			return fmt.Errorf("%w: only one stream per connection", errProtocol)
+		}
+		s.streamID = 1
+		return s.c.writeCommand(0, "_result", txn, nil, float64(s.streamID))
+	case "publish":
+		return s.publish(streamID, str(values, 3))
+	case "FCUnpublish", "deleteStream", "closeStream":
+		if s.publisher != nil {
+			s.publisher.Close(nil)
+			s.publisher = nil
+		}
+	case "play":
+		s.c.writeCommand(streamID, "onStatus", 0.0, nil,
+			Object{"level": "error", "code": "NetStream.Play.Failed", "description": "Playback over RTMP is not supported."})
+		return errors.New("rtmp: play is not supported")
+	}
+	// releaseStream, FCPublish and the like need no answer.
+	return nil
+}
+
+func (s *session) publish(streamID uint32, stream string) error {
+	if s.publisher != nil || streamID == 0 || streamID != s.streamID {
+		return fmt.Errorf("%w: unexpected publish", errProtocol)
+	}
+	name, query, _ := strings.Cut(stream, "?")
+	req := PublishRequest{App: s.app, Name: name, Query: query, RemoteAddr: s.c.nc.RemoteAddr()}
+	pub, err := s.handler.Publish(context.Background(), req)
+	if err != nil {
+		s.c.writeCommand(streamID, "onStatus", 0.0, nil,
+			Object{"level": "error", "code": "NetStream.Publish.BadName", "description": err.Error()})
+		return fmt.Errorf("publish rejected: %w", err)
+	}
+	s.publisher = pub
+	if err := s.c.userControl(eventStreamBegin, streamID); err != nil {
+		return err
+	}
+	return s.c.writeCommand(streamID, "onStatus", 0.0, nil,
+		Object{"level": "status", "code": "NetStream.Publish.Start", "description": "Publishing " + name + "."})
+}
+
+// stripSetDataFrame removes the "@setDataFrame" wrapper publishers put
+// around onMetaData, leaving the script tag body an FLV file would hold.
+func stripSetDataFrame(payload []byte, amf3 bool) []byte {
+	if amf3 && len(payload) > 0 {
+		payload = payload[1:]
+	}
+	const wrapper = "\x02\x00\x0d@setDataFrame"
+	if strings.HasPrefix(string(payload), wrapper) {
+		return payload[len(wrapper):]
+	}
+	return payload
+}
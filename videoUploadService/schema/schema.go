@@ -0,0 +1,75 @@
+// Package schema migrates the tables of the packages sharing a database.
+// Each package owns its tables and lists the steps that create and change
+// them; the steps applied are recorded per package in schema_versions.
+package schema
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+const createVersions = `CREATE TABLE IF NOT EXISTS schema_versions (
+	component TEXT NOT NULL,
+	version INTEGER NOT NULL,
+	PRIMARY KEY (component, version)
+)`
+
+// Migrate applies the steps of component that have not been applied yet, in
+// order and each in its own transaction. Steps are only ever appended, and
+// must work on both PostgreSQL and SQLite.
+func Migrate(ctx context.Context, db *sql.DB, component string, steps []string) error {
+	applied, err := Version(ctx, db, component)
+	if err != nil {
+		return err
+	}
+	for i := applied; i < len(steps); i++ {
+		if err := apply(ctx, db, component, i+1, steps[i]); err != nil {
+			return fmt.Errorf("%s migration %d: %w", component, i+1, err)
+		}
+	}
+	return nil
+}
+
+// Version returns the number of steps of component applied so far.
+func Version(ctx context.Context, db *sql.DB, component string) (int, error) {
+	if _, err := db.ExecContext(ctx, createVersions); err != nil {
+		return 0, fmt.Errorf("create schema_versions: %w", err)
+	}
+	var v int
+	err := db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_versions WHERE component = $1`, component).Scan(&v)
+	if err != nil {
+		return 0, fmt.Errorf("read %s schema version: %w", component, err)
+	}
+	return v, nil
+}
+
+// Baseline records the first n steps of component as applied without
+// running them, for tables created before they were migrated here.
+func Baseline(ctx context.Context, db *sql.DB, component string, n int) error {
+	applied, err := Version(ctx, db, component)
+	if err != nil {
+		return err
+	}
+	for v := applied + 1; v <= n; v++ {
+		if _, err := db.ExecContext(ctx, `INSERT INTO schema_versions (component, version) VALUES ($1, $2)`, component, v); err != nil {
+			return fmt.Errorf("baseline %s: %w", component, err)
+		}
+	}
+	return nil
+}
+
+func apply(ctx context.Context, db *sql.DB, component string, version int, step string) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, step); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO schema_versions (component, version) VALUES ($1, $2)`, component, version); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
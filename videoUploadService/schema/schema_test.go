@@ -0,0 +1,88 @@
+package schema
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite", "file::memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestMigrate(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	a := []string{`CREATE TABLE a (id TEXT)`, `ALTER TABLE a ADD COLUMN name TEXT`}
+	b := []string{`CREATE TABLE b (id TEXT)`}
+	for i := 0; i < 2; i++ {
+		if err := Migrate(ctx, db, "a", a); err != nil {
+			t.Fatalf("migrate a (run %d): %v", i+1, err)
+		}
+		if err := Migrate(ctx, db, "b", b); err != nil {
+			t.Fatalf("migrate b (run %d): %v", i+1, err)
+		}
+	}
+	if v, _ := Version(ctx, db, "a"); v != 2 {
+		t.Errorf("a is at version %d, want 2", v)
+	}
+	if v, _ := Version(ctx, db, "b"); v != 1 {
+		t.Errorf("b is at version %d, want 1", v)
+	}
+
+	// Steps are appended; only the new one runs.
+	b = append(b, `ALTER TABLE b ADD COLUMN name TEXT`)
+	if err := Migrate(ctx, db, "b", b); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`INSERT INTO b (id, name) VALUES ('1', 'x')`); err != nil {
+		t.Errorf("new column missing: %v", err)
+	}
+}
+
+func TestMigrateFailedStep(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	steps := []string{`CREATE TABLE a (id TEXT)`, `ALTER TABLE missing ADD COLUMN name TEXT`}
+	if err := Migrate(ctx, db, "a", steps); err == nil {
+		t.Fatal("failing step applied")
+	}
+	// The steps before it stay applied and the failed one is retried.
+	if v, _ := Version(ctx, db, "a"); v != 1 {
+		t.Errorf("a is at version %d, want 1", v)
+	}
+	steps[1] = `ALTER TABLE a ADD COLUMN name TEXT`
+	if err := Migrate(ctx, db, "a", steps); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := Version(ctx, db, "a"); v != 2 {
+		t.Errorf("a is at version %d, want 2", v)
+	}
+}
+
+func TestBaseline(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	if _, err := db.Exec(`CREATE TABLE a (id TEXT)`); err != nil {
+		t.Fatal(err)
+	}
+	if err := Baseline(ctx, db, "a", 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := Baseline(ctx, db, "a", 1); err != nil {
+		t.Fatalf("second baseline: %v", err)
+	}
+	// The table exists, so running the first step again would fail.
+	if err := Migrate(ctx, db, "a", []string{`CREATE TABLE a (id TEXT)`, `ALTER TABLE a ADD COLUMN name TEXT`}); err != nil {
+		t.Fatal(err)
+	}
+}
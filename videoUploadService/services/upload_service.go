@@ -1,19 +1,27 @@
 package uploadSerivce
 
 import (
+	"VideoUploadService/catalog"
+	"VideoUploadService/contentkey"
+	"VideoUploadService/identity"
+	"VideoUploadService/jobqueue"
+	"VideoUploadService/packager"
+	"VideoUploadService/probe"
+	"VideoUploadService/profile"
+	"VideoUploadService/transcodestatus"
 	pbt "VideoUploadService/transcoding"
 	pb "VideoUploadService/upload"
+	"VideoUploadService/uploadstatus"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"os"
-	"time"
 
 	"github.com/google/uuid"
-	"github.com/joho/godotenv"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type FileServiceServer struct {
@@ -22,15 +30,21 @@ type FileServiceServer struct {
 
 // UploadVideo receives the video in chunks from the client and writes them to a file.
 func (s *FileServiceServer) UploadVideo(stream pb.FileService_UploadVideoServer) error {
-	err := godotenv.Load()
-    if err != nil {
-        log.Fatalf("Error loading .env file")
-    }
 	DEV_PATH := os.Getenv("DEV_PATH")
 	var totalReceived int64
 	fileName := uuid.NewString()
+	owner := identity.FromContext(stream.Context())
+	if _, err := catalog.Default.Create(stream.Context(), fileName, owner); err != nil {
+		return status.Errorf(codes.Internal, "create video: %v", err)
+	}
+	uploads := uploadstatus.Default
+	uploads.Start(fileName, owner, uploadstatus.SourceGRPC, 0)
+	uploads.SetTier(fileName, identity.TierFromContext(stream.Context()))
+
 	file, err := os.Create(DEV_PATH + fileName)
 	if err != nil {
+		uploads.Fail(fileName, err)
+		catalog.Default.Advance(fileName, catalog.StateFailed, err.Error())
 		return err
 	}
 	defer file.Close()
@@ -38,56 +52,154 @@ func (s *FileServiceServer) UploadVideo(stream pb.FileService_UploadVideoServer)
 	for {
 		req, err := stream.Recv()
 		if err == io.EOF {
+			uploads.SetStage(fileName, uploadstatus.StageStored)
+			catalog.Default.Advance(fileName, catalog.StateUploaded, "")
+			go HandOff(fileName, DEV_PATH+fileName)
 			return stream.SendAndClose(&pb.UploadVideoResponse{
 				Status:       200, 
 				ReceivedSize: totalReceived,
+				UploadId:     fileName,
 			})
 		}
 		if err != nil {
+			uploads.Fail(fileName, err)
+			catalog.Default.Advance(fileName, catalog.StateFailed, err.Error())
 			return err
 		}
+		uploads.SetExpected(fileName, req.TotalSize)
 
 		// Write the chunk to the file
 		_, err = file.Write(req.Chunk)
 		if err != nil {
+			uploads.Fail(fileName, err)
+			catalog.Default.Advance(fileName, catalog.StateFailed, err.Error())
 			return err
 		}
 
 		totalReceived += int64(len(req.Chunk))
-		grpc_calls(fileName)
+		uploads.AddBytes(fileName, int64(len(req.Chunk)))
 		fmt.Printf("Received chunk of size: %d, total received: %d\n", len(req.Chunk), totalReceived)
 	}
 }
-func grpc_calls(uuid string) {
-	// Set a reasonable timeout
-	timeout := 30 * time.Second
 
-	// Create a context with timeout for both connection and RPC
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
-	defer cancel()
+// GetUploadStatus reports the progress of an upload made over either gRPC or HTTP.
+func (s *FileServiceServer) GetUploadStatus(ctx context.Context, req *pb.UploadStatusRequest) (*pb.UploadStatusResponse, error) {
+	caller := identity.FromContext(ctx)
+	if caller == "" && !identity.IsAdmin(ctx) {
+		return nil, status.Error(codes.Unauthenticated, "authentication required")
+	}
+	st, ok := uploadstatus.Default.Get(req.UploadId)
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "upload %s not found", req.UploadId)
+	}
+	if !st.MayView(caller, identity.IsAdmin(ctx)) {
+		return nil, status.Error(codes.PermissionDenied, "not allowed to view this upload")
+	}
+	return &pb.UploadStatusResponse{
+		UploadId:      st.ID,
+		Owner:         st.Owner,
+		Source:        st.Source,
+		Stage:         string(st.Stage),
+		ReceivedBytes: st.ReceivedBytes,
+		ExpectedBytes: st.ExpectedBytes,
+		CreatedAt:     st.CreatedAt.Unix(),
+		UpdatedAt:     st.UpdatedAt.Unix(),
+		FailureReason: st.FailureReason,
+	}, nil
+}
 
-	// Create the gRPC connection
-	conn, err := grpc.DialContext(ctx, "localhost:50051",
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-		grpc.WithBlock())
+// HandOff probes a stored upload, computes its encoding profile and queues
+// it for transcoding.
+func HandOff(uuid, path string) {
+	st, _ := uploadstatus.Default.Get(uuid)
+	prof, err := Profile(context.Background(), uuid, st.Tier, path)
 	if err != nil {
-		log.Printf("Failed to connect: %v", err)
+		uploadstatus.Default.Fail(uuid, err)
+		catalog.Default.Advance(uuid, catalog.StateFailed, err.Error())
 		return
 	}
-	defer conn.Close()
+	job := jobqueue.Default.Enqueue(uuid, st.Owner, st.Tier, prof)
+	uploadstatus.Default.SetStage(uuid, uploadstatus.StageQueued)
+	catalog.Default.Advance(uuid, catalog.StateQueued, "")
+	log.Printf("Queued %s as job %s with priority %d", uuid, job.ID, job.Priority)
+}
 
-	client := pbt.NewTranscoderClient(conn)
+// Profile probes a stored upload and computes its encoding profile from the
+// default preset, preparing content keys if the uploader's tier requires
+// encryption.
+func Profile(ctx context.Context, uuid, tier, path string) (*pbt.EncodingProfile, error) {
+	src, err := probe.File(ctx, path)
+	if err != nil {
+		// Let the encoder decide; an unknown source gets the full ladder.
+		log.Printf("Probing %s: %v", uuid, err)
+	} else {
+		uploadstatus.Default.SetMedia(uuid, src)
+	}
 
-	req := &pbt.VideoUuidRequest{
-		Uuid: uuid,
+	preset, ok := profile.Get("")
+	if !ok {
+		return nil, errors.New("no default encoding preset")
 	}
+	prof := profile.Build(preset, src)
+	if contentkey.Default.Required(tier) {
+		enc, err := contentkey.Default.Prepare(ctx, uuid, src.DurationSeconds, prof.SegmentDurationSeconds)
+		if err != nil {
+			// Never fall back to clear segments for videos that must be encrypted.
+			return nil, fmt.Errorf("prepare content keys: %w", err)
+		}
+		prof.Encryption = enc
+	}
+	return prof, nil
+}
 
-	// Call the NotifyUploadComplete RPC
-	res, err := client.NotifyUploadComplete(ctx, req)
-	if err != nil {
-		log.Printf("Error calling NotifyUploadComplete: %v", err)
-		return
+// TrackJob mirrors transcoding job changes into the upload status and the
+// video catalog.
+func TrackJob(job jobqueue.Job) {
+	switch job.State {
+	case jobqueue.StateQueued:
+		catalog.Default.Advance(job.VideoID, catalog.StateQueued, "")
+	case jobqueue.StateLeased:
+		uploadstatus.Default.SetStage(job.VideoID, uploadstatus.StageHandedOff)
+		catalog.Default.Advance(job.VideoID, catalog.StateTranscoding, "")
+	case jobqueue.StateSucceeded:
+		// The output was packaged by Finalize before the job succeeded.
+		if packager.Default != nil {
+			go func() {
+				if err := packager.Default.GenerateSprites(context.Background(), job.VideoID); err != nil {
+					log.Printf("Generating thumbnails of %s: %v", job.VideoID, err)
+				}
+			}()
+		}
+		catalog.Default.Advance(job.VideoID, catalog.StateReady, "")
+	case jobqueue.StateDead:
+		last := job.Attempts[len(job.Attempts)-1]
+		err := fmt.Errorf("transcoding failed after %d attempts: %s", len(job.Attempts), last.Error)
+		uploadstatus.Default.Fail(job.VideoID, err)
+		catalog.Default.Advance(job.VideoID, catalog.StateFailed, err.Error())
+	case jobqueue.StateCancelled:
+		catalog.Default.Advance(job.VideoID, catalog.StateFailed, "transcoding cancelled")
 	}
+}
 
-	log.Printf("Response: StatusCode=%d", res.StatusCode)
+// Finalize packages the output of a transcoding job, so that manifests can
+// be generated from its rendition metadata. It has the signature of
+// jobqueue.Finalize.
+func Finalize(ctx context.Context, job jobqueue.Job) error {
+	if packager.Default == nil {
+		return nil
+	}
+	_, err := packager.Default.Package(ctx, job.VideoID, job.Profile)
+	return err
+}
+
+// TrackTranscode moves videos through the catalog as encoder status arrives.
+// Finished encodes only become ready once their job succeeds, after the
+// output was packaged; see TrackJob.
+func TrackTranscode(u transcodestatus.Update) {
+	switch u.State {
+	case transcodestatus.StateInProgress:
+		catalog.Default.Advance(u.VideoID, catalog.StateTranscoding, "")
+	case transcodestatus.StateFailed:
+		catalog.Default.Advance(u.VideoID, catalog.StateFailed, u.Error)
+	}
 }
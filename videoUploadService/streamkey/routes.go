@@ -0,0 +1,97 @@
+package streamkey
+
+import (
+	"VideoUploadService/identity"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func SetupRoutes(app *fiber.App) {
+	app.Get("/live/channels/:channel/keys", listKeysHandler)
+	app.Post("/live/channels/:channel/keys", createKeyHandler)
+	app.Get("/live/channels/:channel/keys/:id", getKeyHandler)
+	app.Patch("/live/channels/:channel/keys/:id", updateKeyHandler)
+	app.Post("/live/channels/:channel/keys/:id/rotate", rotateKeyHandler)
+	app.Delete("/live/channels/:channel/keys/:id", revokeKeyHandler)
+}
+
+func callerFromFiber(c *fiber.Ctx) Caller {
+	return Caller{ID: identity.FromFiber(c), Admin: identity.IsAdminFiber(c), Tier: identity.TierFromFiber(c)}
+}
+
+func listKeysHandler(c *fiber.Ctx) error {
+	keys, err := Default.List(c.Context(), callerFromFiber(c), c.Params("channel"))
+	if err != nil {
+		return respondErr(c, err)
+	}
+	return c.JSON(fiber.Map{"keys": keys})
+}
+
+// createKeyHandler issues a key. The secret stream_key is only part of this
+// response; it cannot be read back later.
+func createKeyHandler(c *fiber.Ctx) error {
+	var o Options
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&o); err != nil {
+			return c.Status(400).SendString("Invalid request body")
+		}
+	}
+	k, secret, err := Default.Create(c.Context(), callerFromFiber(c), c.Params("channel"), o)
+	if err != nil {
+		return respondErr(c, err)
+	}
+	return c.Status(201).JSON(fiber.Map{"key": k, "stream_key": secret})
+}
+
+func getKeyHandler(c *fiber.Ctx) error {
+	k, err := Default.Get(c.Context(), callerFromFiber(c), c.Params("channel"), c.Params("id"))
+	if err != nil {
+		return respondErr(c, err)
+	}
+	return c.JSON(k)
+}
+
+func updateKeyHandler(c *fiber.Ctx) error {
+	var p Patch
+	if err := c.BodyParser(&p); err != nil {
+		return c.Status(400).SendString("Invalid request body")
+	}
+	k, err := Default.Update(c.Context(), callerFromFiber(c), c.Params("channel"), c.Params("id"), p)
+	if err != nil {
+		return respondErr(c, err)
+	}
+	return c.JSON(k)
+}
+
+func rotateKeyHandler(c *fiber.Ctx) error {
+	k, secret, err := Default.Rotate(c.Context(), callerFromFiber(c), c.Params("channel"), c.Params("id"))
+	if err != nil {
+		return respondErr(c, err)
+	}
+	return c.JSON(fiber.Map{"key": k, "stream_key": secret})
+}
+
+func revokeKeyHandler(c *fiber.Ctx) error {
+	if _, err := Default.Revoke(c.Context(), callerFromFiber(c), c.Params("channel"), c.Params("id")); err != nil {
+		return respondErr(c, err)
+	}
+	return c.SendStatus(204)
+}
+
+func respondErr(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, ErrForbidden) && identity.FromFiber(c) == "":
+		return c.Status(401).SendString("Authentication required")
+	case errors.Is(err, ErrForbidden):
+		return c.Status(403).SendString(err.Error())
+	case errors.Is(err, ErrNotFound):
+		return c.Status(404).SendString(err.Error())
+	case errors.Is(err, ErrInvalid):
+		return c.Status(400).SendString(err.Error())
+	case errors.Is(err, ErrRevoked), errors.Is(err, ErrTooMany):
+		return c.Status(409).SendString(err.Error())
+	default:
+		return c.Status(500).SendString(err.Error())
+	}
+}
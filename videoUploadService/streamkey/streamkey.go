@@ -0,0 +1,441 @@
+// Package streamkey manages the keys creators publish live broadcasts with.
+// Keys are only shown when they are created or rotated; the database holds
+// their hashes.
+package streamkey
+
+import (
+	"VideoUploadService/live"
+	"VideoUploadService/schema"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Prefix starts every stream key, which makes leaked keys easy to spot.
+const Prefix = "live_"
+
+// MaxKeys bounds the active keys of a channel.
+const MaxKeys = 20
+
+const maxNameLength = 100
+
+var (
+	ErrNotFound  = errors.New("stream key not found")
+	ErrForbidden = errors.New("not allowed to manage the stream keys of this channel")
+	ErrInvalid   = errors.New("invalid stream key settings")
+	ErrRevoked   = errors.New("stream key is revoked")
+	ErrTooMany   = errors.New("channel has too many stream keys")
+)
+
+// Key describes a stream key without revealing it.
+type Key struct {
+	ID      string `json:"id"`
+	Channel string `json:"channel"`
+	Name    string `json:"name"`
+	// Hint is the last characters of the key, to tell keys apart.
+	Hint string `json:"hint"`
+	live.Settings
+	CreatedAt  time.Time  `json:"created_at"`
+	RotatedAt  *time.Time `json:"rotated_at,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	Status     string     `json:"status"`
+	// Tier is the account tier of the channel owner when they last created,
+	// rotated or changed the key. Archives of its broadcasts are transcoded
+	// at this tier.
+	Tier string `json:"-"`
+}
+
+// Key statuses.
+const (
+	StatusActive  = "active"
+	StatusExpired = "expired"
+	StatusRevoked = "revoked"
+)
+
+func (k Key) status(now time.Time) string {
+	switch {
+	case k.RevokedAt != nil:
+		return StatusRevoked
+	case k.ExpiresAt != nil && !now.Before(*k.ExpiresAt):
+		return StatusExpired
+	default:
+		return StatusActive
+	}
+}
+
+// Options are what a creator chooses when creating a key.
+type Options struct {
+	Name string `json:"name"`
+	live.Settings
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// Patch changes the options of a key. Nil fields are left alone; NoExpiry
+// removes the expiry.
+type Patch struct {
+	Name        *string           `json:"name"`
+	LatencyMode *live.LatencyMode `json:"latency_mode"`
+	Record      *bool             `json:"record"`
+	ExpiresAt   *time.Time        `json:"expires_at"`
+	NoExpiry    bool              `json:"no_expiry"`
+}
+
+// Service stores stream keys and authenticates publishers with them.
+type Service struct {
+	db  *sql.DB
+	hub *live.Hub
+}
+
+// Default is set up in main.
+var Default *Service
+
+// migrations create the stream_keys table. Earlier versions created it in
+// the catalog migrations, hence IF NOT EXISTS.
+var migrations = []string{
+	`CREATE TABLE IF NOT EXISTS stream_keys (
+		id TEXT PRIMARY KEY,
+		channel TEXT NOT NULL,
+		name TEXT NOT NULL DEFAULT '',
+		key_hash TEXT NOT NULL UNIQUE,
+		hint TEXT NOT NULL,
+		latency_mode TEXT NOT NULL,
+		record BOOLEAN NOT NULL,
+		created_at TIMESTAMP NOT NULL,
+		rotated_at TIMESTAMP,
+		expires_at TIMESTAMP,
+		revoked_at TIMESTAMP,
+		last_used_at TIMESTAMP
+	)`,
+	`CREATE INDEX IF NOT EXISTS stream_keys_channel_idx ON stream_keys (channel)`,
+	`ALTER TABLE stream_keys ADD COLUMN tier TEXT NOT NULL DEFAULT ''`,
+}
+
+// Open stores keys in the stream_keys table of db, migrating it first.
+// Broadcasts on hub are stopped when their key is rotated or revoked.
+func Open(db *sql.DB, hub *live.Hub) (*Service, error) {
+	if err := schema.Migrate(context.Background(), db, "streamkey", migrations); err != nil {
+		return nil, err
+	}
+	return &Service{db: db, hub: hub}, nil
+}
+
+// Caller is who manages keys. Tier is the caller's account tier.
+type Caller struct {
+	ID    string
+	Admin bool
+	Tier  string
+}
+
+// mayManage reports whether the caller may manage a channel's keys. A
+// channel belongs to the user of the same ID.
+func (c Caller) mayManage(channel string) bool {
+	return c.Admin || (c.ID != "" && c.ID == channel)
+}
+
+// ownerTier returns the tier to record on a key of channel: the caller's
+// if they own the channel, else the key's current tier. Admins managing
+// the keys of others don't change it.
+func (c Caller) ownerTier(channel, current string) string {
+	if c.ID != "" && c.ID == channel {
+		return c.Tier
+	}
+	return current
+}
+
+// Create issues a new key for a channel and returns it with the secret
+// key, which is not stored.
+func (s *Service) Create(ctx context.Context, caller Caller, channel string, o Options) (Key, string, error) {
+	if !caller.mayManage(channel) {
+		return Key{}, "", ErrForbidden
+	}
+	now := time.Now().UTC()
+	o.Name = strings.TrimSpace(o.Name)
+	if o.Latency == "" {
+		o.Latency = live.LatencyNormal
+	}
+	if err := validate(o.Name, o.Latency, o.ExpiresAt, now); err != nil {
+		return Key{}, "", err
+	}
+	var active int
+	err := s.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM stream_keys WHERE channel = $1 AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > $2)`,
+		channel, now).Scan(&active)
+	if err != nil {
+		return Key{}, "", err
+	}
+	if active >= MaxKeys {
+		return Key{}, "", ErrTooMany
+	}
+
+	secret, err := generate()
+	if err != nil {
+		return Key{}, "", err
+	}
+	k := Key{
+		ID:        uuid.NewString(),
+		Channel:   channel,
+		Name:      o.Name,
+		Hint:      hint(secret),
+		Settings:  o.Settings,
+		CreatedAt: now,
+		ExpiresAt: utc(o.ExpiresAt),
+		Tier:      caller.ownerTier(channel, ""),
+	}
+	_, err = s.db.ExecContext(ctx,
+		`INSERT INTO stream_keys (id, channel, name, key_hash, hint, latency_mode, record, created_at, expires_at, tier)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		k.ID, k.Channel, k.Name, hash(secret), k.Hint, string(k.Latency), k.Record, k.CreatedAt, k.ExpiresAt, k.Tier)
+	if err != nil {
+		return Key{}, "", err
+	}
+	k.Status = k.status(now)
+	return k, secret, nil
+}
+
+// List returns the keys of a channel, oldest first, including revoked and
+// expired ones.
+func (s *Service) List(ctx context.Context, caller Caller, channel string) ([]Key, error) {
+	if !caller.mayManage(channel) {
+		return nil, ErrForbidden
+	}
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT `+keyColumns+` FROM stream_keys WHERE channel = $1 ORDER BY created_at, id`, channel)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	now := time.Now()
+	keys := []Key{}
+	for rows.Next() {
+		k, err := scanKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		k.Status = k.status(now)
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+// Get returns one key of a channel.
+func (s *Service) Get(ctx context.Context, caller Caller, channel, id string) (Key, error) {
+	if !caller.mayManage(channel) {
+		return Key{}, ErrForbidden
+	}
+	k, err := scanKey(s.db.QueryRowContext(ctx,
+		`SELECT `+keyColumns+` FROM stream_keys WHERE channel = $1 AND id = $2`, channel, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return Key{}, ErrNotFound
+	}
+	if err != nil {
+		return Key{}, err
+	}
+	k.Status = k.status(time.Now())
+	return k, nil
+}
+
+// Update changes the name, settings or expiry of a key. Settings apply
+// from the next broadcast on.
+func (s *Service) Update(ctx context.Context, caller Caller, channel, id string, p Patch) (Key, error) {
+	k, err := s.Get(ctx, caller, channel, id)
+	if err != nil {
+		return Key{}, err
+	}
+	if k.RevokedAt != nil {
+		return Key{}, ErrRevoked
+	}
+	if p.Name != nil {
+		k.Name = strings.TrimSpace(*p.Name)
+	}
+	if p.LatencyMode != nil {
+		k.Latency = *p.LatencyMode
+	}
+	if p.Record != nil {
+		k.Record = *p.Record
+	}
+	// Only a new expiry has to be in the future.
+	var newExpiry *time.Time
+	switch {
+	case p.NoExpiry:
+		k.ExpiresAt = nil
+	case p.ExpiresAt != nil:
+		k.ExpiresAt = utc(p.ExpiresAt)
+		newExpiry = k.ExpiresAt
+	}
+	now := time.Now().UTC()
+	if err := validate(k.Name, k.Latency, newExpiry, now); err != nil {
+		return Key{}, err
+	}
+	k.Tier = caller.ownerTier(channel, k.Tier)
+	_, err = s.db.ExecContext(ctx,
+		`UPDATE stream_keys SET name = $1, latency_mode = $2, record = $3, expires_at = $4, tier = $5 WHERE id = $6`,
+		k.Name, string(k.Latency), k.Record, k.ExpiresAt, k.Tier, k.ID)
+	if err != nil {
+		return Key{}, err
+	}
+	k.Status = k.status(now)
+	return k, nil
+}
+
+// Rotate replaces the secret of a key, keeping its settings. Broadcasts
+// using the old secret are disconnected.
+func (s *Service) Rotate(ctx context.Context, caller Caller, channel, id string) (Key, string, error) {
+	k, err := s.Get(ctx, caller, channel, id)
+	if err != nil {
+		return Key{}, "", err
+	}
+	if k.RevokedAt != nil {
+		return Key{}, "", ErrRevoked
+	}
+	secret, err := generate()
+	if err != nil {
+		return Key{}, "", err
+	}
+	now := time.Now().UTC()
+	k.Hint, k.RotatedAt, k.Tier = hint(secret), &now, caller.ownerTier(channel, k.Tier)
+	_, err = s.db.ExecContext(ctx,
+		`UPDATE stream_keys SET key_hash = $1, hint = $2, rotated_at = $3, tier = $4 WHERE id = $5`,
+		hash(secret), k.Hint, now, k.Tier, k.ID)
+	if err != nil {
+		return Key{}, "", err
+	}
+	s.stop(k, "rotated")
+	k.Status = k.status(now)
+	return k, secret, nil
+}
+
+// Revoke disables a key for good and disconnects broadcasts using it.
+// Revoking a revoked key does nothing.
+func (s *Service) Revoke(ctx context.Context, caller Caller, channel, id string) (Key, error) {
+	k, err := s.Get(ctx, caller, channel, id)
+	if err != nil {
+		return Key{}, err
+	}
+	if k.RevokedAt == nil {
+		now := time.Now().UTC()
+		if _, err := s.db.ExecContext(ctx, `UPDATE stream_keys SET revoked_at = $1 WHERE id = $2`, now, k.ID); err != nil {
+			return Key{}, err
+		}
+		k.RevokedAt = &now
+	}
+	s.stop(k, "revoked")
+	k.Status = StatusRevoked
+	return k, nil
+}
+
+func (s *Service) stop(k Key, why string) {
+	if s.hub == nil {
+		return
+	}
+	if n := s.hub.StopKey(k.ID, errors.New("stream key was "+why)); n > 0 {
+		log.Printf("Stopped %d broadcast(s) of channel %s: stream key %s was %s", n, k.Channel, k.ID, why)
+	}
+}
+
+// Authenticate resolves a secret key to the channel it publishes to. It
+// has the signature of live.Authenticate.
+func (s *Service) Authenticate(ctx context.Context, secret string) (live.Channel, error) {
+	if !strings.HasPrefix(secret, Prefix) {
+		return live.Channel{}, live.ErrUnknownKey
+	}
+	k, err := scanKey(s.db.QueryRowContext(ctx,
+		`SELECT `+keyColumns+` FROM stream_keys WHERE key_hash = $1`, hash(secret)))
+	if errors.Is(err, sql.ErrNoRows) {
+		return live.Channel{}, live.ErrUnknownKey
+	}
+	if err != nil {
+		return live.Channel{}, err
+	}
+	now := time.Now().UTC()
+	if status := k.status(now); status != StatusActive {
+		log.Printf("Rejected %s stream key %s of channel %s", status, k.ID, k.Channel)
+		return live.Channel{}, live.ErrUnknownKey
+	}
+	if _, err := s.db.ExecContext(ctx, `UPDATE stream_keys SET last_used_at = $1 WHERE id = $2`, now, k.ID); err != nil {
+		log.Printf("Recording use of stream key %s: %v", k.ID, err)
+	}
+	return live.Channel{ID: k.Channel, Owner: k.Channel, Tier: k.Tier, KeyID: k.ID, Settings: k.Settings}, nil
+}
+
+const keyColumns = `id, channel, name, hint, latency_mode, record, created_at, rotated_at, expires_at, revoked_at, last_used_at, tier`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanKey(row scanner) (Key, error) {
+	var k Key
+	var latency string
+	var rotated, expires, revoked, used sql.NullTime
+	err := row.Scan(&k.ID, &k.Channel, &k.Name, &k.Hint, &latency, &k.Record, &k.CreatedAt,
+		&rotated, &expires, &revoked, &used, &k.Tier)
+	if err != nil {
+		return Key{}, err
+	}
+	k.Latency = live.LatencyMode(latency)
+	k.RotatedAt, k.ExpiresAt, k.RevokedAt, k.LastUsedAt = nullTime(rotated), nullTime(expires), nullTime(revoked), nullTime(used)
+	return k, nil
+}
+
+func nullTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	u := t.Time.UTC()
+	return &u
+}
+
+func utc(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	u := t.UTC()
+	return &u
+}
+
+// validate checks the options of a key. A nil expiresAt is not checked.
+func validate(name string, latency live.LatencyMode, expiresAt *time.Time, now time.Time) error {
+	if len(name) > maxNameLength {
+		return fmt.Errorf("%w: name is longer than %d bytes", ErrInvalid, maxNameLength)
+	}
+	if latency != live.LatencyNormal && latency != live.LatencyLow {
+		return fmt.Errorf("%w: latency_mode must be %q or %q", ErrInvalid, live.LatencyNormal, live.LatencyLow)
+	}
+	if expiresAt != nil && !expiresAt.After(now) {
+		return fmt.Errorf("%w: expires_at is in the past", ErrInvalid)
+	}
+	return nil
+}
+
+// generate returns a new secret key of 192 random bits.
+func generate() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return Prefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hash is what is stored of a key. Keys are long random strings, so a
+// plain SHA-256 resists guessing as well as a password hash would, and it
+// can be looked up directly.
+func hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func hint(secret string) string {
+	return secret[len(secret)-4:]
+}
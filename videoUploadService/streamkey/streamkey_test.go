@@ -0,0 +1,109 @@
+package streamkey
+
+import (
+	"VideoUploadService/catalog"
+	"VideoUploadService/live"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func openTestService(t *testing.T) *Service {
+	t.Helper()
+	store, err := catalog.Open("sqlite", "file::memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	s, err := Open(store.DB(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestKeyTier(t *testing.T) {
+	s := openTestService(t)
+	ctx := context.Background()
+	admin := Caller{ID: "ops", Admin: true, Tier: "free"}
+
+	k, secret, err := s.Create(ctx, Caller{ID: "alice", Tier: "premium"}, "alice", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ch, _ := s.Authenticate(ctx, secret); ch.Tier != "premium" {
+		t.Errorf("channel tier = %q, want premium", ch.Tier)
+	}
+	// Admins managing the key leave the owner's tier alone.
+	if _, err := s.Update(ctx, admin, "alice", k.ID, Patch{}); err != nil {
+		t.Fatal(err)
+	}
+	_, secret, err = s.Rotate(ctx, admin, "alice", k.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ch, _ := s.Authenticate(ctx, secret); ch.Tier != "premium" {
+		t.Errorf("channel tier after an admin rotated the key = %q, want premium", ch.Tier)
+	}
+	// The owner's current tier is recorded whenever they manage the key.
+	if _, err := s.Update(ctx, Caller{ID: "alice", Tier: "creator"}, "alice", k.ID, Patch{}); err != nil {
+		t.Fatal(err)
+	}
+	if ch, _ := s.Authenticate(ctx, secret); ch.Tier != "creator" {
+		t.Errorf("channel tier after the owner's update = %q, want creator", ch.Tier)
+	}
+}
+
+func TestKeyLifecycle(t *testing.T) {
+	s := openTestService(t)
+	ctx := context.Background()
+	alice := Caller{ID: "alice"}
+
+	if _, _, err := s.Create(ctx, Caller{ID: "bob"}, "alice", Options{}); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("create for another channel: err = %v", err)
+	}
+	k, secret, err := s.Create(ctx, alice, "alice", Options{Name: " studio ", Settings: live.Settings{Record: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(secret, Prefix) || k.Name != "studio" || k.Latency != live.LatencyNormal || k.Status != StatusActive {
+		t.Fatalf("created key %+v with secret %q", k, secret)
+	}
+
+	ch, err := s.Authenticate(ctx, secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ch.ID != "alice" || ch.KeyID != k.ID || !ch.Settings.Record {
+		t.Errorf("channel = %+v", ch)
+	}
+	if _, err := s.Authenticate(ctx, Prefix+"guess"); !errors.Is(err, live.ErrUnknownKey) {
+		t.Errorf("unknown key: err = %v", err)
+	}
+
+	_, rotated, err := s.Rotate(ctx, alice, "alice", k.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Authenticate(ctx, secret); !errors.Is(err, live.ErrUnknownKey) {
+		t.Errorf("old secret after rotation: err = %v", err)
+	}
+	if _, err := s.Authenticate(ctx, rotated); err != nil {
+		t.Errorf("rotated secret: err = %v", err)
+	}
+
+	if _, err := s.Revoke(ctx, alice, "alice", k.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Authenticate(ctx, rotated); !errors.Is(err, live.ErrUnknownKey) {
+		t.Errorf("revoked key: err = %v", err)
+	}
+	keys, err := s.List(ctx, alice, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 1 || keys[0].Status != StatusRevoked || keys[0].LastUsedAt == nil || keys[0].RotatedAt == nil {
+		t.Errorf("keys = %+v", keys)
+	}
+}
@@ -0,0 +1,72 @@
+package transcodectl
+
+import (
+	"VideoUploadService/identity"
+	"VideoUploadService/jobqueue"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func SetupRoutes(app *fiber.App) {
+	group := app.Group("/videos/:id/transcode")
+	group.Post("/cancel", cancelHandler)
+	group.Post("/retry", retryHandler)
+	group.Post("/reencode", reencodeHandler)
+	group.Get("/history", historyHandler)
+}
+
+func callerFromFiber(c *fiber.Ctx) Caller {
+	return Caller{ID: identity.FromFiber(c), Admin: identity.IsAdminFiber(c)}
+}
+
+func cancelHandler(c *fiber.Ctx) error {
+	err := Default.Cancel(c.Context(), callerFromFiber(c), c.Params("id"))
+	return respond(c, err)
+}
+
+func retryHandler(c *fiber.Ctx) error {
+	err := Default.Retry(c.Context(), callerFromFiber(c), c.Params("id"))
+	return respond(c, err)
+}
+
+func reencodeHandler(c *fiber.Ctx) error {
+	var body struct {
+		Renditions []string `json:"renditions"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(400).SendString("Invalid request body")
+	}
+	err := Default.Reencode(c.Context(), callerFromFiber(c), c.Params("id"), body.Renditions)
+	return respond(c, err)
+}
+
+func historyHandler(c *fiber.Ctx) error {
+	videoID := c.Params("id")
+	owner, ok := Owner(videoID)
+	if !ok {
+		return c.Status(404).SendString(ErrNotFound.Error())
+	}
+	if caller := callerFromFiber(c); !caller.Admin && (caller.ID == "" || caller.ID != owner) {
+		return c.Status(403).SendString(ErrNotOwner.Error())
+	}
+	return c.JSON(Default.History(videoID))
+}
+
+func respond(c *fiber.Ctx, err error) error {
+	switch {
+	case err == nil:
+		return c.SendStatus(202)
+	case errors.Is(err, ErrNotFound):
+		return c.Status(404).SendString(err.Error())
+	case errors.Is(err, ErrNotOwner):
+		return c.Status(403).SendString(err.Error())
+	case errors.Is(err, ErrUnknownRendition):
+		return c.Status(400).SendString(err.Error())
+	case errors.Is(err, ErrNotRunning), errors.Is(err, ErrNotFailed),
+		errors.Is(err, jobqueue.ErrActive), errors.Is(err, jobqueue.ErrFinished):
+		return c.Status(409).SendString(err.Error())
+	default:
+		return c.Status(502).SendString(err.Error())
+	}
+}
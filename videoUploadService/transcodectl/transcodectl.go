@@ -0,0 +1,234 @@
+package transcodectl
+
+import (
+	"VideoUploadService/jobqueue"
+	pbt "VideoUploadService/transcoding"
+	"VideoUploadService/uploadstatus"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+type Action string
+
+const (
+	ActionCancel   Action = "cancel"
+	ActionRetry    Action = "retry"
+	ActionReencode Action = "reencode"
+)
+
+var (
+	ErrNotFound         = errors.New("video not found")
+	ErrNotOwner         = errors.New("caller does not own the video")
+	ErrUnknownRendition = errors.New("unknown rendition")
+	ErrNotRunning       = errors.New("transcode is not queued or running")
+	ErrNotFailed        = errors.New("transcode has not failed")
+)
+
+// Caller is who acts on a transcode. Admins may act on any video.
+type Caller struct {
+	ID    string
+	Admin bool
+}
+
+// Record is one action taken on a video's transcode.
+type Record struct {
+	VideoID    string    `json:"video_id"`
+	Actor      string    `json:"actor"`
+	Action     Action    `json:"action"`
+	Renditions []string  `json:"renditions,omitempty"`
+	At         time.Time `json:"at"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// Controller cancels, retries and re-encodes transcodes through the job
+// queue, checking that the caller may act on the video and recording every
+// action that was attempted.
+type Controller struct {
+	addr string
+
+	connOnce sync.Once
+	client   pbt.TranscoderClient
+	connErr  error
+
+	mu      sync.Mutex
+	records map[string][]Record
+}
+
+// Owner returns the owner of a video. It is a variable so that other stores
+// can take over ownership lookups.
+var Owner = func(videoID string) (string, bool) {
+	st, ok := uploadstatus.Default.Get(videoID)
+	return st.Owner, ok
+}
+
+// Tier returns the account tier a video was uploaded under, for videos the
+// queue no longer holds a job of.
+var Tier = func(videoID string) string {
+	st, _ := uploadstatus.Default.Get(videoID)
+	return st.Tier
+}
+
+// Profile computes the encoding profile of a video from its source and the
+// tier it was uploaded under. It is set up in main.
+var Profile func(ctx context.Context, videoID, tier string) (*pbt.EncodingProfile, error)
+
+var Default = NewController("localhost:50051")
+
+func NewController(addr string) *Controller {
+	return &Controller{addr: addr, records: make(map[string][]Record)}
+}
+
+// Cancel stops a queued or running transcode. The encoder is asked to stop a
+// running one, but the job is cancelled whether or not it is reachable; its
+// worker learns about it on the next heartbeat.
+func (c *Controller) Cancel(ctx context.Context, caller Caller, videoID string) error {
+	return c.do(Record{VideoID: videoID, Actor: caller.ID, Action: ActionCancel}, caller.Admin, func() error {
+		job, ok := jobqueue.Default.ForVideo(videoID)
+		if !ok || (job.State != jobqueue.StateQueued && job.State != jobqueue.StateLeased) {
+			return ErrNotRunning
+		}
+		if err := jobqueue.Default.Cancel(job.ID); err != nil {
+			return err
+		}
+		if job.State == jobqueue.StateLeased {
+			if err := c.stopEncoder(ctx, videoID); err != nil {
+				log.Printf("Cancelling %s: stopping the encoder: %v", videoID, err)
+			}
+		}
+		return nil
+	})
+}
+
+// Retry queues a failed or cancelled transcode again with a freshly built
+// profile.
+func (c *Controller) Retry(ctx context.Context, caller Caller, videoID string) error {
+	return c.do(Record{VideoID: videoID, Actor: caller.ID, Action: ActionRetry}, caller.Admin, func() error {
+		job, ok := jobqueue.Default.ForVideo(videoID)
+		if ok && job.State == jobqueue.StateSucceeded {
+			return ErrNotFailed
+		}
+		return c.requeue(ctx, videoID, nil)
+	})
+}
+
+// Reencode queues the video again, producing only the given renditions of
+// its profile.
+func (c *Controller) Reencode(ctx context.Context, caller Caller, videoID string, renditions []string) error {
+	return c.do(Record{VideoID: videoID, Actor: caller.ID, Action: ActionReencode, Renditions: renditions}, caller.Admin, func() error {
+		return c.requeue(ctx, videoID, renditions)
+	})
+}
+
+// requeue builds the profile of a video again and queues its last job with
+// it, or a new job if the queue no longer holds one. With renditions set, the
+// profile keeps only those.
+func (c *Controller) requeue(ctx context.Context, videoID string, renditions []string) error {
+	job, ok := jobqueue.Default.ForVideo(videoID)
+	if ok && (job.State == jobqueue.StateQueued || job.State == jobqueue.StateLeased) {
+		return jobqueue.ErrActive
+	}
+	if Profile == nil {
+		return errors.New("encoding profiles are not configured")
+	}
+	tier := job.Tier
+	if !ok {
+		tier = Tier(videoID)
+	}
+	prof, err := Profile(ctx, videoID, tier)
+	if err != nil {
+		return err
+	}
+	if len(renditions) > 0 {
+		if prof.Renditions, err = selectRenditions(prof.Renditions, renditions); err != nil {
+			return err
+		}
+	}
+
+	if !ok {
+		owner, _ := Owner(videoID)
+		jobqueue.Default.Enqueue(videoID, owner, tier, prof)
+		return nil
+	}
+	_, err = jobqueue.Default.RequeueWith(job.ID, prof)
+	return err
+}
+
+// selectRenditions picks the named renditions out of a profile's, which come
+// from the preset's ladder for the source.
+func selectRenditions(specs []*pbt.RenditionSpec, names []string) ([]*pbt.RenditionSpec, error) {
+	var selected []*pbt.RenditionSpec
+	for _, name := range names {
+		i := slices.IndexFunc(specs, func(s *pbt.RenditionSpec) bool { return s.Name == name })
+		if i < 0 {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownRendition, name)
+		}
+		if !slices.Contains(selected, specs[i]) {
+			selected = append(selected, specs[i])
+		}
+	}
+	return selected, nil
+}
+
+// History returns the actions recorded for a video, oldest first.
+func (c *Controller) History(videoID string) []Record {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Record(nil), c.records[videoID]...)
+}
+
+func (c *Controller) do(rec Record, admin bool, action func() error) error {
+	owner, ok := Owner(rec.VideoID)
+	if !ok {
+		return ErrNotFound
+	}
+	if !admin && (rec.Actor == "" || owner != rec.Actor) {
+		return ErrNotOwner
+	}
+
+	err := action()
+	rec.At = time.Now()
+	if err != nil {
+		rec.Error = err.Error()
+	}
+	c.mu.Lock()
+	c.records[rec.VideoID] = append(c.records[rec.VideoID], rec)
+	c.mu.Unlock()
+	log.Printf("Transcode %s of %s by %s: err=%v", rec.Action, rec.VideoID, rec.Actor, err)
+	return err
+}
+
+// stopEncoder asks the encoder to stop transcoding a video.
+func (c *Controller) stopEncoder(ctx context.Context, videoID string) error {
+	client, err := c.conn()
+	if err != nil {
+		return err
+	}
+	res, err := client.CancelTranscode(ctx, &pbt.VideoUuidRequest{Uuid: videoID})
+	if err != nil {
+		return err
+	}
+	if res.Stage == pbt.TranscodeStage_TRANSCODE_STAGE_FAILED {
+		return fmt.Errorf("transcoder: %s %s", res.ErrorCode, res.ErrorMessage)
+	}
+	return nil
+}
+
+func (c *Controller) conn() (pbt.TranscoderClient, error) {
+	c.connOnce.Do(func() {
+		conn, err := grpc.NewClient(c.addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			c.connErr = err
+			return
+		}
+		c.client = pbt.NewTranscoderClient(conn)
+	})
+	return c.client, c.connErr
+}
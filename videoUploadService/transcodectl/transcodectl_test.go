@@ -0,0 +1,187 @@
+package transcodectl
+
+import (
+	"VideoUploadService/jobqueue"
+	pbt "VideoUploadService/transcoding"
+	"context"
+	"errors"
+	"net"
+	"testing"
+)
+
+var (
+	alice = Caller{ID: "alice"}
+	bob   = Caller{ID: "bob"}
+	admin = Caller{ID: "ops", Admin: true}
+)
+
+// setup gives the tests a fresh queue, videos owned by alice and a profile
+// of three renditions. The controller's encoder is not listening.
+func setup(t *testing.T) *Controller {
+	t.Helper()
+	savedQueue, savedOwner, savedProfile := jobqueue.Default, Owner, Profile
+	t.Cleanup(func() { jobqueue.Default, Owner, Profile = savedQueue, savedOwner, savedProfile })
+	jobqueue.Default = jobqueue.New(jobqueue.DefaultConfig)
+	Owner = func(videoID string) (string, bool) { return "alice", videoID != "missing" }
+	Profile = func(ctx context.Context, videoID, tier string) (*pbt.EncodingProfile, error) {
+		return &pbt.EncodingProfile{Name: "rebuilt-" + tier, Renditions: []*pbt.RenditionSpec{{Name: "360p"}, {Name: "720p"}, {Name: "1080p"}}}, nil
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+	return NewController(addr)
+}
+
+// enqueue adds a job for v1 and moves it to state.
+func enqueue(t *testing.T, state jobqueue.State) jobqueue.Job {
+	t.Helper()
+	q := jobqueue.Default
+	job := q.Enqueue("v1", "alice", "creator", &pbt.EncodingProfile{Name: "original"})
+	if state == jobqueue.StateQueued {
+		return job
+	}
+	job, ok := q.Lease("worker", 0)
+	if !ok {
+		t.Fatal("no job to lease")
+	}
+	var err error
+	switch state {
+	case jobqueue.StateSucceeded:
+		err = q.Complete(job.ID, job.LeaseID, "master.m3u8")
+	case jobqueue.StateDead:
+		err = q.Fail(job.ID, job.LeaseID, "encoder_failed", "boom", false)
+	case jobqueue.StateCancelled:
+		// The worker stops and ends its attempt.
+		if err = q.Cancel(job.ID); err == nil {
+			err = q.Fail(job.ID, job.LeaseID, "cancelled", "", false)
+		}
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	job, _ = q.Get(job.ID)
+	if job.State != state {
+		t.Fatalf("job is %s, want %s", job.State, state)
+	}
+	return job
+}
+
+func TestRetry(t *testing.T) {
+	tests := []struct {
+		state jobqueue.State
+		want  error
+	}{
+		{jobqueue.StateDead, nil},
+		{jobqueue.StateCancelled, nil},
+		{jobqueue.StateQueued, jobqueue.ErrActive},
+		{jobqueue.StateLeased, jobqueue.ErrActive},
+		{jobqueue.StateSucceeded, ErrNotFailed},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.state), func(t *testing.T) {
+			c := setup(t)
+			job := enqueue(t, tt.state)
+			if err := c.Retry(context.Background(), alice, "v1"); !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+			got, _ := jobqueue.Default.Get(job.ID)
+			if tt.want != nil {
+				if got.State != tt.state || got.Profile.Name != "original" {
+					t.Errorf("refused retry left the job %s with profile %s", got.State, got.Profile.Name)
+				}
+				return
+			}
+			if got.State != jobqueue.StateQueued || got.Profile.Name != "rebuilt-creator" || len(got.Profile.Renditions) != 3 {
+				t.Errorf("retried job is %s with profile %v", got.State, got.Profile)
+			}
+		})
+	}
+}
+
+func TestRetryWithoutJob(t *testing.T) {
+	c := setup(t)
+	saved := Tier
+	Tier = func(string) string { return "premium" }
+	t.Cleanup(func() { Tier = saved })
+	if err := c.Retry(context.Background(), alice, "v1"); err != nil {
+		t.Fatal(err)
+	}
+	job, ok := jobqueue.Default.ForVideo("v1")
+	if !ok || job.State != jobqueue.StateQueued || job.Owner != "alice" || job.Tier != "premium" || job.Profile.Name != "rebuilt-premium" {
+		t.Errorf("queued %+v", job)
+	}
+}
+
+func TestReencode(t *testing.T) {
+	c := setup(t)
+	job := enqueue(t, jobqueue.StateSucceeded)
+
+	if err := c.Reencode(context.Background(), alice, "v1", []string{"720p", "4k"}); !errors.Is(err, ErrUnknownRendition) {
+		t.Errorf("reencode to 4k: err = %v, want ErrUnknownRendition", err)
+	}
+	if err := c.Reencode(context.Background(), alice, "v1", []string{"1080p", "360p", "1080p"}); err != nil {
+		t.Fatal(err)
+	}
+	got, _ := jobqueue.Default.Get(job.ID)
+	var names []string
+	for _, r := range got.Profile.Renditions {
+		names = append(names, r.Name)
+	}
+	if got.State != jobqueue.StateQueued || len(names) != 2 || names[0] != "1080p" || names[1] != "360p" {
+		t.Errorf("reencoding job is %s with renditions %v, want queued with [1080p 360p]", got.State, names)
+	}
+	if err := c.Reencode(context.Background(), alice, "v1", nil); !errors.Is(err, jobqueue.ErrActive) {
+		t.Errorf("reencode while queued: err = %v, want ErrActive", err)
+	}
+}
+
+func TestCancel(t *testing.T) {
+	for _, state := range []jobqueue.State{jobqueue.StateQueued, jobqueue.StateLeased} {
+		t.Run(string(state), func(t *testing.T) {
+			c := setup(t)
+			job := enqueue(t, state)
+			// The encoder cannot be reached; the job is cancelled anyway.
+			if err := c.Cancel(context.Background(), alice, "v1"); err != nil {
+				t.Fatal(err)
+			}
+			if got, _ := jobqueue.Default.Get(job.ID); got.State != jobqueue.StateCancelled {
+				t.Errorf("job is %s, want cancelled", got.State)
+			}
+			if err := c.Cancel(context.Background(), alice, "v1"); !errors.Is(err, ErrNotRunning) {
+				t.Errorf("cancel again: err = %v, want ErrNotRunning", err)
+			}
+			if h := c.History("v1"); len(h) != 2 || h[0].Error != "" || h[1].Error == "" {
+				t.Errorf("history %+v", h)
+			}
+		})
+	}
+}
+
+func TestCallers(t *testing.T) {
+	c := setup(t)
+	enqueue(t, jobqueue.StateDead)
+	ctx := context.Background()
+
+	if err := c.Retry(ctx, bob, "v1"); !errors.Is(err, ErrNotOwner) {
+		t.Errorf("retry by another user: err = %v, want ErrNotOwner", err)
+	}
+	if err := c.Retry(ctx, Caller{}, "v1"); !errors.Is(err, ErrNotOwner) {
+		t.Errorf("anonymous retry: err = %v, want ErrNotOwner", err)
+	}
+	if err := c.Retry(ctx, admin, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("retry of a missing video: err = %v, want ErrNotFound", err)
+	}
+	if err := c.Retry(ctx, admin, "v1"); err != nil {
+		t.Errorf("retry by an admin: err = %v", err)
+	}
+	if err := c.Cancel(ctx, admin, "v1"); err != nil {
+		t.Errorf("cancel by an admin: err = %v", err)
+	}
+	if h := c.History("v1"); len(h) != 2 || h[0].Actor != "ops" {
+		t.Errorf("history %+v, want the admin's two actions", h)
+	}
+}
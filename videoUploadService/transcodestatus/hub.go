@@ -0,0 +1,354 @@
+package transcodestatus
+
+import (
+	pbt "VideoUploadService/transcoding"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+type State string
+
+const (
+	StateInProgress State = "in_progress"
+	StateComplete   State = "complete"
+	StateFailed     State = "failed"
+)
+
+// CodeStreamEnded is the error code of a transcode whose status stream
+// ended before it reported a final stage. Such failures are retried.
+const CodeStreamEnded = "status_stream_ended"
+
+var errStreamEnded = errors.New("encoder ended the status stream without a final stage")
+
+// retention is how long the final state of a video is kept for late subscribers.
+const retention = 10 * time.Minute
+
+// Update is one transcoding progress report for a video.
+type Update struct {
+	VideoID    string      `json:"video_id"`
+	State      State       `json:"state"`
+	Stage      string      `json:"stage,omitempty"`
+	Progress   uint32      `json:"progress"`
+	Renditions []Rendition `json:"renditions,omitempty"`
+	EtaSeconds int64       `json:"eta_seconds,omitempty"`
+	ErrorCode  string      `json:"error_code,omitempty"`
+	Error      string      `json:"error,omitempty"`
+	Manifest   string      `json:"manifest,omitempty"`
+	At         time.Time   `json:"at"`
+}
+
+// Rendition is the progress of a single output rendition.
+type Rendition struct {
+	Name     string `json:"name"`
+	Width    uint32 `json:"width"`
+	Height   uint32 `json:"height"`
+	Stage    string `json:"stage"`
+	Progress uint32 `json:"progress"`
+	Playlist string `json:"playlist,omitempty"`
+}
+
+// Hub is the single reader of the encoder's StatusVideo stream for each video
+// and fans the updates out to any number of subscribers.
+type Hub struct {
+	addr string
+
+	connOnce sync.Once
+	client   pbt.VideoStatusServiceClient
+	connErr  error
+
+	mu       sync.Mutex
+	topics   map[string]*topic
+	onUpdate func(Update)
+}
+
+type topic struct {
+	last     *Update
+	subs     map[chan Update]struct{}
+	done     bool
+	relaying bool
+}
+
+// Default relays status from the local encoder.
+var Default = NewHub("localhost:50051")
+
+func NewHub(addr string) *Hub {
+	return &Hub{addr: addr, topics: make(map[string]*topic)}
+}
+
+// OnUpdate registers fn to be called with every published update. fn is
+// called without the hub lock held.
+func (h *Hub) OnUpdate(fn func(Update)) {
+	h.mu.Lock()
+	h.onUpdate = fn
+	h.mu.Unlock()
+}
+
+// Watch starts relaying the encoder's StatusVideo stream for a video. It is a
+// no-op if the video is already being relayed or has finished.
+func (h *Hub) Watch(videoID string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	t := h.topicLocked(videoID)
+	if t.relaying || t.done {
+		return
+	}
+	t.relaying = true
+	go h.relay(videoID)
+}
+
+// Rewatch starts a fresh relay for a video that is being transcoded again,
+// e.g. after a retry, even if the previous transcode already finished.
+func (h *Hub) Rewatch(videoID string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	t := h.topicLocked(videoID)
+	if t.relaying {
+		return
+	}
+	t.last = nil
+	t.done = false
+	t.relaying = true
+	go h.relay(videoID)
+}
+
+// Report publishes a status reported by a worker directly, for encoders that
+// push progress to us instead of serving StatusVideo.
+func (h *Hub) Report(videoID string, res *pbt.VideoStatusResponse) {
+	u := fromResponse(videoID, res)
+	final := u.State != StateInProgress
+	h.publish(videoID, u, final)
+	if final {
+		h.expire(videoID)
+	}
+}
+
+// topicLocked returns the topic of a video, creating it if needed. h.mu must
+// be held.
+func (h *Hub) topicLocked(videoID string) *topic {
+	t, ok := h.topics[videoID]
+	if !ok {
+		t = &topic{subs: make(map[chan Update]struct{})}
+		h.topics[videoID] = t
+	}
+	return t
+}
+
+// Subscribe returns a channel of updates for the video. The latest known
+// update, if any, is delivered first. The channel only ever holds the newest
+// update, so slow readers skip intermediate progress instead of blocking the
+// relay. The channel is closed once the video reaches a final state; call the
+// returned function to unsubscribe early.
+func (h *Hub) Subscribe(videoID string) (<-chan Update, func()) {
+	ch := make(chan Update, 1)
+	h.mu.Lock()
+	t := h.topicLocked(videoID)
+	if t.last != nil {
+		ch <- *t.last
+	}
+	if t.done {
+		close(ch)
+		h.mu.Unlock()
+		return ch, func() {}
+	}
+	t.subs[ch] = struct{}{}
+	h.mu.Unlock()
+
+	return ch, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if _, ok := t.subs[ch]; ok {
+			delete(t.subs, ch)
+			close(ch)
+		}
+		if len(t.subs) == 0 && !t.relaying && !t.done {
+			time.AfterFunc(retention, func() { h.expireIdle(videoID, t) })
+		}
+	}
+}
+
+// expireIdle forgets a topic nobody listens to that no relay feeds and no
+// worker has reported on for the retention period.
+func (h *Hub) expireIdle(videoID string, t *topic) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.topics[videoID] != t || len(t.subs) > 0 || t.relaying || t.done {
+		return
+	}
+	if t.last != nil && time.Since(t.last.At) < retention {
+		time.AfterFunc(retention-time.Since(t.last.At), func() { h.expireIdle(videoID, t) })
+		return
+	}
+	delete(h.topics, videoID)
+}
+
+// Latest returns the most recent update seen for the video.
+func (h *Hub) Latest(videoID string) (Update, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	t, ok := h.topics[videoID]
+	if !ok || t.last == nil {
+		return Update{}, false
+	}
+	return *t.last, true
+}
+
+func (h *Hub) conn() (pbt.VideoStatusServiceClient, error) {
+	h.connOnce.Do(func() {
+		conn, err := grpc.NewClient(h.addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			h.connErr = err
+			return
+		}
+		h.client = pbt.NewVideoStatusServiceClient(conn)
+	})
+	return h.client, h.connErr
+}
+
+func (h *Hub) relay(videoID string) {
+	client, err := h.conn()
+	if err != nil {
+		h.finish(videoID, StateFailed, "", err)
+		return
+	}
+
+	stream, err := client.StatusVideo(context.Background(), &pbt.VideoUuidRequest{Uuid: videoID})
+	if err != nil {
+		log.Printf("StatusVideo %s: %v", videoID, err)
+		h.finish(videoID, StateFailed, "", err)
+		return
+	}
+
+	for {
+		res, err := stream.Recv()
+		if err == io.EOF {
+			// Without a final stage there is no telling whether the output
+			// is complete; report a failure so the job is retried.
+			h.finish(videoID, StateFailed, CodeStreamEnded, errStreamEnded)
+			return
+		}
+		if err != nil {
+			log.Printf("StatusVideo %s: %v", videoID, err)
+			h.finish(videoID, StateFailed, "", err)
+			return
+		}
+
+		u := fromResponse(videoID, res)
+		if u.State != StateInProgress {
+			h.publish(videoID, u, true)
+			h.expire(videoID)
+			return
+		}
+		h.publish(videoID, u, false)
+	}
+}
+
+// fromResponse converts an encoder status message into an Update.
+func fromResponse(videoID string, res *pbt.VideoStatusResponse) Update {
+	u := Update{
+		VideoID:    videoID,
+		State:      StateInProgress,
+		Stage:      StageName(res.Stage),
+		Progress:   res.Status,
+		EtaSeconds: res.EtaSeconds,
+		ErrorCode:  res.ErrorCode,
+		Error:      res.ErrorMessage,
+		Manifest:   res.ManifestLocation,
+		At:         time.Now(),
+	}
+	switch res.Stage {
+	case pbt.TranscodeStage_TRANSCODE_STAGE_COMPLETE:
+		u.State = StateComplete
+		u.Progress = 100
+	case pbt.TranscodeStage_TRANSCODE_STAGE_FAILED:
+		u.State = StateFailed
+	}
+	for _, r := range res.Renditions {
+		u.Renditions = append(u.Renditions, Rendition{
+			Name:     r.Name,
+			Width:    r.Width,
+			Height:   r.Height,
+			Stage:    StageName(r.Stage),
+			Progress: r.Progress,
+			Playlist: r.PlaylistLocation,
+		})
+	}
+	return u
+}
+
+// StageName returns the lower-case name of a stage, e.g. "encoding", or an
+// empty string when the encoder did not report one.
+func StageName(stage pbt.TranscodeStage) string {
+	if stage == pbt.TranscodeStage_TRANSCODE_STAGE_UNSPECIFIED {
+		return ""
+	}
+	return strings.ToLower(strings.TrimPrefix(stage.String(), "TRANSCODE_STAGE_"))
+}
+
+func (h *Hub) finish(videoID string, state State, code string, err error) {
+	u := Update{VideoID: videoID, State: state, Stage: string(state), ErrorCode: code, At: time.Now()}
+	if last, ok := h.Latest(videoID); ok {
+		u.Progress = last.Progress
+		u.Renditions = last.Renditions
+		u.Manifest = last.Manifest
+	}
+	if state == StateComplete {
+		u.Progress = 100
+	}
+	if err != nil {
+		u.Error = err.Error()
+	}
+	h.publish(videoID, u, true)
+	h.expire(videoID)
+}
+
+// expire forgets a finished video after the retention period, unless it has
+// been transcoded again in the meantime.
+func (h *Hub) expire(videoID string) {
+	time.AfterFunc(retention, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if t, ok := h.topics[videoID]; ok && t.done {
+			delete(h.topics, videoID)
+		}
+	})
+}
+
+func (h *Hub) publish(videoID string, u Update, final bool) {
+	h.mu.Lock()
+	h.publishLocked(videoID, u, final)
+	fn := h.onUpdate
+	h.mu.Unlock()
+
+	if fn != nil {
+		fn(u)
+	}
+}
+
+func (h *Hub) publishLocked(videoID string, u Update, final bool) {
+	t := h.topicLocked(videoID)
+	t.last = &u
+	t.done = final
+	for ch := range t.subs {
+		// Replace a pending update nobody has read yet with the newer one.
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- u
+		if final {
+			close(ch)
+		}
+	}
+	if final {
+		t.relaying = false
+		t.subs = make(map[chan Update]struct{})
+	}
+}
@@ -0,0 +1,84 @@
+package transcodestatus
+
+import (
+	pbt "VideoUploadService/transcoding"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+func TestExpireIdle(t *testing.T) {
+	h := NewHub("")
+	_, unsubscribe := h.Subscribe("unknown")
+	unsubscribe()
+	h.expireIdle("unknown", h.topics["unknown"])
+	if _, ok := h.topics["unknown"]; ok {
+		t.Error("idle topic without updates was kept")
+	}
+
+	h.Subscribe("watched")
+	h.expireIdle("watched", h.topics["watched"])
+	if _, ok := h.topics["watched"]; !ok {
+		t.Error("topic with a subscriber was expired")
+	}
+
+	h.publish("reported", Update{VideoID: "reported", State: StateInProgress, At: time.Now()}, false)
+	h.expireIdle("reported", h.topics["reported"])
+	if _, ok := h.topics["reported"]; !ok {
+		t.Error("topic with a recent report was expired")
+	}
+	h.topics["reported"].last.At = time.Now().Add(-2 * retention)
+	h.expireIdle("reported", h.topics["reported"])
+	if _, ok := h.topics["reported"]; ok {
+		t.Error("topic without reports for the retention period was kept")
+	}
+}
+
+// endingEncoder reports some progress and ends the stream without a final
+// stage.
+type endingEncoder struct {
+	pbt.UnimplementedVideoStatusServiceServer
+}
+
+func (endingEncoder) StatusVideo(req *pbt.VideoUuidRequest, stream pbt.VideoStatusService_StatusVideoServer) error {
+	return stream.Send(&pbt.VideoStatusResponse{Status: 40, Stage: pbt.TranscodeStage_TRANSCODE_STAGE_ENCODING})
+}
+
+func TestRelayEndedWithoutFinalStage(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer()
+	pbt.RegisterVideoStatusServiceServer(srv, endingEncoder{})
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	h := NewHub(lis.Addr().String())
+	updates, unsubscribe := h.Subscribe("v1")
+	defer unsubscribe()
+	h.Watch("v1")
+
+	var last Update
+	timeout := time.After(5 * time.Second)
+	for done := false; !done; {
+		select {
+		case u, ok := <-updates:
+			if !ok {
+				done = true
+				break
+			}
+			last = u
+		case <-timeout:
+			t.Fatal("relay did not finish")
+		}
+	}
+	if last.State != StateFailed || last.ErrorCode != CodeStreamEnded {
+		t.Errorf("final update = %s/%q, want %s/%q", last.State, last.ErrorCode, StateFailed, CodeStreamEnded)
+	}
+	if last.Progress != 40 {
+		t.Errorf("progress = %d, want the last reported 40", last.Progress)
+	}
+}
@@ -0,0 +1,101 @@
+package transcodestatus
+
+import (
+	"VideoUploadService/identity"
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/websocket/v2"
+)
+
+// keepalive is how often an idle event stream is written to, so that
+// connections of clients that went away are noticed and closed.
+const keepalive = 15 * time.Second
+
+// Owner returns the owner of a known video. It is set up in main; without
+// it no progress can be watched over HTTP.
+var Owner = func(videoID string) (string, bool) { return "", false }
+
+func SetupRoutes(app *fiber.App) {
+	app.Get("/videos/:id/transcode/events", requireOwner, handleEvents)
+	app.Get("/videos/:id/transcode/ws", requireOwner, websocket.New(handleWebSocket))
+}
+
+// requireOwner only lets the owner of a known video or an admin watch its
+// progress.
+func requireOwner(c *fiber.Ctx) error {
+	caller := identity.FromFiber(c)
+	admin := identity.IsAdminFiber(c)
+	if caller == "" && !admin {
+		return c.Status(401).SendString("Authentication required")
+	}
+	owner, ok := Owner(c.Params("id"))
+	if !ok {
+		return c.Status(404).SendString("Video not found")
+	}
+	if !admin && caller != owner {
+		return c.Status(403).SendString("not allowed to watch this video")
+	}
+	return c.Next()
+}
+
+// handleEvents streams transcoding progress as server-sent events.
+func handleEvents(c *fiber.Ctx) error {
+	updates, unsubscribe := Default.Subscribe(c.Params("id"))
+
+	c.Set("Content-Type", "text/event-stream")
+	c.Set("Cache-Control", "no-cache")
+	c.Set("Connection", "keep-alive")
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer unsubscribe()
+		ticker := time.NewTicker(keepalive)
+		defer ticker.Stop()
+		for {
+			select {
+			case u, ok := <-updates:
+				if !ok {
+					return
+				}
+				data, _ := json.Marshal(u)
+				fmt.Fprintf(w, "data: %s\n\n", data)
+			case <-ticker.C:
+				fmt.Fprint(w, ": keepalive\n\n")
+			}
+			if err := w.Flush(); err != nil {
+				// Client went away.
+				return
+			}
+		}
+	})
+	return nil
+}
+
+// handleWebSocket streams transcoding progress as JSON text messages.
+func handleWebSocket(c *websocket.Conn) {
+	updates, unsubscribe := Default.Subscribe(c.Params("id"))
+	defer unsubscribe()
+
+	ticker := time.NewTicker(keepalive)
+	defer ticker.Stop()
+loop:
+	for {
+		select {
+		case u, ok := <-updates:
+			if !ok {
+				break loop
+			}
+			if err := c.WriteJSON(u); err != nil {
+				break loop
+			}
+		case <-ticker.C:
+			if err := c.WriteMessage(websocket.PingMessage, nil); err != nil {
+				break loop
+			}
+		}
+	}
+	c.WriteMessage(websocket.CloseMessage, []byte{})
+	c.Close()
+}
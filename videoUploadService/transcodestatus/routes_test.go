@@ -0,0 +1,48 @@
+package transcodestatus
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestRequireOwner(t *testing.T) {
+	Owner = func(videoID string) (string, bool) {
+		return "alice", videoID == "v1"
+	}
+	defer func() { Owner = func(string) (string, bool) { return "", false } }()
+
+	app := fiber.New()
+	app.Get("/videos/:id/transcode/events", requireOwner, func(c *fiber.Ctx) error {
+		return c.SendStatus(200)
+	})
+	tests := []struct {
+		name, video, user, role string
+		want                    int
+	}{
+		{"anonymous", "v1", "", "", 401},
+		{"unknown video", "v2", "alice", "", 404},
+		{"other user", "v1", "bob", "", 403},
+		{"owner", "v1", "alice", "", 200},
+		{"admin", "v1", "", "admin", 200},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/videos/"+tt.video+"/transcode/events", nil)
+			if tt.user != "" {
+				req.Header.Set("x-user-id", tt.user)
+			}
+			if tt.role != "" {
+				req.Header.Set("x-user-role", tt.role)
+			}
+			res, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.StatusCode != tt.want {
+				t.Errorf("status = %d, want %d", res.StatusCode, tt.want)
+			}
+		})
+	}
+}
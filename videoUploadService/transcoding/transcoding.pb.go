@@ -20,31 +20,1432 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type TranscodeStage int32
+
+const (
+	TranscodeStage_TRANSCODE_STAGE_UNSPECIFIED TranscodeStage = 0
+	TranscodeStage_TRANSCODE_STAGE_PROBING     TranscodeStage = 1
+	TranscodeStage_TRANSCODE_STAGE_ENCODING    TranscodeStage = 2
+	TranscodeStage_TRANSCODE_STAGE_PACKAGING   TranscodeStage = 3
+	TranscodeStage_TRANSCODE_STAGE_COMPLETE    TranscodeStage = 4
+	TranscodeStage_TRANSCODE_STAGE_FAILED      TranscodeStage = 5
+)
+
+// Enum value maps for TranscodeStage.
+var (
+	TranscodeStage_name = map[int32]string{
+		0: "TRANSCODE_STAGE_UNSPECIFIED",
+		1: "TRANSCODE_STAGE_PROBING",
+		2: "TRANSCODE_STAGE_ENCODING",
+		3: "TRANSCODE_STAGE_PACKAGING",
+		4: "TRANSCODE_STAGE_COMPLETE",
+		5: "TRANSCODE_STAGE_FAILED",
+	}
+	TranscodeStage_value = map[string]int32{
+		"TRANSCODE_STAGE_UNSPECIFIED": 0,
+		"TRANSCODE_STAGE_PROBING":     1,
+		"TRANSCODE_STAGE_ENCODING":    2,
+		"TRANSCODE_STAGE_PACKAGING":   3,
+		"TRANSCODE_STAGE_COMPLETE":    4,
+		"TRANSCODE_STAGE_FAILED":      5,
+	}
+)
+
+func (x TranscodeStage) Enum() *TranscodeStage {
+	p := new(TranscodeStage)
+	*p = x
+	return p
+}
+
+func (x TranscodeStage) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (TranscodeStage) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_transcoding_proto_enumTypes[0].Descriptor()
+}
+
+func (TranscodeStage) Type() protoreflect.EnumType {
+	return &file_proto_transcoding_proto_enumTypes[0]
+}
+
+func (x TranscodeStage) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use TranscodeStage.Descriptor instead.
+func (TranscodeStage) EnumDescriptor() ([]byte, []int) {
+	return file_proto_transcoding_proto_rawDescGZIP(), []int{0}
+}
+
 type TranscodeResponse struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
-
-	StatusCode uint32 `protobuf:"varint,1,opt,name=status_code,json=statusCode,proto3" json:"status_code,omitempty"`
+
+	StatusCode   uint32         `protobuf:"varint,1,opt,name=status_code,json=statusCode,proto3" json:"status_code,omitempty"`
+	Stage        TranscodeStage `protobuf:"varint,2,opt,name=stage,proto3,enum=transcoding.TranscodeStage" json:"stage,omitempty"`
+	ErrorCode    string         `protobuf:"bytes,3,opt,name=error_code,json=errorCode,proto3" json:"error_code,omitempty"`
+	ErrorMessage string         `protobuf:"bytes,4,opt,name=error_message,json=errorMessage,proto3" json:"error_message,omitempty"`
+}
+
+func (x *TranscodeResponse) Reset() {
+	*x = TranscodeResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_transcoding_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TranscodeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TranscodeResponse) ProtoMessage() {}
+
+func (x *TranscodeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_transcoding_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TranscodeResponse.ProtoReflect.Descriptor instead.
+func (*TranscodeResponse) Descriptor() ([]byte, []int) {
+	return file_proto_transcoding_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *TranscodeResponse) GetStatusCode() uint32 {
+	if x != nil {
+		return x.StatusCode
+	}
+	return 0
+}
+
+func (x *TranscodeResponse) GetStage() TranscodeStage {
+	if x != nil {
+		return x.Stage
+	}
+	return TranscodeStage_TRANSCODE_STAGE_UNSPECIFIED
+}
+
+func (x *TranscodeResponse) GetErrorCode() string {
+	if x != nil {
+		return x.ErrorCode
+	}
+	return ""
+}
+
+func (x *TranscodeResponse) GetErrorMessage() string {
+	if x != nil {
+		return x.ErrorMessage
+	}
+	return ""
+}
+
+type VideoUuidRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Uuid string `protobuf:"bytes,1,opt,name=uuid,proto3" json:"uuid,omitempty"`
+}
+
+func (x *VideoUuidRequest) Reset() {
+	*x = VideoUuidRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_transcoding_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *VideoUuidRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VideoUuidRequest) ProtoMessage() {}
+
+func (x *VideoUuidRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_transcoding_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VideoUuidRequest.ProtoReflect.Descriptor instead.
+func (*VideoUuidRequest) Descriptor() ([]byte, []int) {
+	return file_proto_transcoding_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *VideoUuidRequest) GetUuid() string {
+	if x != nil {
+		return x.Uuid
+	}
+	return ""
+}
+
+// UploadCompleteRequest shares field numbers with VideoUuidRequest so that
+// encoders built against the old request keep reading the uuid.
+type UploadCompleteRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Uuid    string           `protobuf:"bytes,1,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	Profile *EncodingProfile `protobuf:"bytes,2,opt,name=profile,proto3" json:"profile,omitempty"`
+	// The job and lease a pushed upload is transcoded under. Encoders present
+	// them to FetchContentKeys when the profile asks for encryption.
+	JobId   string `protobuf:"bytes,3,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	LeaseId string `protobuf:"bytes,4,opt,name=lease_id,json=leaseId,proto3" json:"lease_id,omitempty"`
+}
+
+func (x *UploadCompleteRequest) Reset() {
+	*x = UploadCompleteRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_transcoding_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *UploadCompleteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UploadCompleteRequest) ProtoMessage() {}
+
+func (x *UploadCompleteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_transcoding_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UploadCompleteRequest.ProtoReflect.Descriptor instead.
+func (*UploadCompleteRequest) Descriptor() ([]byte, []int) {
+	return file_proto_transcoding_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *UploadCompleteRequest) GetUuid() string {
+	if x != nil {
+		return x.Uuid
+	}
+	return ""
+}
+
+func (x *UploadCompleteRequest) GetProfile() *EncodingProfile {
+	if x != nil {
+		return x.Profile
+	}
+	return nil
+}
+
+func (x *UploadCompleteRequest) GetJobId() string {
+	if x != nil {
+		return x.JobId
+	}
+	return ""
+}
+
+func (x *UploadCompleteRequest) GetLeaseId() string {
+	if x != nil {
+		return x.LeaseId
+	}
+	return ""
+}
+
+type EncodingProfile struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Name                   string           `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Renditions             []*RenditionSpec `protobuf:"bytes,2,rep,name=renditions,proto3" json:"renditions,omitempty"`
+	VideoCodec             string           `protobuf:"bytes,3,opt,name=video_codec,json=videoCodec,proto3" json:"video_codec,omitempty"`
+	SegmentDurationSeconds uint32           `protobuf:"varint,4,opt,name=segment_duration_seconds,json=segmentDurationSeconds,proto3" json:"segment_duration_seconds,omitempty"`
+	Audio                  *AudioSettings   `protobuf:"bytes,5,opt,name=audio,proto3" json:"audio,omitempty"`
+	// Set when segments must be encrypted.
+	Encryption *HlsEncryption `protobuf:"bytes,6,opt,name=encryption,proto3" json:"encryption,omitempty"`
+}
+
+func (x *EncodingProfile) Reset() {
+	*x = EncodingProfile{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_transcoding_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *EncodingProfile) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EncodingProfile) ProtoMessage() {}
+
+func (x *EncodingProfile) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_transcoding_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EncodingProfile.ProtoReflect.Descriptor instead.
+func (*EncodingProfile) Descriptor() ([]byte, []int) {
+	return file_proto_transcoding_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *EncodingProfile) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *EncodingProfile) GetRenditions() []*RenditionSpec {
+	if x != nil {
+		return x.Renditions
+	}
+	return nil
+}
+
+func (x *EncodingProfile) GetVideoCodec() string {
+	if x != nil {
+		return x.VideoCodec
+	}
+	return ""
+}
+
+func (x *EncodingProfile) GetSegmentDurationSeconds() uint32 {
+	if x != nil {
+		return x.SegmentDurationSeconds
+	}
+	return 0
+}
+
+func (x *EncodingProfile) GetAudio() *AudioSettings {
+	if x != nil {
+		return x.Audio
+	}
+	return nil
+}
+
+func (x *EncodingProfile) GetEncryption() *HlsEncryption {
+	if x != nil {
+		return x.Encryption
+	}
+	return nil
+}
+
+// HlsEncryption asks the encoder to encrypt HLS segments with AES-128. A new
+// key starts every rotation_segments segments, so keys[i] covers segments
+// i*rotation_segments up to (i+1)*rotation_segments. The last key is kept for
+// any segments beyond that. Keys are only referenced here; workers fetch the
+// key material with FetchContentKeys. Encoders that cannot encrypt must fail
+// the job rather than produce clear segments.
+type HlsEncryption struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Method           string        `protobuf:"bytes,1,opt,name=method,proto3" json:"method,omitempty"`
+	RotationSegments uint32        `protobuf:"varint,2,opt,name=rotation_segments,json=rotationSegments,proto3" json:"rotation_segments,omitempty"`
+	Keys             []*ContentKey `protobuf:"bytes,3,rep,name=keys,proto3" json:"keys,omitempty"`
+}
+
+func (x *HlsEncryption) Reset() {
+	*x = HlsEncryption{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_transcoding_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *HlsEncryption) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HlsEncryption) ProtoMessage() {}
+
+func (x *HlsEncryption) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_transcoding_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HlsEncryption.ProtoReflect.Descriptor instead.
+func (*HlsEncryption) Descriptor() ([]byte, []int) {
+	return file_proto_transcoding_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *HlsEncryption) GetMethod() string {
+	if x != nil {
+		return x.Method
+	}
+	return ""
+}
+
+func (x *HlsEncryption) GetRotationSegments() uint32 {
+	if x != nil {
+		return x.RotationSegments
+	}
+	return 0
+}
+
+func (x *HlsEncryption) GetKeys() []*ContentKey {
+	if x != nil {
+		return x.Keys
+	}
+	return nil
+}
+
+type ContentKey struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Index uint32 `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`
+	// Written verbatim as the URI of the EXT-X-KEY tag.
+	Uri string `protobuf:"bytes,4,opt,name=uri,proto3" json:"uri,omitempty"`
+	// Identifies the key in CENC-packaged output.
+	Kid []byte `protobuf:"bytes,5,opt,name=kid,proto3" json:"kid,omitempty"`
+}
+
+func (x *ContentKey) Reset() {
+	*x = ContentKey{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_transcoding_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ContentKey) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ContentKey) ProtoMessage() {}
+
+func (x *ContentKey) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_transcoding_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ContentKey.ProtoReflect.Descriptor instead.
+func (*ContentKey) Descriptor() ([]byte, []int) {
+	return file_proto_transcoding_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ContentKey) GetIndex() uint32 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *ContentKey) GetUri() string {
+	if x != nil {
+		return x.Uri
+	}
+	return ""
+}
+
+func (x *ContentKey) GetKid() []byte {
+	if x != nil {
+		return x.Kid
+	}
+	return nil
+}
+
+// ContentKeyMaterial is the secret part of a ContentKey.
+type ContentKeyMaterial struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Index uint32 `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`
+	Key   []byte `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	Iv    []byte `protobuf:"bytes,3,opt,name=iv,proto3" json:"iv,omitempty"`
+}
+
+func (x *ContentKeyMaterial) Reset() {
+	*x = ContentKeyMaterial{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_transcoding_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ContentKeyMaterial) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ContentKeyMaterial) ProtoMessage() {}
+
+func (x *ContentKeyMaterial) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_transcoding_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ContentKeyMaterial.ProtoReflect.Descriptor instead.
+func (*ContentKeyMaterial) Descriptor() ([]byte, []int) {
+	return file_proto_transcoding_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ContentKeyMaterial) GetIndex() uint32 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *ContentKeyMaterial) GetKey() []byte {
+	if x != nil {
+		return x.Key
+	}
+	return nil
+}
+
+func (x *ContentKeyMaterial) GetIv() []byte {
+	if x != nil {
+		return x.Iv
+	}
+	return nil
+}
+
+type RenditionSpec struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Name             string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Width            uint32 `protobuf:"varint,2,opt,name=width,proto3" json:"width,omitempty"`
+	Height           uint32 `protobuf:"varint,3,opt,name=height,proto3" json:"height,omitempty"`
+	VideoBitrateKbps uint32 `protobuf:"varint,4,opt,name=video_bitrate_kbps,json=videoBitrateKbps,proto3" json:"video_bitrate_kbps,omitempty"`
+	MaxBitrateKbps   uint32 `protobuf:"varint,5,opt,name=max_bitrate_kbps,json=maxBitrateKbps,proto3" json:"max_bitrate_kbps,omitempty"`
+	FrameRate        uint32 `protobuf:"varint,6,opt,name=frame_rate,json=frameRate,proto3" json:"frame_rate,omitempty"`
+}
+
+func (x *RenditionSpec) Reset() {
+	*x = RenditionSpec{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_transcoding_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RenditionSpec) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RenditionSpec) ProtoMessage() {}
+
+func (x *RenditionSpec) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_transcoding_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RenditionSpec.ProtoReflect.Descriptor instead.
+func (*RenditionSpec) Descriptor() ([]byte, []int) {
+	return file_proto_transcoding_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *RenditionSpec) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *RenditionSpec) GetWidth() uint32 {
+	if x != nil {
+		return x.Width
+	}
+	return 0
+}
+
+func (x *RenditionSpec) GetHeight() uint32 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *RenditionSpec) GetVideoBitrateKbps() uint32 {
+	if x != nil {
+		return x.VideoBitrateKbps
+	}
+	return 0
+}
+
+func (x *RenditionSpec) GetMaxBitrateKbps() uint32 {
+	if x != nil {
+		return x.MaxBitrateKbps
+	}
+	return 0
+}
+
+func (x *RenditionSpec) GetFrameRate() uint32 {
+	if x != nil {
+		return x.FrameRate
+	}
+	return 0
+}
+
+type AudioSettings struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Codec       string `protobuf:"bytes,1,opt,name=codec,proto3" json:"codec,omitempty"`
+	BitrateKbps uint32 `protobuf:"varint,2,opt,name=bitrate_kbps,json=bitrateKbps,proto3" json:"bitrate_kbps,omitempty"`
+	SampleRate  uint32 `protobuf:"varint,3,opt,name=sample_rate,json=sampleRate,proto3" json:"sample_rate,omitempty"`
+	Channels    uint32 `protobuf:"varint,4,opt,name=channels,proto3" json:"channels,omitempty"`
+}
+
+func (x *AudioSettings) Reset() {
+	*x = AudioSettings{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_transcoding_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AudioSettings) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AudioSettings) ProtoMessage() {}
+
+func (x *AudioSettings) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_transcoding_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AudioSettings.ProtoReflect.Descriptor instead.
+func (*AudioSettings) Descriptor() ([]byte, []int) {
+	return file_proto_transcoding_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *AudioSettings) GetCodec() string {
+	if x != nil {
+		return x.Codec
+	}
+	return ""
+}
+
+func (x *AudioSettings) GetBitrateKbps() uint32 {
+	if x != nil {
+		return x.BitrateKbps
+	}
+	return 0
+}
+
+func (x *AudioSettings) GetSampleRate() uint32 {
+	if x != nil {
+		return x.SampleRate
+	}
+	return 0
+}
+
+func (x *AudioSettings) GetChannels() uint32 {
+	if x != nil {
+		return x.Channels
+	}
+	return 0
+}
+
+type ReencodeRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Uuid string `protobuf:"bytes,1,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	// Rendition names to produce, e.g. "720p". Empty means the default ladder.
+	Renditions []string `protobuf:"bytes,2,rep,name=renditions,proto3" json:"renditions,omitempty"`
+}
+
+func (x *ReencodeRequest) Reset() {
+	*x = ReencodeRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_transcoding_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ReencodeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReencodeRequest) ProtoMessage() {}
+
+func (x *ReencodeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_transcoding_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReencodeRequest.ProtoReflect.Descriptor instead.
+func (*ReencodeRequest) Descriptor() ([]byte, []int) {
+	return file_proto_transcoding_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ReencodeRequest) GetUuid() string {
+	if x != nil {
+		return x.Uuid
+	}
+	return ""
+}
+
+func (x *ReencodeRequest) GetRenditions() []string {
+	if x != nil {
+		return x.Renditions
+	}
+	return nil
+}
+
+type RenditionProgress struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Name             string         `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Width            uint32         `protobuf:"varint,2,opt,name=width,proto3" json:"width,omitempty"`
+	Height           uint32         `protobuf:"varint,3,opt,name=height,proto3" json:"height,omitempty"`
+	Stage            TranscodeStage `protobuf:"varint,4,opt,name=stage,proto3,enum=transcoding.TranscodeStage" json:"stage,omitempty"`
+	Progress         uint32         `protobuf:"varint,5,opt,name=progress,proto3" json:"progress,omitempty"`
+	PlaylistLocation string         `protobuf:"bytes,6,opt,name=playlist_location,json=playlistLocation,proto3" json:"playlist_location,omitempty"`
+}
+
+func (x *RenditionProgress) Reset() {
+	*x = RenditionProgress{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_transcoding_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RenditionProgress) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RenditionProgress) ProtoMessage() {}
+
+func (x *RenditionProgress) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_transcoding_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RenditionProgress.ProtoReflect.Descriptor instead.
+func (*RenditionProgress) Descriptor() ([]byte, []int) {
+	return file_proto_transcoding_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *RenditionProgress) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *RenditionProgress) GetWidth() uint32 {
+	if x != nil {
+		return x.Width
+	}
+	return 0
+}
+
+func (x *RenditionProgress) GetHeight() uint32 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *RenditionProgress) GetStage() TranscodeStage {
+	if x != nil {
+		return x.Stage
+	}
+	return TranscodeStage_TRANSCODE_STAGE_UNSPECIFIED
+}
+
+func (x *RenditionProgress) GetProgress() uint32 {
+	if x != nil {
+		return x.Progress
+	}
+	return 0
+}
+
+func (x *RenditionProgress) GetPlaylistLocation() string {
+	if x != nil {
+		return x.PlaylistLocation
+	}
+	return ""
+}
+
+type VideoStatusResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Overall progress in percent. Older encoders only set this field.
+	Status           uint32               `protobuf:"varint,1,opt,name=status,proto3" json:"status,omitempty"`
+	Stage            TranscodeStage       `protobuf:"varint,2,opt,name=stage,proto3,enum=transcoding.TranscodeStage" json:"stage,omitempty"`
+	Renditions       []*RenditionProgress `protobuf:"bytes,3,rep,name=renditions,proto3" json:"renditions,omitempty"`
+	EtaSeconds       int64                `protobuf:"varint,4,opt,name=eta_seconds,json=etaSeconds,proto3" json:"eta_seconds,omitempty"`
+	ErrorCode        string               `protobuf:"bytes,5,opt,name=error_code,json=errorCode,proto3" json:"error_code,omitempty"`
+	ErrorMessage     string               `protobuf:"bytes,6,opt,name=error_message,json=errorMessage,proto3" json:"error_message,omitempty"`
+	ManifestLocation string               `protobuf:"bytes,7,opt,name=manifest_location,json=manifestLocation,proto3" json:"manifest_location,omitempty"`
+}
+
+func (x *VideoStatusResponse) Reset() {
+	*x = VideoStatusResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_transcoding_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *VideoStatusResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VideoStatusResponse) ProtoMessage() {}
+
+func (x *VideoStatusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_transcoding_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VideoStatusResponse.ProtoReflect.Descriptor instead.
+func (*VideoStatusResponse) Descriptor() ([]byte, []int) {
+	return file_proto_transcoding_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *VideoStatusResponse) GetStatus() uint32 {
+	if x != nil {
+		return x.Status
+	}
+	return 0
+}
+
+func (x *VideoStatusResponse) GetStage() TranscodeStage {
+	if x != nil {
+		return x.Stage
+	}
+	return TranscodeStage_TRANSCODE_STAGE_UNSPECIFIED
+}
+
+func (x *VideoStatusResponse) GetRenditions() []*RenditionProgress {
+	if x != nil {
+		return x.Renditions
+	}
+	return nil
+}
+
+func (x *VideoStatusResponse) GetEtaSeconds() int64 {
+	if x != nil {
+		return x.EtaSeconds
+	}
+	return 0
+}
+
+func (x *VideoStatusResponse) GetErrorCode() string {
+	if x != nil {
+		return x.ErrorCode
+	}
+	return ""
+}
+
+func (x *VideoStatusResponse) GetErrorMessage() string {
+	if x != nil {
+		return x.ErrorMessage
+	}
+	return ""
+}
+
+func (x *VideoStatusResponse) GetManifestLocation() string {
+	if x != nil {
+		return x.ManifestLocation
+	}
+	return ""
+}
+
+type TranscodeJob struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	JobId    string           `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	Uuid     string           `protobuf:"bytes,2,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	Profile  *EncodingProfile `protobuf:"bytes,3,opt,name=profile,proto3" json:"profile,omitempty"`
+	Attempt  uint32           `protobuf:"varint,4,opt,name=attempt,proto3" json:"attempt,omitempty"`
+	Priority int32            `protobuf:"varint,5,opt,name=priority,proto3" json:"priority,omitempty"`
+}
+
+func (x *TranscodeJob) Reset() {
+	*x = TranscodeJob{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_transcoding_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TranscodeJob) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TranscodeJob) ProtoMessage() {}
+
+func (x *TranscodeJob) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_transcoding_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TranscodeJob.ProtoReflect.Descriptor instead.
+func (*TranscodeJob) Descriptor() ([]byte, []int) {
+	return file_proto_transcoding_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *TranscodeJob) GetJobId() string {
+	if x != nil {
+		return x.JobId
+	}
+	return ""
+}
+
+func (x *TranscodeJob) GetUuid() string {
+	if x != nil {
+		return x.Uuid
+	}
+	return ""
+}
+
+func (x *TranscodeJob) GetProfile() *EncodingProfile {
+	if x != nil {
+		return x.Profile
+	}
+	return nil
+}
+
+func (x *TranscodeJob) GetAttempt() uint32 {
+	if x != nil {
+		return x.Attempt
+	}
+	return 0
+}
+
+func (x *TranscodeJob) GetPriority() int32 {
+	if x != nil {
+		return x.Priority
+	}
+	return 0
+}
+
+type LeaseJobRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	WorkerId string `protobuf:"bytes,1,opt,name=worker_id,json=workerId,proto3" json:"worker_id,omitempty"`
+	// How long the job stays invisible to other workers without a heartbeat.
+	// Zero uses the queue default.
+	VisibilityTimeoutSeconds uint32 `protobuf:"varint,2,opt,name=visibility_timeout_seconds,json=visibilityTimeoutSeconds,proto3" json:"visibility_timeout_seconds,omitempty"`
+}
+
+func (x *LeaseJobRequest) Reset() {
+	*x = LeaseJobRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_transcoding_proto_msgTypes[13]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *LeaseJobRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LeaseJobRequest) ProtoMessage() {}
+
+func (x *LeaseJobRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_transcoding_proto_msgTypes[13]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LeaseJobRequest.ProtoReflect.Descriptor instead.
+func (*LeaseJobRequest) Descriptor() ([]byte, []int) {
+	return file_proto_transcoding_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *LeaseJobRequest) GetWorkerId() string {
+	if x != nil {
+		return x.WorkerId
+	}
+	return ""
+}
+
+func (x *LeaseJobRequest) GetVisibilityTimeoutSeconds() uint32 {
+	if x != nil {
+		return x.VisibilityTimeoutSeconds
+	}
+	return 0
+}
+
+type LeaseJobResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Unset when no job is available.
+	Job            *TranscodeJob `protobuf:"bytes,1,opt,name=job,proto3" json:"job,omitempty"`
+	LeaseId        string        `protobuf:"bytes,2,opt,name=lease_id,json=leaseId,proto3" json:"lease_id,omitempty"`
+	LeaseExpiresAt int64         `protobuf:"varint,3,opt,name=lease_expires_at,json=leaseExpiresAt,proto3" json:"lease_expires_at,omitempty"`
+}
+
+func (x *LeaseJobResponse) Reset() {
+	*x = LeaseJobResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_transcoding_proto_msgTypes[14]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *LeaseJobResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LeaseJobResponse) ProtoMessage() {}
+
+func (x *LeaseJobResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_transcoding_proto_msgTypes[14]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LeaseJobResponse.ProtoReflect.Descriptor instead.
+func (*LeaseJobResponse) Descriptor() ([]byte, []int) {
+	return file_proto_transcoding_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *LeaseJobResponse) GetJob() *TranscodeJob {
+	if x != nil {
+		return x.Job
+	}
+	return nil
+}
+
+func (x *LeaseJobResponse) GetLeaseId() string {
+	if x != nil {
+		return x.LeaseId
+	}
+	return ""
+}
+
+func (x *LeaseJobResponse) GetLeaseExpiresAt() int64 {
+	if x != nil {
+		return x.LeaseExpiresAt
+	}
+	return 0
+}
+
+type JobHeartbeatRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	JobId         string               `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	LeaseId       string               `protobuf:"bytes,2,opt,name=lease_id,json=leaseId,proto3" json:"lease_id,omitempty"`
+	ExtendSeconds uint32               `protobuf:"varint,3,opt,name=extend_seconds,json=extendSeconds,proto3" json:"extend_seconds,omitempty"`
+	Status        *VideoStatusResponse `protobuf:"bytes,4,opt,name=status,proto3" json:"status,omitempty"`
+}
+
+func (x *JobHeartbeatRequest) Reset() {
+	*x = JobHeartbeatRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_transcoding_proto_msgTypes[15]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *JobHeartbeatRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JobHeartbeatRequest) ProtoMessage() {}
+
+func (x *JobHeartbeatRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_transcoding_proto_msgTypes[15]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JobHeartbeatRequest.ProtoReflect.Descriptor instead.
+func (*JobHeartbeatRequest) Descriptor() ([]byte, []int) {
+	return file_proto_transcoding_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *JobHeartbeatRequest) GetJobId() string {
+	if x != nil {
+		return x.JobId
+	}
+	return ""
+}
+
+func (x *JobHeartbeatRequest) GetLeaseId() string {
+	if x != nil {
+		return x.LeaseId
+	}
+	return ""
+}
+
+func (x *JobHeartbeatRequest) GetExtendSeconds() uint32 {
+	if x != nil {
+		return x.ExtendSeconds
+	}
+	return 0
+}
+
+func (x *JobHeartbeatRequest) GetStatus() *VideoStatusResponse {
+	if x != nil {
+		return x.Status
+	}
+	return nil
+}
+
+type JobHeartbeatResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	LeaseExpiresAt int64 `protobuf:"varint,1,opt,name=lease_expires_at,json=leaseExpiresAt,proto3" json:"lease_expires_at,omitempty"`
+	// Set when the job was cancelled; the worker should stop encoding.
+	Cancelled bool `protobuf:"varint,2,opt,name=cancelled,proto3" json:"cancelled,omitempty"`
+}
+
+func (x *JobHeartbeatResponse) Reset() {
+	*x = JobHeartbeatResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_transcoding_proto_msgTypes[16]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *JobHeartbeatResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JobHeartbeatResponse) ProtoMessage() {}
+
+func (x *JobHeartbeatResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_transcoding_proto_msgTypes[16]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JobHeartbeatResponse.ProtoReflect.Descriptor instead.
+func (*JobHeartbeatResponse) Descriptor() ([]byte, []int) {
+	return file_proto_transcoding_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *JobHeartbeatResponse) GetLeaseExpiresAt() int64 {
+	if x != nil {
+		return x.LeaseExpiresAt
+	}
+	return 0
+}
+
+func (x *JobHeartbeatResponse) GetCancelled() bool {
+	if x != nil {
+		return x.Cancelled
+	}
+	return false
+}
+
+type CompleteJobRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	JobId            string `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	LeaseId          string `protobuf:"bytes,2,opt,name=lease_id,json=leaseId,proto3" json:"lease_id,omitempty"`
+	ManifestLocation string `protobuf:"bytes,3,opt,name=manifest_location,json=manifestLocation,proto3" json:"manifest_location,omitempty"`
+}
+
+func (x *CompleteJobRequest) Reset() {
+	*x = CompleteJobRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_transcoding_proto_msgTypes[17]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CompleteJobRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CompleteJobRequest) ProtoMessage() {}
+
+func (x *CompleteJobRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_transcoding_proto_msgTypes[17]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CompleteJobRequest.ProtoReflect.Descriptor instead.
+func (*CompleteJobRequest) Descriptor() ([]byte, []int) {
+	return file_proto_transcoding_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *CompleteJobRequest) GetJobId() string {
+	if x != nil {
+		return x.JobId
+	}
+	return ""
+}
+
+func (x *CompleteJobRequest) GetLeaseId() string {
+	if x != nil {
+		return x.LeaseId
+	}
+	return ""
+}
+
+func (x *CompleteJobRequest) GetManifestLocation() string {
+	if x != nil {
+		return x.ManifestLocation
+	}
+	return ""
+}
+
+type FailJobRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	JobId        string `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	LeaseId      string `protobuf:"bytes,2,opt,name=lease_id,json=leaseId,proto3" json:"lease_id,omitempty"`
+	ErrorCode    string `protobuf:"bytes,3,opt,name=error_code,json=errorCode,proto3" json:"error_code,omitempty"`
+	ErrorMessage string `protobuf:"bytes,4,opt,name=error_message,json=errorMessage,proto3" json:"error_message,omitempty"`
+	Retryable    bool   `protobuf:"varint,5,opt,name=retryable,proto3" json:"retryable,omitempty"`
+}
+
+func (x *FailJobRequest) Reset() {
+	*x = FailJobRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_transcoding_proto_msgTypes[18]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FailJobRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FailJobRequest) ProtoMessage() {}
+
+func (x *FailJobRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_transcoding_proto_msgTypes[18]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FailJobRequest.ProtoReflect.Descriptor instead.
+func (*FailJobRequest) Descriptor() ([]byte, []int) {
+	return file_proto_transcoding_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *FailJobRequest) GetJobId() string {
+	if x != nil {
+		return x.JobId
+	}
+	return ""
+}
+
+func (x *FailJobRequest) GetLeaseId() string {
+	if x != nil {
+		return x.LeaseId
+	}
+	return ""
+}
+
+func (x *FailJobRequest) GetErrorCode() string {
+	if x != nil {
+		return x.ErrorCode
+	}
+	return ""
+}
+
+func (x *FailJobRequest) GetErrorMessage() string {
+	if x != nil {
+		return x.ErrorMessage
+	}
+	return ""
+}
+
+func (x *FailJobRequest) GetRetryable() bool {
+	if x != nil {
+		return x.Retryable
+	}
+	return false
+}
+
+type JobAck struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
 }
 
-func (x *TranscodeResponse) Reset() {
-	*x = TranscodeResponse{}
+func (x *JobAck) Reset() {
+	*x = JobAck{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_proto_transcoding_proto_msgTypes[0]
+		mi := &file_proto_transcoding_proto_msgTypes[19]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *TranscodeResponse) String() string {
+func (x *JobAck) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*TranscodeResponse) ProtoMessage() {}
+func (*JobAck) ProtoMessage() {}
 
-func (x *TranscodeResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_transcoding_proto_msgTypes[0]
+func (x *JobAck) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_transcoding_proto_msgTypes[19]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -55,43 +1456,37 @@ func (x *TranscodeResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use TranscodeResponse.ProtoReflect.Descriptor instead.
-func (*TranscodeResponse) Descriptor() ([]byte, []int) {
-	return file_proto_transcoding_proto_rawDescGZIP(), []int{0}
-}
-
-func (x *TranscodeResponse) GetStatusCode() uint32 {
-	if x != nil {
-		return x.StatusCode
-	}
-	return 0
+// Deprecated: Use JobAck.ProtoReflect.Descriptor instead.
+func (*JobAck) Descriptor() ([]byte, []int) {
+	return file_proto_transcoding_proto_rawDescGZIP(), []int{19}
 }
 
-type VideoUuidRequest struct {
+type FetchContentKeysRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Uuid string `protobuf:"bytes,1,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	JobId   string `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	LeaseId string `protobuf:"bytes,2,opt,name=lease_id,json=leaseId,proto3" json:"lease_id,omitempty"`
 }
 
-func (x *VideoUuidRequest) Reset() {
-	*x = VideoUuidRequest{}
+func (x *FetchContentKeysRequest) Reset() {
+	*x = FetchContentKeysRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_proto_transcoding_proto_msgTypes[1]
+		mi := &file_proto_transcoding_proto_msgTypes[20]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *VideoUuidRequest) String() string {
+func (x *FetchContentKeysRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*VideoUuidRequest) ProtoMessage() {}
+func (*FetchContentKeysRequest) ProtoMessage() {}
 
-func (x *VideoUuidRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_transcoding_proto_msgTypes[1]
+func (x *FetchContentKeysRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_transcoding_proto_msgTypes[20]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -102,43 +1497,50 @@ func (x *VideoUuidRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use VideoUuidRequest.ProtoReflect.Descriptor instead.
-func (*VideoUuidRequest) Descriptor() ([]byte, []int) {
-	return file_proto_transcoding_proto_rawDescGZIP(), []int{1}
+// Deprecated: Use FetchContentKeysRequest.ProtoReflect.Descriptor instead.
+func (*FetchContentKeysRequest) Descriptor() ([]byte, []int) {
+	return file_proto_transcoding_proto_rawDescGZIP(), []int{20}
 }
 
-func (x *VideoUuidRequest) GetUuid() string {
+func (x *FetchContentKeysRequest) GetJobId() string {
 	if x != nil {
-		return x.Uuid
+		return x.JobId
 	}
 	return ""
 }
 
-type VideoStatusResponse struct {
+func (x *FetchContentKeysRequest) GetLeaseId() string {
+	if x != nil {
+		return x.LeaseId
+	}
+	return ""
+}
+
+type FetchContentKeysResponse struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Status uint32 `protobuf:"varint,1,opt,name=status,proto3" json:"status,omitempty"`
+	Keys []*ContentKeyMaterial `protobuf:"bytes,1,rep,name=keys,proto3" json:"keys,omitempty"`
 }
 
-func (x *VideoStatusResponse) Reset() {
-	*x = VideoStatusResponse{}
+func (x *FetchContentKeysResponse) Reset() {
+	*x = FetchContentKeysResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_proto_transcoding_proto_msgTypes[2]
+		mi := &file_proto_transcoding_proto_msgTypes[21]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *VideoStatusResponse) String() string {
+func (x *FetchContentKeysResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*VideoStatusResponse) ProtoMessage() {}
+func (*FetchContentKeysResponse) ProtoMessage() {}
 
-func (x *VideoStatusResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_transcoding_proto_msgTypes[2]
+func (x *FetchContentKeysResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_transcoding_proto_msgTypes[21]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -149,16 +1551,16 @@ func (x *VideoStatusResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use VideoStatusResponse.ProtoReflect.Descriptor instead.
-func (*VideoStatusResponse) Descriptor() ([]byte, []int) {
-	return file_proto_transcoding_proto_rawDescGZIP(), []int{2}
+// Deprecated: Use FetchContentKeysResponse.ProtoReflect.Descriptor instead.
+func (*FetchContentKeysResponse) Descriptor() ([]byte, []int) {
+	return file_proto_transcoding_proto_rawDescGZIP(), []int{21}
 }
 
-func (x *VideoStatusResponse) GetStatus() uint32 {
+func (x *FetchContentKeysResponse) GetKeys() []*ContentKeyMaterial {
 	if x != nil {
-		return x.Status
+		return x.Keys
 	}
-	return 0
+	return nil
 }
 
 var File_proto_transcoding_proto protoreflect.FileDescriptor
@@ -166,31 +1568,265 @@ var File_proto_transcoding_proto protoreflect.FileDescriptor
 var file_proto_transcoding_proto_rawDesc = []byte{
 	0x0a, 0x17, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x63, 0x6f, 0x64,
 	0x69, 0x6e, 0x67, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x0b, 0x74, 0x72, 0x61, 0x6e, 0x73,
-	0x63, 0x6f, 0x64, 0x69, 0x6e, 0x67, 0x22, 0x34, 0x0a, 0x11, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x63,
-	0x6f, 0x64, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1f, 0x0a, 0x0b, 0x73,
-	0x74, 0x61, 0x74, 0x75, 0x73, 0x5f, 0x63, 0x6f, 0x64, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d,
-	0x52, 0x0a, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x43, 0x6f, 0x64, 0x65, 0x22, 0x26, 0x0a, 0x10,
-	0x56, 0x69, 0x64, 0x65, 0x6f, 0x55, 0x75, 0x69, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
-	0x12, 0x12, 0x0a, 0x04, 0x75, 0x75, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04,
-	0x75, 0x75, 0x69, 0x64, 0x22, 0x2d, 0x0a, 0x13, 0x56, 0x69, 0x64, 0x65, 0x6f, 0x53, 0x74, 0x61,
-	0x74, 0x75, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x73,
-	0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x06, 0x73, 0x74, 0x61,
-	0x74, 0x75, 0x73, 0x32, 0x66, 0x0a, 0x12, 0x56, 0x69, 0x64, 0x65, 0x6f, 0x53, 0x74, 0x61, 0x74,
-	0x75, 0x73, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x50, 0x0a, 0x0b, 0x53, 0x74, 0x61,
-	0x74, 0x75, 0x73, 0x56, 0x69, 0x64, 0x65, 0x6f, 0x12, 0x1d, 0x2e, 0x74, 0x72, 0x61, 0x6e, 0x73,
-	0x63, 0x6f, 0x64, 0x69, 0x6e, 0x67, 0x2e, 0x56, 0x69, 0x64, 0x65, 0x6f, 0x55, 0x75, 0x69, 0x64,
-	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x20, 0x2e, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x63,
-	0x6f, 0x64, 0x69, 0x6e, 0x67, 0x2e, 0x56, 0x69, 0x64, 0x65, 0x6f, 0x53, 0x74, 0x61, 0x74, 0x75,
-	0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x30, 0x01, 0x32, 0x63, 0x0a, 0x0a, 0x54,
-	0x72, 0x61, 0x6e, 0x73, 0x63, 0x6f, 0x64, 0x65, 0x72, 0x12, 0x55, 0x0a, 0x14, 0x4e, 0x6f, 0x74,
-	0x69, 0x66, 0x79, 0x55, 0x70, 0x6c, 0x6f, 0x61, 0x64, 0x43, 0x6f, 0x6d, 0x70, 0x6c, 0x65, 0x74,
-	0x65, 0x12, 0x1d, 0x2e, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x63, 0x6f, 0x64, 0x69, 0x6e, 0x67, 0x2e,
-	0x56, 0x69, 0x64, 0x65, 0x6f, 0x55, 0x75, 0x69, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
-	0x1a, 0x1e, 0x2e, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x63, 0x6f, 0x64, 0x69, 0x6e, 0x67, 0x2e, 0x54,
-	0x72, 0x61, 0x6e, 0x73, 0x63, 0x6f, 0x64, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
-	0x42, 0x22, 0x5a, 0x20, 0x2e, 0x2f, 0x76, 0x69, 0x64, 0x65, 0x6f, 0x55, 0x70, 0x6c, 0x6f, 0x61,
-	0x64, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2f, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x63, 0x6f,
-	0x64, 0x69, 0x6e, 0x67, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x63, 0x6f, 0x64, 0x69, 0x6e, 0x67, 0x22, 0xab, 0x01, 0x0a, 0x11, 0x54, 0x72, 0x61, 0x6e, 0x73,
+	0x63, 0x6f, 0x64, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1f, 0x0a, 0x0b,
+	0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x5f, 0x63, 0x6f, 0x64, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0d, 0x52, 0x0a, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x43, 0x6f, 0x64, 0x65, 0x12, 0x31, 0x0a,
+	0x05, 0x73, 0x74, 0x61, 0x67, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x1b, 0x2e, 0x74,
+	0x72, 0x61, 0x6e, 0x73, 0x63, 0x6f, 0x64, 0x69, 0x6e, 0x67, 0x2e, 0x54, 0x72, 0x61, 0x6e, 0x73,
+	0x63, 0x6f, 0x64, 0x65, 0x53, 0x74, 0x61, 0x67, 0x65, 0x52, 0x05, 0x73, 0x74, 0x61, 0x67, 0x65,
+	0x12, 0x1d, 0x0a, 0x0a, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x5f, 0x63, 0x6f, 0x64, 0x65, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x43, 0x6f, 0x64, 0x65, 0x12,
+	0x23, 0x0a, 0x0d, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x5f, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x4d, 0x65, 0x73,
+	0x73, 0x61, 0x67, 0x65, 0x22, 0x26, 0x0a, 0x10, 0x56, 0x69, 0x64, 0x65, 0x6f, 0x55, 0x75, 0x69,
+	0x64, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x75, 0x75, 0x69, 0x64,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x75, 0x75, 0x69, 0x64, 0x22, 0x95, 0x01, 0x0a,
+	0x15, 0x55, 0x70, 0x6c, 0x6f, 0x61, 0x64, 0x43, 0x6f, 0x6d, 0x70, 0x6c, 0x65, 0x74, 0x65, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x75, 0x75, 0x69, 0x64, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x75, 0x75, 0x69, 0x64, 0x12, 0x36, 0x0a, 0x07, 0x70, 0x72,
+	0x6f, 0x66, 0x69, 0x6c, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x74, 0x72,
+	0x61, 0x6e, 0x73, 0x63, 0x6f, 0x64, 0x69, 0x6e, 0x67, 0x2e, 0x45, 0x6e, 0x63, 0x6f, 0x64, 0x69,
+	0x6e, 0x67, 0x50, 0x72, 0x6f, 0x66, 0x69, 0x6c, 0x65, 0x52, 0x07, 0x70, 0x72, 0x6f, 0x66, 0x69,
+	0x6c, 0x65, 0x12, 0x15, 0x0a, 0x06, 0x6a, 0x6f, 0x62, 0x5f, 0x69, 0x64, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x05, 0x6a, 0x6f, 0x62, 0x49, 0x64, 0x12, 0x19, 0x0a, 0x08, 0x6c, 0x65, 0x61,
+	0x73, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x6c, 0x65, 0x61,
+	0x73, 0x65, 0x49, 0x64, 0x22, 0xaa, 0x02, 0x0a, 0x0f, 0x45, 0x6e, 0x63, 0x6f, 0x64, 0x69, 0x6e,
+	0x67, 0x50, 0x72, 0x6f, 0x66, 0x69, 0x6c, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x3a, 0x0a, 0x0a,
+	0x72, 0x65, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b,
+	0x32, 0x1a, 0x2e, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x63, 0x6f, 0x64, 0x69, 0x6e, 0x67, 0x2e, 0x52,
+	0x65, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x70, 0x65, 0x63, 0x52, 0x0a, 0x72, 0x65,
+	0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x1f, 0x0a, 0x0b, 0x76, 0x69, 0x64, 0x65,
+	0x6f, 0x5f, 0x63, 0x6f, 0x64, 0x65, 0x63, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x76,
+	0x69, 0x64, 0x65, 0x6f, 0x43, 0x6f, 0x64, 0x65, 0x63, 0x12, 0x38, 0x0a, 0x18, 0x73, 0x65, 0x67,
+	0x6d, 0x65, 0x6e, 0x74, 0x5f, 0x64, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x73, 0x65,
+	0x63, 0x6f, 0x6e, 0x64, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x16, 0x73, 0x65, 0x67,
+	0x6d, 0x65, 0x6e, 0x74, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x65, 0x63, 0x6f,
+	0x6e, 0x64, 0x73, 0x12, 0x30, 0x0a, 0x05, 0x61, 0x75, 0x64, 0x69, 0x6f, 0x18, 0x05, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x63, 0x6f, 0x64, 0x69, 0x6e, 0x67,
+	0x2e, 0x41, 0x75, 0x64, 0x69, 0x6f, 0x53, 0x65, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x52, 0x05,
+	0x61, 0x75, 0x64, 0x69, 0x6f, 0x12, 0x3a, 0x0a, 0x0a, 0x65, 0x6e, 0x63, 0x72, 0x79, 0x70, 0x74,
+	0x69, 0x6f, 0x6e, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x74, 0x72, 0x61, 0x6e,
+	0x73, 0x63, 0x6f, 0x64, 0x69, 0x6e, 0x67, 0x2e, 0x48, 0x6c, 0x73, 0x45, 0x6e, 0x63, 0x72, 0x79,
+	0x70, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x0a, 0x65, 0x6e, 0x63, 0x72, 0x79, 0x70, 0x74, 0x69, 0x6f,
+	0x6e, 0x22, 0x81, 0x01, 0x0a, 0x0d, 0x48, 0x6c, 0x73, 0x45, 0x6e, 0x63, 0x72, 0x79, 0x70, 0x74,
+	0x69, 0x6f, 0x6e, 0x12, 0x16, 0x0a, 0x06, 0x6d, 0x65, 0x74, 0x68, 0x6f, 0x64, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x06, 0x6d, 0x65, 0x74, 0x68, 0x6f, 0x64, 0x12, 0x2b, 0x0a, 0x11, 0x72,
+	0x6f, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x73, 0x65, 0x67, 0x6d, 0x65, 0x6e, 0x74, 0x73,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x10, 0x72, 0x6f, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x53, 0x65, 0x67, 0x6d, 0x65, 0x6e, 0x74, 0x73, 0x12, 0x2b, 0x0a, 0x04, 0x6b, 0x65, 0x79, 0x73,
+	0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x63, 0x6f,
+	0x64, 0x69, 0x6e, 0x67, 0x2e, 0x43, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x4b, 0x65, 0x79, 0x52,
+	0x04, 0x6b, 0x65, 0x79, 0x73, 0x22, 0x5b, 0x0a, 0x0a, 0x43, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74,
+	0x4b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x0d, 0x52, 0x05, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x12, 0x10, 0x0a, 0x03, 0x75, 0x72, 0x69,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x75, 0x72, 0x69, 0x12, 0x10, 0x0a, 0x03, 0x6b,
+	0x69, 0x64, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x03, 0x6b, 0x69, 0x64, 0x4a, 0x04, 0x08,
+	0x02, 0x10, 0x03, 0x4a, 0x04, 0x08, 0x03, 0x10, 0x04, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x52, 0x02,
+	0x69, 0x76, 0x22, 0x4c, 0x0a, 0x12, 0x43, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x4b, 0x65, 0x79,
+	0x4d, 0x61, 0x74, 0x65, 0x72, 0x69, 0x61, 0x6c, 0x12, 0x14, 0x0a, 0x05, 0x69, 0x6e, 0x64, 0x65,
+	0x78, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x05, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x12, 0x10,
+	0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x03, 0x6b, 0x65, 0x79,
+	0x12, 0x0e, 0x0a, 0x02, 0x69, 0x76, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x02, 0x69, 0x76,
+	0x22, 0xc8, 0x01, 0x0a, 0x0d, 0x52, 0x65, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x70,
+	0x65, 0x63, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x77, 0x69, 0x64, 0x74, 0x68, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x05, 0x77, 0x69, 0x64, 0x74, 0x68, 0x12, 0x16, 0x0a, 0x06,
+	0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x06, 0x68, 0x65,
+	0x69, 0x67, 0x68, 0x74, 0x12, 0x2c, 0x0a, 0x12, 0x76, 0x69, 0x64, 0x65, 0x6f, 0x5f, 0x62, 0x69,
+	0x74, 0x72, 0x61, 0x74, 0x65, 0x5f, 0x6b, 0x62, 0x70, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0d,
+	0x52, 0x10, 0x76, 0x69, 0x64, 0x65, 0x6f, 0x42, 0x69, 0x74, 0x72, 0x61, 0x74, 0x65, 0x4b, 0x62,
+	0x70, 0x73, 0x12, 0x28, 0x0a, 0x10, 0x6d, 0x61, 0x78, 0x5f, 0x62, 0x69, 0x74, 0x72, 0x61, 0x74,
+	0x65, 0x5f, 0x6b, 0x62, 0x70, 0x73, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0e, 0x6d, 0x61,
+	0x78, 0x42, 0x69, 0x74, 0x72, 0x61, 0x74, 0x65, 0x4b, 0x62, 0x70, 0x73, 0x12, 0x1d, 0x0a, 0x0a,
+	0x66, 0x72, 0x61, 0x6d, 0x65, 0x5f, 0x72, 0x61, 0x74, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0d,
+	0x52, 0x09, 0x66, 0x72, 0x61, 0x6d, 0x65, 0x52, 0x61, 0x74, 0x65, 0x22, 0x85, 0x01, 0x0a, 0x0d,
+	0x41, 0x75, 0x64, 0x69, 0x6f, 0x53, 0x65, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x12, 0x14, 0x0a,
+	0x05, 0x63, 0x6f, 0x64, 0x65, 0x63, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x63, 0x6f,
+	0x64, 0x65, 0x63, 0x12, 0x21, 0x0a, 0x0c, 0x62, 0x69, 0x74, 0x72, 0x61, 0x74, 0x65, 0x5f, 0x6b,
+	0x62, 0x70, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0b, 0x62, 0x69, 0x74, 0x72, 0x61,
+	0x74, 0x65, 0x4b, 0x62, 0x70, 0x73, 0x12, 0x1f, 0x0a, 0x0b, 0x73, 0x61, 0x6d, 0x70, 0x6c, 0x65,
+	0x5f, 0x72, 0x61, 0x74, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0a, 0x73, 0x61, 0x6d,
+	0x70, 0x6c, 0x65, 0x52, 0x61, 0x74, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x63, 0x68, 0x61, 0x6e, 0x6e,
+	0x65, 0x6c, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x08, 0x63, 0x68, 0x61, 0x6e, 0x6e,
+	0x65, 0x6c, 0x73, 0x22, 0x45, 0x0a, 0x0f, 0x52, 0x65, 0x65, 0x6e, 0x63, 0x6f, 0x64, 0x65, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x75, 0x75, 0x69, 0x64, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x75, 0x75, 0x69, 0x64, 0x12, 0x1e, 0x0a, 0x0a, 0x72, 0x65,
+	0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0a,
+	0x72, 0x65, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x22, 0xd1, 0x01, 0x0a, 0x11, 0x52,
+	0x65, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x50, 0x72, 0x6f, 0x67, 0x72, 0x65, 0x73, 0x73,
+	0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04,
+	0x6e, 0x61, 0x6d, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x77, 0x69, 0x64, 0x74, 0x68, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x0d, 0x52, 0x05, 0x77, 0x69, 0x64, 0x74, 0x68, 0x12, 0x16, 0x0a, 0x06, 0x68, 0x65,
+	0x69, 0x67, 0x68, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x06, 0x68, 0x65, 0x69, 0x67,
+	0x68, 0x74, 0x12, 0x31, 0x0a, 0x05, 0x73, 0x74, 0x61, 0x67, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28,
+	0x0e, 0x32, 0x1b, 0x2e, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x63, 0x6f, 0x64, 0x69, 0x6e, 0x67, 0x2e,
+	0x54, 0x72, 0x61, 0x6e, 0x73, 0x63, 0x6f, 0x64, 0x65, 0x53, 0x74, 0x61, 0x67, 0x65, 0x52, 0x05,
+	0x73, 0x74, 0x61, 0x67, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x70, 0x72, 0x6f, 0x67, 0x72, 0x65, 0x73,
+	0x73, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x08, 0x70, 0x72, 0x6f, 0x67, 0x72, 0x65, 0x73,
+	0x73, 0x12, 0x2b, 0x0a, 0x11, 0x70, 0x6c, 0x61, 0x79, 0x6c, 0x69, 0x73, 0x74, 0x5f, 0x6c, 0x6f,
+	0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x10, 0x70, 0x6c,
+	0x61, 0x79, 0x6c, 0x69, 0x73, 0x74, 0x4c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0xb2,
+	0x02, 0x0a, 0x13, 0x56, 0x69, 0x64, 0x65, 0x6f, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x31,
+	0x0a, 0x05, 0x73, 0x74, 0x61, 0x67, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x1b, 0x2e,
+	0x74, 0x72, 0x61, 0x6e, 0x73, 0x63, 0x6f, 0x64, 0x69, 0x6e, 0x67, 0x2e, 0x54, 0x72, 0x61, 0x6e,
+	0x73, 0x63, 0x6f, 0x64, 0x65, 0x53, 0x74, 0x61, 0x67, 0x65, 0x52, 0x05, 0x73, 0x74, 0x61, 0x67,
+	0x65, 0x12, 0x3e, 0x0a, 0x0a, 0x72, 0x65, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18,
+	0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x63, 0x6f, 0x64,
+	0x69, 0x6e, 0x67, 0x2e, 0x52, 0x65, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x50, 0x72, 0x6f,
+	0x67, 0x72, 0x65, 0x73, 0x73, 0x52, 0x0a, 0x72, 0x65, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e,
+	0x73, 0x12, 0x1f, 0x0a, 0x0b, 0x65, 0x74, 0x61, 0x5f, 0x73, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0a, 0x65, 0x74, 0x61, 0x53, 0x65, 0x63, 0x6f, 0x6e,
+	0x64, 0x73, 0x12, 0x1d, 0x0a, 0x0a, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x5f, 0x63, 0x6f, 0x64, 0x65,
+	0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x43, 0x6f, 0x64,
+	0x65, 0x12, 0x23, 0x0a, 0x0d, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x5f, 0x6d, 0x65, 0x73, 0x73, 0x61,
+	0x67, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x4d,
+	0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x2b, 0x0a, 0x11, 0x6d, 0x61, 0x6e, 0x69, 0x66, 0x65,
+	0x73, 0x74, 0x5f, 0x6c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x07, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x10, 0x6d, 0x61, 0x6e, 0x69, 0x66, 0x65, 0x73, 0x74, 0x4c, 0x6f, 0x63, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x22, 0xa7, 0x01, 0x0a, 0x0c, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x63, 0x6f, 0x64,
+	0x65, 0x4a, 0x6f, 0x62, 0x12, 0x15, 0x0a, 0x06, 0x6a, 0x6f, 0x62, 0x5f, 0x69, 0x64, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x6a, 0x6f, 0x62, 0x49, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x75,
+	0x75, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x75, 0x75, 0x69, 0x64, 0x12,
+	0x36, 0x0a, 0x07, 0x70, 0x72, 0x6f, 0x66, 0x69, 0x6c, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x1c, 0x2e, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x63, 0x6f, 0x64, 0x69, 0x6e, 0x67, 0x2e, 0x45,
+	0x6e, 0x63, 0x6f, 0x64, 0x69, 0x6e, 0x67, 0x50, 0x72, 0x6f, 0x66, 0x69, 0x6c, 0x65, 0x52, 0x07,
+	0x70, 0x72, 0x6f, 0x66, 0x69, 0x6c, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x61, 0x74, 0x74, 0x65, 0x6d,
+	0x70, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x07, 0x61, 0x74, 0x74, 0x65, 0x6d, 0x70,
+	0x74, 0x12, 0x1a, 0x0a, 0x08, 0x70, 0x72, 0x69, 0x6f, 0x72, 0x69, 0x74, 0x79, 0x18, 0x05, 0x20,
+	0x01, 0x28, 0x05, 0x52, 0x08, 0x70, 0x72, 0x69, 0x6f, 0x72, 0x69, 0x74, 0x79, 0x22, 0x6c, 0x0a,
+	0x0f, 0x4c, 0x65, 0x61, 0x73, 0x65, 0x4a, 0x6f, 0x62, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x12, 0x1b, 0x0a, 0x09, 0x77, 0x6f, 0x72, 0x6b, 0x65, 0x72, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x08, 0x77, 0x6f, 0x72, 0x6b, 0x65, 0x72, 0x49, 0x64, 0x12, 0x3c, 0x0a,
+	0x1a, 0x76, 0x69, 0x73, 0x69, 0x62, 0x69, 0x6c, 0x69, 0x74, 0x79, 0x5f, 0x74, 0x69, 0x6d, 0x65,
+	0x6f, 0x75, 0x74, 0x5f, 0x73, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x0d, 0x52, 0x18, 0x76, 0x69, 0x73, 0x69, 0x62, 0x69, 0x6c, 0x69, 0x74, 0x79, 0x54, 0x69, 0x6d,
+	0x65, 0x6f, 0x75, 0x74, 0x53, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x22, 0x84, 0x01, 0x0a, 0x10,
+	0x4c, 0x65, 0x61, 0x73, 0x65, 0x4a, 0x6f, 0x62, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x12, 0x2b, 0x0a, 0x03, 0x6a, 0x6f, 0x62, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e,
+	0x74, 0x72, 0x61, 0x6e, 0x73, 0x63, 0x6f, 0x64, 0x69, 0x6e, 0x67, 0x2e, 0x54, 0x72, 0x61, 0x6e,
+	0x73, 0x63, 0x6f, 0x64, 0x65, 0x4a, 0x6f, 0x62, 0x52, 0x03, 0x6a, 0x6f, 0x62, 0x12, 0x19, 0x0a,
+	0x08, 0x6c, 0x65, 0x61, 0x73, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x07, 0x6c, 0x65, 0x61, 0x73, 0x65, 0x49, 0x64, 0x12, 0x28, 0x0a, 0x10, 0x6c, 0x65, 0x61, 0x73,
+	0x65, 0x5f, 0x65, 0x78, 0x70, 0x69, 0x72, 0x65, 0x73, 0x5f, 0x61, 0x74, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x0e, 0x6c, 0x65, 0x61, 0x73, 0x65, 0x45, 0x78, 0x70, 0x69, 0x72, 0x65, 0x73,
+	0x41, 0x74, 0x22, 0xa8, 0x01, 0x0a, 0x13, 0x4a, 0x6f, 0x62, 0x48, 0x65, 0x61, 0x72, 0x74, 0x62,
+	0x65, 0x61, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x15, 0x0a, 0x06, 0x6a, 0x6f,
+	0x62, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x6a, 0x6f, 0x62, 0x49,
+	0x64, 0x12, 0x19, 0x0a, 0x08, 0x6c, 0x65, 0x61, 0x73, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x07, 0x6c, 0x65, 0x61, 0x73, 0x65, 0x49, 0x64, 0x12, 0x25, 0x0a, 0x0e,
+	0x65, 0x78, 0x74, 0x65, 0x6e, 0x64, 0x5f, 0x73, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x0d, 0x52, 0x0d, 0x65, 0x78, 0x74, 0x65, 0x6e, 0x64, 0x53, 0x65, 0x63, 0x6f,
+	0x6e, 0x64, 0x73, 0x12, 0x38, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x63, 0x6f, 0x64, 0x69, 0x6e,
+	0x67, 0x2e, 0x56, 0x69, 0x64, 0x65, 0x6f, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x22, 0x5e, 0x0a,
+	0x14, 0x4a, 0x6f, 0x62, 0x48, 0x65, 0x61, 0x72, 0x74, 0x62, 0x65, 0x61, 0x74, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x28, 0x0a, 0x10, 0x6c, 0x65, 0x61, 0x73, 0x65, 0x5f, 0x65,
+	0x78, 0x70, 0x69, 0x72, 0x65, 0x73, 0x5f, 0x61, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x0e, 0x6c, 0x65, 0x61, 0x73, 0x65, 0x45, 0x78, 0x70, 0x69, 0x72, 0x65, 0x73, 0x41, 0x74, 0x12,
+	0x1c, 0x0a, 0x09, 0x63, 0x61, 0x6e, 0x63, 0x65, 0x6c, 0x6c, 0x65, 0x64, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x08, 0x52, 0x09, 0x63, 0x61, 0x6e, 0x63, 0x65, 0x6c, 0x6c, 0x65, 0x64, 0x22, 0x73, 0x0a,
+	0x12, 0x43, 0x6f, 0x6d, 0x70, 0x6c, 0x65, 0x74, 0x65, 0x4a, 0x6f, 0x62, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x12, 0x15, 0x0a, 0x06, 0x6a, 0x6f, 0x62, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x05, 0x6a, 0x6f, 0x62, 0x49, 0x64, 0x12, 0x19, 0x0a, 0x08, 0x6c, 0x65,
+	0x61, 0x73, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x6c, 0x65,
+	0x61, 0x73, 0x65, 0x49, 0x64, 0x12, 0x2b, 0x0a, 0x11, 0x6d, 0x61, 0x6e, 0x69, 0x66, 0x65, 0x73,
+	0x74, 0x5f, 0x6c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x10, 0x6d, 0x61, 0x6e, 0x69, 0x66, 0x65, 0x73, 0x74, 0x4c, 0x6f, 0x63, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x22, 0xa4, 0x01, 0x0a, 0x0e, 0x46, 0x61, 0x69, 0x6c, 0x4a, 0x6f, 0x62, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x15, 0x0a, 0x06, 0x6a, 0x6f, 0x62, 0x5f, 0x69, 0x64, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x6a, 0x6f, 0x62, 0x49, 0x64, 0x12, 0x19, 0x0a, 0x08,
+	0x6c, 0x65, 0x61, 0x73, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07,
+	0x6c, 0x65, 0x61, 0x73, 0x65, 0x49, 0x64, 0x12, 0x1d, 0x0a, 0x0a, 0x65, 0x72, 0x72, 0x6f, 0x72,
+	0x5f, 0x63, 0x6f, 0x64, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x65, 0x72, 0x72,
+	0x6f, 0x72, 0x43, 0x6f, 0x64, 0x65, 0x12, 0x23, 0x0a, 0x0d, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x5f,
+	0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x65,
+	0x72, 0x72, 0x6f, 0x72, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x72,
+	0x65, 0x74, 0x72, 0x79, 0x61, 0x62, 0x6c, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x08, 0x52, 0x09,
+	0x72, 0x65, 0x74, 0x72, 0x79, 0x61, 0x62, 0x6c, 0x65, 0x22, 0x08, 0x0a, 0x06, 0x4a, 0x6f, 0x62,
+	0x41, 0x63, 0x6b, 0x22, 0x4b, 0x0a, 0x17, 0x46, 0x65, 0x74, 0x63, 0x68, 0x43, 0x6f, 0x6e, 0x74,
+	0x65, 0x6e, 0x74, 0x4b, 0x65, 0x79, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x15,
+	0x0a, 0x06, 0x6a, 0x6f, 0x62, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05,
+	0x6a, 0x6f, 0x62, 0x49, 0x64, 0x12, 0x19, 0x0a, 0x08, 0x6c, 0x65, 0x61, 0x73, 0x65, 0x5f, 0x69,
+	0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x6c, 0x65, 0x61, 0x73, 0x65, 0x49, 0x64,
+	0x22, 0x4f, 0x0a, 0x18, 0x46, 0x65, 0x74, 0x63, 0x68, 0x43, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74,
+	0x4b, 0x65, 0x79, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x33, 0x0a, 0x04,
+	0x6b, 0x65, 0x79, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1f, 0x2e, 0x74, 0x72, 0x61,
+	0x6e, 0x73, 0x63, 0x6f, 0x64, 0x69, 0x6e, 0x67, 0x2e, 0x43, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74,
+	0x4b, 0x65, 0x79, 0x4d, 0x61, 0x74, 0x65, 0x72, 0x69, 0x61, 0x6c, 0x52, 0x04, 0x6b, 0x65, 0x79,
+	0x73, 0x2a, 0xc5, 0x01, 0x0a, 0x0e, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x63, 0x6f, 0x64, 0x65, 0x53,
+	0x74, 0x61, 0x67, 0x65, 0x12, 0x1f, 0x0a, 0x1b, 0x54, 0x52, 0x41, 0x4e, 0x53, 0x43, 0x4f, 0x44,
+	0x45, 0x5f, 0x53, 0x54, 0x41, 0x47, 0x45, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46,
+	0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x1b, 0x0a, 0x17, 0x54, 0x52, 0x41, 0x4e, 0x53, 0x43, 0x4f,
+	0x44, 0x45, 0x5f, 0x53, 0x54, 0x41, 0x47, 0x45, 0x5f, 0x50, 0x52, 0x4f, 0x42, 0x49, 0x4e, 0x47,
+	0x10, 0x01, 0x12, 0x1c, 0x0a, 0x18, 0x54, 0x52, 0x41, 0x4e, 0x53, 0x43, 0x4f, 0x44, 0x45, 0x5f,
+	0x53, 0x54, 0x41, 0x47, 0x45, 0x5f, 0x45, 0x4e, 0x43, 0x4f, 0x44, 0x49, 0x4e, 0x47, 0x10, 0x02,
+	0x12, 0x1d, 0x0a, 0x19, 0x54, 0x52, 0x41, 0x4e, 0x53, 0x43, 0x4f, 0x44, 0x45, 0x5f, 0x53, 0x54,
+	0x41, 0x47, 0x45, 0x5f, 0x50, 0x41, 0x43, 0x4b, 0x41, 0x47, 0x49, 0x4e, 0x47, 0x10, 0x03, 0x12,
+	0x1c, 0x0a, 0x18, 0x54, 0x52, 0x41, 0x4e, 0x53, 0x43, 0x4f, 0x44, 0x45, 0x5f, 0x53, 0x54, 0x41,
+	0x47, 0x45, 0x5f, 0x43, 0x4f, 0x4d, 0x50, 0x4c, 0x45, 0x54, 0x45, 0x10, 0x04, 0x12, 0x1a, 0x0a,
+	0x16, 0x54, 0x52, 0x41, 0x4e, 0x53, 0x43, 0x4f, 0x44, 0x45, 0x5f, 0x53, 0x54, 0x41, 0x47, 0x45,
+	0x5f, 0x46, 0x41, 0x49, 0x4c, 0x45, 0x44, 0x10, 0x05, 0x32, 0x66, 0x0a, 0x12, 0x56, 0x69, 0x64,
+	0x65, 0x6f, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12,
+	0x50, 0x0a, 0x0b, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x56, 0x69, 0x64, 0x65, 0x6f, 0x12, 0x1d,
+	0x2e, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x63, 0x6f, 0x64, 0x69, 0x6e, 0x67, 0x2e, 0x56, 0x69, 0x64,
+	0x65, 0x6f, 0x55, 0x75, 0x69, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x20, 0x2e,
+	0x74, 0x72, 0x61, 0x6e, 0x73, 0x63, 0x6f, 0x64, 0x69, 0x6e, 0x67, 0x2e, 0x56, 0x69, 0x64, 0x65,
+	0x6f, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x30,
+	0x01, 0x32, 0xda, 0x02, 0x0a, 0x0a, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x63, 0x6f, 0x64, 0x65, 0x72,
+	0x12, 0x5a, 0x0a, 0x14, 0x4e, 0x6f, 0x74, 0x69, 0x66, 0x79, 0x55, 0x70, 0x6c, 0x6f, 0x61, 0x64,
+	0x43, 0x6f, 0x6d, 0x70, 0x6c, 0x65, 0x74, 0x65, 0x12, 0x22, 0x2e, 0x74, 0x72, 0x61, 0x6e, 0x73,
+	0x63, 0x6f, 0x64, 0x69, 0x6e, 0x67, 0x2e, 0x55, 0x70, 0x6c, 0x6f, 0x61, 0x64, 0x43, 0x6f, 0x6d,
+	0x70, 0x6c, 0x65, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1e, 0x2e, 0x74,
+	0x72, 0x61, 0x6e, 0x73, 0x63, 0x6f, 0x64, 0x69, 0x6e, 0x67, 0x2e, 0x54, 0x72, 0x61, 0x6e, 0x73,
+	0x63, 0x6f, 0x64, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x50, 0x0a, 0x0f,
+	0x43, 0x61, 0x6e, 0x63, 0x65, 0x6c, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x63, 0x6f, 0x64, 0x65, 0x12,
+	0x1d, 0x2e, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x63, 0x6f, 0x64, 0x69, 0x6e, 0x67, 0x2e, 0x56, 0x69,
+	0x64, 0x65, 0x6f, 0x55, 0x75, 0x69, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1e,
+	0x2e, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x63, 0x6f, 0x64, 0x69, 0x6e, 0x67, 0x2e, 0x54, 0x72, 0x61,
+	0x6e, 0x73, 0x63, 0x6f, 0x64, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x4f,
+	0x0a, 0x0e, 0x52, 0x65, 0x74, 0x72, 0x79, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x63, 0x6f, 0x64, 0x65,
+	0x12, 0x1d, 0x2e, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x63, 0x6f, 0x64, 0x69, 0x6e, 0x67, 0x2e, 0x56,
+	0x69, 0x64, 0x65, 0x6f, 0x55, 0x75, 0x69, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
+	0x1e, 0x2e, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x63, 0x6f, 0x64, 0x69, 0x6e, 0x67, 0x2e, 0x54, 0x72,
+	0x61, 0x6e, 0x73, 0x63, 0x6f, 0x64, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12,
+	0x4d, 0x0a, 0x0d, 0x52, 0x65, 0x65, 0x6e, 0x63, 0x6f, 0x64, 0x65, 0x56, 0x69, 0x64, 0x65, 0x6f,
+	0x12, 0x1c, 0x2e, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x63, 0x6f, 0x64, 0x69, 0x6e, 0x67, 0x2e, 0x52,
+	0x65, 0x65, 0x6e, 0x63, 0x6f, 0x64, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1e,
+	0x2e, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x63, 0x6f, 0x64, 0x69, 0x6e, 0x67, 0x2e, 0x54, 0x72, 0x61,
+	0x6e, 0x73, 0x63, 0x6f, 0x64, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x32, 0x91,
+	0x03, 0x0a, 0x11, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x63, 0x6f, 0x64, 0x65, 0x4a, 0x6f, 0x62, 0x51,
+	0x75, 0x65, 0x75, 0x65, 0x12, 0x47, 0x0a, 0x08, 0x4c, 0x65, 0x61, 0x73, 0x65, 0x4a, 0x6f, 0x62,
+	0x12, 0x1c, 0x2e, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x63, 0x6f, 0x64, 0x69, 0x6e, 0x67, 0x2e, 0x4c,
+	0x65, 0x61, 0x73, 0x65, 0x4a, 0x6f, 0x62, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1d,
+	0x2e, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x63, 0x6f, 0x64, 0x69, 0x6e, 0x67, 0x2e, 0x4c, 0x65, 0x61,
+	0x73, 0x65, 0x4a, 0x6f, 0x62, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x50, 0x0a,
+	0x09, 0x48, 0x65, 0x61, 0x72, 0x74, 0x62, 0x65, 0x61, 0x74, 0x12, 0x20, 0x2e, 0x74, 0x72, 0x61,
+	0x6e, 0x73, 0x63, 0x6f, 0x64, 0x69, 0x6e, 0x67, 0x2e, 0x4a, 0x6f, 0x62, 0x48, 0x65, 0x61, 0x72,
+	0x74, 0x62, 0x65, 0x61, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x21, 0x2e, 0x74,
+	0x72, 0x61, 0x6e, 0x73, 0x63, 0x6f, 0x64, 0x69, 0x6e, 0x67, 0x2e, 0x4a, 0x6f, 0x62, 0x48, 0x65,
+	0x61, 0x72, 0x74, 0x62, 0x65, 0x61, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12,
+	0x43, 0x0a, 0x0b, 0x43, 0x6f, 0x6d, 0x70, 0x6c, 0x65, 0x74, 0x65, 0x4a, 0x6f, 0x62, 0x12, 0x1f,
+	0x2e, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x63, 0x6f, 0x64, 0x69, 0x6e, 0x67, 0x2e, 0x43, 0x6f, 0x6d,
+	0x70, 0x6c, 0x65, 0x74, 0x65, 0x4a, 0x6f, 0x62, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
+	0x13, 0x2e, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x63, 0x6f, 0x64, 0x69, 0x6e, 0x67, 0x2e, 0x4a, 0x6f,
+	0x62, 0x41, 0x63, 0x6b, 0x12, 0x3b, 0x0a, 0x07, 0x46, 0x61, 0x69, 0x6c, 0x4a, 0x6f, 0x62, 0x12,
+	0x1b, 0x2e, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x63, 0x6f, 0x64, 0x69, 0x6e, 0x67, 0x2e, 0x46, 0x61,
+	0x69, 0x6c, 0x4a, 0x6f, 0x62, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x13, 0x2e, 0x74,
+	0x72, 0x61, 0x6e, 0x73, 0x63, 0x6f, 0x64, 0x69, 0x6e, 0x67, 0x2e, 0x4a, 0x6f, 0x62, 0x41, 0x63,
+	0x6b, 0x12, 0x5f, 0x0a, 0x10, 0x46, 0x65, 0x74, 0x63, 0x68, 0x43, 0x6f, 0x6e, 0x74, 0x65, 0x6e,
+	0x74, 0x4b, 0x65, 0x79, 0x73, 0x12, 0x24, 0x2e, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x63, 0x6f, 0x64,
+	0x69, 0x6e, 0x67, 0x2e, 0x46, 0x65, 0x74, 0x63, 0x68, 0x43, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74,
+	0x4b, 0x65, 0x79, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x25, 0x2e, 0x74, 0x72,
+	0x61, 0x6e, 0x73, 0x63, 0x6f, 0x64, 0x69, 0x6e, 0x67, 0x2e, 0x46, 0x65, 0x74, 0x63, 0x68, 0x43,
+	0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x4b, 0x65, 0x79, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
+	0x73, 0x65, 0x42, 0x22, 0x5a, 0x20, 0x2e, 0x2f, 0x76, 0x69, 0x64, 0x65, 0x6f, 0x55, 0x70, 0x6c,
+	0x6f, 0x61, 0x64, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2f, 0x74, 0x72, 0x61, 0x6e, 0x73,
+	0x63, 0x6f, 0x64, 0x69, 0x6e, 0x67, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -205,22 +1841,72 @@ func file_proto_transcoding_proto_rawDescGZIP() []byte {
 	return file_proto_transcoding_proto_rawDescData
 }
 
-var file_proto_transcoding_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_proto_transcoding_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_proto_transcoding_proto_msgTypes = make([]protoimpl.MessageInfo, 22)
 var file_proto_transcoding_proto_goTypes = []any{
-	(*TranscodeResponse)(nil),   // 0: transcoding.TranscodeResponse
-	(*VideoUuidRequest)(nil),    // 1: transcoding.VideoUuidRequest
-	(*VideoStatusResponse)(nil), // 2: transcoding.VideoStatusResponse
+	(TranscodeStage)(0),              // 0: transcoding.TranscodeStage
+	(*TranscodeResponse)(nil),        // 1: transcoding.TranscodeResponse
+	(*VideoUuidRequest)(nil),         // 2: transcoding.VideoUuidRequest
+	(*UploadCompleteRequest)(nil),    // 3: transcoding.UploadCompleteRequest
+	(*EncodingProfile)(nil),          // 4: transcoding.EncodingProfile
+	(*HlsEncryption)(nil),            // 5: transcoding.HlsEncryption
+	(*ContentKey)(nil),               // 6: transcoding.ContentKey
+	(*ContentKeyMaterial)(nil),       // 7: transcoding.ContentKeyMaterial
+	(*RenditionSpec)(nil),            // 8: transcoding.RenditionSpec
+	(*AudioSettings)(nil),            // 9: transcoding.AudioSettings
+	(*ReencodeRequest)(nil),          // 10: transcoding.ReencodeRequest
+	(*RenditionProgress)(nil),        // 11: transcoding.RenditionProgress
+	(*VideoStatusResponse)(nil),      // 12: transcoding.VideoStatusResponse
+	(*TranscodeJob)(nil),             // 13: transcoding.TranscodeJob
+	(*LeaseJobRequest)(nil),          // 14: transcoding.LeaseJobRequest
+	(*LeaseJobResponse)(nil),         // 15: transcoding.LeaseJobResponse
+	(*JobHeartbeatRequest)(nil),      // 16: transcoding.JobHeartbeatRequest
+	(*JobHeartbeatResponse)(nil),     // 17: transcoding.JobHeartbeatResponse
+	(*CompleteJobRequest)(nil),       // 18: transcoding.CompleteJobRequest
+	(*FailJobRequest)(nil),           // 19: transcoding.FailJobRequest
+	(*JobAck)(nil),                   // 20: transcoding.JobAck
+	(*FetchContentKeysRequest)(nil),  // 21: transcoding.FetchContentKeysRequest
+	(*FetchContentKeysResponse)(nil), // 22: transcoding.FetchContentKeysResponse
 }
 var file_proto_transcoding_proto_depIdxs = []int32{
-	1, // 0: transcoding.VideoStatusService.StatusVideo:input_type -> transcoding.VideoUuidRequest
-	1, // 1: transcoding.Transcoder.NotifyUploadComplete:input_type -> transcoding.VideoUuidRequest
-	2, // 2: transcoding.VideoStatusService.StatusVideo:output_type -> transcoding.VideoStatusResponse
-	0, // 3: transcoding.Transcoder.NotifyUploadComplete:output_type -> transcoding.TranscodeResponse
-	2, // [2:4] is the sub-list for method output_type
-	0, // [0:2] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	0,  // 0: transcoding.TranscodeResponse.stage:type_name -> transcoding.TranscodeStage
+	4,  // 1: transcoding.UploadCompleteRequest.profile:type_name -> transcoding.EncodingProfile
+	8,  // 2: transcoding.EncodingProfile.renditions:type_name -> transcoding.RenditionSpec
+	9,  // 3: transcoding.EncodingProfile.audio:type_name -> transcoding.AudioSettings
+	5,  // 4: transcoding.EncodingProfile.encryption:type_name -> transcoding.HlsEncryption
+	6,  // 5: transcoding.HlsEncryption.keys:type_name -> transcoding.ContentKey
+	0,  // 6: transcoding.RenditionProgress.stage:type_name -> transcoding.TranscodeStage
+	0,  // 7: transcoding.VideoStatusResponse.stage:type_name -> transcoding.TranscodeStage
+	11, // 8: transcoding.VideoStatusResponse.renditions:type_name -> transcoding.RenditionProgress
+	4,  // 9: transcoding.TranscodeJob.profile:type_name -> transcoding.EncodingProfile
+	13, // 10: transcoding.LeaseJobResponse.job:type_name -> transcoding.TranscodeJob
+	12, // 11: transcoding.JobHeartbeatRequest.status:type_name -> transcoding.VideoStatusResponse
+	7,  // 12: transcoding.FetchContentKeysResponse.keys:type_name -> transcoding.ContentKeyMaterial
+	2,  // 13: transcoding.VideoStatusService.StatusVideo:input_type -> transcoding.VideoUuidRequest
+	3,  // 14: transcoding.Transcoder.NotifyUploadComplete:input_type -> transcoding.UploadCompleteRequest
+	2,  // 15: transcoding.Transcoder.CancelTranscode:input_type -> transcoding.VideoUuidRequest
+	2,  // 16: transcoding.Transcoder.RetryTranscode:input_type -> transcoding.VideoUuidRequest
+	10, // 17: transcoding.Transcoder.ReencodeVideo:input_type -> transcoding.ReencodeRequest
+	14, // 18: transcoding.TranscodeJobQueue.LeaseJob:input_type -> transcoding.LeaseJobRequest
+	16, // 19: transcoding.TranscodeJobQueue.Heartbeat:input_type -> transcoding.JobHeartbeatRequest
+	18, // 20: transcoding.TranscodeJobQueue.CompleteJob:input_type -> transcoding.CompleteJobRequest
+	19, // 21: transcoding.TranscodeJobQueue.FailJob:input_type -> transcoding.FailJobRequest
+	21, // 22: transcoding.TranscodeJobQueue.FetchContentKeys:input_type -> transcoding.FetchContentKeysRequest
+	12, // 23: transcoding.VideoStatusService.StatusVideo:output_type -> transcoding.VideoStatusResponse
+	1,  // 24: transcoding.Transcoder.NotifyUploadComplete:output_type -> transcoding.TranscodeResponse
+	1,  // 25: transcoding.Transcoder.CancelTranscode:output_type -> transcoding.TranscodeResponse
+	1,  // 26: transcoding.Transcoder.RetryTranscode:output_type -> transcoding.TranscodeResponse
+	1,  // 27: transcoding.Transcoder.ReencodeVideo:output_type -> transcoding.TranscodeResponse
+	15, // 28: transcoding.TranscodeJobQueue.LeaseJob:output_type -> transcoding.LeaseJobResponse
+	17, // 29: transcoding.TranscodeJobQueue.Heartbeat:output_type -> transcoding.JobHeartbeatResponse
+	20, // 30: transcoding.TranscodeJobQueue.CompleteJob:output_type -> transcoding.JobAck
+	20, // 31: transcoding.TranscodeJobQueue.FailJob:output_type -> transcoding.JobAck
+	22, // 32: transcoding.TranscodeJobQueue.FetchContentKeys:output_type -> transcoding.FetchContentKeysResponse
+	23, // [23:33] is the sub-list for method output_type
+	13, // [13:23] is the sub-list for method input_type
+	13, // [13:13] is the sub-list for extension type_name
+	13, // [13:13] is the sub-list for extension extendee
+	0,  // [0:13] is the sub-list for field type_name
 }
 
 func init() { file_proto_transcoding_proto_init() }
@@ -254,6 +1940,114 @@ func file_proto_transcoding_proto_init() {
 			}
 		}
 		file_proto_transcoding_proto_msgTypes[2].Exporter = func(v any, i int) any {
+			switch v := v.(*UploadCompleteRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_transcoding_proto_msgTypes[3].Exporter = func(v any, i int) any {
+			switch v := v.(*EncodingProfile); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_transcoding_proto_msgTypes[4].Exporter = func(v any, i int) any {
+			switch v := v.(*HlsEncryption); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_transcoding_proto_msgTypes[5].Exporter = func(v any, i int) any {
+			switch v := v.(*ContentKey); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_transcoding_proto_msgTypes[6].Exporter = func(v any, i int) any {
+			switch v := v.(*ContentKeyMaterial); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_transcoding_proto_msgTypes[7].Exporter = func(v any, i int) any {
+			switch v := v.(*RenditionSpec); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_transcoding_proto_msgTypes[8].Exporter = func(v any, i int) any {
+			switch v := v.(*AudioSettings); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_transcoding_proto_msgTypes[9].Exporter = func(v any, i int) any {
+			switch v := v.(*ReencodeRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_transcoding_proto_msgTypes[10].Exporter = func(v any, i int) any {
+			switch v := v.(*RenditionProgress); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_transcoding_proto_msgTypes[11].Exporter = func(v any, i int) any {
 			switch v := v.(*VideoStatusResponse); i {
 			case 0:
 				return &v.state
@@ -265,19 +2059,140 @@ func file_proto_transcoding_proto_init() {
 				return nil
 			}
 		}
+		file_proto_transcoding_proto_msgTypes[12].Exporter = func(v any, i int) any {
+			switch v := v.(*TranscodeJob); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_transcoding_proto_msgTypes[13].Exporter = func(v any, i int) any {
+			switch v := v.(*LeaseJobRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_transcoding_proto_msgTypes[14].Exporter = func(v any, i int) any {
+			switch v := v.(*LeaseJobResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_transcoding_proto_msgTypes[15].Exporter = func(v any, i int) any {
+			switch v := v.(*JobHeartbeatRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_transcoding_proto_msgTypes[16].Exporter = func(v any, i int) any {
+			switch v := v.(*JobHeartbeatResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_transcoding_proto_msgTypes[17].Exporter = func(v any, i int) any {
+			switch v := v.(*CompleteJobRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_transcoding_proto_msgTypes[18].Exporter = func(v any, i int) any {
+			switch v := v.(*FailJobRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_transcoding_proto_msgTypes[19].Exporter = func(v any, i int) any {
+			switch v := v.(*JobAck); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_transcoding_proto_msgTypes[20].Exporter = func(v any, i int) any {
+			switch v := v.(*FetchContentKeysRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_transcoding_proto_msgTypes[21].Exporter = func(v any, i int) any {
+			switch v := v.(*FetchContentKeysResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_proto_transcoding_proto_rawDesc,
-			NumEnums:      0,
-			NumMessages:   3,
+			NumEnums:      1,
+			NumMessages:   22,
 			NumExtensions: 0,
-			NumServices:   2,
+			NumServices:   3,
 		},
 		GoTypes:           file_proto_transcoding_proto_goTypes,
 		DependencyIndexes: file_proto_transcoding_proto_depIdxs,
+		EnumInfos:         file_proto_transcoding_proto_enumTypes,
 		MessageInfos:      file_proto_transcoding_proto_msgTypes,
 	}.Build()
 	File_proto_transcoding_proto = out.File
@@ -138,13 +138,19 @@ var VideoStatusService_ServiceDesc = grpc.ServiceDesc{
 
 const (
 	Transcoder_NotifyUploadComplete_FullMethodName = "/transcoding.Transcoder/NotifyUploadComplete"
+	Transcoder_CancelTranscode_FullMethodName      = "/transcoding.Transcoder/CancelTranscode"
+	Transcoder_RetryTranscode_FullMethodName       = "/transcoding.Transcoder/RetryTranscode"
+	Transcoder_ReencodeVideo_FullMethodName        = "/transcoding.Transcoder/ReencodeVideo"
 )
 
 // TranscoderClient is the client API for Transcoder service.
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type TranscoderClient interface {
-	NotifyUploadComplete(ctx context.Context, in *VideoUuidRequest, opts ...grpc.CallOption) (*TranscodeResponse, error)
+	NotifyUploadComplete(ctx context.Context, in *UploadCompleteRequest, opts ...grpc.CallOption) (*TranscodeResponse, error)
+	CancelTranscode(ctx context.Context, in *VideoUuidRequest, opts ...grpc.CallOption) (*TranscodeResponse, error)
+	RetryTranscode(ctx context.Context, in *VideoUuidRequest, opts ...grpc.CallOption) (*TranscodeResponse, error)
+	ReencodeVideo(ctx context.Context, in *ReencodeRequest, opts ...grpc.CallOption) (*TranscodeResponse, error)
 }
 
 type transcoderClient struct {
@@ -155,7 +161,7 @@ func NewTranscoderClient(cc grpc.ClientConnInterface) TranscoderClient {
 	return &transcoderClient{cc}
 }
 
-func (c *transcoderClient) NotifyUploadComplete(ctx context.Context, in *VideoUuidRequest, opts ...grpc.CallOption) (*TranscodeResponse, error) {
+func (c *transcoderClient) NotifyUploadComplete(ctx context.Context, in *UploadCompleteRequest, opts ...grpc.CallOption) (*TranscodeResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(TranscodeResponse)
 	err := c.cc.Invoke(ctx, Transcoder_NotifyUploadComplete_FullMethodName, in, out, cOpts...)
@@ -165,11 +171,44 @@ func (c *transcoderClient) NotifyUploadComplete(ctx context.Context, in *VideoUu
 	return out, nil
 }
 
+func (c *transcoderClient) CancelTranscode(ctx context.Context, in *VideoUuidRequest, opts ...grpc.CallOption) (*TranscodeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TranscodeResponse)
+	err := c.cc.Invoke(ctx, Transcoder_CancelTranscode_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *transcoderClient) RetryTranscode(ctx context.Context, in *VideoUuidRequest, opts ...grpc.CallOption) (*TranscodeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TranscodeResponse)
+	err := c.cc.Invoke(ctx, Transcoder_RetryTranscode_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *transcoderClient) ReencodeVideo(ctx context.Context, in *ReencodeRequest, opts ...grpc.CallOption) (*TranscodeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TranscodeResponse)
+	err := c.cc.Invoke(ctx, Transcoder_ReencodeVideo_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // TranscoderServer is the server API for Transcoder service.
 // All implementations must embed UnimplementedTranscoderServer
 // for forward compatibility
 type TranscoderServer interface {
-	NotifyUploadComplete(context.Context, *VideoUuidRequest) (*TranscodeResponse, error)
+	NotifyUploadComplete(context.Context, *UploadCompleteRequest) (*TranscodeResponse, error)
+	CancelTranscode(context.Context, *VideoUuidRequest) (*TranscodeResponse, error)
+	RetryTranscode(context.Context, *VideoUuidRequest) (*TranscodeResponse, error)
+	ReencodeVideo(context.Context, *ReencodeRequest) (*TranscodeResponse, error)
 	mustEmbedUnimplementedTranscoderServer()
 }
 
@@ -177,9 +216,18 @@ type TranscoderServer interface {
 type UnimplementedTranscoderServer struct {
 }
 
-func (UnimplementedTranscoderServer) NotifyUploadComplete(context.Context, *VideoUuidRequest) (*TranscodeResponse, error) {
+func (UnimplementedTranscoderServer) NotifyUploadComplete(context.Context, *UploadCompleteRequest) (*TranscodeResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method NotifyUploadComplete not implemented")
 }
+func (UnimplementedTranscoderServer) CancelTranscode(context.Context, *VideoUuidRequest) (*TranscodeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelTranscode not implemented")
+}
+func (UnimplementedTranscoderServer) RetryTranscode(context.Context, *VideoUuidRequest) (*TranscodeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RetryTranscode not implemented")
+}
+func (UnimplementedTranscoderServer) ReencodeVideo(context.Context, *ReencodeRequest) (*TranscodeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReencodeVideo not implemented")
+}
 func (UnimplementedTranscoderServer) mustEmbedUnimplementedTranscoderServer() {}
 
 // UnsafeTranscoderServer may be embedded to opt out of forward compatibility for this service.
@@ -194,7 +242,7 @@ func RegisterTranscoderServer(s grpc.ServiceRegistrar, srv TranscoderServer) {
 }
 
 func _Transcoder_NotifyUploadComplete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(VideoUuidRequest)
+	in := new(UploadCompleteRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
@@ -206,7 +254,61 @@ func _Transcoder_NotifyUploadComplete_Handler(srv interface{}, ctx context.Conte
 		FullMethod: Transcoder_NotifyUploadComplete_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(TranscoderServer).NotifyUploadComplete(ctx, req.(*VideoUuidRequest))
+		return srv.(TranscoderServer).NotifyUploadComplete(ctx, req.(*UploadCompleteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Transcoder_CancelTranscode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VideoUuidRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TranscoderServer).CancelTranscode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Transcoder_CancelTranscode_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TranscoderServer).CancelTranscode(ctx, req.(*VideoUuidRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Transcoder_RetryTranscode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VideoUuidRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TranscoderServer).RetryTranscode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Transcoder_RetryTranscode_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TranscoderServer).RetryTranscode(ctx, req.(*VideoUuidRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Transcoder_ReencodeVideo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReencodeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TranscoderServer).ReencodeVideo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Transcoder_ReencodeVideo_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TranscoderServer).ReencodeVideo(ctx, req.(*ReencodeRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
@@ -222,6 +324,271 @@ var Transcoder_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "NotifyUploadComplete",
 			Handler:    _Transcoder_NotifyUploadComplete_Handler,
 		},
+		{
+			MethodName: "CancelTranscode",
+			Handler:    _Transcoder_CancelTranscode_Handler,
+		},
+		{
+			MethodName: "RetryTranscode",
+			Handler:    _Transcoder_RetryTranscode_Handler,
+		},
+		{
+			MethodName: "ReencodeVideo",
+			Handler:    _Transcoder_ReencodeVideo_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "proto/transcoding.proto",
+}
+
+const (
+	TranscodeJobQueue_LeaseJob_FullMethodName         = "/transcoding.TranscodeJobQueue/LeaseJob"
+	TranscodeJobQueue_Heartbeat_FullMethodName        = "/transcoding.TranscodeJobQueue/Heartbeat"
+	TranscodeJobQueue_CompleteJob_FullMethodName      = "/transcoding.TranscodeJobQueue/CompleteJob"
+	TranscodeJobQueue_FailJob_FullMethodName          = "/transcoding.TranscodeJobQueue/FailJob"
+	TranscodeJobQueue_FetchContentKeys_FullMethodName = "/transcoding.TranscodeJobQueue/FetchContentKeys"
+)
+
+// TranscodeJobQueueClient is the client API for TranscodeJobQueue service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// TranscodeJobQueue is served by the upload service. Encoder workers pull
+// jobs from it instead of being pushed one request per upload.
+type TranscodeJobQueueClient interface {
+	LeaseJob(ctx context.Context, in *LeaseJobRequest, opts ...grpc.CallOption) (*LeaseJobResponse, error)
+	Heartbeat(ctx context.Context, in *JobHeartbeatRequest, opts ...grpc.CallOption) (*JobHeartbeatResponse, error)
+	CompleteJob(ctx context.Context, in *CompleteJobRequest, opts ...grpc.CallOption) (*JobAck, error)
+	FailJob(ctx context.Context, in *FailJobRequest, opts ...grpc.CallOption) (*JobAck, error)
+	// FetchContentKeys returns the key material referenced by the encryption
+	// settings of a leased job.
+	FetchContentKeys(ctx context.Context, in *FetchContentKeysRequest, opts ...grpc.CallOption) (*FetchContentKeysResponse, error)
+}
+
+type transcodeJobQueueClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewTranscodeJobQueueClient(cc grpc.ClientConnInterface) TranscodeJobQueueClient {
+	return &transcodeJobQueueClient{cc}
+}
+
+func (c *transcodeJobQueueClient) LeaseJob(ctx context.Context, in *LeaseJobRequest, opts ...grpc.CallOption) (*LeaseJobResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LeaseJobResponse)
+	err := c.cc.Invoke(ctx, TranscodeJobQueue_LeaseJob_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *transcodeJobQueueClient) Heartbeat(ctx context.Context, in *JobHeartbeatRequest, opts ...grpc.CallOption) (*JobHeartbeatResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(JobHeartbeatResponse)
+	err := c.cc.Invoke(ctx, TranscodeJobQueue_Heartbeat_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *transcodeJobQueueClient) CompleteJob(ctx context.Context, in *CompleteJobRequest, opts ...grpc.CallOption) (*JobAck, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(JobAck)
+	err := c.cc.Invoke(ctx, TranscodeJobQueue_CompleteJob_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *transcodeJobQueueClient) FailJob(ctx context.Context, in *FailJobRequest, opts ...grpc.CallOption) (*JobAck, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(JobAck)
+	err := c.cc.Invoke(ctx, TranscodeJobQueue_FailJob_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *transcodeJobQueueClient) FetchContentKeys(ctx context.Context, in *FetchContentKeysRequest, opts ...grpc.CallOption) (*FetchContentKeysResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FetchContentKeysResponse)
+	err := c.cc.Invoke(ctx, TranscodeJobQueue_FetchContentKeys_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// TranscodeJobQueueServer is the server API for TranscodeJobQueue service.
+// All implementations must embed UnimplementedTranscodeJobQueueServer
+// for forward compatibility
+//
+// TranscodeJobQueue is served by the upload service. Encoder workers pull
+// jobs from it instead of being pushed one request per upload.
+type TranscodeJobQueueServer interface {
+	LeaseJob(context.Context, *LeaseJobRequest) (*LeaseJobResponse, error)
+	Heartbeat(context.Context, *JobHeartbeatRequest) (*JobHeartbeatResponse, error)
+	CompleteJob(context.Context, *CompleteJobRequest) (*JobAck, error)
+	FailJob(context.Context, *FailJobRequest) (*JobAck, error)
+	// FetchContentKeys returns the key material referenced by the encryption
+	// settings of a leased job.
+	FetchContentKeys(context.Context, *FetchContentKeysRequest) (*FetchContentKeysResponse, error)
+	mustEmbedUnimplementedTranscodeJobQueueServer()
+}
+
+// UnimplementedTranscodeJobQueueServer must be embedded to have forward compatible implementations.
+type UnimplementedTranscodeJobQueueServer struct {
+}
+
+func (UnimplementedTranscodeJobQueueServer) LeaseJob(context.Context, *LeaseJobRequest) (*LeaseJobResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LeaseJob not implemented")
+}
+func (UnimplementedTranscodeJobQueueServer) Heartbeat(context.Context, *JobHeartbeatRequest) (*JobHeartbeatResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Heartbeat not implemented")
+}
+func (UnimplementedTranscodeJobQueueServer) CompleteJob(context.Context, *CompleteJobRequest) (*JobAck, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CompleteJob not implemented")
+}
+func (UnimplementedTranscodeJobQueueServer) FailJob(context.Context, *FailJobRequest) (*JobAck, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FailJob not implemented")
+}
+func (UnimplementedTranscodeJobQueueServer) FetchContentKeys(context.Context, *FetchContentKeysRequest) (*FetchContentKeysResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FetchContentKeys not implemented")
+}
+func (UnimplementedTranscodeJobQueueServer) mustEmbedUnimplementedTranscodeJobQueueServer() {}
+
+// UnsafeTranscodeJobQueueServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to TranscodeJobQueueServer will
+// result in compilation errors.
+type UnsafeTranscodeJobQueueServer interface {
+	mustEmbedUnimplementedTranscodeJobQueueServer()
+}
+
+func RegisterTranscodeJobQueueServer(s grpc.ServiceRegistrar, srv TranscodeJobQueueServer) {
+	s.RegisterService(&TranscodeJobQueue_ServiceDesc, srv)
+}
+
+func _TranscodeJobQueue_LeaseJob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LeaseJobRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TranscodeJobQueueServer).LeaseJob(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TranscodeJobQueue_LeaseJob_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TranscodeJobQueueServer).LeaseJob(ctx, req.(*LeaseJobRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TranscodeJobQueue_Heartbeat_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(JobHeartbeatRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TranscodeJobQueueServer).Heartbeat(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TranscodeJobQueue_Heartbeat_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TranscodeJobQueueServer).Heartbeat(ctx, req.(*JobHeartbeatRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TranscodeJobQueue_CompleteJob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CompleteJobRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TranscodeJobQueueServer).CompleteJob(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TranscodeJobQueue_CompleteJob_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TranscodeJobQueueServer).CompleteJob(ctx, req.(*CompleteJobRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TranscodeJobQueue_FailJob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FailJobRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TranscodeJobQueueServer).FailJob(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TranscodeJobQueue_FailJob_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TranscodeJobQueueServer).FailJob(ctx, req.(*FailJobRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TranscodeJobQueue_FetchContentKeys_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FetchContentKeysRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TranscodeJobQueueServer).FetchContentKeys(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TranscodeJobQueue_FetchContentKeys_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TranscodeJobQueueServer).FetchContentKeys(ctx, req.(*FetchContentKeysRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// TranscodeJobQueue_ServiceDesc is the grpc.ServiceDesc for TranscodeJobQueue service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var TranscodeJobQueue_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "transcoding.TranscodeJobQueue",
+	HandlerType: (*TranscodeJobQueueServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "LeaseJob",
+			Handler:    _TranscodeJobQueue_LeaseJob_Handler,
+		},
+		{
+			MethodName: "Heartbeat",
+			Handler:    _TranscodeJobQueue_Heartbeat_Handler,
+		},
+		{
+			MethodName: "CompleteJob",
+			Handler:    _TranscodeJobQueue_CompleteJob_Handler,
+		},
+		{
+			MethodName: "FailJob",
+			Handler:    _TranscodeJobQueue_FailJob_Handler,
+		},
+		{
+			MethodName: "FetchContentKeys",
+			Handler:    _TranscodeJobQueue_FetchContentKeys_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "proto/transcoding.proto",
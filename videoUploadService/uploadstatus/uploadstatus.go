@@ -0,0 +1,207 @@
+package uploadstatus
+
+import (
+	"VideoUploadService/probe"
+	"sync"
+	"time"
+)
+
+type Stage string
+
+const (
+	StageReceiving Stage = "receiving"
+	StageStored    Stage = "stored"
+	StageQueued    Stage = "queued"
+	StageHandedOff Stage = "handed_off"
+	StageFailed    Stage = "failed"
+)
+
+// Source identifies which upload path created the entry.
+const (
+	SourceGRPC = "grpc"
+	SourceHTTP = "http"
+	SourceLive = "live"
+)
+
+// Status is a snapshot of a single upload.
+type Status struct {
+	ID            string      `json:"id"`
+	Owner         string      `json:"owner"`
+	Tier          string      `json:"tier,omitempty"`
+	Source        string      `json:"source"`
+	Stage         Stage       `json:"stage"`
+	ReceivedBytes int64       `json:"received_bytes"`
+	ExpectedBytes int64       `json:"expected_bytes"`
+	CreatedAt     time.Time   `json:"created_at"`
+	UpdatedAt     time.Time   `json:"updated_at"`
+	FailureReason string      `json:"failure_reason,omitempty"`
+	Media         *probe.Info `json:"media,omitempty"`
+}
+
+// MayView reports whether a caller may see the status of the upload: its
+// owner or an admin.
+func (st Status) MayView(caller string, admin bool) bool {
+	return admin || (caller != "" && caller == st.Owner)
+}
+
+// finished reports whether nothing more happens to the upload here: it was
+// handed to the encoder or failed.
+func (st Status) finished() bool {
+	return st.Stage == StageHandedOff || st.Stage == StageFailed
+}
+
+// DefaultRetention is how long finished uploads are kept.
+const DefaultRetention = time.Hour
+
+// Store keeps the status of every upload seen by this process. Entries are
+// kept for a while after the upload finishes so that failed uploads can
+// still be looked up.
+type Store struct {
+	mu        sync.RWMutex
+	uploads   map[string]*Status
+	retention time.Duration
+}
+
+// Default is the store shared by the gRPC and HTTP upload paths.
+var Default = NewStore()
+
+func NewStore() *Store {
+	s := &Store{uploads: make(map[string]*Status), retention: DefaultRetention}
+	go s.reapLoop()
+	return s
+}
+
+// SetRetention sets how long finished uploads are kept after their last
+// change.
+func (s *Store) SetRetention(d time.Duration) {
+	s.mu.Lock()
+	s.retention = d
+	s.mu.Unlock()
+}
+
+// reap removes the uploads that finished more than the retention ago and
+// returns how many.
+func (s *Store) reap(now time.Time) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for id, st := range s.uploads {
+		if st.finished() && now.Sub(st.UpdatedAt) > s.retention {
+			delete(s.uploads, id)
+			n++
+		}
+	}
+	return n
+}
+
+func (s *Store) reapLoop() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		s.reap(now)
+	}
+}
+
+// Start registers a new upload in the receiving stage.
+func (s *Store) Start(id, owner, source string, expected int64) {
+	now := time.Now()
+	s.mu.Lock()
+	s.uploads[id] = &Status{
+		ID:            id,
+		Owner:         owner,
+		Source:        source,
+		Stage:         StageReceiving,
+		ExpectedBytes: expected,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	s.mu.Unlock()
+}
+
+// SetTier records the account tier of the uploader, used to prioritise
+// transcoding.
+func (s *Store) SetTier(id, tier string) {
+	s.update(id, func(st *Status) {
+		st.Tier = tier
+	})
+}
+
+// AddBytes records n more bytes received for the upload.
+func (s *Store) AddBytes(id string, n int64) {
+	s.update(id, func(st *Status) {
+		st.ReceivedBytes += n
+	})
+}
+
+// SetExpected records the size announced by the client. Zero is ignored so
+// that later chunks without a size don't clear it.
+func (s *Store) SetExpected(id string, expected int64) {
+	if expected <= 0 {
+		return
+	}
+	s.update(id, func(st *Status) {
+		st.ExpectedBytes = expected
+	})
+}
+
+// SetStage moves the upload to the given stage.
+func (s *Store) SetStage(id string, stage Stage) {
+	s.update(id, func(st *Status) {
+		st.Stage = stage
+	})
+}
+
+// SetMedia records what probing the stored file found.
+func (s *Store) SetMedia(id string, info probe.Info) {
+	s.update(id, func(st *Status) {
+		st.Media = &info
+	})
+}
+
+// Fail marks the upload as failed with the reason taken from err.
+func (s *Store) Fail(id string, err error) {
+	s.update(id, func(st *Status) {
+		st.Stage = StageFailed
+		if err != nil {
+			st.FailureReason = err.Error()
+		}
+	})
+}
+
+// Get returns a copy of the upload status.
+func (s *Store) Get(id string) (Status, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	st, ok := s.uploads[id]
+	if !ok {
+		return Status{}, false
+	}
+	return *st, true
+}
+
+func (s *Store) update(id string, fn func(*Status)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st, ok := s.uploads[id]
+	if !ok {
+		return
+	}
+	fn(st)
+	st.UpdatedAt = time.Now()
+}
+
+// Writer returns an io.Writer that counts bytes written into the upload's
+// received size, for use with io.TeeReader.
+func (s *Store) Writer(id string) *Counter {
+	return &Counter{store: s, id: id}
+}
+
+type Counter struct {
+	store *Store
+	id    string
+}
+
+func (c *Counter) Write(b []byte) (int, error) {
+	c.store.AddBytes(c.id, int64(len(b)))
+	return len(b), nil
+}
@@ -0,0 +1,55 @@
+package uploadstatus
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestReap(t *testing.T) {
+	s := &Store{uploads: make(map[string]*Status), retention: time.Hour}
+	now := time.Now()
+	for _, id := range []string{"receiving", "stored", "handed-off", "failed", "recent"} {
+		s.Start(id, "alice", SourceHTTP, 10)
+	}
+	s.SetStage("stored", StageStored)
+	s.SetStage("handed-off", StageHandedOff)
+	s.Fail("failed", errors.New("disk full"))
+	s.SetStage("recent", StageHandedOff)
+	for _, st := range s.uploads {
+		if st.ID != "recent" {
+			st.UpdatedAt = now.Add(-2 * time.Hour)
+		}
+	}
+
+	if n := s.reap(now); n != 2 {
+		t.Errorf("reaped %d uploads, want 2", n)
+	}
+	for id, want := range map[string]bool{"receiving": true, "stored": true, "handed-off": false, "failed": false, "recent": true} {
+		if _, ok := s.Get(id); ok != want {
+			t.Errorf("%s kept = %v, want %v", id, ok, want)
+		}
+	}
+}
+
+func TestMayView(t *testing.T) {
+	st := Status{Owner: "alice"}
+	tests := []struct {
+		caller string
+		admin  bool
+		want   bool
+	}{
+		{"alice", false, true},
+		{"bob", false, false},
+		{"", false, false},
+		{"bob", true, true},
+	}
+	for _, tt := range tests {
+		if got := st.MayView(tt.caller, tt.admin); got != tt.want {
+			t.Errorf("MayView(%q, %v) = %v, want %v", tt.caller, tt.admin, got, tt.want)
+		}
+	}
+	if (Status{}).MayView("", false) {
+		t.Error("anonymous caller may view an anonymous upload")
+	}
+}